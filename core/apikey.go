@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/backium/backend/errors"
+)
+
+const (
+	// Characters of the token kept visible to tell keys apart
+	KeyPrefixLength = 10
+	// Minimum time between updates of the last use of a key
+	KeyTouchInterval = time.Minute
+)
+
+// Key is a merchant API key, only the hash of its token is stored and requests
+// made with it are limited to its scopes
+type Key struct {
+	ID         ID           `bson:"id"`
+	Name       string       `bson:"name"`
+	Prefix     string       `bson:"prefix"`
+	Hash       string       `bson:"hash"`
+	Scopes     []Permission `bson:"scopes"`
+	CreatedBy  ID           `bson:"created_by"`
+	CreatedAt  int64        `bson:"created_at"`
+	LastUsedAt int64        `bson:"last_used_at"`
+	RevokedAt  int64        `bson:"revoked_at"`
+	// Plaintext token of keys created before hashing, it is replaced by the
+	// hash the first time the key is used
+	Token string `bson:"token,omitempty"`
+}
+
+// NewKey returns the key along with its token, the token can't be recovered
+// afterwards
+func NewKey(name string, scopes []Permission) (Key, string) {
+	token := string(NewIDWithSize("sk", 32))
+	return Key{
+		ID:        NewID("key"),
+		Name:      name,
+		Prefix:    token[:KeyPrefixLength],
+		Hash:      hashToken(token),
+		Scopes:    scopes,
+		CreatedAt: time.Now().Unix(),
+	}, token
+}
+
+func (k *Key) Revoked() bool {
+	return k.RevokedAt != 0
+}
+
+func (k *Key) matches(token string) bool {
+	if k.Hash != "" {
+		return subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashToken(token))) == 1
+	}
+	return k.Token != "" && subtle.ConstantTimeCompare([]byte(k.Token), []byte(token)) == 1
+}
+
+// Employee returns the employee acting for the key, requests made with the key
+// are attributed to it and limited to the key scopes
+func (k *Key) Employee(merchantID ID) Employee {
+	return Employee{
+		ID:          k.ID,
+		FirstName:   k.Name,
+		Permissions: k.Scopes,
+		LocationIDs: []ID{},
+		MerchantID:  merchantID,
+		Status:      StatusActive,
+	}
+}
+
+func canManageKeys(ctx context.Context) bool {
+	employee := EmployeeFromContext(ctx)
+	return employee != nil && employee.IsOwner && KeyFromContext(ctx) == nil
+}
+
+func (svc *MerchantService) CreateKey(ctx context.Context, name string, scopes []Permission) (Key, string, error) {
+	const op = errors.Op("core/MerchantService.CreateKey")
+
+	merchant := MerchantFromContext(ctx)
+	if merchant == nil {
+		return Key{}, "", errors.E(op, errors.KindUnexpected, "Unknown merchant")
+	}
+	if !canManageKeys(ctx) {
+		return Key{}, "", errors.E(op, errors.KindNoPermission, "Only owners can manage API keys")
+	}
+	for _, s := range scopes {
+		if !s.Validate() {
+			return Key{}, "", errors.E(op, errors.KindValidation, "Unknown scope "+string(s))
+		}
+	}
+
+	key, token := NewKey(name, scopes)
+	key.CreatedBy = EmployeeFromContext(ctx).ID
+	if err := svc.MerchantStorage.PutKey(ctx, merchant.ID, key); err != nil {
+		return Key{}, "", errors.E(op, err)
+	}
+
+	return key, token, nil
+}
+
+func (svc *MerchantService) ListKeys(ctx context.Context) ([]Key, error) {
+	const op = errors.Op("core/MerchantService.ListKeys")
+
+	merchant := MerchantFromContext(ctx)
+	if merchant == nil {
+		return nil, errors.E(op, errors.KindUnexpected, "Unknown merchant")
+	}
+	if !canManageKeys(ctx) {
+		return nil, errors.E(op, errors.KindNoPermission, "Only owners can manage API keys")
+	}
+
+	stored, err := svc.MerchantStorage.Get(ctx, merchant.ID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	for i, k := range stored.Keys {
+		if k.Hash != "" || k.Token == "" {
+			continue
+		}
+		stored.Keys[i] = migrateKey(k)
+		if err := svc.MerchantStorage.MigrateKey(ctx, stored.ID, k.Token, stored.Keys[i]); err != nil {
+			return nil, errors.E(op, err)
+		}
+	}
+
+	return stored.Keys, nil
+}
+
+func (svc *MerchantService) RevokeKey(ctx context.Context, id ID) (Key, error) {
+	const op = errors.Op("core/MerchantService.RevokeKey")
+
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		return Key{}, errors.E(op, err)
+	}
+
+	for _, key := range keys {
+		if key.ID != id {
+			continue
+		}
+		if !key.Revoked() {
+			key.RevokedAt = time.Now().Unix()
+			if err := svc.MerchantStorage.RevokeKey(ctx, MerchantFromContext(ctx).ID, key.ID, key.RevokedAt); err != nil {
+				return Key{}, errors.E(op, err)
+			}
+		}
+		return key, nil
+	}
+
+	return Key{}, errors.E(op, errors.KindNotFound, "Key not found")
+}
+
+// AuthenticateKey returns the merchant and the key of the token, recording
+// the use of the key
+func (svc *MerchantService) AuthenticateKey(ctx context.Context, token string) (Merchant, Key, error) {
+	const op = errors.Op("core/MerchantService.AuthenticateKey")
+
+	if !strings.HasPrefix(token, "sk_") {
+		return Merchant{}, Key{}, errors.E(op, errors.KindInvalidSession, "invalid API key")
+	}
+
+	merchant, key, err := svc.keyOf(ctx, token)
+	if err != nil {
+		return Merchant{}, Key{}, errors.E(op, errors.KindInvalidSession, err)
+	}
+
+	if key.Hash == "" {
+		if err := svc.MerchantStorage.MigrateKey(ctx, merchant.ID, token, migrateKey(*key)); err != nil {
+			return Merchant{}, Key{}, errors.E(op, err)
+		}
+		// Read it again in case another request migrated it first
+		merchant, key, err = svc.keyOf(ctx, token)
+		if err != nil {
+			return Merchant{}, Key{}, errors.E(op, errors.KindInvalidSession, err)
+		}
+	}
+	if time.Now().Unix()-key.LastUsedAt >= int64(KeyTouchInterval.Seconds()) {
+		key.LastUsedAt = time.Now().Unix()
+		if err := svc.MerchantStorage.TouchKey(ctx, merchant.ID, key.ID, key.LastUsedAt); err != nil {
+			return Merchant{}, Key{}, errors.E(op, err)
+		}
+	}
+
+	return merchant, *key, nil
+}
+
+// keyOf returns the merchant of the token along with its key, revoked keys
+// are invalid
+func (svc *MerchantService) keyOf(ctx context.Context, token string) (Merchant, *Key, error) {
+	merchant, err := svc.MerchantStorage.GetByKey(ctx, token)
+	if err != nil {
+		return Merchant{}, nil, err
+	}
+	for i := range merchant.Keys {
+		if merchant.Keys[i].matches(token) {
+			if merchant.Keys[i].Revoked() {
+				break
+			}
+			return merchant, &merchant.Keys[i], nil
+		}
+	}
+	return Merchant{}, nil, errors.E(errors.KindInvalidSession, "invalid API key")
+}
+
+// migrateKey replaces the plaintext token of the key by its hash
+func migrateKey(k Key) Key {
+	k.ID = NewID("key")
+	k.Prefix = k.Token[:KeyPrefixLength]
+	k.Hash = hashToken(k.Token)
+	k.Token = ""
+	return k
+}
+
+// HashKey returns the hash stored for an API key token
+func HashKey(token string) string {
+	return hashToken(token)
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func keyTestService(merchant *Merchant) MerchantService {
+	storage := NewMockMerchantStorage()
+	storage.GetFn = func(ctx context.Context, id ID) (Merchant, error) {
+		return *merchant, nil
+	}
+	storage.GetByKeyFn = func(ctx context.Context, token string) (Merchant, error) {
+		for _, k := range merchant.Keys {
+			if k.Hash == HashKey(token) || k.Token == token {
+				m := *merchant
+				m.Keys = append([]Key{}, merchant.Keys...)
+				return m, nil
+			}
+		}
+		return Merchant{}, errors.E(errors.KindNotFound)
+	}
+	storage.PutFn = func(ctx context.Context, m Merchant) error {
+		*merchant = m
+		return nil
+	}
+	storage.PutKeyFn = func(ctx context.Context, merchantID ID, key Key) error {
+		for i, k := range merchant.Keys {
+			if k.ID == key.ID {
+				merchant.Keys[i] = key
+				return nil
+			}
+		}
+		merchant.Keys = append(merchant.Keys, key)
+		return nil
+	}
+	storage.TouchKeyFn = func(ctx context.Context, merchantID, keyID ID, usedAt int64) error {
+		for i, k := range merchant.Keys {
+			if k.ID == keyID {
+				merchant.Keys[i].LastUsedAt = usedAt
+			}
+		}
+		return nil
+	}
+	storage.RevokeKeyFn = func(ctx context.Context, merchantID, keyID ID, revokedAt int64) error {
+		for i, k := range merchant.Keys {
+			if k.ID == keyID && k.RevokedAt == 0 {
+				merchant.Keys[i].RevokedAt = revokedAt
+			}
+		}
+		return nil
+	}
+	storage.MigrateKeyFn = func(ctx context.Context, merchantID ID, token string, key Key) error {
+		for i, k := range merchant.Keys {
+			if k.Token == token {
+				merchant.Keys[i].ID = key.ID
+				merchant.Keys[i].Prefix = key.Prefix
+				merchant.Keys[i].Hash = key.Hash
+				merchant.Keys[i].Token = ""
+			}
+		}
+		return nil
+	}
+	return MerchantService{MerchantStorage: storage}
+}
+
+func TestCreateKey(t *testing.T) {
+	merchant := NewMerchant()
+	svc := keyTestService(&merchant)
+	owner := NewEmployee("Anna", "Smith", merchant.ID)
+	owner.IsOwner = true
+	ctx := ContextWithMerchant(context.Background(), &merchant)
+
+	key, token, err := svc.CreateKey(ContextWithEmployee(ctx, &owner), "Shop", []Permission{OrderRead})
+	assert.NoError(t, err)
+	assert.Equal(t, token[:KeyPrefixLength], key.Prefix)
+	assert.Equal(t, owner.ID, key.CreatedBy)
+	// Only the hash is stored
+	assert.Equal(t, []Key{key}, merchant.Keys)
+	assert.NotContains(t, merchant.Keys[0].Hash, token)
+	assert.Empty(t, merchant.Keys[0].Token)
+
+	_, _, err = svc.CreateKey(ContextWithEmployee(ctx, &owner), "Shop", []Permission{"everything"})
+	assert.True(t, errors.Is(err, errors.KindValidation))
+
+	cashier := NewEmployee("Bob", "Jones", merchant.ID)
+	_, _, err = svc.CreateKey(ContextWithEmployee(ctx, &cashier), "Shop", nil)
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+
+	// Keys can't be used to manage keys even with the owner employee
+	keyCtx := ContextWithKey(ContextWithEmployee(ctx, &owner), &key)
+	_, _, err = svc.CreateKey(keyCtx, "Shop", nil)
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+}
+
+func TestAuthenticateKey(t *testing.T) {
+	merchant := NewMerchant()
+	svc := keyTestService(&merchant)
+	owner := NewEmployee("Anna", "Smith", merchant.ID)
+	owner.IsOwner = true
+	ctx := ContextWithEmployee(ContextWithMerchant(context.Background(), &merchant), &owner)
+
+	key, token, err := svc.CreateKey(ctx, "Shop", []Permission{OrderRead})
+	assert.NoError(t, err)
+
+	_, _, err = svc.AuthenticateKey(context.Background(), "pk_"+token[3:])
+	assert.True(t, errors.Is(err, errors.KindInvalidSession))
+	_, _, err = svc.AuthenticateKey(context.Background(), token+"x")
+	assert.True(t, errors.Is(err, errors.KindInvalidSession))
+
+	m, authenticated, err := svc.AuthenticateKey(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, merchant.ID, m.ID)
+	assert.Equal(t, key.ID, authenticated.ID)
+	assert.NotZero(t, merchant.Keys[0].LastUsedAt)
+	employee := authenticated.Employee(m.ID)
+	assert.Equal(t, []Permission{OrderRead}, employee.Permissions)
+	assert.False(t, employee.IsOwner)
+
+	_, err = svc.RevokeKey(ctx, key.ID)
+	assert.NoError(t, err)
+	_, _, err = svc.AuthenticateKey(context.Background(), token)
+	assert.True(t, errors.Is(err, errors.KindInvalidSession))
+}
+
+func TestAuthenticateKeyRevokedMeanwhile(t *testing.T) {
+	merchant := NewMerchant()
+	svc := keyTestService(&merchant)
+	owner := NewEmployee("Anna", "Smith", merchant.ID)
+	owner.IsOwner = true
+	ctx := ContextWithEmployee(ContextWithMerchant(context.Background(), &merchant), &owner)
+
+	key, token, err := svc.CreateKey(ctx, "Shop", []Permission{OrderRead})
+	assert.NoError(t, err)
+
+	// The key is revoked after the request read it but before its use is
+	// recorded
+	storage := svc.MerchantStorage.(*mockMerchantStorage)
+	getByKey := storage.GetByKeyFn
+	storage.GetByKeyFn = func(ctx context.Context, token string) (Merchant, error) {
+		m, err := getByKey(ctx, token)
+		if _, err := svc.RevokeKey(ctx, key.ID); err != nil {
+			return Merchant{}, err
+		}
+		return m, err
+	}
+	_, _, err = svc.AuthenticateKey(ctx, token)
+	assert.NoError(t, err)
+	assert.NotZero(t, merchant.Keys[0].LastUsedAt)
+	assert.NotZero(t, merchant.Keys[0].RevokedAt, "the revocation is kept")
+
+	storage.GetByKeyFn = getByKey
+	_, _, err = svc.AuthenticateKey(ctx, token)
+	assert.True(t, errors.Is(err, errors.KindInvalidSession))
+}
+
+func TestAuthenticateLegacyKey(t *testing.T) {
+	token := string(NewIDWithSize("sk", 25))
+	merchant := NewMerchant()
+	merchant.Keys = []Key{{Name: "Old", Token: token}}
+	svc := keyTestService(&merchant)
+
+	_, key, err := svc.AuthenticateKey(context.Background(), token)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, key.ID)
+	// The plaintext token is replaced by its hash
+	assert.Empty(t, merchant.Keys[0].Token)
+	assert.Equal(t, HashKey(token), merchant.Keys[0].Hash)
+	assert.Equal(t, token[:KeyPrefixLength], merchant.Keys[0].Prefix)
+
+	_, _, err = svc.AuthenticateKey(context.Background(), token)
+	assert.NoError(t, err)
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+
+	"github.com/backium/backend/errors"
 )
 
 type Authorizer struct {
@@ -9,115 +11,646 @@ type Authorizer struct {
 	ItemStorage          ItemStorage
 	CategoryStorage      CategoryStorage
 	EmployeeStorage      EmployeeStorage
+	RoleStorage          RoleStorage
+	LocationStorage      LocationStorage
+	OrderStorage         OrderStorage
+	TaxStorage           TaxStorage
+	DiscountStorage      DiscountStorage
+	CustomerStorage      CustomerStorage
+	CashDrawerStorage    CashDrawerStorage
+	TipStorage           TipStorage
 }
 
-func (auth *Authorizer) CanCreateEmployee(ctx context.Context, empl Employee) bool {
-	merchant := MerchantFromContext(ctx)
-	employee := EmployeeFromContext(ctx)
+// EffectivePermissions returns the permissions given to the employee directly
+// and through its roles
+func (auth *Authorizer) EffectivePermissions(ctx context.Context, employee Employee) ([]Permission, error) {
+	const op = errors.Op("core/Authorizer.EffectivePermissions")
+
+	permissions := append([]Permission{}, employee.Permissions...)
+	if len(employee.RoleIDs) == 0 {
+		return permissions, nil
+	}
 
-	return empl.MerchantID == merchant.ID && employee.IsOwner
+	roles, _, err := auth.RoleStorage.List(ctx, RoleQuery{
+		Filter: RoleFilter{IDs: employee.RoleIDs, MerchantID: employee.MerchantID},
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	for _, role := range roles {
+		if role.Status != StatusActive {
+			continue
+		}
+		for _, p := range role.Permissions {
+			if !Can(permissions, p) {
+				permissions = append(permissions, p)
+			}
+		}
+	}
+
+	return permissions, nil
+}
+
+// HasPermission reports whether the employee has the permission, owners have
+// all of them
+func (e *Employee) HasPermission(p Permission) bool {
+	return e.IsOwner || Can(e.Permissions, p)
 }
 
-func (auth *Authorizer) CanUpdateEmployee(ctx context.Context, id ID) bool {
+// IsManager reports whether the employee has any of the manager permissions
+func (e *Employee) IsManager() bool {
+	for _, p := range ManagerPermissions {
+		if e.HasPermission(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// LimitToDevice drops the owner and manager permissions of the employee, a
+// PIN is a single factor so device sessions can't get the access that needs
+// two-factor authentication with a password login
+func (e *Employee) LimitToDevice() {
+	e.IsOwner = false
+	permissions := []Permission{}
+	for _, p := range e.Permissions {
+		if !Can(ManagerPermissions, p) {
+			permissions = append(permissions, p)
+		}
+	}
+	e.Permissions = permissions
+}
+
+// locationRestricted reports whether the employee can only access some
+// locations, employees without locations can access all of them
+func (e *Employee) locationRestricted() bool {
+	return !e.IsOwner && len(e.LocationIDs) != 0
+}
+
+// canUseLocations reports whether the employee can access all the locations
+func (e *Employee) canUseLocations(locationIDs []ID) bool {
+	return !e.locationRestricted() || ContainsAllID(e.LocationIDs, locationIDs)
+}
+
+// canSeeLocations reports whether the employee can access a resource available
+// at the locations, resources without locations are available everywhere
+func (e *Employee) canSeeLocations(locationIDs []ID) bool {
+	return !e.locationRestricted() || len(locationIDs) == 0 || ContainsOneID(e.LocationIDs, locationIDs)
+}
+
+// Authorization checks return false without error when the employee in the
+// context is not allowed to access an existing resource, and a KindNotFound
+// error when the resource doesn't exist or belongs to another merchant. Any
+// other failure is returned as is and never grants access.
+
+// principal returns the merchant and employee in the context
+func principal(ctx context.Context) (*Merchant, *Employee, error) {
 	merchant := MerchantFromContext(ctx)
 	employee := EmployeeFromContext(ctx)
+	if merchant == nil || employee == nil {
+		return nil, nil, errors.E(errors.KindUnexpected, "Unknown employee")
+	}
+	return merchant, employee, nil
+}
 
-	if !employee.IsOwner {
-		return false
+// actor returns the employee in the context if the resources of the merchant
+// are visible to it
+func actor(ctx context.Context, merchantID ID) (*Employee, error) {
+	merchant, employee, err := principal(ctx)
+	if err != nil {
+		return nil, err
 	}
+	if merchantID != merchant.ID {
+		return nil, errors.E(errors.KindNotFound, "Resource not found")
+	}
+	return employee, nil
+}
 
-	empl, err := auth.EmployeeStorage.Get(ctx, id)
+// can reports whether the employee in the context has the permission over the
+// resources of the merchant
+func can(ctx context.Context, p Permission, merchantID ID) (bool, error) {
+	employee, err := actor(ctx, merchantID)
 	if err != nil {
-		return true
+		return false, err
 	}
+	return employee.HasPermission(p), nil
+}
 
-	return empl.MerchantID == merchant.ID
+// canAt is like can but also requires access to all the locations
+func canAt(ctx context.Context, p Permission, merchantID ID, locationIDs ...ID) (bool, error) {
+	employee, err := actor(ctx, merchantID)
+	if err != nil {
+		return false, err
+	}
+	return employee.HasPermission(p) && employee.canUseLocations(locationIDs), nil
 }
 
-func (auth *Authorizer) CanGetItem(ctx context.Context, id ID) bool {
-	merchant := MerchantFromContext(ctx)
+// canSee is like can but only requires access to one of the locations
+func canSee(ctx context.Context, p Permission, merchantID ID, locationIDs []ID) (bool, error) {
+	employee, err := actor(ctx, merchantID)
+	if err != nil {
+		return false, err
+	}
+	return employee.HasPermission(p) && employee.canSeeLocations(locationIDs), nil
+}
+
+func (auth *Authorizer) HasPermission(ctx context.Context, p Permission) bool {
 	employee := EmployeeFromContext(ctx)
+	return employee != nil && employee.HasPermission(p)
+}
 
-	if !(Can(employee.Permissions, CatalogRead) || employee.IsOwner) {
-		return false
+// LocationScope returns the locations a search is limited to, searches of
+// employees restricted to some locations default to them
+func (auth *Authorizer) LocationScope(ctx context.Context, locationIDs []ID) []ID {
+	employee := EmployeeFromContext(ctx)
+	if len(locationIDs) != 0 || employee == nil || !employee.locationRestricted() {
+		return locationIDs
 	}
+	return employee.LocationIDs
+}
 
-	item, err := auth.ItemStorage.Get(ctx, id)
+// CanSearch reports whether the employee can search the resources protected by
+// the permission at the locations
+func (auth *Authorizer) CanSearch(ctx context.Context, p Permission, locationIDs []ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanSearch")
+
+	_, employee, err := principal(ctx)
 	if err != nil {
-		return true
+		return false, errors.E(op, err)
+	}
+	if !employee.HasPermission(p) || (employee.locationRestricted() && len(locationIDs) == 0) {
+		return false, nil
 	}
 
-	return item.MerchantID == merchant.ID &&
-		(ContainsOneID(item.LocationIDs, employee.LocationIDs) || employee.IsOwner)
+	return employee.canUseLocations(locationIDs), nil
 }
 
-func (auth *Authorizer) CanCreateItem(ctx context.Context, item Item) bool {
-	merchant := MerchantFromContext(ctx)
-	employee := EmployeeFromContext(ctx)
+func (auth *Authorizer) CanCreateEmployee(ctx context.Context, empl Employee) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanCreateEmployee")
 
-	if !(Can(employee.Permissions, CatalogWrite) || employee.IsOwner) {
-		return false
+	employee, err := actor(ctx, empl.MerchantID)
+	if err != nil {
+		return false, errors.E(op, err)
 	}
 
-	return item.MerchantID == merchant.ID &&
-		(ContainsAllID(employee.LocationIDs, item.LocationIDs) || employee.IsOwner)
+	return employee.IsOwner, nil
 }
 
-func (auth *Authorizer) CanUpdateItem(ctx context.Context, id ID) bool {
-	merchant := MerchantFromContext(ctx)
+func (auth *Authorizer) CanGetEmployee(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetEmployee")
+
+	empl, err := auth.EmployeeStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+	employee, err := actor(ctx, empl.MerchantID)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+	if employee.ID == empl.ID {
+		return true, nil
+	}
+
+	return employee.HasPermission(EmployeeRead) && employee.canSeeLocations(empl.LocationIDs), nil
+}
+
+// CanSeePay reports whether the employee in the context can see the rates and
+// salaries of the employee, only owners and the employee itself can
+func (auth *Authorizer) CanSeePay(ctx context.Context, empl Employee) bool {
 	employee := EmployeeFromContext(ctx)
+	return employee != nil && employee.MerchantID == empl.MerchantID &&
+		(employee.IsOwner || employee.ID == empl.ID)
+}
 
-	if !(Can(employee.Permissions, CatalogWrite) || employee.IsOwner) {
-		return false
+func (auth *Authorizer) CanUpdateEmployee(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUpdateEmployee")
+
+	empl, err := auth.EmployeeStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
 	}
+	employee, err := actor(ctx, empl.MerchantID)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return employee.IsOwner, nil
+}
+
+func (auth *Authorizer) CanGetItem(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetItem")
 
 	item, err := auth.ItemStorage.Get(ctx, id)
 	if err != nil {
-		return true
+		return false, errors.E(op, err)
 	}
 
-	return item.MerchantID == merchant.ID
+	return canSee(ctx, CatalogRead, item.MerchantID, item.LocationIDs)
 }
 
-func (auth *Authorizer) CanSearchItem(ctx context.Context, f ItemFilter) bool {
-	merchant := MerchantFromContext(ctx)
-	employee := EmployeeFromContext(ctx)
+func (auth *Authorizer) CanCreateItem(ctx context.Context, item Item) (bool, error) {
+	return canAt(ctx, CatalogWrite, item.MerchantID, item.LocationIDs...)
+}
 
-	if !(Can(employee.Permissions, CatalogRead) || employee.IsOwner) {
-		return false
+func (auth *Authorizer) CanUpdateItem(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUpdateItem")
+
+	item, err := auth.ItemStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
 	}
 
-	items, _, _ := auth.ItemStorage.List(ctx, ItemQuery{
+	return canAt(ctx, CatalogWrite, item.MerchantID, item.LocationIDs...)
+}
+
+// CanSearchItem reports whether the employee can see all the items matching
+// the filter
+func (auth *Authorizer) CanSearchItem(ctx context.Context, f ItemFilter) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanSearchItem")
+
+	merchant, employee, err := principal(ctx)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+	if !employee.HasPermission(CatalogRead) {
+		return false, nil
+	}
+
+	items, _, err := auth.ItemStorage.List(ctx, ItemQuery{
 		Filter: f,
 	})
+	if err != nil {
+		return false, errors.E(op, err)
+	}
 
 	for _, item := range items {
-		if item.MerchantID != merchant.ID {
-			return false
-		}
-		if !ContainsOneID(employee.LocationIDs, item.LocationIDs) && !employee.IsOwner {
-			return false
+		if item.MerchantID != merchant.ID || !employee.canSeeLocations(item.LocationIDs) {
+			return false, nil
 		}
 	}
 
-	return true
+	return true, nil
 }
 
-func (auth *Authorizer) CanChangeInventory(ctx context.Context, variationIDs []ID) bool {
-	merchant := MerchantFromContext(ctx)
+func (auth *Authorizer) CanGetItemVariation(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetItemVariation")
+
+	variation, err := auth.ItemVariationStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canSee(ctx, CatalogRead, variation.MerchantID, variation.LocationIDs)
+}
+
+func (auth *Authorizer) CanCreateItemVariation(ctx context.Context, variation ItemVariation) (bool, error) {
+	return canAt(ctx, CatalogWrite, variation.MerchantID, variation.LocationIDs...)
+}
+
+func (auth *Authorizer) CanUpdateItemVariation(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUpdateItemVariation")
+
+	variation, err := auth.ItemVariationStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canAt(ctx, CatalogWrite, variation.MerchantID, variation.LocationIDs...)
+}
+
+func (auth *Authorizer) CanGetCategory(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetCategory")
+
+	category, err := auth.CategoryStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return can(ctx, CatalogRead, category.MerchantID)
+}
+
+func (auth *Authorizer) CanCreateCategory(ctx context.Context, category Category) (bool, error) {
+	return can(ctx, CatalogWrite, category.MerchantID)
+}
+
+func (auth *Authorizer) CanUpdateCategory(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUpdateCategory")
+
+	category, err := auth.CategoryStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return can(ctx, CatalogWrite, category.MerchantID)
+}
+
+// CanChangeInventory reports whether the employee can change the stock of the
+// variations at the locations
+func (auth *Authorizer) CanChangeInventory(ctx context.Context, variationIDs []ID, locationIDs []ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanChangeInventory")
+
+	merchant, employee, err := principal(ctx)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+	if !employee.HasPermission(InventoryWrite) || !employee.canUseLocations(locationIDs) {
+		return false, nil
+	}
 
 	variations, _, err := auth.ItemVariationStorage.List(ctx, ItemVariationQuery{
 		Filter: ItemVariationFilter{IDs: variationIDs},
 	})
 	if err != nil {
-		return true
+		return false, errors.E(op, err)
 	}
 
 	for _, v := range variations {
 		if v.MerchantID != merchant.ID {
-			return false
+			return false, errors.E(op, errors.KindNotFound, "Item variation not found")
+		}
+	}
+
+	return true, nil
+}
+
+func (auth *Authorizer) CanGetLocation(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetLocation")
+
+	location, err := auth.LocationStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canAt(ctx, LocationRead, location.MerchantID, location.ID)
+}
+
+// CanCreateLocation reports whether the employee can create locations, only
+// employees with access to all the locations can
+func (auth *Authorizer) CanCreateLocation(ctx context.Context, location Location) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanCreateLocation")
+
+	employee, err := actor(ctx, location.MerchantID)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return employee.HasPermission(LocationWrite) && !employee.locationRestricted(), nil
+}
+
+func (auth *Authorizer) CanUpdateLocation(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUpdateLocation")
+
+	location, err := auth.LocationStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canAt(ctx, LocationWrite, location.MerchantID, location.ID)
+}
+
+func (auth *Authorizer) CanGetOrder(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetOrder")
+
+	order, err := auth.OrderStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canAt(ctx, OrderRead, order.MerchantID, order.LocationID)
+}
+
+func (auth *Authorizer) CanCreateOrder(ctx context.Context, schema OrderSchema) (bool, error) {
+	return canAt(ctx, OrderWrite, schema.MerchantID, schema.LocationID)
+}
+
+func (auth *Authorizer) CanUpdateOrder(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUpdateOrder")
+
+	order, err := auth.OrderStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canAt(ctx, OrderWrite, order.MerchantID, order.LocationID)
+}
+
+// CanCreatePayment reports whether the employee can pay the order with the
+// payment, the payment has to be taken at the order location
+func (auth *Authorizer) CanCreatePayment(ctx context.Context, payment Payment) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanCreatePayment")
+
+	order, err := auth.OrderStorage.Get(ctx, payment.OrderID)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+	if order.MerchantID != payment.MerchantID {
+		return false, errors.E(op, errors.KindNotFound, "Order not found")
+	}
+	if order.LocationID != payment.LocationID {
+		return false, nil
+	}
+
+	return canAt(ctx, OrderWrite, payment.MerchantID, payment.LocationID)
+}
+
+func (auth *Authorizer) CanGetTax(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetTax")
+
+	tax, err := auth.TaxStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canSee(ctx, CatalogRead, tax.MerchantID, tax.LocationIDs)
+}
+
+func (auth *Authorizer) CanCreateTax(ctx context.Context, tax Tax) (bool, error) {
+	return canAt(ctx, CatalogWrite, tax.MerchantID, tax.LocationIDs...)
+}
+
+func (auth *Authorizer) CanUpdateTax(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUpdateTax")
+
+	tax, err := auth.TaxStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canAt(ctx, CatalogWrite, tax.MerchantID, tax.LocationIDs...)
+}
+
+func (auth *Authorizer) CanGetDiscount(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetDiscount")
+
+	discount, err := auth.DiscountStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canSee(ctx, CatalogRead, discount.MerchantID, discount.LocationIDs)
+}
+
+func (auth *Authorizer) CanCreateDiscount(ctx context.Context, discount Discount) (bool, error) {
+	return canAt(ctx, CatalogWrite, discount.MerchantID, discount.LocationIDs...)
+}
+
+func (auth *Authorizer) CanUpdateDiscount(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUpdateDiscount")
+
+	discount, err := auth.DiscountStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canAt(ctx, CatalogWrite, discount.MerchantID, discount.LocationIDs...)
+}
+
+func (auth *Authorizer) CanGetCustomer(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetCustomer")
+
+	customer, err := auth.CustomerStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return can(ctx, CustomerRead, customer.MerchantID)
+}
+
+func (auth *Authorizer) CanCreateCustomer(ctx context.Context, customer Customer) (bool, error) {
+	return can(ctx, CustomerWrite, customer.MerchantID)
+}
+
+func (auth *Authorizer) CanUpdateCustomer(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUpdateCustomer")
+
+	customer, err := auth.CustomerStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return can(ctx, CustomerWrite, customer.MerchantID)
+}
+
+func (auth *Authorizer) CanCreateCashDrawer(ctx context.Context, drawer CashDrawer) (bool, error) {
+	return canAt(ctx, CashDrawerAccess, drawer.MerchantID, drawer.LocationID)
+}
+
+// CanUseCashDrawer reports whether the employee can adjust the drawer and open
+// or close its shifts
+func (auth *Authorizer) CanUseCashDrawer(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUseCashDrawer")
+
+	drawer, err := auth.CashDrawerStorage.Get(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canAt(ctx, CashDrawerAccess, drawer.MerchantID, drawer.LocationID)
+}
+
+func (auth *Authorizer) CanUseCashDrawerShift(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanUseCashDrawerShift")
+
+	shift, err := auth.CashDrawerStorage.GetShift(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return canAt(ctx, CashDrawerAccess, shift.MerchantID, shift.LocationID)
+}
+
+// CanGenerateReport reports whether the employee can see the reports of the
+// locations, restricted employees have to limit them to their locations
+func (auth *Authorizer) CanGenerateReport(ctx context.Context, locationIDs []ID) (bool, error) {
+	return auth.CanSearch(ctx, ReportRead, locationIDs)
+}
+
+// canReadTipPool reports whether the employee can see the tips of all the
+// locations of the pool, pools without locations collect the tips of all of them
+func (e *Employee) canReadTipPool(pool TipPool) bool {
+	if e.locationRestricted() && len(pool.LocationIDs) == 0 {
+		return false
+	}
+	return e.HasPermission(ReportRead) && e.canUseLocations(pool.LocationIDs)
+}
+
+// CanGetTipPool reports whether the employee can see the pool and calculate
+// its payouts
+func (auth *Authorizer) CanGetTipPool(ctx context.Context, id ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanGetTipPool")
+
+	pool, err := auth.TipStorage.GetPool(ctx, id)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+	employee, err := actor(ctx, pool.MerchantID)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+
+	return employee.canReadTipPool(pool), nil
+}
+
+// TipPoolScope returns the pools a payout search is limited to, searches of
+// employees restricted to some locations default to the pools they can see
+func (auth *Authorizer) TipPoolScope(ctx context.Context, tipPoolIDs []ID) ([]ID, error) {
+	const op = errors.Op("core/Authorizer.TipPoolScope")
+
+	merchant, employee, err := principal(ctx)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	if len(tipPoolIDs) != 0 || !employee.locationRestricted() {
+		return tipPoolIDs, nil
+	}
+
+	pools, _, err := auth.TipStorage.ListPool(ctx, TipPoolQuery{
+		Filter: TipPoolFilter{
+			LocationIDs: employee.LocationIDs,
+			MerchantID:  merchant.ID,
+		},
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	ids := []ID{}
+	for _, pool := range pools {
+		if employee.canReadTipPool(pool) {
+			ids = append(ids, pool.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+// CanSearchTipPayout reports whether the employee can see the payouts of the
+// pools, restricted employees have to limit them to the pools they can see
+func (auth *Authorizer) CanSearchTipPayout(ctx context.Context, tipPoolIDs []ID) (bool, error) {
+	const op = errors.Op("core/Authorizer.CanSearchTipPayout")
+
+	_, employee, err := principal(ctx)
+	if err != nil {
+		return false, errors.E(op, err)
+	}
+	if !employee.HasPermission(ReportRead) {
+		return false, nil
+	}
+	if !employee.locationRestricted() {
+		return true, nil
+	}
+	if len(tipPoolIDs) == 0 {
+		return false, nil
+	}
+
+	for _, id := range tipPoolIDs {
+		ok, err := auth.CanGetTipPool(ctx, id)
+		if err != nil {
+			return false, errors.E(op, err)
+		}
+		if !ok {
+			return false, nil
 		}
 	}
 
-	return true
+	return true, nil
 }
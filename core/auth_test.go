@@ -0,0 +1,515 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type authTest struct {
+	merchantID ID
+	locationA  ID
+	locationB  ID
+	owner      context.Context
+	// Employee limited to location A with the given permissions
+	cashier func(...Permission) context.Context
+	// Owner of another business
+	stranger context.Context
+}
+
+const (
+	granted   = "granted"
+	forbidden = "forbidden"
+	notFound  = "not found"
+	failed    = "failed"
+)
+
+// outcome describes the result of an authorization check
+func outcome(ok bool, err error) string {
+	switch {
+	case err != nil && errors.Is(err, errors.KindNotFound):
+		return notFound
+	case err != nil:
+		return failed
+	case ok:
+		return granted
+	default:
+		return forbidden
+	}
+}
+
+func newAuthTest() authTest {
+	merchantID, locationA, locationB := NewID("merch"), NewID("loc"), NewID("loc")
+	merchant := Merchant{ID: merchantID}
+
+	owner := NewEmployee("Jhon", "Doe", merchantID)
+	owner.IsOwner = true
+	ownerCtx := ContextWithEmployee(ContextWithMerchant(context.Background(), &merchant), &owner)
+
+	other := Merchant{ID: NewID("merch")}
+	stranger := NewEmployee("Jane", "Roe", other.ID)
+	stranger.IsOwner = true
+	strangerCtx := ContextWithEmployee(ContextWithMerchant(context.Background(), &other), &stranger)
+
+	return authTest{
+		merchantID: merchantID,
+		locationA:  locationA,
+		locationB:  locationB,
+		owner:      ownerCtx,
+		cashier: func(permissions ...Permission) context.Context {
+			cashier := NewEmployee("Anna", "Smith", merchantID)
+			cashier.Permissions = permissions
+			cashier.LocationIDs = []ID{locationA}
+			return ContextWithEmployee(ContextWithMerchant(context.Background(), &merchant), &cashier)
+		},
+		stranger: strangerCtx,
+	}
+}
+
+func TestAuthorizerPermissions(t *testing.T) {
+	at := newAuthTest()
+	auth := Authorizer{}
+
+	assert.True(t, auth.HasPermission(at.owner, ReportRead))
+	assert.True(t, auth.HasPermission(at.cashier(OrderRead), OrderRead))
+	assert.False(t, auth.HasPermission(at.cashier(OrderRead), OrderWrite))
+	assert.False(t, auth.HasPermission(context.Background(), OrderRead))
+
+	// Restricted employees search their locations unless they ask for others
+	cashier := at.cashier(OrderRead)
+	assert.Equal(t, []ID{at.locationA}, auth.LocationScope(cashier, nil))
+	assert.Equal(t, []ID{at.locationB}, auth.LocationScope(cashier, []ID{at.locationB}))
+	assert.Empty(t, auth.LocationScope(at.owner, nil))
+
+	assert.Equal(t, granted, outcome(auth.CanSearch(cashier, OrderRead, []ID{at.locationA})))
+	assert.Equal(t, forbidden, outcome(auth.CanSearch(cashier, OrderRead, []ID{at.locationA, at.locationB})))
+	assert.Equal(t, forbidden, outcome(auth.CanSearch(cashier, OrderRead, nil)))
+	assert.Equal(t, forbidden, outcome(auth.CanSearch(cashier, OrderWrite, []ID{at.locationA})))
+	assert.Equal(t, granted, outcome(auth.CanSearch(at.owner, OrderRead, nil)))
+}
+
+func TestEmployeeLimitToDevice(t *testing.T) {
+	merchantID := NewID("merch")
+	owner := NewEmployee("Jhon", "Doe", merchantID)
+	owner.IsOwner = true
+	owner.LimitToDevice()
+	assert.False(t, owner.HasPermission(ReportRead))
+	assert.False(t, owner.IsManager())
+
+	manager := NewEmployee("Anna", "Smith", merchantID)
+	manager.Permissions = []Permission{OrderWrite, ReportRead, TimesheetAccess, TimesheetApprove}
+	manager.LimitToDevice()
+	assert.Equal(t, []Permission{OrderWrite, TimesheetAccess}, manager.Permissions)
+	assert.False(t, manager.IsManager())
+}
+
+func TestAuthorizerOrders(t *testing.T) {
+	at := newAuthTest()
+	orderA := NewOrder(at.locationA, at.merchantID)
+	orderB := NewOrder(at.locationB, at.merchantID)
+	orders := map[ID]Order{orderA.ID: orderA, orderB.ID: orderB}
+
+	orderStorage := NewMockOrderStorage()
+	orderStorage.GetFn = func(ctx context.Context, id ID) (Order, error) {
+		order, ok := orders[id]
+		if !ok {
+			return Order{}, errors.E(errors.KindNotFound)
+		}
+		return order, nil
+	}
+	auth := Authorizer{OrderStorage: orderStorage}
+
+	reader := at.cashier(OrderRead)
+	writer := at.cashier(OrderRead, OrderWrite)
+
+	assert.Equal(t, granted, outcome(auth.CanGetOrder(reader, orderA.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanGetOrder(reader, orderB.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanGetOrder(at.cashier(), orderA.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanGetOrder(at.stranger, orderA.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanGetOrder(at.owner, NewID("ord"))))
+	assert.Equal(t, granted, outcome(auth.CanGetOrder(at.owner, orderB.ID)))
+
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateOrder(reader, orderA.ID)))
+	assert.Equal(t, granted, outcome(auth.CanUpdateOrder(writer, orderA.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateOrder(writer, orderB.ID)))
+
+	schema := OrderSchema{LocationID: at.locationA, MerchantID: at.merchantID}
+	assert.Equal(t, granted, outcome(auth.CanCreateOrder(writer, schema)))
+	assert.Equal(t, forbidden, outcome(auth.CanCreateOrder(reader, schema)))
+	schema.LocationID = at.locationB
+	assert.Equal(t, forbidden, outcome(auth.CanCreateOrder(writer, schema)))
+	assert.Equal(t, notFound, outcome(auth.CanCreateOrder(at.stranger, schema)))
+}
+
+func TestAuthorizerPayments(t *testing.T) {
+	at := newAuthTest()
+	order := NewOrder(at.locationA, at.merchantID)
+
+	orderStorage := NewMockOrderStorage()
+	orderStorage.GetFn = func(ctx context.Context, id ID) (Order, error) {
+		if id != order.ID {
+			return Order{}, errors.E(errors.KindNotFound)
+		}
+		return order, nil
+	}
+	auth := Authorizer{OrderStorage: orderStorage}
+
+	writer := at.cashier(OrderWrite)
+	payment := NewPayment(PaymentCash, order.ID, at.merchantID, at.locationA)
+	assert.Equal(t, granted, outcome(auth.CanCreatePayment(writer, payment)))
+	assert.Equal(t, forbidden, outcome(auth.CanCreatePayment(at.cashier(OrderRead), payment)))
+	assert.Equal(t, notFound, outcome(auth.CanCreatePayment(at.stranger, payment)))
+
+	// Payments are taken at the order location
+	elsewhere := NewPayment(PaymentCash, order.ID, at.merchantID, at.locationB)
+	assert.Equal(t, forbidden, outcome(auth.CanCreatePayment(at.owner, elsewhere)))
+
+	unknown := NewPayment(PaymentCash, NewID("ord"), at.merchantID, at.locationA)
+	assert.Equal(t, notFound, outcome(auth.CanCreatePayment(at.owner, unknown)))
+}
+
+func TestAuthorizerTaxes(t *testing.T) {
+	at := newAuthTest()
+	tax := NewTax("IGV", at.merchantID)
+	tax.LocationIDs = []ID{at.locationA, at.locationB}
+	taxB := NewTax("ISC", at.merchantID)
+	taxB.LocationIDs = []ID{at.locationB}
+	taxes := map[ID]Tax{tax.ID: tax, taxB.ID: taxB}
+
+	taxStorage := NewMockTaxStorage()
+	taxStorage.GetFn = func(ctx context.Context, id ID) (Tax, error) {
+		t, ok := taxes[id]
+		if !ok {
+			return Tax{}, errors.E(errors.KindNotFound)
+		}
+		return t, nil
+	}
+	auth := Authorizer{TaxStorage: taxStorage}
+
+	reader := at.cashier(CatalogRead)
+	writer := at.cashier(CatalogRead, CatalogWrite)
+
+	// Taxes of any of the employee locations can be seen, but only changed if
+	// they belong to the employee locations alone
+	assert.Equal(t, granted, outcome(auth.CanGetTax(reader, tax.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanGetTax(reader, taxB.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanGetTax(at.stranger, tax.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateTax(reader, tax.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateTax(writer, tax.ID)))
+	assert.Equal(t, granted, outcome(auth.CanUpdateTax(at.owner, tax.ID)))
+
+	created := NewTax("IVA", at.merchantID)
+	created.LocationIDs = []ID{at.locationA}
+	assert.Equal(t, granted, outcome(auth.CanCreateTax(writer, created)))
+	assert.Equal(t, forbidden, outcome(auth.CanCreateTax(reader, created)))
+	created.LocationIDs = []ID{at.locationB}
+	assert.Equal(t, forbidden, outcome(auth.CanCreateTax(writer, created)))
+}
+
+func TestAuthorizerDiscounts(t *testing.T) {
+	at := newAuthTest()
+	discount := NewDiscount("Happy hour", DiscountPercentage, at.merchantID)
+	discount.LocationIDs = []ID{at.locationA}
+	discountB := NewDiscount("Opening", DiscountFixed, at.merchantID)
+	discountB.LocationIDs = []ID{at.locationB}
+	discounts := map[ID]Discount{discount.ID: discount, discountB.ID: discountB}
+
+	discountStorage := NewMockDiscountStorage()
+	discountStorage.GetFn = func(ctx context.Context, id ID) (Discount, error) {
+		d, ok := discounts[id]
+		if !ok {
+			return Discount{}, errors.E(errors.KindNotFound)
+		}
+		return d, nil
+	}
+	auth := Authorizer{DiscountStorage: discountStorage}
+
+	reader := at.cashier(CatalogRead)
+	writer := at.cashier(CatalogRead, CatalogWrite)
+
+	assert.Equal(t, granted, outcome(auth.CanGetDiscount(reader, discount.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanGetDiscount(reader, discountB.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanGetDiscount(at.stranger, discount.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateDiscount(reader, discount.ID)))
+	assert.Equal(t, granted, outcome(auth.CanUpdateDiscount(writer, discount.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateDiscount(writer, discountB.ID)))
+	assert.Equal(t, granted, outcome(auth.CanCreateDiscount(writer, discount)))
+	assert.Equal(t, forbidden, outcome(auth.CanCreateDiscount(writer, discountB)))
+}
+
+func TestAuthorizerCustomers(t *testing.T) {
+	at := newAuthTest()
+	customer := NewCustomer("Maria", "maria@mail.com", at.merchantID)
+
+	customerStorage := NewMockCustomerStorage()
+	customerStorage.GetFn = func(ctx context.Context, id ID) (Customer, error) {
+		if id != customer.ID {
+			return Customer{}, errors.E(errors.KindNotFound)
+		}
+		return customer, nil
+	}
+	auth := Authorizer{CustomerStorage: customerStorage}
+
+	reader := at.cashier(CustomerRead)
+	writer := at.cashier(CustomerRead, CustomerWrite)
+
+	assert.Equal(t, granted, outcome(auth.CanGetCustomer(reader, customer.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanGetCustomer(at.cashier(), customer.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanGetCustomer(at.stranger, customer.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateCustomer(reader, customer.ID)))
+	assert.Equal(t, granted, outcome(auth.CanUpdateCustomer(writer, customer.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanUpdateCustomer(writer, NewID("cust"))))
+	assert.Equal(t, granted, outcome(auth.CanCreateCustomer(writer, customer)))
+	assert.Equal(t, notFound, outcome(auth.CanCreateCustomer(at.stranger, customer)))
+}
+
+func TestAuthorizerCashDrawers(t *testing.T) {
+	at := newAuthTest()
+	drawerA := NewCashDrawer(at.locationA, at.merchantID)
+	drawerB := NewCashDrawer(at.locationB, at.merchantID)
+	drawers := map[ID]CashDrawer{drawerA.ID: drawerA, drawerB.ID: drawerB}
+	shiftA := NewCashDrawerShift(drawerA)
+	shiftB := NewCashDrawerShift(drawerB)
+	shifts := map[ID]CashDrawerShift{shiftA.ID: shiftA, shiftB.ID: shiftB}
+
+	cashDrawerStorage := NewMockCashDrawerStorage()
+	cashDrawerStorage.GetFn = func(ctx context.Context, id ID) (CashDrawer, error) {
+		d, ok := drawers[id]
+		if !ok {
+			return CashDrawer{}, errors.E(errors.KindNotFound)
+		}
+		return d, nil
+	}
+	cashDrawerStorage.GetShiftFn = func(ctx context.Context, id ID) (CashDrawerShift, error) {
+		s, ok := shifts[id]
+		if !ok {
+			return CashDrawerShift{}, errors.E(errors.KindNotFound)
+		}
+		return s, nil
+	}
+	auth := Authorizer{CashDrawerStorage: cashDrawerStorage}
+
+	cashier := at.cashier(CashDrawerAccess)
+
+	assert.Equal(t, granted, outcome(auth.CanUseCashDrawer(cashier, drawerA.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUseCashDrawer(cashier, drawerB.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUseCashDrawer(at.cashier(SalesAccess), drawerA.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanUseCashDrawer(at.stranger, drawerA.ID)))
+	assert.Equal(t, granted, outcome(auth.CanUseCashDrawerShift(cashier, shiftA.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUseCashDrawerShift(cashier, shiftB.ID)))
+	assert.Equal(t, granted, outcome(auth.CanCreateCashDrawer(cashier, NewCashDrawer(at.locationA, at.merchantID))))
+	assert.Equal(t, forbidden, outcome(auth.CanCreateCashDrawer(cashier, NewCashDrawer(at.locationB, at.merchantID))))
+}
+
+func TestAuthorizerLocations(t *testing.T) {
+	at := newAuthTest()
+	locationA := NewLocation("Downtown", at.merchantID)
+	locationA.ID = at.locationA
+	locationB := NewLocation("Airport", at.merchantID)
+	locationB.ID = at.locationB
+	locations := map[ID]Location{locationA.ID: locationA, locationB.ID: locationB}
+
+	locationStorage := NewMockLocationStorage()
+	locationStorage.GetFn = func(ctx context.Context, id ID) (Location, error) {
+		l, ok := locations[id]
+		if !ok {
+			return Location{}, errors.E(errors.KindNotFound)
+		}
+		return l, nil
+	}
+	auth := Authorizer{LocationStorage: locationStorage}
+
+	reader := at.cashier(LocationRead)
+	writer := at.cashier(LocationRead, LocationWrite)
+
+	assert.Equal(t, granted, outcome(auth.CanGetLocation(reader, at.locationA)))
+	assert.Equal(t, forbidden, outcome(auth.CanGetLocation(reader, at.locationB)))
+	assert.Equal(t, notFound, outcome(auth.CanGetLocation(at.stranger, at.locationA)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateLocation(reader, at.locationA)))
+	assert.Equal(t, granted, outcome(auth.CanUpdateLocation(writer, at.locationA)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateLocation(writer, at.locationB)))
+
+	// Only employees with access to every location can add new ones
+	assert.Equal(t, forbidden, outcome(auth.CanCreateLocation(writer, NewLocation("Mall", at.merchantID))))
+	assert.Equal(t, granted, outcome(auth.CanCreateLocation(at.owner, NewLocation("Mall", at.merchantID))))
+}
+
+func TestAuthorizerCatalog(t *testing.T) {
+	at := newAuthTest()
+	item := NewItem("Coffee", NewID("cat"), at.merchantID)
+	item.LocationIDs = []ID{at.locationB}
+	variation := NewItemVariation("Large", item.ID, at.merchantID)
+	variation.LocationIDs = []ID{at.locationA}
+	category := NewCategory("Drinks", at.merchantID)
+
+	itemStorage := NewMockItemStorage()
+	itemStorage.GetFn = func(ctx context.Context, id ID) (Item, error) {
+		return item, nil
+	}
+	variationStorage := NewMockItemVariationStorage()
+	variationStorage.GetFn = func(ctx context.Context, id ID) (ItemVariation, error) {
+		return variation, nil
+	}
+	variationStorage.ListFn = func(ctx context.Context, q ItemVariationQuery) ([]ItemVariation, int64, error) {
+		return []ItemVariation{variation}, 1, nil
+	}
+	categoryStorage := NewMockCategoryStorage()
+	categoryStorage.GetFn = func(ctx context.Context, id ID) (Category, error) {
+		return category, nil
+	}
+	auth := Authorizer{
+		ItemStorage:          itemStorage,
+		ItemVariationStorage: variationStorage,
+		CategoryStorage:      categoryStorage,
+	}
+
+	reader := at.cashier(CatalogRead)
+	writer := at.cashier(CatalogRead, CatalogWrite)
+
+	assert.Equal(t, forbidden, outcome(auth.CanGetItem(reader, item.ID)))
+	assert.Equal(t, granted, outcome(auth.CanGetItem(at.owner, item.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateItem(writer, item.ID)))
+	assert.Equal(t, granted, outcome(auth.CanGetItemVariation(reader, variation.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanGetItemVariation(at.stranger, variation.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateItemVariation(reader, variation.ID)))
+	assert.Equal(t, granted, outcome(auth.CanUpdateItemVariation(writer, variation.ID)))
+	assert.Equal(t, granted, outcome(auth.CanGetCategory(reader, category.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateCategory(reader, category.ID)))
+	assert.Equal(t, granted, outcome(auth.CanUpdateCategory(writer, category.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanGetCategory(at.stranger, category.ID)))
+
+	// Inventory changes need the permission and the locations
+	stocker := at.cashier(InventoryWrite)
+	assert.Equal(t, granted, outcome(auth.CanChangeInventory(stocker, []ID{variation.ID}, []ID{at.locationA})))
+	assert.Equal(t, forbidden, outcome(auth.CanChangeInventory(stocker, []ID{variation.ID}, []ID{at.locationB})))
+	assert.Equal(t, forbidden, outcome(auth.CanChangeInventory(reader, []ID{variation.ID}, []ID{at.locationA})))
+	assert.Equal(t, notFound, outcome(auth.CanChangeInventory(at.stranger, []ID{variation.ID}, []ID{at.locationA})))
+}
+
+func TestAuthorizerReports(t *testing.T) {
+	at := newAuthTest()
+	auth := Authorizer{}
+
+	analyst := at.cashier(ReportRead)
+	assert.Equal(t, granted, outcome(auth.CanGenerateReport(analyst, []ID{at.locationA})))
+	assert.Equal(t, forbidden, outcome(auth.CanGenerateReport(analyst, []ID{at.locationB})))
+	assert.Equal(t, forbidden, outcome(auth.CanGenerateReport(analyst, nil)))
+	assert.Equal(t, forbidden, outcome(auth.CanGenerateReport(at.cashier(OrderRead), []ID{at.locationA})))
+	assert.Equal(t, granted, outcome(auth.CanGenerateReport(at.owner, nil)))
+}
+
+func TestAuthorizerTipPools(t *testing.T) {
+	at := newAuthTest()
+	poolA := NewTipPool("Downtown", TipPoolMethodEqual, at.merchantID)
+	poolA.LocationIDs = []ID{at.locationA}
+	poolAB := NewTipPool("Downtown and airport", TipPoolMethodEqual, at.merchantID)
+	poolAB.LocationIDs = []ID{at.locationA, at.locationB}
+	poolAll := NewTipPool("Everywhere", TipPoolMethodEqual, at.merchantID)
+	pools := map[ID]TipPool{poolA.ID: poolA, poolAB.ID: poolAB, poolAll.ID: poolAll}
+
+	tipStorage := NewMockTipStorage()
+	tipStorage.GetPoolFn = func(ctx context.Context, id ID) (TipPool, error) {
+		pool, ok := pools[id]
+		if !ok {
+			return TipPool{}, errors.E(errors.KindNotFound)
+		}
+		return pool, nil
+	}
+	tipStorage.ListPoolFn = func(ctx context.Context, q TipPoolQuery) ([]TipPool, int64, error) {
+		assert.Equal(t, at.merchantID, q.Filter.MerchantID)
+		assert.Equal(t, []ID{at.locationA}, q.Filter.LocationIDs)
+		return []TipPool{poolA, poolAB}, 2, nil
+	}
+	auth := Authorizer{TipStorage: tipStorage}
+
+	// Restricted employees only see the pools of their locations
+	analyst := at.cashier(ReportRead)
+	assert.Equal(t, granted, outcome(auth.CanGetTipPool(analyst, poolA.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanGetTipPool(analyst, poolAB.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanGetTipPool(analyst, poolAll.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanGetTipPool(at.cashier(OrderRead), poolA.ID)))
+	assert.Equal(t, granted, outcome(auth.CanGetTipPool(at.owner, poolAll.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanGetTipPool(at.stranger, poolA.ID)))
+
+	scope, err := auth.TipPoolScope(analyst, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []ID{poolA.ID}, scope)
+	scope, err = auth.TipPoolScope(analyst, []ID{poolAB.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []ID{poolAB.ID}, scope)
+	scope, err = auth.TipPoolScope(at.owner, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, scope)
+
+	assert.Equal(t, granted, outcome(auth.CanSearchTipPayout(analyst, []ID{poolA.ID})))
+	assert.Equal(t, forbidden, outcome(auth.CanSearchTipPayout(analyst, []ID{poolA.ID, poolAB.ID})))
+	assert.Equal(t, forbidden, outcome(auth.CanSearchTipPayout(analyst, nil)))
+	assert.Equal(t, forbidden, outcome(auth.CanSearchTipPayout(at.cashier(OrderRead), []ID{poolA.ID})))
+	assert.Equal(t, granted, outcome(auth.CanSearchTipPayout(at.owner, nil)))
+}
+
+func TestAuthorizerEmployees(t *testing.T) {
+	at := newAuthTest()
+	employee := NewEmployee("Luis", "Diaz", at.merchantID)
+
+	employeeStorage := NewMockEmployeeStorage()
+	employeeStorage.GetFn = func(ctx context.Context, id ID) (Employee, error) {
+		if id != employee.ID {
+			return Employee{}, errors.E(errors.KindNotFound)
+		}
+		return employee, nil
+	}
+	auth := Authorizer{EmployeeStorage: employeeStorage}
+
+	assert.Equal(t, forbidden, outcome(auth.CanGetEmployee(at.cashier(), employee.ID)))
+	assert.Equal(t, granted, outcome(auth.CanGetEmployee(at.cashier(EmployeeRead), employee.ID)))
+	assert.Equal(t, granted, outcome(auth.CanGetEmployee(at.owner, employee.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanGetEmployee(at.stranger, employee.ID)))
+	assert.Equal(t, granted, outcome(auth.CanCreateEmployee(at.owner, employee)))
+	assert.Equal(t, forbidden, outcome(auth.CanCreateEmployee(at.cashier(), employee)))
+	assert.Equal(t, granted, outcome(auth.CanUpdateEmployee(at.owner, employee.ID)))
+	assert.Equal(t, forbidden, outcome(auth.CanUpdateEmployee(at.cashier(), employee.ID)))
+	assert.Equal(t, notFound, outcome(auth.CanUpdateEmployee(at.stranger, employee.ID)))
+
+	assert.True(t, auth.CanSeePay(at.owner, employee))
+	assert.False(t, auth.CanSeePay(at.cashier(EmployeeRead), employee))
+	assert.False(t, auth.CanSeePay(at.stranger, employee))
+}
+
+func TestAuthorizerFailsClosed(t *testing.T) {
+	at := newAuthTest()
+	storageErr := errors.E(errors.KindUnexpected, "connection lost")
+
+	employeeStorage := NewMockEmployeeStorage()
+	employeeStorage.GetFn = func(ctx context.Context, id ID) (Employee, error) {
+		return Employee{}, storageErr
+	}
+	itemStorage := NewMockItemStorage()
+	itemStorage.GetFn = func(ctx context.Context, id ID) (Item, error) {
+		return Item{}, storageErr
+	}
+	itemStorage.ListFn = func(ctx context.Context, q ItemQuery) ([]Item, int64, error) {
+		return nil, 0, storageErr
+	}
+	variationStorage := NewMockItemVariationStorage()
+	variationStorage.ListFn = func(ctx context.Context, q ItemVariationQuery) ([]ItemVariation, int64, error) {
+		return nil, 0, storageErr
+	}
+	auth := Authorizer{
+		EmployeeStorage:      employeeStorage,
+		ItemStorage:          itemStorage,
+		ItemVariationStorage: variationStorage,
+	}
+
+	assert.Equal(t, failed, outcome(auth.CanUpdateEmployee(at.owner, NewID("empl"))))
+	assert.Equal(t, failed, outcome(auth.CanGetItem(at.owner, NewID("item"))))
+	assert.Equal(t, failed, outcome(auth.CanUpdateItem(at.owner, NewID("item"))))
+	assert.Equal(t, failed, outcome(auth.CanSearchItem(at.owner, ItemFilter{MerchantID: at.merchantID})))
+	assert.Equal(t, failed, outcome(auth.CanChangeInventory(at.owner, []ID{NewID("itemvar")}, nil)))
+
+	// Checks without an employee in the context never grant access
+	assert.Equal(t, failed, outcome(auth.CanCreateItem(context.Background(), NewItem("Tea", NewID("cat"), at.merchantID))))
+	assert.Equal(t, failed, outcome(auth.CanSearch(context.Background(), CatalogRead, nil)))
+}
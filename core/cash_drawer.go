@@ -13,17 +13,40 @@ const (
 	CashDrawerOpRemove = "remove"
 )
 
+// CashDrawerReason marks the adjustments made by shifts, it's empty for cash
+// payments and cash paid in or out of the drawer
+type CashDrawerReason string
+
+const (
+	// The drawer balance is set to the starting float when a shift opens
+	CashDrawerReasonOpeningFloat CashDrawerReason = "opening_float"
+	// The drawer balance is set to the counted cash when a shift closes
+	CashDrawerReasonCountCorrection CashDrawerReason = "count_correction"
+)
+
 type CashDrawerAdjustment struct {
-	ID            ID           `bson:"_id"`
-	CashDrawerID  ID           `bson:"cash_drawer_id"`
-	Amount        Money        `bson:"amount"`
-	Op            CashDrawerOp `bson:"operation"`
-	Note          string       `bson:"note"`
-	AutoGenerated bool         `bson:"auto_generated"`
-	EmployeeID    ID           `bson:"employee_id"`
-	LocationID    ID           `bson:"location_id"`
-	MerchantID    ID           `bson:"merchant_id"`
-	CreatedAt     int64        `bson:"created_at"`
+	ID           ID               `bson:"_id"`
+	CashDrawerID ID               `bson:"cash_drawer_id"`
+	ShiftID      ID               `bson:"shift_id"`
+	Amount       Money            `bson:"amount"`
+	Op           CashDrawerOp     `bson:"operation"`
+	Reason       CashDrawerReason `bson:"reason"`
+	// Optional breakdown by denomination, the amount is calculated from it
+	CashCount     *CashCount `bson:"cash_count"`
+	Note          string     `bson:"note"`
+	AutoGenerated bool       `bson:"auto_generated"`
+	EmployeeID    ID         `bson:"employee_id"`
+	LocationID    ID         `bson:"location_id"`
+	MerchantID    ID         `bson:"merchant_id"`
+	CreatedAt     int64      `bson:"created_at"`
+}
+
+// Delta returns the signed change in the drawer balance caused by the adjustment
+func (adj CashDrawerAdjustment) Delta() int64 {
+	if adj.Op == CashDrawerOpRemove {
+		return -adj.Amount.Value
+	}
+	return adj.Amount.Value
 }
 
 func NewCashDrawerAdjustment(cashDrawerID, merchantID ID) CashDrawerAdjustment {
@@ -34,9 +57,16 @@ func NewCashDrawerAdjustment(cashDrawerID, merchantID ID) CashDrawerAdjustment {
 	}
 }
 
+// DefaultCashDrawerName is the name of the drawer created with each location
+const DefaultCashDrawerName = "Main"
+
+// CashDrawer holds the cash of a register, a location can have several of them
 type CashDrawer struct {
-	ID           ID    `bson:"_id"`
-	Amount       Money `bson:"amount"`
+	ID     ID     `bson:"_id"`
+	Name   string `bson:"name"`
+	Amount Money  `bson:"amount"`
+	// The currently open shift, empty if the drawer is closed
+	ShiftID      ID    `bson:"shift_id"`
 	CalculatedAt int64 `bson:"calculated_at"`
 	LocationID   ID    `bson:"location_id"`
 	MerchantID   ID    `bson:"merchant_id"`
@@ -45,13 +75,18 @@ type CashDrawer struct {
 func NewCashDrawer(locationID, merchantID ID) CashDrawer {
 	return CashDrawer{
 		ID:         NewID("cash"),
+		Name:       DefaultCashDrawerName,
 		LocationID: locationID,
 		MerchantID: merchantID,
 	}
 }
 
 type CashDrawerFilter struct {
-	IDs           []ID
+	IDs []ID
+	// Only used by adjustment queries
+	CashDrawerIDs []ID
+	ShiftIDs      []ID
+	CreatedAt     DateFilter
 	LocationIDs   []ID
 	MerchantID    ID
 	AutoGenerated bool
@@ -69,41 +104,53 @@ type CashDrawerStorage interface {
 	Get(context.Context, ID) (CashDrawer, error)
 	List(context.Context, CashDrawerQuery) ([]CashDrawer, int64, error)
 	ListAdjustment(context.Context, CashDrawerQuery) ([]CashDrawerAdjustment, int64, error)
+	// ApplyAdjustment atomically increments the drawer balance by the adjustment delta and
+	// stores the adjustment, attached to the drawer location and current shift
+	ApplyAdjustment(context.Context, CashDrawerAdjustment) (CashDrawer, error)
+	// OpenShift stores the shift and sets it as the open shift of the drawer
+	// if it has none, the drawer balance is adjusted to the opening float
+	OpenShift(context.Context, CashDrawerShift) (CashDrawer, error)
+	// CloseShift stores the closed shift reconciled with the adjustments made
+	// during it if it's still the open shift of the drawer, the drawer balance
+	// is adjusted to the counted amount
+	CloseShift(context.Context, CashDrawerShift) (CashDrawerShift, error)
+	PutShift(context.Context, CashDrawerShift) error
+	GetShift(context.Context, ID) (CashDrawerShift, error)
+	ListShift(context.Context, CashDrawerShiftQuery) ([]CashDrawerShift, int64, error)
 }
 
-func adjustCashDrawer(ctx context.Context, storage CashDrawerStorage, adj CashDrawerAdjustment) error {
+func adjustCashDrawer(ctx context.Context, storage CashDrawerStorage, adj CashDrawerAdjustment) (CashDrawer, error) {
 	const op = errors.Op("core/adjustCashDrawer")
 
 	user := UserFromContext(ctx)
 	if user == nil {
-		return errors.E(op, errors.KindUnexpected, "Unknown user")
+		return CashDrawer{}, errors.E(op, errors.KindUnexpected, "Unknown user")
 	}
 
-	cash, err := storage.Get(ctx, adj.CashDrawerID)
-	if err != nil {
-		return errors.E(op, err)
+	if adj.Op != CashDrawerOpAdd && adj.Op != CashDrawerOpRemove {
+		return CashDrawer{}, errors.E(op, errors.KindValidation, "Invalid cashdrawer operation")
 	}
 
-	switch adj.Op {
-	case CashDrawerOpAdd:
-		cash.Amount.Value += adj.Amount.Value
-	case CashDrawerOpRemove:
-		cash.Amount.Value -= adj.Amount.Value
-	default:
-		return errors.E(op, errors.KindValidation, "Invalid cashdrawer operation")
+	if adj.CashCount != nil {
+		cash, err := storage.Get(ctx, adj.CashDrawerID)
+		if err != nil {
+			return CashDrawer{}, errors.E(op, err)
+		}
+		count, err := NewCashCount(cash.Amount.Currency, adj.CashCount.Denominations)
+		if err != nil {
+			return CashDrawer{}, errors.E(op, err)
+		}
+		adj.CashCount = &count
+		adj.Amount = count.Total
 	}
 
-	adj.LocationID = cash.LocationID
 	adj.EmployeeID = user.EmployeeID
-	if err := storage.PutAdj(ctx, adj); err != nil {
-		return errors.E(op, err)
-	}
-
-	if err := storage.Put(ctx, cash); err != nil {
-		return errors.E(op, err)
+	cash, err := storage.ApplyAdjustment(ctx, adj)
+	if err != nil {
+		return CashDrawer{}, errors.E(op, err)
 	}
 
-	return nil
+	return cash, nil
 }
 
 func (s *LocationService) AdjustCashDrawer(ctx context.Context, adj CashDrawerAdjustment) (CashDrawer, error) {
@@ -114,16 +161,41 @@ func (s *LocationService) AdjustCashDrawer(ctx context.Context, adj CashDrawerAd
 		return CashDrawer{}, errors.E(op, errors.KindUnexpected, "Unknown user")
 	}
 
-	if err := adjustCashDrawer(ctx, s.CashDrawerStorage, adj); err != nil {
+	cash, err := adjustCashDrawer(ctx, s.CashDrawerStorage, adj)
+	if err != nil {
 		return CashDrawer{}, errors.E(op, err)
 	}
 
-	cash, err := s.CashDrawerStorage.Get(ctx, adj.CashDrawerID)
+	return cash, nil
+}
+
+func (s *LocationService) CreateCashDrawer(ctx context.Context, drawer CashDrawer) (CashDrawer, error) {
+	const op = errors.Op("core/LocationService.CreateCashDrawer")
+
+	merchant := MerchantFromContext(ctx)
+	if merchant == nil {
+		return CashDrawer{}, errors.E(op, errors.KindUnexpected, "Unknown merchant")
+	}
+
+	location, err := s.LocationStorage.Get(ctx, drawer.LocationID)
 	if err != nil {
 		return CashDrawer{}, errors.E(op, err)
 	}
+	if location.MerchantID != drawer.MerchantID {
+		return CashDrawer{}, errors.E(op, errors.KindNotFound, "Location not found")
+	}
 
-	return cash, nil
+	drawer.Amount = NewMoney(0, merchant.Currency)
+	if err := s.CashDrawerStorage.Put(ctx, drawer); err != nil {
+		return CashDrawer{}, errors.E(op, err)
+	}
+
+	drawer, err = s.CashDrawerStorage.Get(ctx, drawer.ID)
+	if err != nil {
+		return CashDrawer{}, errors.E(op, err)
+	}
+
+	return drawer, nil
 }
 
 func (s *LocationService) ListCashDrawer(ctx context.Context, q CashDrawerQuery) ([]CashDrawer, int64, error) {
@@ -0,0 +1,291 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/backium/backend/errors"
+)
+
+type CashDrawerShiftState string
+
+const (
+	CashDrawerShiftStateOpen   CashDrawerShiftState = "open"
+	CashDrawerShiftStateClosed CashDrawerShiftState = "closed"
+)
+
+// CashDrawerShift is the period between opening a drawer with a starting float
+// and closing it with the counted cash
+type CashDrawerShift struct {
+	ID           ID                   `bson:"_id"`
+	CashDrawerID ID                   `bson:"cash_drawer_id"`
+	State        CashDrawerShiftState `bson:"state"`
+	// Starting float
+	OpeningAmount Money `bson:"opening_amount"`
+	// Cash received from payments, tips included
+	CashPaymentAmount Money `bson:"cash_payment_amount"`
+	// Cash manually added to the drawer
+	PaidInAmount Money `bson:"paid_in_amount"`
+	// Cash manually removed from the drawer
+	PaidOutAmount  Money `bson:"paid_out_amount"`
+	ExpectedAmount Money `bson:"expected_amount"`
+	CountedAmount  Money `bson:"counted_amount"`
+	// Breakdown by denomination of the counted amount, if given at close
+	ClosingCount *CashCount `bson:"closing_count"`
+	// Counted minus expected amount, negative when the drawer is short
+	DifferenceAmount  Money  `bson:"difference_amount"`
+	OpeningNote       string `bson:"opening_note"`
+	ClosingNote       string `bson:"closing_note"`
+	OpeningEmployeeID ID     `bson:"opening_employee_id"`
+	ClosingEmployeeID ID     `bson:"closing_employee_id"`
+	OpenedAt          int64  `bson:"opened_at"`
+	ClosedAt          int64  `bson:"closed_at"`
+	LocationID        ID     `bson:"location_id"`
+	MerchantID        ID     `bson:"merchant_id"`
+}
+
+func NewCashDrawerShift(drawer CashDrawer) CashDrawerShift {
+	currency := drawer.Amount.Currency
+	return CashDrawerShift{
+		ID:                NewID("cashshift"),
+		CashDrawerID:      drawer.ID,
+		State:             CashDrawerShiftStateOpen,
+		OpeningAmount:     NewMoney(0, currency),
+		CashPaymentAmount: NewMoney(0, currency),
+		PaidInAmount:      NewMoney(0, currency),
+		PaidOutAmount:     NewMoney(0, currency),
+		ExpectedAmount:    NewMoney(0, currency),
+		CountedAmount:     NewMoney(0, currency),
+		DifferenceAmount:  NewMoney(0, currency),
+		OpenedAt:          time.Now().Unix(),
+		LocationID:        drawer.LocationID,
+		MerchantID:        drawer.MerchantID,
+	}
+}
+
+// calculate sets the shift totals from the drawer adjustments made during the
+// shift, the opening float and count correction are already in the opening and
+// counted amounts
+func (shift *CashDrawerShift) calculate(adjs []CashDrawerAdjustment) {
+	shift.CashPaymentAmount.Value = 0
+	shift.PaidInAmount.Value = 0
+	shift.PaidOutAmount.Value = 0
+	for _, adj := range adjs {
+		if adj.ShiftID != shift.ID || adj.Reason != "" {
+			continue
+		}
+		switch {
+		case adj.Op == CashDrawerOpAdd && adj.AutoGenerated:
+			shift.CashPaymentAmount.Value += adj.Amount.Value
+		case adj.Op == CashDrawerOpAdd:
+			shift.PaidInAmount.Value += adj.Amount.Value
+		case adj.Op == CashDrawerOpRemove:
+			shift.PaidOutAmount.Value += adj.Amount.Value
+		}
+	}
+	shift.ExpectedAmount.Value = shift.OpeningAmount.Value +
+		shift.CashPaymentAmount.Value +
+		shift.PaidInAmount.Value -
+		shift.PaidOutAmount.Value
+	if shift.State == CashDrawerShiftStateClosed {
+		shift.DifferenceAmount.Value = shift.CountedAmount.Value - shift.ExpectedAmount.Value
+	}
+}
+
+// OpeningFloat returns the adjustment that sets the drawer balance to the
+// starting float of the shift, false if the balance already matches
+func (shift CashDrawerShift) OpeningFloat(balance Money) (CashDrawerAdjustment, bool) {
+	delta := shift.OpeningAmount.Value - balance.Value
+	if delta == 0 {
+		return CashDrawerAdjustment{}, false
+	}
+	adj := shiftAdjustment(shift, CashDrawerReasonOpeningFloat, delta)
+	adj.EmployeeID = shift.OpeningEmployeeID
+	return adj, true
+}
+
+// Reconcile calculates the totals of the closed shift from the adjustments
+// made during it and returns the adjustment that sets the drawer balance to
+// the counted amount, false if the balance already matches
+func (shift *CashDrawerShift) Reconcile(adjs []CashDrawerAdjustment, balance Money) (CashDrawerAdjustment, bool) {
+	shift.calculate(adjs)
+	delta := shift.CountedAmount.Value - balance.Value
+	if delta == 0 {
+		return CashDrawerAdjustment{}, false
+	}
+	adj := shiftAdjustment(*shift, CashDrawerReasonCountCorrection, delta)
+	adj.EmployeeID = shift.ClosingEmployeeID
+	return adj, true
+}
+
+// shiftAdjustment returns an adjustment of the shift drawer that changes its
+// balance by delta
+func shiftAdjustment(shift CashDrawerShift, reason CashDrawerReason, delta int64) CashDrawerAdjustment {
+	adj := NewCashDrawerAdjustment(shift.CashDrawerID, shift.MerchantID)
+	adj.ShiftID = shift.ID
+	adj.LocationID = shift.LocationID
+	adj.Reason = reason
+	adj.AutoGenerated = true
+	adj.Op = CashDrawerOpAdd
+	if delta < 0 {
+		adj.Op = CashDrawerOpRemove
+		delta = -delta
+	}
+	adj.Amount = NewMoney(delta, shift.OpeningAmount.Currency)
+	return adj
+}
+
+type CashDrawerShiftFilter struct {
+	IDs           []ID
+	CashDrawerIDs []ID
+	LocationIDs   []ID
+	EmployeeIDs   []ID
+	States        []CashDrawerShiftState
+	OpenedAt      DateFilter
+	MerchantID    ID
+}
+
+type CashDrawerShiftQuery struct {
+	Limit  int64
+	Offset int64
+	Filter CashDrawerShiftFilter
+}
+
+func (s *LocationService) OpenCashDrawerShift(ctx context.Context, drawerID ID, openingAmount Money, note string) (CashDrawerShift, error) {
+	const op = errors.Op("core/LocationService.OpenCashDrawerShift")
+
+	user := UserFromContext(ctx)
+	if user == nil {
+		return CashDrawerShift{}, errors.E(op, errors.KindUnexpected, "Unknown user")
+	}
+
+	drawer, err := s.CashDrawerStorage.Get(ctx, drawerID)
+	if err != nil {
+		return CashDrawerShift{}, errors.E(op, err)
+	}
+	if drawer.MerchantID != user.MerchantID {
+		return CashDrawerShift{}, errors.E(op, errors.KindNotFound, "Cash drawer not found")
+	}
+	if drawer.ShiftID != "" {
+		return CashDrawerShift{}, errors.E(op, errors.KindValidation, "Cash drawer already has an open shift")
+	}
+
+	shift := NewCashDrawerShift(drawer)
+	shift.OpeningAmount.Value = openingAmount.Value
+	shift.ExpectedAmount.Value = openingAmount.Value
+	shift.OpeningNote = note
+	shift.OpeningEmployeeID = user.EmployeeID
+	// The drawer starts the shift with the float in it, the storage fails if
+	// another shift was opened since the drawer was read
+	if _, err := s.CashDrawerStorage.OpenShift(ctx, shift); err != nil {
+		return CashDrawerShift{}, errors.E(op, err)
+	}
+
+	return shift, nil
+}
+
+// CloseCashDrawerShift closes the shift with the counted cash. When a count by
+// denomination is given the counted amount is calculated from it.
+func (s *LocationService) CloseCashDrawerShift(ctx context.Context, shiftID ID, countedAmount Money, count *CashCount, note string) (CashDrawerShift, error) {
+	const op = errors.Op("core/LocationService.CloseCashDrawerShift")
+
+	user := UserFromContext(ctx)
+	if user == nil {
+		return CashDrawerShift{}, errors.E(op, errors.KindUnexpected, "Unknown user")
+	}
+
+	shift, err := s.GetCashDrawerShift(ctx, shiftID)
+	if err != nil {
+		return CashDrawerShift{}, errors.E(op, err)
+	}
+	if shift.State == CashDrawerShiftStateClosed {
+		return CashDrawerShift{}, errors.E(op, errors.KindValidation, "Cash drawer shift is already closed")
+	}
+
+	drawer, err := s.CashDrawerStorage.Get(ctx, shift.CashDrawerID)
+	if err != nil {
+		return CashDrawerShift{}, errors.E(op, err)
+	}
+
+	if count != nil {
+		c, err := NewCashCount(drawer.Amount.Currency, count.Denominations)
+		if err != nil {
+			return CashDrawerShift{}, errors.E(op, err)
+		}
+		shift.ClosingCount = &c
+		countedAmount = c.Total
+	}
+
+	shift.State = CashDrawerShiftStateClosed
+	shift.CountedAmount.Value = countedAmount.Value
+	shift.ClosingNote = note
+	shift.ClosingEmployeeID = user.EmployeeID
+	shift.ClosedAt = time.Now().Unix()
+	// The drawer balance becomes what was actually counted, the storage
+	// reconciles the shift with the adjustments made up to its close
+	shift, err = s.CashDrawerStorage.CloseShift(ctx, shift)
+	if err != nil {
+		return CashDrawerShift{}, errors.E(op, err)
+	}
+
+	return shift, nil
+}
+
+// GetCashDrawerShift returns the shift, totals of open shifts are calculated up to now
+func (s *LocationService) GetCashDrawerShift(ctx context.Context, shiftID ID) (CashDrawerShift, error) {
+	const op = errors.Op("core/LocationService.GetCashDrawerShift")
+
+	user := UserFromContext(ctx)
+	if user == nil {
+		return CashDrawerShift{}, errors.E(op, errors.KindUnexpected, "Unknown user")
+	}
+
+	shift, err := s.CashDrawerStorage.GetShift(ctx, shiftID)
+	if err != nil {
+		return CashDrawerShift{}, errors.E(op, err)
+	}
+	if shift.MerchantID != user.MerchantID {
+		return CashDrawerShift{}, errors.E(op, errors.KindNotFound, "Cash drawer shift not found")
+	}
+	if shift.State == CashDrawerShiftStateClosed {
+		return shift, nil
+	}
+
+	adjs, err := s.listShiftAdjustments(ctx, shift)
+	if err != nil {
+		return CashDrawerShift{}, errors.E(op, err)
+	}
+	shift.calculate(adjs)
+
+	return shift, nil
+}
+
+func (s *LocationService) ListCashDrawerShift(ctx context.Context, q CashDrawerShiftQuery) ([]CashDrawerShift, int64, error) {
+	const op = errors.Op("core/LocationService.ListCashDrawerShift")
+
+	shifts, count, err := s.CashDrawerStorage.ListShift(ctx, q)
+	if err != nil {
+		return nil, 0, errors.E(op, err)
+	}
+
+	return shifts, count, nil
+}
+
+// listShiftAdjustments returns both manual and auto generated adjustments of the shift
+func (s *LocationService) listShiftAdjustments(ctx context.Context, shift CashDrawerShift) ([]CashDrawerAdjustment, error) {
+	filter := CashDrawerFilter{
+		ShiftIDs:   []ID{shift.ID},
+		MerchantID: shift.MerchantID,
+	}
+	manual, _, err := s.CashDrawerStorage.ListAdjustment(ctx, CashDrawerQuery{Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+	filter.AutoGenerated = true
+	auto, _, err := s.CashDrawerStorage.ListAdjustment(ctx, CashDrawerQuery{Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(manual, auto...), nil
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCashDrawerShift(t *testing.T) {
+	merchantID := NewID("merch")
+	employeeID := NewID("emp")
+	drawer := NewCashDrawer(NewID("loc"), merchantID)
+	drawer.Amount = NewMoney(500, PEN)
+
+	ctx := context.Background()
+	ctx = ContextWithUser(ctx, &User{MerchantID: merchantID, EmployeeID: employeeID})
+
+	storage := NewMockCashDrawerStorage()
+	shifts := map[ID]CashDrawerShift{}
+	var adjs []CashDrawerAdjustment
+	storage.GetFn = func(ctx context.Context, id ID) (CashDrawer, error) {
+		return drawer, nil
+	}
+	storage.ApplyAdjFn = func(ctx context.Context, adj CashDrawerAdjustment) (CashDrawer, error) {
+		adj.ShiftID = drawer.ShiftID
+		adjs = append(adjs, adj)
+		drawer.Amount.Value += adj.Delta()
+		return drawer, nil
+	}
+	storage.ListAdjustmentFn = func(ctx context.Context, q CashDrawerQuery) ([]CashDrawerAdjustment, int64, error) {
+		var res []CashDrawerAdjustment
+		for _, adj := range adjs {
+			if adj.AutoGenerated == q.Filter.AutoGenerated {
+				res = append(res, adj)
+			}
+		}
+		return res, int64(len(res)), nil
+	}
+	storage.OpenShiftFn = func(ctx context.Context, shift CashDrawerShift) (CashDrawer, error) {
+		if drawer.ShiftID != "" {
+			return CashDrawer{}, errors.E(errors.KindValidation, "Cash drawer already has an open shift")
+		}
+		drawer.ShiftID = shift.ID
+		shifts[shift.ID] = shift
+		if adj, ok := shift.OpeningFloat(drawer.Amount); ok {
+			adjs = append(adjs, adj)
+			drawer.Amount.Value += adj.Delta()
+		}
+		return drawer, nil
+	}
+	storage.CloseShiftFn = func(ctx context.Context, shift CashDrawerShift) (CashDrawerShift, error) {
+		if drawer.ShiftID != shift.ID {
+			return CashDrawerShift{}, errors.E(errors.KindValidation, "Cash drawer shift is already closed")
+		}
+		drawer.ShiftID = ""
+		if adj, ok := shift.Reconcile(adjs, drawer.Amount); ok {
+			adjs = append(adjs, adj)
+			drawer.Amount.Value += adj.Delta()
+		}
+		shifts[shift.ID] = shift
+		return shift, nil
+	}
+	storage.PutShiftFn = func(ctx context.Context, shift CashDrawerShift) error {
+		shifts[shift.ID] = shift
+		return nil
+	}
+	storage.GetShiftFn = func(ctx context.Context, id ID) (CashDrawerShift, error) {
+		return shifts[id], nil
+	}
+	svc := LocationService{CashDrawerStorage: storage}
+
+	shift, err := svc.OpenCashDrawerShift(ctx, drawer.ID, NewMoney(1000, PEN), "")
+	assert.NoError(t, err)
+	assert.Equal(t, shift.ID, drawer.ShiftID)
+	assert.Equal(t, int64(1000), drawer.Amount.Value)
+
+	_, err = svc.OpenCashDrawerShift(ctx, drawer.ID, NewMoney(1000, PEN), "")
+	assert.Error(t, err, "a drawer can't have two open shifts")
+
+	sale := NewCashDrawerAdjustment(drawer.ID, merchantID)
+	sale.Op = CashDrawerOpAdd
+	sale.Amount = NewMoney(2500, PEN)
+	sale.AutoGenerated = true
+	paidOut := NewCashDrawerAdjustment(drawer.ID, merchantID)
+	paidOut.Op = CashDrawerOpRemove
+	paidOut.Amount = NewMoney(300, PEN)
+	for _, adj := range []CashDrawerAdjustment{sale, paidOut} {
+		_, err := adjustCashDrawer(ctx, storage, adj)
+		assert.NoError(t, err)
+	}
+
+	shift, err = svc.CloseCashDrawerShift(ctx, shift.ID, NewMoney(3100, PEN), nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, CashDrawerShiftStateClosed, shift.State)
+	assert.Equal(t, int64(2500), shift.CashPaymentAmount.Value)
+	assert.Equal(t, int64(300), shift.PaidOutAmount.Value)
+	assert.Equal(t, int64(3200), shift.ExpectedAmount.Value)
+	assert.Equal(t, int64(-100), shift.DifferenceAmount.Value)
+	assert.Equal(t, employeeID, shift.ClosingEmployeeID)
+	assert.Equal(t, ID(""), drawer.ShiftID)
+	assert.Equal(t, int64(3100), drawer.Amount.Value)
+
+	_, err = svc.CloseCashDrawerShift(ctx, shift.ID, NewMoney(3100, PEN), nil, "")
+	assert.Error(t, err, "a shift can't be closed twice")
+
+	// The float and the count correction are in the drawer ledger
+	balance := int64(500)
+	reasons := map[CashDrawerReason]int64{}
+	for _, adj := range adjs {
+		balance += adj.Delta()
+		reasons[adj.Reason] += adj.Delta()
+	}
+	assert.Equal(t, drawer.Amount.Value, balance)
+	assert.Equal(t, int64(500), reasons[CashDrawerReasonOpeningFloat])
+	assert.Equal(t, int64(-100), reasons[CashDrawerReasonCountCorrection])
+}
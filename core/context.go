@@ -10,7 +10,9 @@ const (
 	contextKeyMerchant = contextKey("merchant")
 	contextKeyUser     = contextKey("user")
 	contextKeyEmployee = contextKey("employee")
+	contextKeyCustomer = contextKey("customer")
 	contextKeySession  = contextKey("session")
+	contextKeyAPIKey   = contextKey("api_key")
 )
 
 func ContextWithMerchant(ctx context.Context, merchant *Merchant) context.Context {
@@ -25,6 +27,10 @@ func ContextWithEmployee(ctx context.Context, employee *Employee) context.Contex
 	return context.WithValue(ctx, contextKeyEmployee, employee)
 }
 
+func ContextWithCustomer(ctx context.Context, customer *Customer) context.Context {
+	return context.WithValue(ctx, contextKeyCustomer, customer)
+}
+
 func ContextWithSession(ctx context.Context, sess *Session) context.Context {
 	return context.WithValue(ctx, contextKeySession, sess)
 }
@@ -68,6 +74,21 @@ func EmployeeFromContext(ctx context.Context) *Employee {
 	return t
 }
 
+// CustomerFromContext returns the customer of customer users, nil for
+// employees
+func CustomerFromContext(ctx context.Context) *Customer {
+	v := ctx.Value(contextKeyCustomer)
+	if v == nil {
+		return nil
+	}
+
+	t, ok := v.(*Customer)
+	if !ok {
+		return nil
+	}
+	return t
+}
+
 func SessionFromContext(ctx context.Context) *Session {
 	v := ctx.Value(contextKeySession)
 	if v == nil {
@@ -80,3 +101,22 @@ func SessionFromContext(ctx context.Context) *Session {
 	}
 	return t
 }
+
+func ContextWithKey(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, contextKeyAPIKey, key)
+}
+
+// KeyFromContext returns the API key used to authenticate the request, nil for
+// session requests
+func KeyFromContext(ctx context.Context) *Key {
+	v := ctx.Value(contextKeyAPIKey)
+	if v == nil {
+		return nil
+	}
+
+	t, ok := v.(*Key)
+	if !ok {
+		return nil
+	}
+	return t
+}
@@ -126,7 +126,9 @@ func (svc *CustomerService) DeleteCustomer(ctx context.Context, id ID) (Customer
 }
 
 type CustomerFilter struct {
-	Name       string
+	Name string
+	// Email matches the customers with the email regardless of its case
+	Email      string
 	IDs        []ID
 	MerchantID ID
 }
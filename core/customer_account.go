@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+
+	"github.com/backium/backend/errors"
+)
+
+// CustomerLoyalty sums up the purchases of a customer, only completed orders
+// count as visits
+type CustomerLoyalty struct {
+	Visits       int64
+	TotalSpent   Money
+	FirstVisitAt int64
+	LastVisitAt  int64
+}
+
+// CustomerAccountService gives customer users access to the data of their own
+// customer record
+type CustomerAccountService struct {
+	OrderStorage OrderStorage
+}
+
+func (svc *CustomerAccountService) ListOrder(ctx context.Context, q OrderQuery) ([]Order, int64, error) {
+	const op = errors.Op("core/CustomerAccountService.ListOrder")
+
+	customer := CustomerFromContext(ctx)
+	if customer == nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, "Unknown customer")
+	}
+
+	q.Filter.CustomerIDs = []ID{customer.ID}
+	q.Filter.MerchantID = customer.MerchantID
+	orders, count, err := svc.OrderStorage.List(ctx, q)
+	if err != nil {
+		return nil, 0, errors.E(op, err)
+	}
+
+	return orders, count, nil
+}
+
+// GetOrder returns an order of the customer, the orders of other customers
+// are not found
+func (svc *CustomerAccountService) GetOrder(ctx context.Context, id ID) (Order, error) {
+	const op = errors.Op("core/CustomerAccountService.GetOrder")
+
+	customer := CustomerFromContext(ctx)
+	if customer == nil {
+		return Order{}, errors.E(op, errors.KindUnexpected, "Unknown customer")
+	}
+
+	order, err := svc.OrderStorage.Get(ctx, id)
+	if err != nil {
+		return Order{}, errors.E(op, err)
+	}
+	if order.CustomerID != customer.ID || order.MerchantID != customer.MerchantID {
+		return Order{}, errors.E(op, errors.KindNotFound, "Order not found")
+	}
+
+	return order, nil
+}
+
+func (svc *CustomerAccountService) GetLoyalty(ctx context.Context) (CustomerLoyalty, error) {
+	const op = errors.Op("core/CustomerAccountService.GetLoyalty")
+
+	merchant := MerchantFromContext(ctx)
+	if merchant == nil {
+		return CustomerLoyalty{}, errors.E(op, errors.KindUnexpected, "Unknown merchant")
+	}
+
+	orders, _, err := svc.ListOrder(ctx, OrderQuery{
+		Filter: OrderFilter{States: []OrderState{OrderStateCompleted}},
+	})
+	if err != nil {
+		return CustomerLoyalty{}, errors.E(op, err)
+	}
+
+	loyalty := CustomerLoyalty{TotalSpent: NewMoney(0, merchant.Currency)}
+	for _, o := range orders {
+		loyalty.Visits++
+		loyalty.TotalSpent.Value += o.TotalAmount.Value
+		if loyalty.FirstVisitAt == 0 || o.CreatedAt < loyalty.FirstVisitAt {
+			loyalty.FirstVisitAt = o.CreatedAt
+		}
+		if o.CreatedAt > loyalty.LastVisitAt {
+			loyalty.LastVisitAt = o.CreatedAt
+		}
+	}
+
+	return loyalty, nil
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func customerAccountTestService(orders ...Order) CustomerAccountService {
+	orderStorage := NewMockOrderStorage()
+	orderStorage.GetFn = func(ctx context.Context, id ID) (Order, error) {
+		for _, o := range orders {
+			if o.ID == id {
+				return o, nil
+			}
+		}
+		return Order{}, errors.E(errors.KindNotFound)
+	}
+	orderStorage.ListFn = func(ctx context.Context, q OrderQuery) ([]Order, int64, error) {
+		var list []Order
+		for _, o := range orders {
+			if o.MerchantID != q.Filter.MerchantID || !ContainsID(q.Filter.CustomerIDs, o.CustomerID) {
+				continue
+			}
+			if len(q.Filter.States) != 0 && !containsState(q.Filter.States, o.State) {
+				continue
+			}
+			list = append(list, o)
+		}
+		return list, int64(len(list)), nil
+	}
+	return CustomerAccountService{OrderStorage: orderStorage}
+}
+
+func containsState(states []OrderState, state OrderState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCustomerAccount(t *testing.T) {
+	merchant := NewMerchant()
+	merchant.Currency = PEN
+	anna := NewCustomer("Anna", "anna@mail.com", merchant.ID)
+	bob := NewCustomer("Bob", "bob@mail.com", merchant.ID)
+
+	order := func(customer Customer, state OrderState, total, createdAt int64) Order {
+		o := NewOrder(NewID("loc"), merchant.ID)
+		o.CustomerID = customer.ID
+		o.State = state
+		o.TotalAmount = NewMoney(total, PEN)
+		o.CreatedAt = createdAt
+		return o
+	}
+	first := order(anna, OrderStateCompleted, 1500, 100)
+	last := order(anna, OrderStateCompleted, 2500, 300)
+	canceled := order(anna, OrderStateCanceled, 9000, 400)
+	bobs := order(bob, OrderStateCompleted, 4000, 200)
+
+	svc := customerAccountTestService(last, first, canceled, bobs)
+	ctx := ContextWithMerchant(context.Background(), &merchant)
+	ctx = ContextWithCustomer(ctx, &anna)
+
+	// Filters can't widen the orders to other customers
+	orders, count, err := svc.ListOrder(ctx, OrderQuery{
+		Filter: OrderFilter{CustomerIDs: []ID{bob.ID}, MerchantID: NewID("merch")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, []Order{last, first, canceled}, orders)
+
+	got, err := svc.GetOrder(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, first, got)
+	_, err = svc.GetOrder(ctx, bobs.ID)
+	assert.True(t, errors.Is(err, errors.KindNotFound))
+
+	loyalty, err := svc.GetLoyalty(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, CustomerLoyalty{
+		Visits:       2,
+		TotalSpent:   NewMoney(4000, PEN),
+		FirstVisitAt: 100,
+		LastVisitAt:  300,
+	}, loyalty)
+
+	// Employees have no customer
+	_, _, err = svc.ListOrder(ContextWithMerchant(context.Background(), &merchant), OrderQuery{})
+	assert.True(t, errors.Is(err, errors.KindUnexpected))
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/backium/backend/errors"
+)
+
+type DenominationKind string
+
+const (
+	DenominationBill DenominationKind = "bill"
+	DenominationCoin DenominationKind = "coin"
+)
+
+// Denomination is a bill or coin, its value is in the currency minor unit
+type Denomination struct {
+	Value int64
+	Kind  DenominationKind
+}
+
+var denominations = map[Currency][]Denomination{
+	PEN: {
+		{Value: 20000, Kind: DenominationBill},
+		{Value: 10000, Kind: DenominationBill},
+		{Value: 5000, Kind: DenominationBill},
+		{Value: 2000, Kind: DenominationBill},
+		{Value: 1000, Kind: DenominationBill},
+		{Value: 500, Kind: DenominationCoin},
+		{Value: 200, Kind: DenominationCoin},
+		{Value: 100, Kind: DenominationCoin},
+		{Value: 50, Kind: DenominationCoin},
+		{Value: 20, Kind: DenominationCoin},
+		{Value: 10, Kind: DenominationCoin},
+	},
+	USD: {
+		{Value: 10000, Kind: DenominationBill},
+		{Value: 5000, Kind: DenominationBill},
+		{Value: 2000, Kind: DenominationBill},
+		{Value: 1000, Kind: DenominationBill},
+		{Value: 500, Kind: DenominationBill},
+		{Value: 200, Kind: DenominationBill},
+		{Value: 100, Kind: DenominationBill},
+		{Value: 25, Kind: DenominationCoin},
+		{Value: 10, Kind: DenominationCoin},
+		{Value: 5, Kind: DenominationCoin},
+		{Value: 1, Kind: DenominationCoin},
+	},
+}
+
+// Denominations returns the bills and coins of the currency from highest to lowest value
+func Denominations(currency Currency) []Denomination {
+	return denominations[currency]
+}
+
+// DenominationCount is the number of bills or coins of a denomination
+type DenominationCount struct {
+	Value int64 `bson:"value"`
+	Count int64 `bson:"count"`
+}
+
+// CashCount is the cash counted by denomination
+type CashCount struct {
+	Denominations []DenominationCount `bson:"denominations"`
+	Total         Money               `bson:"total"`
+}
+
+// NewCashCount validates the counts against the currency denominations and calculates the total
+func NewCashCount(currency Currency, counts []DenominationCount) (CashCount, error) {
+	const op = errors.Op("core/NewCashCount")
+
+	valid := map[int64]bool{}
+	for _, d := range Denominations(currency) {
+		valid[d.Value] = true
+	}
+	if len(valid) == 0 {
+		return CashCount{}, errors.E(op, errors.KindValidation,
+			fmt.Sprintf("Currency '%v' doesn't have denominations", currency))
+	}
+
+	count := CashCount{
+		Denominations: []DenominationCount{},
+		Total:         NewMoney(0, currency),
+	}
+	for _, c := range counts {
+		if !valid[c.Value] {
+			return CashCount{}, errors.E(op, errors.KindValidation,
+				fmt.Sprintf("Invalid denomination '%v' for currency '%v'", c.Value, currency))
+		}
+		if c.Count < 0 {
+			return CashCount{}, errors.E(op, errors.KindValidation, "Denomination counts can't be negative")
+		}
+		count.Denominations = append(count.Denominations, c)
+		count.Total.Value += c.Value * c.Count
+	}
+
+	return count, nil
+}
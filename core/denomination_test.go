@@ -0,0 +1,27 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCashCount(t *testing.T) {
+	count, err := NewCashCount(PEN, []DenominationCount{
+		{Value: 10000, Count: 2},
+		{Value: 500, Count: 3},
+		{Value: 10, Count: 4},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(21540, PEN), count.Total)
+	assert.Len(t, count.Denominations, 3)
+
+	_, err = NewCashCount(PEN, []DenominationCount{{Value: 25, Count: 1}})
+	assert.Error(t, err, "quarters are not a PEN denomination")
+
+	_, err = NewCashCount(PEN, []DenominationCount{{Value: 100, Count: -1}})
+	assert.Error(t, err)
+
+	_, err = NewCashCount(Currency("eur"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,335 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"github.com/backium/backend/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PINMinLength = 4
+	PINMaxLength = 6
+	// Failed PIN attempts allowed before the employee is locked out
+	MaxPINAttempts     = 5
+	PINLockoutDuration = 15 * time.Minute
+)
+
+// Device is a terminal registered for a location where employees log in with
+// their PIN instead of their email and password
+type Device struct {
+	ID           ID     `bson:"_id"`
+	Name         string `bson:"name"`
+	TokenHash    string `bson:"token_hash"`
+	LocationID   ID     `bson:"location_id"`
+	MerchantID   ID     `bson:"merchant_id"`
+	RegisteredBy ID     `bson:"registered_by"`
+	LastSeenAt   int64  `bson:"last_seen_at"`
+	CreatedAt    int64  `bson:"created_at"`
+	UpdatedAt    int64  `bson:"updated_at"`
+	Status       Status `bson:"status"`
+}
+
+func NewDevice(name string, locationID, merchantID ID) Device {
+	return Device{
+		ID:         NewID("device"),
+		Name:       name,
+		LocationID: locationID,
+		MerchantID: merchantID,
+		Status:     StatusActive,
+	}
+}
+
+// generateToken sets a new device token, only its hash is stored so the token
+// is returned to be handed to the device once
+func (d *Device) generateToken() string {
+	token := string(NewIDWithSize("dtk", 32))
+	d.TokenHash = hashToken(token)
+	return token
+}
+
+func (d *Device) TokenEquals(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(d.TokenHash), []byte(hashToken(token))) == 1
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func validPIN(pin string) bool {
+	if len(pin) < PINMinLength || len(pin) > PINMaxLength {
+		return false
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Employee) HashPIN(pin string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	e.PINHash = string(hash)
+	e.PINFailedAttempts = 0
+	e.PINLockedUntil = 0
+	return nil
+}
+
+func (e *Employee) PINEquals(pin string) bool {
+	if e.PINHash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(e.PINHash), []byte(pin)) == nil
+}
+
+func (e *Employee) canUseLocation(locationID ID) bool {
+	return !e.locationRestricted() || ContainsID(e.LocationIDs, locationID)
+}
+
+type DeviceFilter struct {
+	IDs         []ID
+	LocationIDs []ID
+	MerchantID  ID
+}
+
+type DeviceQuery struct {
+	Limit  int64
+	Offset int64
+	Filter DeviceFilter
+}
+
+type DeviceStorage interface {
+	Put(context.Context, Device) error
+	Get(context.Context, ID) (Device, error)
+	List(context.Context, DeviceQuery) ([]Device, int64, error)
+}
+
+type DeviceService struct {
+	DeviceStorage   DeviceStorage
+	EmployeeStorage EmployeeStorage
+	LocationStorage LocationStorage
+	SessionStorage  SessionStorage
+}
+
+func canManageDevices(employee *Employee, locationID ID) bool {
+	if employee == nil {
+		return false
+	}
+	return employee.IsOwner ||
+		(Can(employee.Permissions, LocationWrite) && ContainsID(employee.LocationIDs, locationID))
+}
+
+// RegisterDevice registers the device and returns it along with the token the
+// device has to present to start PIN sessions
+func (svc *DeviceService) RegisterDevice(ctx context.Context, device Device) (Device, string, error) {
+	const op = errors.Op("core/DeviceService.RegisterDevice")
+
+	employee := EmployeeFromContext(ctx)
+	if !canManageDevices(employee, device.LocationID) {
+		return Device{}, "", errors.E(op, errors.KindNoPermission, "Not allowed to register devices for this location")
+	}
+
+	location, err := svc.LocationStorage.Get(ctx, device.LocationID)
+	if err != nil {
+		return Device{}, "", errors.E(op, err)
+	}
+	if location.MerchantID != device.MerchantID {
+		return Device{}, "", errors.E(op, errors.KindValidation, "Provided location doesn't belong to your business")
+	}
+
+	token := device.generateToken()
+	device.RegisteredBy = employee.ID
+	if err := svc.DeviceStorage.Put(ctx, device); err != nil {
+		return Device{}, "", errors.E(op, err)
+	}
+
+	device, err = svc.DeviceStorage.Get(ctx, device.ID)
+	if err != nil {
+		return Device{}, "", errors.E(op, err)
+	}
+
+	return device, token, nil
+}
+
+func (svc *DeviceService) GetDevice(ctx context.Context, id ID) (Device, error) {
+	const op = errors.Op("core/DeviceService.GetDevice")
+
+	merchant := MerchantFromContext(ctx)
+	if merchant == nil {
+		return Device{}, errors.E(op, errors.KindUnexpected, "Unknown merchant")
+	}
+
+	device, err := svc.DeviceStorage.Get(ctx, id)
+	if err != nil {
+		return Device{}, errors.E(op, err)
+	}
+	if device.MerchantID != merchant.ID {
+		return Device{}, errors.E(op, errors.KindNotFound, "Device not found")
+	}
+
+	return device, nil
+}
+
+func (svc *DeviceService) ListDevice(ctx context.Context, q DeviceQuery) ([]Device, int64, error) {
+	const op = errors.Op("core/DeviceService.ListDevice")
+
+	devices, count, err := svc.DeviceStorage.List(ctx, q)
+	if err != nil {
+		return nil, 0, errors.E(op, err)
+	}
+
+	return devices, count, nil
+}
+
+// DeleteDevice deregisters the device, its sessions are rejected from then on
+func (svc *DeviceService) DeleteDevice(ctx context.Context, id ID) (Device, error) {
+	const op = errors.Op("core/DeviceService.DeleteDevice")
+
+	device, err := svc.GetDevice(ctx, id)
+	if err != nil {
+		return Device{}, errors.E(op, err)
+	}
+	if !canManageDevices(EmployeeFromContext(ctx), device.LocationID) {
+		return Device{}, errors.E(op, errors.KindNoPermission, "Not allowed to remove devices of this location")
+	}
+
+	device.Status = StatusShadowDeleted
+	if err := svc.DeviceStorage.Put(ctx, device); err != nil {
+		return Device{}, errors.E(op, err)
+	}
+
+	device, err = svc.DeviceStorage.Get(ctx, id)
+	if err != nil {
+		return Device{}, errors.E(op, err)
+	}
+
+	return device, nil
+}
+
+// SetEmployeePIN changes the PIN of an employee, employees can change their own
+// PIN and owners can change anyone's
+func (svc *DeviceService) SetEmployeePIN(ctx context.Context, employeeID ID, pin string) error {
+	const op = errors.Op("core/DeviceService.SetEmployeePIN")
+
+	current := EmployeeFromContext(ctx)
+	if current == nil {
+		return errors.E(op, errors.KindUnexpected, "Unknown employee")
+	}
+	if current.ID != employeeID && !current.IsOwner {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change the PIN of other employees")
+	}
+	if !validPIN(pin) {
+		return errors.E(op, errors.KindValidation, "PIN should have between 4 and 6 digits")
+	}
+
+	employee, err := svc.EmployeeStorage.Get(ctx, employeeID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if employee.MerchantID != current.MerchantID {
+		return errors.E(op, errors.KindNotFound, "Employee not found")
+	}
+
+	if err := employee.HashPIN(pin); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	if err := svc.EmployeeStorage.Put(ctx, employee); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// PINLogin starts a session for the employee on the device
+func (svc *DeviceService) PINLogin(ctx context.Context, deviceID ID, token string, employeeID ID, pin string) (Session, error) {
+	const op = errors.Op("core/DeviceService.PINLogin")
+
+	device, err := svc.DeviceStorage.Get(ctx, deviceID)
+	if err != nil || device.Status != StatusActive || !device.TokenEquals(token) {
+		return Session{}, errors.E(op, errors.KindInvalidCredentials, "Unknown device")
+	}
+
+	session, err := svc.startSession(ctx, device, employeeID, pin)
+	if err != nil {
+		return Session{}, errors.E(op, err)
+	}
+
+	return session, nil
+}
+
+// SwitchEmployee replaces the device session in the context with a session for
+// another employee on the same device
+func (svc *DeviceService) SwitchEmployee(ctx context.Context, employeeID ID, pin string) (Session, error) {
+	const op = errors.Op("core/DeviceService.SwitchEmployee")
+
+	current := SessionFromContext(ctx)
+	if current == nil || current.DeviceID == "" {
+		return Session{}, errors.E(op, errors.KindInvalidSession, "Not a device session")
+	}
+
+	device, err := svc.DeviceStorage.Get(ctx, current.DeviceID)
+	if err != nil || device.Status != StatusActive {
+		return Session{}, errors.E(op, errors.KindInvalidSession, "Unknown device")
+	}
+
+	session, err := svc.startSession(ctx, device, employeeID, pin)
+	if err != nil {
+		return Session{}, errors.E(op, err)
+	}
+	if err := svc.SessionStorage.Delete(ctx, current.ID); err != nil {
+		return Session{}, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return session, nil
+}
+
+func (svc *DeviceService) startSession(ctx context.Context, device Device, employeeID ID, pin string) (Session, error) {
+	employee, err := svc.EmployeeStorage.Get(ctx, employeeID)
+	if err != nil {
+		return Session{}, errors.E(errors.KindInvalidCredentials, err)
+	}
+	if employee.MerchantID != device.MerchantID ||
+		employee.Status != StatusActive ||
+		!employee.canUseLocation(device.LocationID) {
+		return Session{}, errors.E(errors.KindInvalidCredentials, "Employee can't log in on this device")
+	}
+
+	now := time.Now()
+	if employee.PINLockedUntil > now.Unix() {
+		return Session{}, errors.E(errors.KindInvalidCredentials, "Too many failed attempts, try again later")
+	}
+	if !employee.PINEquals(pin) {
+		lockedUntil := now.Add(PINLockoutDuration).Unix()
+		if _, err := svc.EmployeeStorage.AddPINFailure(ctx, employee.ID, MaxPINAttempts, lockedUntil); err != nil {
+			return Session{}, err
+		}
+		return Session{}, errors.E(errors.KindInvalidCredentials, "invalid PIN")
+	}
+
+	if employee.PINFailedAttempts != 0 || employee.PINLockedUntil != 0 {
+		if err := svc.EmployeeStorage.ResetPINFailures(ctx, employee.ID); err != nil {
+			return Session{}, err
+		}
+	}
+
+	device.LastSeenAt = now.Unix()
+	if err := svc.DeviceStorage.Put(ctx, device); err != nil {
+		return Session{}, err
+	}
+
+	session := NewDeviceSession(device, employee)
+	if err := svc.SessionStorage.Set(ctx, session); err != nil {
+		return Session{}, errors.E(errors.KindUnexpected, err)
+	}
+
+	return session, nil
+}
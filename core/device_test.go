@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func deviceTestService(device *Device, employees map[ID]*Employee) (DeviceService, map[ID]Session) {
+	sessions := map[ID]Session{}
+
+	deviceStorage := NewMockDeviceStorage()
+	deviceStorage.GetFn = func(ctx context.Context, id ID) (Device, error) {
+		if id != device.ID {
+			return Device{}, errors.E(errors.KindNotFound)
+		}
+		return *device, nil
+	}
+	deviceStorage.PutFn = func(ctx context.Context, d Device) error {
+		*device = d
+		return nil
+	}
+	employeeStorage := NewMockEmployeeStorage()
+	employeeStorage.GetFn = func(ctx context.Context, id ID) (Employee, error) {
+		e, ok := employees[id]
+		if !ok {
+			return Employee{}, errors.E(errors.KindNotFound)
+		}
+		return *e, nil
+	}
+	employeeStorage.AddPINFailureFn = func(ctx context.Context, id ID, maxAttempts int, lockedUntil int64) (Employee, error) {
+		e := employees[id]
+		e.PINFailedAttempts++
+		if e.PINFailedAttempts >= maxAttempts {
+			e.PINFailedAttempts = 0
+			e.PINLockedUntil = lockedUntil
+		}
+		return *e, nil
+	}
+	employeeStorage.ResetPINFailuresFn = func(ctx context.Context, id ID) error {
+		employees[id].PINFailedAttempts = 0
+		employees[id].PINLockedUntil = 0
+		return nil
+	}
+	sessionStorage := NewMockSessionStorage()
+	sessionStorage.SetFn = func(ctx context.Context, s Session) error {
+		sessions[s.ID] = s
+		return nil
+	}
+	sessionStorage.DeleteFn = func(ctx context.Context, id ID) error {
+		delete(sessions, id)
+		return nil
+	}
+
+	return DeviceService{
+		DeviceStorage:   deviceStorage,
+		EmployeeStorage: employeeStorage,
+		SessionStorage:  sessionStorage,
+	}, sessions
+}
+
+func TestPINLogin(t *testing.T) {
+	merchantID, locationID := NewID("merch"), NewID("loc")
+	device := NewDevice("Front counter", locationID, merchantID)
+	token := device.generateToken()
+
+	anna := NewEmployee("Anna", "Smith", merchantID)
+	anna.LocationIDs = []ID{locationID}
+	assert.NoError(t, anna.HashPIN("1234"))
+	other := NewEmployee("Bob", "Jones", merchantID)
+	other.LocationIDs = []ID{NewID("loc")}
+	assert.NoError(t, other.HashPIN("4321"))
+
+	employees := map[ID]*Employee{anna.ID: &anna, other.ID: &other}
+	svc, sessions := deviceTestService(&device, employees)
+	ctx := context.Background()
+
+	_, err := svc.PINLogin(ctx, device.ID, "dtk_wrong", anna.ID, "1234")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+
+	// Employees can only log in on devices of their locations
+	_, err = svc.PINLogin(ctx, device.ID, token, other.ID, "4321")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+
+	session, err := svc.PINLogin(ctx, device.ID, token, anna.ID, "1234")
+	assert.NoError(t, err)
+	assert.Equal(t, device.ID, session.DeviceID)
+	assert.Equal(t, anna.ID, session.EmployeeID)
+	assert.Equal(t, []ID{locationID}, session.LocationIDs)
+	assert.Contains(t, sessions, session.ID)
+	assert.NotZero(t, device.LastSeenAt)
+}
+
+func TestPINLockout(t *testing.T) {
+	merchantID, locationID := NewID("merch"), NewID("loc")
+	device := NewDevice("Front counter", locationID, merchantID)
+	token := device.generateToken()
+
+	anna := NewEmployee("Anna", "Smith", merchantID)
+	assert.NoError(t, anna.HashPIN("1234"))
+
+	employees := map[ID]*Employee{anna.ID: &anna}
+	svc, _ := deviceTestService(&device, employees)
+	ctx := context.Background()
+
+	for i := 0; i < MaxPINAttempts; i++ {
+		_, err := svc.PINLogin(ctx, device.ID, token, anna.ID, "0000")
+		assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	}
+	assert.NotZero(t, anna.PINLockedUntil)
+
+	// The right PIN is rejected while locked out
+	_, err := svc.PINLogin(ctx, device.ID, token, anna.ID, "1234")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+
+	anna.PINLockedUntil = 1
+	_, err = svc.PINLogin(ctx, device.ID, token, anna.ID, "1234")
+	assert.NoError(t, err)
+	assert.Zero(t, anna.PINLockedUntil)
+	assert.Zero(t, anna.PINFailedAttempts)
+}
+
+func TestSwitchEmployee(t *testing.T) {
+	merchantID, locationID := NewID("merch"), NewID("loc")
+	device := NewDevice("Front counter", locationID, merchantID)
+	token := device.generateToken()
+
+	anna := NewEmployee("Anna", "Smith", merchantID)
+	assert.NoError(t, anna.HashPIN("1234"))
+	bob := NewEmployee("Bob", "Jones", merchantID)
+	assert.NoError(t, bob.HashPIN("4321"))
+
+	employees := map[ID]*Employee{anna.ID: &anna, bob.ID: &bob}
+	svc, sessions := deviceTestService(&device, employees)
+
+	session, err := svc.PINLogin(context.Background(), device.ID, token, anna.ID, "1234")
+	assert.NoError(t, err)
+
+	ctx := ContextWithSession(context.Background(), &session)
+	_, err = svc.SwitchEmployee(ctx, bob.ID, "0000")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	assert.Contains(t, sessions, session.ID)
+
+	switched, err := svc.SwitchEmployee(ctx, bob.ID, "4321")
+	assert.NoError(t, err)
+	assert.Equal(t, bob.ID, switched.EmployeeID)
+	assert.Equal(t, device.ID, switched.DeviceID)
+	assert.NotContains(t, sessions, session.ID)
+	assert.Contains(t, sessions, switched.ID)
+}
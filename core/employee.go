@@ -32,11 +32,16 @@ type Employee struct {
 	Salary        *Money        `bson:"salary"`
 	SalaryHistory []SalaryEntry `bson:"salary_history"`
 	Permissions   []Permission  `bson:"permissions"`
+	RoleIDs       []ID          `bson:"role_ids"`
 	LocationIDs   []ID          `bson:"location_ids"`
-	MerchantID    ID            `bson:"merchant_id"`
-	CreatedAt     int64         `bson:"created_at"`
-	UpdatedAt     int64         `bson:"updated_at"`
-	Status        Status        `bson:"status"`
+	// PIN used to log in on the registered devices
+	PINHash           string `bson:"pin_hash,omitempty"`
+	PINFailedAttempts int    `bson:"pin_failed_attempts"`
+	PINLockedUntil    int64  `bson:"pin_locked_until"`
+	MerchantID        ID     `bson:"merchant_id"`
+	CreatedAt         int64  `bson:"created_at"`
+	UpdatedAt         int64  `bson:"updated_at"`
+	Status            Status `bson:"status"`
 }
 
 func NewEmployee(firstName, lastName string, merchantID ID) Employee {
@@ -44,6 +49,7 @@ func NewEmployee(firstName, lastName string, merchantID ID) Employee {
 		ID:          NewID("empl"),
 		FirstName:   firstName,
 		LastName:    lastName,
+		RoleIDs:     []ID{},
 		LocationIDs: []ID{},
 		MerchantID:  merchantID,
 		IsOwner:     false,
@@ -68,19 +74,49 @@ func (e *Employee) ChangeSalary(amount Money, note string) {
 	})
 }
 
+// HidePay removes the rates and salaries of the employee before showing it to
+// someone not allowed to see them
+func (e *Employee) HidePay() {
+	e.Rate = nil
+	e.RateHistory = nil
+	e.Salary = nil
+	e.SalaryHistory = nil
+}
+
 type EmployeeStorage interface {
 	Put(context.Context, Employee) error
 	Get(context.Context, ID) (Employee, error)
 	List(context.Context, EmployeeQuery) ([]Employee, int64, error)
+	// AddPINFailure atomically counts a failed PIN attempt of the employee,
+	// when the attempts reach the maximum they are reset and the employee is
+	// locked out until the given time
+	AddPINFailure(ctx context.Context, id ID, maxAttempts int, lockedUntil int64) (Employee, error)
+	// ResetPINFailures clears the failed PIN attempts and lockout of the employee
+	ResetPINFailures(context.Context, ID) error
 }
 
 type EmployeeService struct {
-	EmployeeStorage EmployeeStorage
+	EmployeeStorage  EmployeeStorage
+	TimesheetStorage TimesheetStorage
+	RoleStorage      RoleStorage
+	LocationStorage  LocationStorage
 }
 
 func (svc *EmployeeService) Put(ctx context.Context, employee Employee) (Employee, error) {
 	const op = errors.Op("core/EmployeeService.Put")
 
+	if len(employee.RoleIDs) != 0 {
+		roles, _, err := svc.RoleStorage.List(ctx, RoleQuery{
+			Filter: RoleFilter{IDs: employee.RoleIDs, MerchantID: employee.MerchantID},
+		})
+		if err != nil {
+			return Employee{}, errors.E(op, err)
+		}
+		if len(roles) != len(employee.RoleIDs) {
+			return Employee{}, errors.E(op, errors.KindValidation, "Provided roles don't belong to your business")
+		}
+	}
+
 	if err := svc.EmployeeStorage.Put(ctx, employee); err != nil {
 		return Employee{}, errors.E(op, err)
 	}
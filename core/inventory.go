@@ -2,6 +2,10 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/backium/backend/errors"
 )
@@ -19,19 +23,127 @@ const (
 	InventoryOpAddStock    InventoryOp = "add_stock"
 	InventoryOpRemoveStock InventoryOp = "remove_stock"
 	InventoryOpResetStock  InventoryOp = "reset_stock"
+	// InventoryOpWasteStock removes stock that was thrown away (spoiled, expired, damaged)
+	InventoryOpWasteStock InventoryOp = "waste_stock"
 )
 
-type InventoryAdjustment struct {
-	ID              ID          `bson:"_id"`
+// StockPolicy defines what happens when an order requests more stock than available
+type StockPolicy string
+
+const (
+	StockPolicyAllow StockPolicy = "allow"
+	StockPolicyWarn  StockPolicy = "warn"
+	StockPolicyBlock StockPolicy = "block"
+)
+
+// InsufficientStock describes an order item that requests more stock than available
+type InsufficientStock struct {
+	UID             string      `bson:"uid"`
 	ItemVariationID ID          `bson:"item_variation_id"`
-	Quantity        int64       `bson:"quantity"`
-	Op              InventoryOp `bson:"operation"`
-	Note            string      `bson:"note"`
-	AutoGenerated   bool        `bson:"auto_generated"`
-	EmployeeID      ID          `bson:"employee_id"`
-	LocationID      ID          `bson:"location_id"`
-	MerchantID      ID          `bson:"merchant_id"`
-	CreatedAt       int64       `bson:"created_at"`
+	Name            string      `bson:"name"`
+	Requested       int64       `bson:"requested"`
+	Available       int64       `bson:"available"`
+	Policy          StockPolicy `bson:"policy"`
+}
+
+// InsufficientStockError is returned when an order contains items blocked by the stock policy
+type InsufficientStockError struct {
+	Lines []InsufficientStock
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("Not enough stock for %v order items", len(e.Lines))
+}
+
+type InventoryReason string
+
+const (
+	InventoryReasonSale            InventoryReason = "sale"
+	InventoryReasonReturn          InventoryReason = "return"
+	InventoryReasonDamage          InventoryReason = "damage"
+	InventoryReasonTheft           InventoryReason = "theft"
+	InventoryReasonSpoilage        InventoryReason = "spoilage"
+	InventoryReasonReceived        InventoryReason = "received"
+	InventoryReasonTransfer        InventoryReason = "transfer"
+	InventoryReasonCountCorrection InventoryReason = "count_correction"
+)
+
+func (r *InventoryReason) Validate() bool {
+	switch *r {
+	case InventoryReasonSale,
+		InventoryReasonReturn,
+		InventoryReasonDamage,
+		InventoryReasonTheft,
+		InventoryReasonSpoilage,
+		InventoryReasonReceived,
+		InventoryReasonTransfer,
+		InventoryReasonCountCorrection:
+		return true
+	default:
+		return false
+	}
+}
+
+func InventoryReasons() string {
+	return strings.Join([]string{
+		string(InventoryReasonSale),
+		string(InventoryReasonReturn),
+		string(InventoryReasonDamage),
+		string(InventoryReasonTheft),
+		string(InventoryReasonSpoilage),
+		string(InventoryReasonReceived),
+		string(InventoryReasonTransfer),
+		string(InventoryReasonCountCorrection),
+	}, ",")
+}
+
+// inventoryReasonOps are the operations adjustments can make for each reason
+var inventoryReasonOps = map[InventoryReason][]InventoryOp{
+	InventoryReasonSale:            {InventoryOpRemoveStock},
+	InventoryReasonReturn:          {InventoryOpAddStock},
+	InventoryReasonDamage:          {InventoryOpRemoveStock, InventoryOpWasteStock},
+	InventoryReasonTheft:           {InventoryOpRemoveStock},
+	InventoryReasonSpoilage:        {InventoryOpRemoveStock, InventoryOpWasteStock},
+	InventoryReasonReceived:        {InventoryOpAddStock},
+	InventoryReasonTransfer:        {InventoryOpAddStock, InventoryOpRemoveStock},
+	InventoryReasonCountCorrection: {InventoryOpAddStock, InventoryOpRemoveStock, InventoryOpResetStock},
+}
+
+// Allows reports whether adjustments with the reason can make the operation,
+// e.g. a sale can't add stock
+func (r InventoryReason) Allows(op InventoryOp) bool {
+	for _, o := range inventoryReasonOps[r] {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// ShrinkageReasons are the reasons that represent stock lost without being sold
+func ShrinkageReasons() []InventoryReason {
+	return []InventoryReason{
+		InventoryReasonDamage,
+		InventoryReasonTheft,
+		InventoryReasonSpoilage,
+		InventoryReasonCountCorrection,
+	}
+}
+
+type InventoryAdjustment struct {
+	ID              ID              `bson:"_id"`
+	ItemVariationID ID              `bson:"item_variation_id"`
+	Quantity        int64           `bson:"quantity"`
+	Op              InventoryOp     `bson:"operation"`
+	Reason          InventoryReason `bson:"reason"`
+	Note            string          `bson:"note"`
+	LotNumber       string          `bson:"lot_number"`
+	ExpiresAt       int64           `bson:"expires_at"`
+	AutoGenerated   bool            `bson:"auto_generated"`
+	EmployeeID      ID              `bson:"employee_id"`
+	LocationID      ID              `bson:"location_id"`
+	MerchantID      ID              `bson:"merchant_id"`
+	CreatedAt       int64           `bson:"created_at"`
 }
 
 func NewInventoryAdjustment(variationID, locationID, merchantID ID) InventoryAdjustment {
@@ -43,21 +155,46 @@ func NewInventoryAdjustment(variationID, locationID, merchantID ID) InventoryAdj
 	}
 }
 
+// Delta returns the signed change in quantity caused by the adjustment.
+// Resets don't have a delta since they depend on the current quantity.
+func (adj InventoryAdjustment) Delta() int64 {
+	switch adj.Op {
+	case InventoryOpAddStock:
+		return adj.Quantity
+	case InventoryOpRemoveStock, InventoryOpWasteStock:
+		return -adj.Quantity
+	default:
+		return 0
+	}
+}
+
+// InventoryLot is a batch of stock received together and sharing an expiry date
+type InventoryLot struct {
+	LotNumber string `bson:"lot_number"`
+	// Unix time when the lot expires, 0 if it doesn't expire
+	ExpiresAt int64 `bson:"expires_at"`
+	Quantity  int64 `bson:"quantity"`
+}
+
 type InventoryCount struct {
 	ID              ID             `bson:"_id"`
 	ItemVariationID ID             `bson:"item_variation_id"`
 	Quantity        int64          `bson:"quantity"`
+	Lots            []InventoryLot `bson:"lots"`
 	State           InventoryState `bson:"state"`
-	Status          Status         `bson:"status"`
-	CalculatedAt    int64          `bson:"calculated_at"`
-	LocationID      ID             `bson:"location_id"`
-	MerchantID      ID             `bson:"merchant_id"`
+	// Manually marked as sold out, the POS should hide the variation in this location
+	SoldOut      bool   `bson:"sold_out"`
+	Status       Status `bson:"status"`
+	CalculatedAt int64  `bson:"calculated_at"`
+	LocationID   ID     `bson:"location_id"`
+	MerchantID   ID     `bson:"merchant_id"`
 }
 
 func NewInventoryCount(variationID, locationID, merchantID ID) InventoryCount {
 	return InventoryCount{
 		ID:              NewID("invcount"),
 		ItemVariationID: variationID,
+		Lots:            []InventoryLot{},
 		State:           InventoryStateSold,
 		LocationID:      locationID,
 		MerchantID:      merchantID,
@@ -65,7 +202,9 @@ func NewInventoryCount(variationID, locationID, merchantID ID) InventoryCount {
 	}
 }
 
-func (count *InventoryCount) applyAdjustments(adjs []InventoryAdjustment) (bool, error) {
+// ApplyAdjustments applies the adjustments that match the count variation and location,
+// it reports whether any adjustment was applied
+func (count *InventoryCount) ApplyAdjustments(adjs []InventoryAdjustment) (bool, error) {
 	changed := false
 	count.State = InventoryStateInStock
 	for _, adj := range adjs {
@@ -78,10 +217,12 @@ func (count *InventoryCount) applyAdjustments(adjs []InventoryAdjustment) (bool,
 		switch adj.Op {
 		case InventoryOpAddStock:
 			count.Quantity += adj.Quantity
-		case InventoryOpRemoveStock:
+			count.addToLot(adj.LotNumber, adj.ExpiresAt, adj.Quantity)
+		case InventoryOpRemoveStock, InventoryOpWasteStock:
 			count.Quantity -= adj.Quantity
+			count.depleteLots(adj.LotNumber, adj.Quantity)
 		case InventoryOpResetStock:
-			count.Quantity = adj.Quantity
+			count.resetStock(adj.LotNumber, adj.ExpiresAt, adj.Quantity)
 		default:
 			return false, errors.E(errors.KindValidation, "Invalid inventory adjusment operation")
 		}
@@ -92,12 +233,147 @@ func (count *InventoryCount) applyAdjustments(adjs []InventoryAdjustment) (bool,
 	return changed, nil
 }
 
+// addToLot adds quantity to the given lot, creating it if needed.
+// Stock added without a lot number is not tracked by lot.
+func (count *InventoryCount) addToLot(lotNumber string, expiresAt int64, quantity int64) {
+	if lotNumber == "" {
+		return
+	}
+	for i := range count.Lots {
+		if count.Lots[i].LotNumber == lotNumber {
+			count.Lots[i].Quantity += quantity
+			if expiresAt != 0 {
+				count.Lots[i].ExpiresAt = expiresAt
+			}
+			return
+		}
+	}
+	count.Lots = append(count.Lots, InventoryLot{
+		LotNumber: lotNumber,
+		ExpiresAt: expiresAt,
+		Quantity:  quantity,
+	})
+}
+
+// depleteLots removes quantity from the lots, starting with the given lot (if any)
+// and then in first-expiry-first-out order. Empty lots are dropped.
+func (count *InventoryCount) depleteLots(lotNumber string, quantity int64) {
+	remaining := quantity
+	if lotNumber != "" {
+		for i := range count.Lots {
+			if count.Lots[i].LotNumber == lotNumber {
+				remaining -= takeFromLot(&count.Lots[i], remaining)
+			}
+		}
+	}
+
+	count.sortLots()
+	for i := range count.Lots {
+		if remaining <= 0 {
+			break
+		}
+		remaining -= takeFromLot(&count.Lots[i], remaining)
+	}
+
+	lots := []InventoryLot{}
+	for _, lot := range count.Lots {
+		if lot.Quantity > 0 {
+			lots = append(lots, lot)
+		}
+	}
+	count.Lots = lots
+}
+
+// resetStock sets the counted quantity. When a lot number is given only that lot
+// is recounted and the total changes by the difference, a lot missing from the
+// count is created with the expiry date.
+func (count *InventoryCount) resetStock(lotNumber string, expiresAt int64, quantity int64) {
+	if lotNumber == "" {
+		count.Quantity = quantity
+		if excess := count.lotsQuantity() - quantity; excess > 0 {
+			count.depleteLots("", excess)
+		}
+		return
+	}
+
+	var previous int64
+	for _, lot := range count.Lots {
+		if lot.LotNumber == lotNumber {
+			previous = lot.Quantity
+		}
+	}
+	count.Quantity += quantity - previous
+	if quantity > previous {
+		count.addToLot(lotNumber, expiresAt, quantity-previous)
+	} else {
+		count.depleteLots(lotNumber, previous-quantity)
+	}
+}
+
+func (count *InventoryCount) lotsQuantity() int64 {
+	var total int64
+	for _, lot := range count.Lots {
+		total += lot.Quantity
+	}
+	return total
+}
+
+// sortLots orders the lots by expiry date, lots without expiry go last
+func (count *InventoryCount) sortLots() {
+	sort.SliceStable(count.Lots, func(i, j int) bool {
+		a, b := count.Lots[i].ExpiresAt, count.Lots[j].ExpiresAt
+		if a == 0 || b == 0 {
+			return b == 0 && a != 0
+		}
+		return a < b
+	})
+}
+
+func takeFromLot(lot *InventoryLot, quantity int64) int64 {
+	if quantity <= 0 {
+		return 0
+	}
+	taken := quantity
+	if lot.Quantity < taken {
+		taken = lot.Quantity
+	}
+	lot.Quantity -= taken
+	return taken
+}
+
+// InventoryHistoryEntry is the balance of an inventory count right after an adjustment
+type InventoryHistoryEntry struct {
+	Adjustment InventoryAdjustment
+	Balance    int64
+}
+
+// InventoryHistory is the running balance of a variation in a location
+type InventoryHistory struct {
+	ItemVariationID ID
+	LocationID      ID
+	// Quantity on hand right before BeginTime
+	OpeningQuantity int64
+	// Quantity on hand at EndTime
+	Quantity int64
+	Entries  []InventoryHistoryEntry
+}
+
+type InventoryHistoryRequest struct {
+	ItemVariationID ID
+	LocationID      ID
+	MerchantID      ID
+	BeginTime       int64
+	// Defaults to now
+	EndTime int64
+}
+
 type InventoryFilter struct {
 	Limit            int64
 	Offset           int64
 	IDs              []ID
 	ItemVariationIDs []ID
 	EmployeeIDs      []ID
+	Reasons          []InventoryReason
 	CreatedAt        DateFilter
 	AutoGenerated    bool
 	LocationIDs      []ID
@@ -110,6 +386,12 @@ type InventoryStorage interface {
 	PutBatchAdj(context.Context, []InventoryAdjustment) error
 	ListCount(context.Context, InventoryFilter) ([]InventoryCount, int64, error)
 	ListAdjustment(context.Context, InventoryFilter) ([]InventoryAdjustment, int64, error)
+	// ApplyAdjustments atomically applies the adjustments to the matching counts and
+	// stores them. It returns the updated counts, nothing is stored if no count matches.
+	ApplyAdjustments(context.Context, []InventoryAdjustment) ([]InventoryCount, error)
+	// SetSoldOut only sets the sold out flag of the count, leaving its quantity to
+	// the adjustments applied meanwhile. It returns the updated count.
+	SetSoldOut(ctx context.Context, variationID, locationID, merchantID ID, soldOut bool) (InventoryCount, error)
 }
 
 func (s *CatalogService) initializeInventory(ctx context.Context, variation ItemVariation) error {
@@ -138,46 +420,20 @@ func (s *CatalogService) initializeInventory(ctx context.Context, variation Item
 func (s *CatalogService) ApplyInventoryAdjustments(ctx context.Context, adjs []InventoryAdjustment) ([]InventoryCount, error) {
 	const op = errors.Op("core/CatalogService.PutInventoryAdjusments")
 
-	variations := make([]ID, len(adjs))
-	for i, adj := range adjs {
-		variations[i] = adj.ItemVariationID
-	}
-	counts, _, err := s.InventoryStorage.ListCount(ctx, InventoryFilter{
-		ItemVariationIDs: variations,
-	})
-	if err != nil {
-		return nil, err
-	}
-	if len(counts) == 0 {
-		return nil, errors.E(op, errors.KindValidation, "Unknown item variations in adjustment request")
-	}
-
-	var countsToUpdate []InventoryCount
-	var countIDs []ID
-	for _, count := range counts {
-		changed, err := count.applyAdjustments(adjs)
-		if err != nil {
-			return nil, errors.E(op, err)
-		}
-		if changed {
-			countsToUpdate = append(countsToUpdate, count)
-			countIDs = append(countIDs, count.ID)
+	for _, adj := range adjs {
+		if !adj.Reason.Allows(adj.Op) {
+			msg := fmt.Sprintf("Adjustments with reason %v can't %v", adj.Reason, adj.Op)
+			return nil, errors.E(op, errors.KindValidation, msg)
 		}
 	}
 
-	if err := s.InventoryStorage.PutBatchCount(ctx, countsToUpdate); err != nil {
-		return nil, errors.E(op, err)
-	}
-	if err := s.InventoryStorage.PutBatchAdj(ctx, adjs); err != nil {
-		return nil, errors.E(op, err)
-	}
-
-	counts, _, err = s.InventoryStorage.ListCount(ctx, InventoryFilter{
-		IDs: countIDs,
-	})
+	counts, err := s.InventoryStorage.ApplyAdjustments(ctx, adjs)
 	if err != nil {
 		return nil, errors.E(op, err)
 	}
+	if len(counts) == 0 {
+		return nil, errors.E(op, errors.KindValidation, "Unknown item variations in adjustment request")
+	}
 
 	return counts, nil
 }
@@ -185,41 +441,22 @@ func (s *CatalogService) ApplyInventoryAdjustments(ctx context.Context, adjs []I
 func applyInventoryAdjustments(ctx context.Context, storage InventoryStorage, adjs []InventoryAdjustment) error {
 	const op = errors.Op("core/CatalogService.PutInventoryAdjusments")
 
-	variations := make([]ID, len(adjs))
-	for i, adj := range adjs {
-		variations[i] = adj.ItemVariationID
-	}
-	counts, _, err := storage.ListCount(ctx, InventoryFilter{
-		ItemVariationIDs: variations,
-	})
-	if err != nil {
-		return err
-	}
-	if len(counts) == 0 {
-		return nil
+	if _, err := storage.ApplyAdjustments(ctx, adjs); err != nil {
+		return errors.E(op, err)
 	}
 
-	var countsToUpdate []InventoryCount
-	var countIDs []ID
-	for _, count := range counts {
-		changed, err := count.applyAdjustments(adjs)
-		if err != nil {
-			return errors.E(op, err)
-		}
-		if changed {
-			countsToUpdate = append(countsToUpdate, count)
-			countIDs = append(countIDs, count.ID)
-		}
-	}
+	return nil
+}
 
-	if err := storage.PutBatchCount(ctx, countsToUpdate); err != nil {
-		return errors.E(op, err)
-	}
-	if err := storage.PutBatchAdj(ctx, adjs); err != nil {
-		return errors.E(op, err)
+func (s *CatalogService) SetSoldOut(ctx context.Context, variationID, locationID, merchantID ID, soldOut bool) (InventoryCount, error) {
+	const op = errors.Op("core/CatalogService.SetSoldOut")
+
+	count, err := s.InventoryStorage.SetSoldOut(ctx, variationID, locationID, merchantID, soldOut)
+	if err != nil {
+		return InventoryCount{}, errors.E(op, err)
 	}
 
-	return nil
+	return count, nil
 }
 
 func (s *CatalogService) ListInventoryCounts(ctx context.Context, f InventoryFilter) ([]InventoryCount, int64, error) {
@@ -243,3 +480,102 @@ func (s *CatalogService) ListInventoryAdjustment(ctx context.Context, f Inventor
 
 	return adjs, total, nil
 }
+
+// GetInventoryHistory replays the adjustments of a variation in a location to build
+// its running balance, entries before BeginTime are only used for the opening quantity
+func (s *CatalogService) GetInventoryHistory(ctx context.Context, req InventoryHistoryRequest) (InventoryHistory, error) {
+	const op = errors.Op("core/CatalogService.GetInventoryHistory")
+
+	endTime := req.EndTime
+	if endTime == 0 {
+		endTime = time.Now().Unix()
+	}
+	if req.BeginTime > endTime {
+		return InventoryHistory{}, errors.E(op, errors.KindValidation, "Begin time should be before end time")
+	}
+
+	adjs, err := listAdjustmentHistory(ctx, s.InventoryStorage, InventoryFilter{
+		ItemVariationIDs: []ID{req.ItemVariationID},
+		LocationIDs:      []ID{req.LocationID},
+		MerchantID:       req.MerchantID,
+		CreatedAt:        DateFilter{Lte: endTime},
+	})
+	if err != nil {
+		return InventoryHistory{}, errors.E(op, err)
+	}
+
+	history := InventoryHistory{
+		ItemVariationID: req.ItemVariationID,
+		LocationID:      req.LocationID,
+		Entries:         []InventoryHistoryEntry{},
+	}
+	count := NewInventoryCount(req.ItemVariationID, req.LocationID, req.MerchantID)
+	for _, adj := range adjs {
+		if _, err := count.ApplyAdjustments([]InventoryAdjustment{adj}); err != nil {
+			return InventoryHistory{}, errors.E(op, err)
+		}
+		if adj.CreatedAt < req.BeginTime {
+			history.OpeningQuantity = count.Quantity
+			continue
+		}
+		history.Entries = append(history.Entries, InventoryHistoryEntry{
+			Adjustment: adj,
+			Balance:    count.Quantity,
+		})
+	}
+	history.Quantity = count.Quantity
+
+	return history, nil
+}
+
+// inventorySnapshot rebuilds the inventory counts as they were at the given time
+func inventorySnapshot(ctx context.Context, storage InventoryStorage, f StockFilter, at int64) ([]InventoryCount, error) {
+	adjs, err := listAdjustmentHistory(ctx, storage, InventoryFilter{
+		ItemVariationIDs: f.ItemVariationIDs,
+		LocationIDs:      f.LocationIDs,
+		MerchantID:       f.MerchantID,
+		CreatedAt:        DateFilter{Lte: at},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var counts []InventoryCount
+	index := map[[2]ID]int{}
+	for _, adj := range adjs {
+		key := [2]ID{adj.ItemVariationID, adj.LocationID}
+		i, ok := index[key]
+		if !ok {
+			i = len(counts)
+			index[key] = i
+			counts = append(counts, NewInventoryCount(adj.ItemVariationID, adj.LocationID, adj.MerchantID))
+		}
+		if _, err := counts[i].ApplyAdjustments([]InventoryAdjustment{adj}); err != nil {
+			return nil, err
+		}
+		counts[i].CalculatedAt = at
+	}
+
+	return counts, nil
+}
+
+// listAdjustmentHistory returns both manual and auto generated adjustments
+// sorted by creation time
+func listAdjustmentHistory(ctx context.Context, storage InventoryStorage, f InventoryFilter) ([]InventoryAdjustment, error) {
+	f.AutoGenerated = false
+	manual, _, err := storage.ListAdjustment(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	f.AutoGenerated = true
+	auto, _, err := storage.ListAdjustment(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	adjs := append(manual, auto...)
+	sort.SliceStable(adjs, func(i, j int) bool {
+		return adjs[i].CreatedAt < adjs[j].CreatedAt
+	})
+	return adjs, nil
+}
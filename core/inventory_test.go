@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyAdjustmentsLots(t *testing.T) {
+	variationID := NewID("itemvar")
+	locationID := NewID("loc")
+	merchantID := NewID("merch")
+
+	newAdj := func(op InventoryOp, quantity int64, lot string, expiresAt int64) InventoryAdjustment {
+		adj := NewInventoryAdjustment(variationID, locationID, merchantID)
+		adj.Op = op
+		adj.Quantity = quantity
+		adj.LotNumber = lot
+		adj.ExpiresAt = expiresAt
+		return adj
+	}
+
+	count := NewInventoryCount(variationID, locationID, merchantID)
+	_, err := count.ApplyAdjustments([]InventoryAdjustment{
+		newAdj(InventoryOpAddStock, 5, "late", 3000),
+		newAdj(InventoryOpAddStock, 3, "early", 1000),
+		newAdj(InventoryOpAddStock, 2, "", 0),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), count.Quantity)
+
+	// Sales deplete the lot that expires first
+	_, err = count.ApplyAdjustments([]InventoryAdjustment{
+		newAdj(InventoryOpRemoveStock, 4, "", 0),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), count.Quantity)
+	assert.Equal(t, []InventoryLot{{LotNumber: "late", ExpiresAt: 3000, Quantity: 4}}, count.Lots)
+
+	// Waste can target a specific lot
+	_, err = count.ApplyAdjustments([]InventoryAdjustment{
+		newAdj(InventoryOpWasteStock, 1, "late", 0),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), count.Quantity)
+	assert.Equal(t, int64(3), count.Lots[0].Quantity)
+
+	// Recounting a lot changes the total by the difference
+	_, err = count.ApplyAdjustments([]InventoryAdjustment{
+		newAdj(InventoryOpResetStock, 1, "late", 0),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count.Quantity)
+	assert.Equal(t, int64(1), count.Lots[0].Quantity)
+
+	// Recounting a new lot keeps its expiry date so it's sold first
+	_, err = count.ApplyAdjustments([]InventoryAdjustment{
+		newAdj(InventoryOpResetStock, 2, "found", 2000),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), count.Quantity)
+	assert.Contains(t, count.Lots, InventoryLot{LotNumber: "found", ExpiresAt: 2000, Quantity: 2})
+
+	_, err = count.ApplyAdjustments([]InventoryAdjustment{
+		newAdj(InventoryOpRemoveStock, 2, "", 0),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []InventoryLot{{LotNumber: "late", ExpiresAt: 3000, Quantity: 1}}, count.Lots)
+}
+
+func TestGetInventoryHistory(t *testing.T) {
+	variationID := NewID("itemvar")
+	locationID := NewID("loc")
+	merchantID := NewID("merch")
+
+	newAdj := func(op InventoryOp, quantity int64, createdAt int64, auto bool) InventoryAdjustment {
+		adj := NewInventoryAdjustment(variationID, locationID, merchantID)
+		adj.Op = op
+		adj.Quantity = quantity
+		adj.CreatedAt = createdAt
+		adj.AutoGenerated = auto
+		return adj
+	}
+
+	adjs := []InventoryAdjustment{
+		newAdj(InventoryOpAddStock, 10, 100, false),
+		newAdj(InventoryOpRemoveStock, 2, 200, true),
+		newAdj(InventoryOpResetStock, 7, 300, false),
+		newAdj(InventoryOpRemoveStock, 3, 400, true),
+	}
+
+	storage := NewMockInventoryStorage()
+	storage.ListAdjustmentFn = func(ctx context.Context, f InventoryFilter) ([]InventoryAdjustment, int64, error) {
+		var res []InventoryAdjustment
+		for _, adj := range adjs {
+			if adj.AutoGenerated == f.AutoGenerated && adj.CreatedAt <= f.CreatedAt.Lte {
+				res = append(res, adj)
+			}
+		}
+		return res, int64(len(res)), nil
+	}
+	svc := CatalogService{InventoryStorage: storage}
+
+	history, err := svc.GetInventoryHistory(context.Background(), InventoryHistoryRequest{
+		ItemVariationID: variationID,
+		LocationID:      locationID,
+		MerchantID:      merchantID,
+		BeginTime:       150,
+		EndTime:         350,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), history.OpeningQuantity)
+	assert.Equal(t, int64(7), history.Quantity)
+	assert.Len(t, history.Entries, 2)
+	assert.Equal(t, int64(8), history.Entries[0].Balance)
+	assert.Equal(t, int64(7), history.Entries[1].Balance)
+}
+
+func TestInventoryReasonOps(t *testing.T) {
+	assert.True(t, InventoryReasonSale.Allows(InventoryOpRemoveStock))
+	assert.False(t, InventoryReasonSale.Allows(InventoryOpAddStock))
+	assert.True(t, InventoryReasonReceived.Allows(InventoryOpAddStock))
+	assert.False(t, InventoryReasonReceived.Allows(InventoryOpWasteStock))
+	assert.True(t, InventoryReasonSpoilage.Allows(InventoryOpWasteStock))
+	assert.False(t, InventoryReasonTheft.Allows(InventoryOpResetStock))
+	assert.True(t, InventoryReasonCountCorrection.Allows(InventoryOpResetStock))
+	assert.False(t, InventoryReason("lost").Allows(InventoryOpRemoveStock))
+
+	storage := NewMockInventoryStorage()
+	storage.ApplyAdjFn = func(ctx context.Context, adjs []InventoryAdjustment) ([]InventoryCount, error) {
+		t.Fatal("invalid adjustments applied")
+		return nil, nil
+	}
+	svc := CatalogService{InventoryStorage: storage}
+
+	adj := NewInventoryAdjustment(NewID("itemvar"), NewID("loc"), NewID("merch"))
+	adj.Op = InventoryOpAddStock
+	adj.Reason = InventoryReasonSale
+	adj.Quantity = 1
+	_, err := svc.ApplyInventoryAdjustments(context.Background(), []InventoryAdjustment{adj})
+	assert.True(t, errors.Is(err, errors.KindValidation))
+}
@@ -17,11 +17,13 @@ type ItemVariation struct {
 	Cost                 *Money          `bson:"cost"`
 	Image                string          `bson:"image"`
 	MinimumRequiredStock int64           `bson:"minimum_required_stock"`
-	LocationIDs          []ID            `bson:"location_ids"`
-	MerchantID           ID              `bson:"merchant_id"`
-	CreatedAt            int64           `bson:"created_at"`
-	UpdatedAt            int64           `bson:"updated_at"`
-	Status               Status          `bson:"status"`
+	// Overrides the merchant stock policy when set
+	StockPolicy StockPolicy `bson:"stock_policy"`
+	LocationIDs []ID        `bson:"location_ids"`
+	MerchantID  ID          `bson:"merchant_id"`
+	CreatedAt   int64       `bson:"created_at"`
+	UpdatedAt   int64       `bson:"updated_at"`
+	Status      Status      `bson:"status"`
 }
 
 // Creates an ItemVariationVariation with default values
@@ -36,6 +38,17 @@ func NewItemVariation(name string, itemID, merchantID ID) ItemVariation {
 	}
 }
 
+// EffectiveStockPolicy returns the variation stock policy, falling back to the merchant one
+func (v *ItemVariation) EffectiveStockPolicy(merchant *Merchant) StockPolicy {
+	if v.StockPolicy != "" {
+		return v.StockPolicy
+	}
+	if merchant != nil && merchant.StockPolicy != "" {
+		return merchant.StockPolicy
+	}
+	return StockPolicyAllow
+}
+
 type ItemVariationStorage interface {
 	Put(context.Context, ItemVariation) error
 	PutBatch(context.Context, []ItemVariation) error
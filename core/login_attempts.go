@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoginPolicy limits the failed logins of an email or an IP, logins are
+// delayed with an exponential backoff after some failures and locked out after
+// many of them
+type LoginPolicy struct {
+	// Failed attempts allowed without delay
+	FreeAttempts int
+	// Delay after the first failure over the free ones, it doubles with every
+	// failure after it
+	Backoff time.Duration
+	// Failed attempts after which logins are locked out
+	LockoutAttempts int
+	Lockout         time.Duration
+}
+
+var (
+	EmailLoginPolicy = LoginPolicy{
+		FreeAttempts:    3,
+		Backoff:         time.Second,
+		LockoutAttempts: 10,
+		Lockout:         15 * time.Minute,
+	}
+	// IPs can be shared by many users so they are allowed more failures
+	IPLoginPolicy = LoginPolicy{
+		FreeAttempts:    20,
+		Backoff:         time.Second,
+		LockoutAttempts: 100,
+		Lockout:         15 * time.Minute,
+	}
+)
+
+// Failed attempts are forgotten after this time without new failures
+const LoginAttemptWindow = time.Hour
+
+// Wait returns the time left before a new login is allowed after the attempts
+func (p LoginPolicy) Wait(attempts LoginAttempts, now time.Time) time.Duration {
+	if attempts.Failures < p.FreeAttempts {
+		return 0
+	}
+	wait := p.Lockout
+	if n := attempts.Failures - p.FreeAttempts; attempts.Failures < p.LockoutAttempts && n < 30 {
+		if backoff := p.Backoff << n; backoff < wait {
+			wait = backoff
+		}
+	}
+	left := time.Unix(attempts.LastFailureAt, 0).Add(wait).Sub(now)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// LoginAttempts are the recent failed logins of an email or an IP
+type LoginAttempts struct {
+	Failures      int
+	LastFailureAt int64
+}
+
+type LoginAttemptStorage interface {
+	// RecordFailure atomically adds a failed attempt to the key and returns
+	// the attempts made before it
+	RecordFailure(ctx context.Context, key string, now time.Time) (LoginAttempts, error)
+	// CancelFailure takes back the failed attempt of the key recorded at the
+	// time, restoring the time of the failure before it unless a new one was
+	// recorded since
+	CancelFailure(ctx context.Context, key string, at time.Time, before LoginAttempts) error
+	// Reset forgets the failed attempts of the key
+	Reset(ctx context.Context, key string) error
+}
+
+func loginEmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginClient is the client attempting a login
+type LoginClient struct {
+	IP        string
+	UserAgent string
+}
+
+// LoginThrottledError is returned when logins are not allowed for a while
+// because of too many failed attempts
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("Too many failed login attempts, try again in %v", e.RetryAfter.Round(time.Second))
+}
+
+type FailedLoginReason string
+
+const (
+	FailedLoginUnknownEmail  FailedLoginReason = "unknown_email"
+	FailedLoginWrongPassword FailedLoginReason = "wrong_password"
+	FailedLoginWrongCode     FailedLoginReason = "wrong_code"
+	FailedLoginThrottled     FailedLoginReason = "throttled"
+)
+
+// FailedLogin is the audit record of a failed login, the user is unknown when
+// the email isn't registered
+type FailedLogin struct {
+	ID         ID                `bson:"_id"`
+	Email      string            `bson:"email"`
+	UserID     ID                `bson:"user_id"`
+	MerchantID ID                `bson:"merchant_id"`
+	Reason     FailedLoginReason `bson:"reason"`
+	IP         string            `bson:"ip"`
+	UserAgent  string            `bson:"user_agent"`
+	CreatedAt  int64             `bson:"created_at"`
+}
+
+type FailedLoginStorage interface {
+	Put(context.Context, FailedLogin) error
+}
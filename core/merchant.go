@@ -12,9 +12,13 @@ type Merchant struct {
 	LastName     string   `bson:"last_name"`
 	BusinessName string   `bson:"business_name"`
 	Currency     Currency `bson:"currency"`
-	CreatedAt    int64    `bson:"created_at"`
-	UpdatedAt    int64    `bson:"updated_at"`
-	Keys         []Key    `bson:"keys"`
+	// Default stock policy for all item variations
+	StockPolicy StockPolicy `bson:"stock_policy"`
+	// Managers have to log in with two-factor authentication
+	RequireTwoFactor bool  `bson:"require_two_factor"`
+	CreatedAt        int64 `bson:"created_at"`
+	UpdatedAt        int64 `bson:"updated_at"`
+	Keys             []Key `bson:"keys"`
 }
 
 func NewMerchant() Merchant {
@@ -24,22 +28,25 @@ func NewMerchant() Merchant {
 	}
 }
 
-type Key struct {
-	Name  string `bson:"name"`
-	Token ID     `bson:"token"`
-}
-
-func NewKey(name string) Key {
-	return Key{
-		Name:  name,
-		Token: NewIDWithSize("sk", 25),
-	}
-}
-
 type MerchantStorage interface {
 	Put(context.Context, Merchant) error
+	// PutKey adds the key to the merchant or replaces the key with its ID
 	PutKey(context.Context, ID, Key) error
+	// TouchKey only sets the last use of the key, so it doesn't undo a
+	// revocation made meanwhile
+	TouchKey(ctx context.Context, merchantID, keyID ID, usedAt int64) error
+	// RevokeKey only sets the revocation time of the key, keys revoked
+	// before keep theirs
+	RevokeKey(ctx context.Context, merchantID, keyID ID, revokedAt int64) error
+	// UpdateSettings only sets the business settings of the merchant, its
+	// keys are never written from a copy of the merchant
+	UpdateSettings(context.Context, Merchant) error
+	// MigrateKey replaces the legacy key with the plaintext token by the
+	// migrated key, keeping the fields changed meanwhile
+	MigrateKey(ctx context.Context, merchantID ID, token string, key Key) error
 	Get(context.Context, ID) (Merchant, error)
+	// GetByKey returns the merchant owning the API key token, matching it by
+	// its hash or by the plaintext token of keys created before hashing
 	GetByKey(context.Context, string) (Merchant, error)
 }
 
@@ -47,9 +54,15 @@ type MerchantService struct {
 	MerchantStorage MerchantStorage
 }
 
-func (svc *MerchantService) PutMerchant(ctx context.Context, merchant Merchant) (Merchant, error) {
-	const op = errors.Op("controller.Merchant.Create")
-	if err := svc.MerchantStorage.Put(ctx, merchant); err != nil {
+// UpdateSettings saves the business name, stock policy and two-factor
+// requirement of the merchant
+func (svc *MerchantService) UpdateSettings(ctx context.Context, merchant Merchant) (Merchant, error) {
+	const op = errors.Op("core/MerchantService.UpdateSettings")
+	// Business settings like the stock policy are only for the owner
+	if employee := EmployeeFromContext(ctx); employee == nil || !employee.IsOwner {
+		return Merchant{}, errors.E(op, errors.KindNoPermission, "Only owners can change the business settings")
+	}
+	if err := svc.MerchantStorage.UpdateSettings(ctx, merchant); err != nil {
 		return Merchant{}, err
 	}
 	merchant, err := svc.MerchantStorage.Get(ctx, merchant.ID)
@@ -67,21 +80,3 @@ func (svc *MerchantService) GetMerchant(ctx context.Context, id ID) (Merchant, e
 	}
 	return merchant, nil
 }
-
-func (svc *MerchantService) CreateKey(ctx context.Context, keyName string, merchantID ID) (Key, error) {
-	const op = errors.Op("core/MerchantService.CreateKey")
-	key := NewKey(keyName)
-	if err := svc.MerchantStorage.PutKey(ctx, merchantID, key); err != nil {
-		return Key{}, errors.E(op, err)
-	}
-	return key, nil
-}
-
-func (svc *MerchantService) GetMerchantByKey(ctx context.Context, key string) (Merchant, error) {
-	const op = errors.Op("core/MerchantService.GetMerchantByKey")
-	merchant, err := svc.MerchantStorage.GetByKey(ctx, key)
-	if err != nil {
-		return Merchant{}, errors.E(op, err)
-	}
-	return merchant, nil
-}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateMerchantSettings(t *testing.T) {
+	merchant := NewMerchant()
+	storage := NewMockMerchantStorage()
+	storage.UpdateSettingsFn = func(ctx context.Context, m Merchant) error {
+		merchant.BusinessName = m.BusinessName
+		merchant.StockPolicy = m.StockPolicy
+		merchant.RequireTwoFactor = m.RequireTwoFactor
+		return nil
+	}
+	storage.GetFn = func(ctx context.Context, id ID) (Merchant, error) {
+		return merchant, nil
+	}
+	svc := MerchantService{MerchantStorage: storage}
+
+	updated := merchant
+	updated.StockPolicy = StockPolicyBlock
+
+	cashier := NewEmployee("Bob", "Doe", merchant.ID)
+	_, err := svc.UpdateSettings(ContextWithEmployee(context.Background(), &cashier), updated)
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+	_, err = svc.UpdateSettings(context.Background(), updated)
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+	assert.NotEqual(t, StockPolicyBlock, merchant.StockPolicy)
+
+	owner := NewEmployee("Anna", "Doe", merchant.ID)
+	owner.IsOwner = true
+	got, err := svc.UpdateSettings(ContextWithEmployee(context.Background(), &owner), updated)
+	assert.NoError(t, err)
+	assert.Equal(t, StockPolicyBlock, got.StockPolicy)
+}
@@ -31,6 +31,8 @@ type Order struct {
 	State               OrderState           `bson:"state"`
 	PaymentTypes        []PaymentType        `bson:"payment_types"`
 	CancelReason        string               `bson:"cancel_reason"`
+	CanceledBy          ID                   `bson:"canceled_by"`
+	InsufficientStock   []InsufficientStock  `bson:"insufficient_stock"`
 	EmployeeID          ID                   `bson:"employee_id"`
 	CustomerID          ID                   `bson:"customer_id"`
 	LocationID          ID                   `bson:"location_id"`
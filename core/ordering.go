@@ -68,6 +68,16 @@ func (s *OrderingService) CreateOrder(ctx context.Context, schema OrderSchema) (
 		return Order{}, errors.E(op, err)
 	}
 
+	var blocked []InsufficientStock
+	for _, line := range order.InsufficientStock {
+		if line.Policy == StockPolicyBlock {
+			blocked = append(blocked, line)
+		}
+	}
+	if len(blocked) != 0 {
+		return Order{}, errors.E(op, errors.KindValidation, &InsufficientStockError{Lines: blocked})
+	}
+
 	if err := s.OrderStorage.Put(ctx, *order); err != nil {
 		return Order{}, errors.E(op, err)
 	}
@@ -78,6 +88,7 @@ func (s *OrderingService) CreateOrder(ctx context.Context, schema OrderSchema) (
 		adjs[i] = NewInventoryAdjustment(v.ID, order.LocationID, order.MerchantID)
 		adjs[i].Quantity = v.Quantity
 		adjs[i].Op = InventoryOpRemoveStock
+		adjs[i].Reason = InventoryReasonSale
 		adjs[i].EmployeeID = order.EmployeeID
 		adjs[i].LocationID = order.LocationID
 		adjs[i].AutoGenerated = true
@@ -105,6 +116,9 @@ func (s *OrderingService) CancelOrder(ctx context.Context, orderID ID, reason st
 
 	order.State = OrderStateCanceled
 	order.CancelReason = reason
+	if user := UserFromContext(ctx); user != nil {
+		order.CanceledBy = user.EmployeeID
+	}
 	if err := s.OrderStorage.Put(ctx, order); err != nil {
 		return Order{}, errors.E(op, errors.KindUnexpected, err)
 	}
@@ -115,6 +129,7 @@ func (s *OrderingService) CancelOrder(ctx context.Context, orderID ID, reason st
 		adjs[i] = NewInventoryAdjustment(v.ID, order.LocationID, order.MerchantID)
 		adjs[i].Quantity = v.Quantity
 		adjs[i].Op = InventoryOpAddStock
+		adjs[i].Reason = InventoryReasonReturn
 		adjs[i].EmployeeID = order.EmployeeID
 		adjs[i].LocationID = order.LocationID
 		adjs[i].AutoGenerated = true
@@ -193,25 +208,31 @@ func (s *OrderingService) adjustCashDrawers(ctx context.Context, payments []Paym
 			continue
 		}
 
-		cash, _, err := s.CashDrawerStorage.List(ctx, CashDrawerQuery{
-			Filter: CashDrawerFilter{
-				LocationIDs: []ID{payment.LocationID},
-			},
-		})
-		if err != nil {
-			return errors.E(errors.KindUnexpected, err)
+		drawerID := payment.CashDrawerID
+		if drawerID == "" {
+			// Payments that don't reference a drawer go to the first one of the location
+			cash, _, err := s.CashDrawerStorage.List(ctx, CashDrawerQuery{
+				Filter: CashDrawerFilter{
+					LocationIDs: []ID{payment.LocationID},
+				},
+			})
+			if err != nil {
+				return errors.E(errors.KindUnexpected, err)
+			}
+			if len(cash) == 0 {
+				continue
+			}
+			drawerID = cash[0].ID
 		}
 
-		if len(cash) >= 1 {
-			adj := NewCashDrawerAdjustment(cash[0].ID, payment.MerchantID)
-			adj.Op = CashDrawerOpAdd
-			adj.Amount.Value = payment.Amount.Value + payment.TipAmount.Value
-			adj.Amount.Currency = payment.Amount.Currency
-			adj.AutoGenerated = true
+		adj := NewCashDrawerAdjustment(drawerID, payment.MerchantID)
+		adj.Op = CashDrawerOpAdd
+		adj.Amount.Value = payment.Amount.Value + payment.TipAmount.Value
+		adj.Amount.Currency = payment.Amount.Currency
+		adj.AutoGenerated = true
 
-			if err := adjustCashDrawer(ctx, s.CashDrawerStorage, adj); err != nil {
-				return errors.E(errors.KindUnexpected, err)
-			}
+		if _, err := adjustCashDrawer(ctx, s.CashDrawerStorage, adj); err != nil {
+			return errors.E(errors.KindUnexpected, err)
 		}
 	}
 
@@ -683,5 +704,68 @@ func (s *OrderingService) build(ctx context.Context, sch OrderSchema) (*Order, e
 	// Apply order level taxes and set tax related fields
 	builder.applyOrderLevelTaxes(&order)
 
+	if err := s.checkStock(ctx, &order, lookup); err != nil {
+		return nil, errors.E(op, err)
+	}
+
 	return &order, nil
 }
+
+// checkStock populates the order items that request more stock than available
+// in the order location. Items whose stock policy is allow are not checked.
+func (s *OrderingService) checkStock(ctx context.Context, order *Order, lookup *OrderLookup) error {
+	merchant := MerchantFromContext(ctx)
+
+	var variationIDs []ID
+	for _, orderItem := range order.ItemVariations {
+		variation := lookup.ItemVariation(orderItem.UID)
+		if variation.EffectiveStockPolicy(merchant) != StockPolicyAllow {
+			variationIDs = append(variationIDs, variation.ID)
+		}
+	}
+	if len(variationIDs) == 0 {
+		return nil
+	}
+
+	counts, _, err := s.InventoryStorage.ListCount(ctx, InventoryFilter{
+		ItemVariationIDs: variationIDs,
+		LocationIDs:      []ID{order.LocationID},
+		MerchantID:       order.MerchantID,
+	})
+	if err != nil {
+		return err
+	}
+
+	available := map[ID]int64{}
+	for _, count := range counts {
+		if !count.SoldOut {
+			available[count.ItemVariationID] = count.Quantity
+		}
+	}
+
+	for _, orderItem := range order.ItemVariations {
+		variation := lookup.ItemVariation(orderItem.UID)
+		policy := variation.EffectiveStockPolicy(merchant)
+		if policy == StockPolicyAllow {
+			continue
+		}
+
+		remaining := available[variation.ID]
+		if orderItem.Quantity > remaining {
+			if remaining < 0 {
+				remaining = 0
+			}
+			order.InsufficientStock = append(order.InsufficientStock, InsufficientStock{
+				UID:             orderItem.UID,
+				ItemVariationID: variation.ID,
+				Name:            variation.Name,
+				Requested:       orderItem.Quantity,
+				Available:       remaining,
+				Policy:          policy,
+			})
+		}
+		available[variation.ID] -= orderItem.Quantity
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/backium/backend/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -80,6 +81,9 @@ func TestCreateOrder(t *testing.T) {
 			inventoryStorage.ListCountFn = func(ctx context.Context, q InventoryFilter) ([]InventoryCount, int64, error) {
 				return []InventoryCount{}, 0, nil
 			}
+			inventoryStorage.ApplyAdjFn = func(ctx context.Context, adjs []InventoryAdjustment) ([]InventoryCount, error) {
+				return []InventoryCount{}, nil
+			}
 			orderInMem := Order{}
 			orderStorage.PutFn = func(ctx context.Context, order Order) error {
 				orderInMem = order
@@ -102,3 +106,143 @@ func TestCreateOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateOrderStockPolicy(t *testing.T) {
+	type testcase struct {
+		Name        string
+		Policy      StockPolicy
+		Quantity    int64
+		WantErr     bool
+		WantMissing int
+	}
+
+	testcases := []testcase{
+		{Name: "allow", Policy: StockPolicyAllow, Quantity: 5},
+		{Name: "warn", Policy: StockPolicyWarn, Quantity: 5, WantMissing: 1},
+		{Name: "block", Policy: StockPolicyBlock, Quantity: 5, WantErr: true},
+		{Name: "block with enough stock", Policy: StockPolicyBlock, Quantity: 2},
+	}
+
+	merchantID := NewID("merch")
+	locationID := NewID("loc")
+	category := NewCategory("Food", merchantID)
+	item := NewItem("Burguer", category.ID, merchantID)
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			ctx = ContextWithUser(ctx, &User{})
+			ctx = ContextWithMerchant(ctx, &Merchant{ID: merchantID})
+
+			variation := NewItemVariation("Regular", item.ID, merchantID)
+			variation.Measurement = PerItem
+			variation.Price = NewMoney(1000, PEN)
+			variation.StockPolicy = tc.Policy
+
+			count := NewInventoryCount(variation.ID, locationID, merchantID)
+			count.Quantity = 3
+
+			orderStorage := NewMockOrderStorage()
+			variationStorage := NewMockItemVariationStorage()
+			taxStorage := NewMockTaxStorage()
+			discountStorage := NewMockDiscountStorage()
+			categoryStorage := NewMockCategoryStorage()
+			itemStorage := NewMockItemStorage()
+			customerStorage := NewMockCustomerStorage()
+			inventoryStorage := NewMockInventoryStorage()
+
+			svc := OrderingService{
+				OrderStorage:         orderStorage,
+				ItemVariationStorage: variationStorage,
+				TaxStorage:           taxStorage,
+				DiscountStorage:      discountStorage,
+				CategoryStorage:      categoryStorage,
+				CustomerStorage:      customerStorage,
+				InventoryStorage:     inventoryStorage,
+				ItemStorage:          itemStorage,
+			}
+
+			categoryStorage.ListFn = func(ctx context.Context, q CategoryQuery) ([]Category, int64, error) {
+				return []Category{category}, 1, nil
+			}
+			itemStorage.ListFn = func(ctx context.Context, q ItemQuery) ([]Item, int64, error) {
+				return []Item{item}, 1, nil
+			}
+			variationStorage.ListFn = func(ctx context.Context, q ItemVariationQuery) ([]ItemVariation, int64, error) {
+				return []ItemVariation{variation}, 1, nil
+			}
+			taxStorage.ListFn = func(ctx context.Context, q TaxQuery) ([]Tax, int64, error) {
+				return nil, 0, nil
+			}
+			discountStorage.ListFn = func(ctx context.Context, q DiscountQuery) ([]Discount, int64, error) {
+				return nil, 0, nil
+			}
+			customerStorage.GetFn = func(ctx context.Context, id ID) (Customer, error) {
+				return Customer{}, nil
+			}
+			inventoryStorage.ListCountFn = func(ctx context.Context, f InventoryFilter) ([]InventoryCount, int64, error) {
+				return []InventoryCount{count}, 1, nil
+			}
+			inventoryStorage.ApplyAdjFn = func(ctx context.Context, adjs []InventoryAdjustment) ([]InventoryCount, error) {
+				return []InventoryCount{count}, nil
+			}
+			orderInMem := Order{}
+			orderStorage.PutFn = func(ctx context.Context, order Order) error {
+				orderInMem = order
+				return nil
+			}
+			orderStorage.GetFn = func(ctx context.Context, id ID) (Order, error) {
+				return orderInMem, nil
+			}
+
+			order, err := svc.CreateOrder(ctx, OrderSchema{
+				ItemVariations: []OrderSchemaItemVariation{
+					{UID: "item1", ID: variation.ID, Quantity: tc.Quantity},
+				},
+				LocationID: locationID,
+				MerchantID: merchantID,
+			})
+			if tc.WantErr {
+				var stockErr *InsufficientStockError
+				assert.True(t, errors.As(err, &stockErr), "expected insufficient stock error")
+				assert.Equal(t, int64(3), stockErr.Lines[0].Available)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, order.InsufficientStock, tc.WantMissing)
+		})
+	}
+}
+
+func TestAdjustCashDrawersRouting(t *testing.T) {
+	merchantID := NewID("merch")
+	locationID := NewID("loc")
+	first := NewCashDrawer(locationID, merchantID)
+	second := NewCashDrawer(locationID, merchantID)
+	drawers := map[ID]CashDrawer{first.ID: first, second.ID: second}
+
+	ctx := ContextWithUser(context.Background(), &User{MerchantID: merchantID})
+
+	storage := NewMockCashDrawerStorage()
+	storage.ListFn = func(ctx context.Context, q CashDrawerQuery) ([]CashDrawer, int64, error) {
+		return []CashDrawer{first, second}, 2, nil
+	}
+	storage.ApplyAdjFn = func(ctx context.Context, adj CashDrawerAdjustment) (CashDrawer, error) {
+		drawer := drawers[adj.CashDrawerID]
+		drawer.Amount.Value += adj.Delta()
+		drawers[drawer.ID] = drawer
+		return drawer, nil
+	}
+	svc := OrderingService{CashDrawerStorage: storage}
+
+	withDrawer := NewPayment(PaymentCash, NewID("order"), merchantID, locationID)
+	withDrawer.Amount = NewMoney(1000, PEN)
+	withDrawer.CashDrawerID = second.ID
+	withoutDrawer := NewPayment(PaymentCash, NewID("order"), merchantID, locationID)
+	withoutDrawer.Amount = NewMoney(300, PEN)
+
+	err := svc.adjustCashDrawers(ctx, []Payment{withDrawer, withoutDrawer})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), drawers[first.ID].Amount.Value)
+	assert.Equal(t, int64(1000), drawers[second.ID].Amount.Value)
+}
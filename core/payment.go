@@ -20,12 +20,14 @@ type Payment struct {
 	OrderID ID          `bson:"order_id"`
 	Type    PaymentType `bson:"type"`
 	// The Payment amount without tips
-	Amount     Money `bson:"amount"`
-	TipAmount  Money `bson:"tip_amount"`
-	LocationID ID    `bson:"location_id"`
-	MerchantID ID    `bson:"merchant_id"`
-	CreatedAt  int64 `bson:"created_at"`
-	UpdatedAt  int64 `bson:"updated_at"`
+	Amount    Money `bson:"amount"`
+	TipAmount Money `bson:"tip_amount"`
+	// The drawer that received the cash, only for cash payments
+	CashDrawerID ID    `bson:"cash_drawer_id"`
+	LocationID   ID    `bson:"location_id"`
+	MerchantID   ID    `bson:"merchant_id"`
+	CreatedAt    int64 `bson:"created_at"`
+	UpdatedAt    int64 `bson:"updated_at"`
 }
 
 func NewPayment(ptype PaymentType, orderID, merchantID, locationID ID) Payment {
@@ -39,12 +41,13 @@ func NewPayment(ptype PaymentType, orderID, merchantID, locationID ID) Payment {
 }
 
 type PaymentFilter struct {
-	IDs         []ID
-	OrderIDs    []ID
-	LocationIDs []ID
-	Types       []PaymentType
-	MerchantID  ID
-	CreatedAt   DateFilter
+	IDs           []ID
+	OrderIDs      []ID
+	LocationIDs   []ID
+	CashDrawerIDs []ID
+	Types         []PaymentType
+	MerchantID    ID
+	CreatedAt     DateFilter
 }
 
 type PaymentSort struct {
@@ -65,12 +68,26 @@ type PaymentStorage interface {
 }
 
 type PaymentService struct {
-	PaymentStorage PaymentStorage
+	PaymentStorage    PaymentStorage
+	CashDrawerStorage CashDrawerStorage
 }
 
 func (svc *PaymentService) CreatePayment(ctx context.Context, payment Payment) (Payment, error) {
 	const op = errors.Op("core/PaymentService.PutPayment")
 
+	if payment.CashDrawerID != "" {
+		if payment.Type != PaymentCash {
+			return Payment{}, errors.E(op, errors.KindValidation, "Only cash payments can reference a cash drawer")
+		}
+		drawer, err := svc.CashDrawerStorage.Get(ctx, payment.CashDrawerID)
+		if err != nil {
+			return Payment{}, errors.E(op, err)
+		}
+		if drawer.MerchantID != payment.MerchantID || drawer.LocationID != payment.LocationID {
+			return Payment{}, errors.E(op, errors.KindValidation, "Cash drawer doesn't belong to the payment location")
+		}
+	}
+
 	if err := svc.PaymentStorage.Put(ctx, payment); err != nil {
 		return Payment{}, err
 	}
@@ -0,0 +1,310 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/backium/backend/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+type PayrollTipSource string
+
+const (
+	// Tips are the employee lines of the tip payouts ending in the period
+	PayrollTipSourcePayouts PayrollTipSource = "payouts"
+	// Tips are the ones received in the orders taken by the employee
+	PayrollTipSourceOrders PayrollTipSource = "orders"
+)
+
+func (s *PayrollTipSource) Validate() bool {
+	switch *s {
+	case PayrollTipSourcePayouts,
+		PayrollTipSourceOrders:
+		return true
+	}
+	return false
+}
+
+func PayrollTipSources() string {
+	return strings.Join([]string{
+		string(PayrollTipSourcePayouts),
+		string(PayrollTipSourceOrders),
+	}, ", ")
+}
+
+type PayrollLine struct {
+	EmployeeID     ID
+	EmployeeName   string
+	SalaryAmount   Money
+	RegularHours   float64
+	OvertimeHours  float64
+	RegularAmount  Money
+	OvertimeAmount Money
+	TipAmount      Money
+	TotalAmount    Money
+}
+
+// Payroll is the pay of the employees for a period
+type Payroll struct {
+	BeginDate   string
+	EndDate     string
+	BeginTime   int64
+	EndTime     int64
+	Lines       []PayrollLine
+	TotalAmount Money
+}
+
+type PayrollRequest struct {
+	MerchantID  ID
+	EmployeeIDs []ID
+	// Period in YYYY-MM-DD format, both days included
+	BeginDate string
+	EndDate   string
+	Timezone  string
+	TipSource PayrollTipSource
+}
+
+type PayrollService struct {
+	EmployeeStorage  EmployeeStorage
+	TimesheetStorage TimesheetStorage
+	TipStorage       TipStorage
+	OrderStorage     OrderStorage
+	PaymentStorage   PaymentStorage
+	Uploader         Uploader
+}
+
+// RunPayroll calculates the pay of each employee for the period: the salary
+// prorated by day, the approved timesheet hours at the rate effective when
+// they were worked, and the tips
+func (svc *PayrollService) RunPayroll(ctx context.Context, req PayrollRequest) (Payroll, error) {
+	const op = errors.Op("core/PayrollService.RunPayroll")
+
+	if employee := EmployeeFromContext(ctx); employee == nil || !employee.IsOwner {
+		return Payroll{}, errors.E(op, errors.KindNoPermission, "Only owners can run the payroll")
+	}
+
+	timezone, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return Payroll{}, errors.E(op, errors.KindValidation, "Invalid timezone")
+	}
+	begin, err := time.ParseInLocation("2006-01-02", req.BeginDate, timezone)
+	if err != nil {
+		return Payroll{}, errors.E(op, errors.KindValidation, "Invalid begin date, it should have the format YYYY-MM-DD")
+	}
+	end, err := time.ParseInLocation("2006-01-02", req.EndDate, timezone)
+	if err != nil {
+		return Payroll{}, errors.E(op, errors.KindValidation, "Invalid end date, it should have the format YYYY-MM-DD")
+	}
+	if end.Before(begin) {
+		return Payroll{}, errors.E(op, errors.KindValidation, "End date should be after begin date")
+	}
+	if req.TipSource == "" {
+		req.TipSource = PayrollTipSourcePayouts
+	}
+
+	currency := reportCurrency(ctx)
+	payroll := Payroll{
+		BeginDate:   req.BeginDate,
+		EndDate:     req.EndDate,
+		BeginTime:   startOfDay(begin).Unix(),
+		EndTime:     endOfDay(end).Unix(),
+		Lines:       []PayrollLine{},
+		TotalAmount: NewMoney(0, currency),
+	}
+
+	employees, _, err := svc.EmployeeStorage.List(ctx, EmployeeQuery{
+		Filter: EmployeeFilter{IDs: req.EmployeeIDs, MerchantID: req.MerchantID},
+	})
+	if err != nil {
+		return Payroll{}, errors.E(op, err)
+	}
+	if len(employees) == 0 {
+		return payroll, nil
+	}
+	employeeIDs := make([]ID, len(employees))
+	for i, e := range employees {
+		employeeIDs[i] = e.ID
+	}
+
+	timesheets, _, err := svc.TimesheetStorage.List(ctx, TimesheetQuery{
+		Filter: TimesheetFilter{
+			EmployeeIDs: employeeIDs,
+			States:      []TimesheetState{TimesheetStateApproved},
+			MerchantID:  req.MerchantID,
+			ClockInAt:   DateFilter{Gte: payroll.BeginTime, Lte: payroll.EndTime},
+		},
+	})
+	if err != nil {
+		return Payroll{}, errors.E(op, err)
+	}
+	byEmployee := map[ID][]Timesheet{}
+	for _, ts := range timesheets {
+		byEmployee[ts.EmployeeID] = append(byEmployee[ts.EmployeeID], ts)
+	}
+
+	tips, err := svc.employeeTips(ctx, req, payroll.BeginTime, payroll.EndTime)
+	if err != nil {
+		return Payroll{}, errors.E(op, err)
+	}
+
+	for _, employee := range employees {
+		summary := summarizeTimesheets(employee, byEmployee[employee.ID], DefaultOvertimePolicy, timezone, currency)
+		line := PayrollLine{
+			EmployeeID:     employee.ID,
+			EmployeeName:   strings.TrimSpace(fmt.Sprintf("%s %s", employee.FirstName, employee.LastName)),
+			SalaryAmount:   NewMoney(proratedSalary(employee, begin, end), currency),
+			RegularHours:   summary.RegularHours,
+			OvertimeHours:  summary.OvertimeHours,
+			RegularAmount:  summary.RegularAmount,
+			OvertimeAmount: summary.OvertimeAmount,
+			TipAmount:      NewMoney(tips[employee.ID], currency),
+			TotalAmount:    NewMoney(0, currency),
+		}
+		line.TotalAmount.Value = line.SalaryAmount.Value +
+			line.RegularAmount.Value +
+			line.OvertimeAmount.Value +
+			line.TipAmount.Value
+		if line.TotalAmount.Value == 0 && summary.TimesheetCount == 0 {
+			continue
+		}
+		payroll.Lines = append(payroll.Lines, line)
+		payroll.TotalAmount.Value += line.TotalAmount.Value
+	}
+
+	return payroll, nil
+}
+
+func (svc *PayrollService) employeeTips(ctx context.Context, req PayrollRequest, beginTime, endTime int64) (map[ID]int64, error) {
+	tips := map[ID]int64{}
+
+	if req.TipSource == PayrollTipSourceOrders {
+		orderTips, err := listTips(ctx, svc.OrderStorage, svc.PaymentStorage, TipReportRequest{
+			MerchantID: req.MerchantID,
+			BeginTime:  beginTime,
+			EndTime:    endTime,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range orderTips {
+			tips[t.EmployeeID] += t.Amount.Value
+		}
+		return tips, nil
+	}
+
+	payouts, _, err := svc.TipStorage.ListPayout(ctx, TipPayoutQuery{
+		Filter: TipPayoutFilter{MerchantID: req.MerchantID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, payout := range payouts {
+		if payout.EndTime < beginTime || payout.EndTime > endTime {
+			continue
+		}
+		for _, line := range payout.Lines {
+			tips[line.EmployeeID] += line.Amount.Value
+		}
+	}
+	return tips, nil
+}
+
+// SalaryAt returns the monthly salary effective at the given time, nil if the
+// employee didn't have a salary yet
+func (e *Employee) SalaryAt(at int64) *Money {
+	history := make([]SalaryEntry, len(e.SalaryHistory))
+	copy(history, e.SalaryHistory)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt < history[j].CreatedAt
+	})
+
+	var salary *Money
+	for _, entry := range history {
+		if entry.CreatedAt > at {
+			break
+		}
+		s := entry.Salary
+		salary = &s
+	}
+	return salary
+}
+
+// proratedSalary returns the salary for the days between begin and end, each
+// day is paid as the fraction of its month salary effective by the end of the day
+func proratedSalary(employee Employee, begin, end time.Time) int64 {
+	var amount float64
+	for day := begin; !day.After(end); day = day.AddDate(0, 0, 1) {
+		salary := employee.SalaryAt(endOfDay(day).Unix() - 1)
+		if salary == nil {
+			continue
+		}
+		daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		amount += float64(salary.Value) / float64(daysInMonth)
+	}
+	return int64(math.Round(amount))
+}
+
+// ExportPayroll runs the payroll and uploads it as a spreadsheet
+func (svc *PayrollService) ExportPayroll(ctx context.Context, req PayrollRequest) (string, error) {
+	const op = errors.Op("core/PayrollService.ExportPayroll")
+
+	payroll, err := svc.RunPayroll(ctx, req)
+	if err != nil {
+		return "", errors.E(op, err)
+	}
+
+	sheetName := "Payroll"
+	filename := fmt.Sprintf("payroll_%v.xlsx", time.Now().UnixNano())
+	title := &[]interface{}{
+		"Employee",
+		"Salary",
+		"Regular hours",
+		"Regular pay",
+		"Overtime hours",
+		"Overtime pay",
+		"Tips",
+		"Total",
+	}
+
+	f := excelize.NewFile()
+	f.NewSheet(sheetName)
+	f.DeleteSheet("Sheet1")
+	f.SetSheetRow(sheetName, "A1", title)
+	f.SetColWidth(sheetName, "A", "A", 30)
+
+	for i, line := range payroll.Lines {
+		f.SetSheetRow(sheetName, fmt.Sprintf("A%v", i+2), &[]interface{}{
+			line.EmployeeName,
+			centsToString(line.SalaryAmount.Value),
+			fmt.Sprintf("%.2f", line.RegularHours),
+			centsToString(line.RegularAmount.Value),
+			fmt.Sprintf("%.2f", line.OvertimeHours),
+			centsToString(line.OvertimeAmount.Value),
+			centsToString(line.TipAmount.Value),
+			centsToString(line.TotalAmount.Value),
+		})
+	}
+	f.SetSheetRow(sheetName, fmt.Sprintf("A%v", len(payroll.Lines)+2), &[]interface{}{
+		fmt.Sprintf("Total %s - %s", payroll.BeginDate, payroll.EndDate),
+		"", "", "", "", "", "",
+		centsToString(payroll.TotalAmount.Value),
+	})
+
+	if err := f.SaveAs(filename); err != nil {
+		return "", errors.E(op, err)
+	}
+	defer os.Remove(filename)
+
+	url, err := svc.Uploader.Upload(ctx, filename)
+	if err != nil {
+		return "", errors.E(op, err)
+	}
+
+	return url, nil
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProratedSalary(t *testing.T) {
+	june := func(day int) time.Time {
+		return time.Date(2021, 6, day, 0, 0, 0, 0, time.UTC)
+	}
+	employee := Employee{
+		SalaryHistory: []SalaryEntry{
+			{Salary: NewMoney(300000, PEN), CreatedAt: june(1).AddDate(0, -1, 0).Unix()},
+			// The raise is effective from June 16
+			{Salary: NewMoney(600000, PEN), CreatedAt: june(16).Add(10 * time.Hour).Unix()},
+		},
+	}
+
+	assert.Equal(t, int64(150000), proratedSalary(employee, june(1), june(15)))
+	assert.Equal(t, int64(150000+300000), proratedSalary(employee, june(1), june(30)))
+
+	hired := Employee{
+		SalaryHistory: []SalaryEntry{{Salary: NewMoney(300000, PEN), CreatedAt: june(21).Unix()}},
+	}
+	assert.Equal(t, int64(100000), proratedSalary(hired, june(1), june(30)))
+}
+
+func TestRunPayroll(t *testing.T) {
+	merchantID := NewID("merch")
+	day := time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC).Unix()
+	salaried := Employee{
+		ID:            "empl_salaried",
+		FirstName:     "Ana",
+		SalaryHistory: []SalaryEntry{{Salary: NewMoney(300000, PEN), CreatedAt: day - 100*24*3600}},
+	}
+	hourly := Employee{
+		ID:          "empl_hourly",
+		FirstName:   "Luis",
+		RateHistory: []RateEntry{{Rate: NewMoney(1000, PEN), CreatedAt: day - 100*24*3600}},
+	}
+	idle := Employee{ID: "empl_idle"}
+
+	employeeStorage := NewMockEmployeeStorage()
+	employeeStorage.ListFn = func(ctx context.Context, q EmployeeQuery) ([]Employee, int64, error) {
+		return []Employee{salaried, hourly, idle}, 3, nil
+	}
+	timesheetStorage := NewMockTimesheetStorage()
+	timesheetStorage.ListFn = func(ctx context.Context, q TimesheetQuery) ([]Timesheet, int64, error) {
+		assert.Equal(t, []TimesheetState{TimesheetStateApproved}, q.Filter.States)
+		return []Timesheet{
+			{EmployeeID: hourly.ID, State: TimesheetStateApproved, ClockInAt: day, ClockOutAt: day + 10*3600},
+		}, 1, nil
+	}
+	tipStorage := NewMockTipStorage()
+	tipStorage.ListPayoutFn = func(ctx context.Context, q TipPayoutQuery) ([]TipPayout, int64, error) {
+		return []TipPayout{
+			{EndTime: day, Lines: []TipPayoutLine{{EmployeeID: hourly.ID, Amount: NewMoney(700, PEN)}}},
+			// Paid out in a previous payroll
+			{EndTime: day - 60*24*3600, Lines: []TipPayoutLine{{EmployeeID: hourly.ID, Amount: NewMoney(900, PEN)}}},
+		}, 2, nil
+	}
+	svc := PayrollService{
+		EmployeeStorage:  employeeStorage,
+		TimesheetStorage: timesheetStorage,
+		TipStorage:       tipStorage,
+	}
+
+	ctx := ContextWithEmployee(context.Background(), &Employee{ID: "empl_cashier"})
+	_, err := svc.RunPayroll(ctx, PayrollRequest{MerchantID: merchantID, BeginDate: "2021-06-01", EndDate: "2021-06-30", Timezone: "UTC"})
+	assert.Error(t, err, "only owners run the payroll")
+
+	ctx = ContextWithEmployee(context.Background(), &Employee{ID: "empl_owner", IsOwner: true})
+	payroll, err := svc.RunPayroll(ctx, PayrollRequest{
+		MerchantID: merchantID,
+		BeginDate:  "2021-06-01",
+		EndDate:    "2021-06-30",
+		Timezone:   "UTC",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, payroll.Lines, 2)
+	assert.Equal(t, int64(300000), payroll.Lines[0].SalaryAmount.Value)
+	assert.Equal(t, 8.0, payroll.Lines[1].RegularHours)
+	assert.Equal(t, 2.0, payroll.Lines[1].OvertimeHours)
+	assert.Equal(t, int64(8000+2500), payroll.Lines[1].RegularAmount.Value+payroll.Lines[1].OvertimeAmount.Value)
+	assert.Equal(t, int64(700), payroll.Lines[1].TipAmount.Value)
+	assert.Equal(t, int64(300000+8000+2500+700), payroll.TotalAmount.Value)
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/backium/backend/errors"
+)
+
+// EmployeePerformance summarizes the sales of an employee. Canceled orders that
+// were already paid are counted as refunds, both are attributed to the employee
+// that canceled the order.
+type EmployeePerformance struct {
+	EmployeeID       ID
+	OrderCount       int64
+	GrossSalesAmount Money
+	SalesAmount      Money
+	AverageTicket    Money
+	DiscountCount    int64
+	DiscountAmount   Money
+	// Discount amount over gross sales
+	DiscountRate        float64
+	CanceledOrderCount  int64
+	CanceledOrderAmount Money
+	RefundCount         int64
+	RefundAmount        Money
+}
+
+type EmployeePerformanceReport struct {
+	// Sorted by sales amount from highest to lowest
+	Employees []EmployeePerformance
+}
+
+type EmployeePerformanceRequest struct {
+	MerchantID  ID
+	LocationIDs []ID
+	EmployeeIDs []ID
+	BeginTime   int64
+	EndTime     int64
+}
+
+func (svc *ReportService) GenerateEmployeePerformanceReport(ctx context.Context, req EmployeePerformanceRequest) (EmployeePerformanceReport, error) {
+	const op = errors.Op("core/ReportService.GenerateEmployeePerformanceReport")
+
+	if req.EndTime == 0 {
+		req.EndTime = time.Now().Unix()
+	}
+
+	// Employees are filtered after listing since cancellations are attributed
+	// to the employee that canceled the order, not the one that took it
+	orders, _, err := svc.OrderStorage.List(ctx, OrderQuery{
+		Filter: OrderFilter{
+			LocationIDs: req.LocationIDs,
+			MerchantID:  req.MerchantID,
+			States:      []OrderState{OrderStateCompleted, OrderStateCanceled},
+			UpdatedAt:   DateFilter{Gte: req.BeginTime, Lte: req.EndTime},
+		},
+	})
+	if err != nil {
+		return EmployeePerformanceReport{}, errors.E(op, err)
+	}
+
+	report := employeePerformance(orders, reportCurrency(ctx))
+	if len(req.EmployeeIDs) != 0 {
+		var filtered []EmployeePerformance
+		for _, p := range report.Employees {
+			if ContainsID(req.EmployeeIDs, p.EmployeeID) {
+				filtered = append(filtered, p)
+			}
+		}
+		report.Employees = filtered
+	}
+	if report.Employees == nil {
+		report.Employees = []EmployeePerformance{}
+	}
+
+	return report, nil
+}
+
+func employeePerformance(orders []Order, currency Currency) EmployeePerformanceReport {
+	index := map[ID]int{}
+	report := EmployeePerformanceReport{}
+	get := func(id ID) *EmployeePerformance {
+		i, ok := index[id]
+		if !ok {
+			i = len(report.Employees)
+			index[id] = i
+			report.Employees = append(report.Employees, EmployeePerformance{
+				EmployeeID:          id,
+				GrossSalesAmount:    NewMoney(0, currency),
+				SalesAmount:         NewMoney(0, currency),
+				AverageTicket:       NewMoney(0, currency),
+				DiscountAmount:      NewMoney(0, currency),
+				CanceledOrderAmount: NewMoney(0, currency),
+				RefundAmount:        NewMoney(0, currency),
+			})
+		}
+		return &report.Employees[i]
+	}
+
+	for _, order := range orders {
+		switch order.State {
+		case OrderStateCompleted:
+			p := get(order.EmployeeID)
+			p.OrderCount++
+			p.SalesAmount.Value += order.TotalAmount.Value
+			for _, v := range order.ItemVariations {
+				p.GrossSalesAmount.Value += v.GrossSales.Value
+			}
+			p.DiscountCount += int64(len(order.Discounts))
+			p.DiscountAmount.Value += order.TotalDiscountAmount.Value
+		case OrderStateCanceled:
+			employeeID := order.CanceledBy
+			if employeeID == "" {
+				employeeID = order.EmployeeID
+			}
+			p := get(employeeID)
+			if len(order.PaymentTypes) != 0 {
+				p.RefundCount++
+				p.RefundAmount.Value += order.TotalAmount.Value
+			} else {
+				p.CanceledOrderCount++
+				p.CanceledOrderAmount.Value += order.TotalAmount.Value
+			}
+		}
+	}
+
+	for i := range report.Employees {
+		p := &report.Employees[i]
+		if p.OrderCount != 0 {
+			p.AverageTicket.Value = p.SalesAmount.Value / p.OrderCount
+		}
+		if p.GrossSalesAmount.Value != 0 {
+			p.DiscountRate = float64(p.DiscountAmount.Value) / float64(p.GrossSalesAmount.Value)
+		}
+	}
+	sort.SliceStable(report.Employees, func(i, j int) bool {
+		return report.Employees[i].SalesAmount.Value > report.Employees[j].SalesAmount.Value
+	})
+
+	return report
+}
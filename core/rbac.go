@@ -1,5 +1,7 @@
 package core
 
+import "strings"
+
 type Permission string
 
 const (
@@ -22,8 +24,81 @@ const (
 	LocationRead   Permission = "location_read"
 	OrderWrite     Permission = "order_write"
 	OrderRead      Permission = "order_read"
+	// Clock in and out and see the own timesheets
+	TimesheetAccess Permission = "timesheet_access"
+	// Edit and approve the timesheets of other employees
+	TimesheetApprove Permission = "timesheet_approve"
+	// See the employees and roles of the business, pay is only shown to owners
+	EmployeeRead Permission = "employee_read"
+	// Generate sales, stock, cash drawer and employee reports
+	ReportRead Permission = "report_read"
 )
 
+func (p *Permission) Validate() bool {
+	switch *p {
+	case HomeAccess,
+		LocationAccess,
+		CashDrawerAccess,
+		SalesAccess,
+		InventoryAccess,
+		StockAccess,
+		GuestbookAccess,
+		CatalogWrite,
+		CatalogRead,
+		InventoryWrite,
+		InventoryRead,
+		CustomerWrite,
+		CustomerRead,
+		LocationWrite,
+		LocationRead,
+		OrderWrite,
+		OrderRead,
+		TimesheetAccess,
+		TimesheetApprove,
+		EmployeeRead,
+		ReportRead:
+		return true
+	}
+	return false
+}
+
+// ManagerPermissions make an employee with any of them a manager, businesses
+// can require two-factor authentication for managers
+var ManagerPermissions = []Permission{
+	CatalogWrite,
+	InventoryWrite,
+	LocationWrite,
+	TimesheetApprove,
+	EmployeeRead,
+	ReportRead,
+}
+
+func Permissions() string {
+	return strings.Join([]string{
+		string(HomeAccess),
+		string(LocationAccess),
+		string(CashDrawerAccess),
+		string(SalesAccess),
+		string(InventoryAccess),
+		string(StockAccess),
+		string(GuestbookAccess),
+		string(CatalogWrite),
+		string(CatalogRead),
+		string(InventoryWrite),
+		string(InventoryRead),
+		string(CustomerWrite),
+		string(CustomerRead),
+		string(LocationWrite),
+		string(LocationRead),
+		string(OrderWrite),
+		string(OrderRead),
+		string(TimesheetAccess),
+		string(TimesheetApprove),
+		string(EmployeeRead),
+		string(ReportRead),
+	}, ", ")
+}
+
 func Can(given []Permission, target Permission) bool {
 	for _, p := range given {
 		if p == target {
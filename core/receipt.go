@@ -54,7 +54,7 @@ func (s *OrderingService) GenerateOrderReceipt(ctx context.Context, orderID ID)
 	}
 	defer os.Remove(htmlFilename)
 
-	pdfFilename, err := htmlToPdf(htmlFilename)
+	pdfFilename, err := htmlToPdf(htmlFilename, "600px")
 	if err != nil {
 		return "", errors.E(op, errors.KindUnexpected, errors.Errorf("html to pdf: %v", err))
 	}
@@ -68,17 +68,18 @@ func (s *OrderingService) GenerateOrderReceipt(ctx context.Context, orderID ID)
 	return url, nil
 }
 
-func htmlToPdf(filename string) (string, error) {
+func htmlToPdf(filename string, pageHeight string) (string, error) {
 	pdfFilename := fmt.Sprint(time.Now().Unix())
-	cmd := exec.Command("wkhtmltopdf", "--page-width", "200px", "--page-height", "600px", filename, pdfFilename)
+	cmd := exec.Command("wkhtmltopdf", "--page-width", "200px", "--page-height", pageHeight, filename, pdfFilename)
 	if err := cmd.Run(); err != nil {
 		return "", err
 	}
 	return pdfFilename, nil
 }
 
-func compileReceiptHtml(location Location, order Order, customer Customer) (string, error) {
-	t, err := template.ParseFiles("core/receipt/order.html")
+// compileHtml executes the template with the given data into a new html file
+func compileHtml(templateFile string, data interface{}) (string, error) {
+	t, err := template.ParseFiles(templateFile)
 	if err != nil {
 		return "", err
 	}
@@ -90,7 +91,16 @@ func compileReceiptHtml(location Location, order Order, customer Customer) (stri
 	if err != nil {
 		return "", err
 	}
+	defer f.Close()
+
+	if err := t.Execute(f, data); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
 
+func compileReceiptHtml(location Location, order Order, customer Customer) (string, error) {
 	items := make([]item, len(order.ItemVariations))
 	for i, v := range order.ItemVariations {
 		quantity := fmt.Sprintf("%v", v.Quantity)
@@ -105,11 +115,10 @@ func compileReceiptHtml(location Location, order Order, customer Customer) (stri
 	}
 
 	now := time.Now()
-	receiptID := string(order.ID[len(order.ID)-7:])
 	receipt := receiptContent{
 		LocationName: location.Name,
 		CustomerName: customer.Name,
-		ReceiptID:    receiptID,
+		ReceiptID:    receiptNumber(order.ID),
 		Date:         now.Format("January 2, 2006"),
 		Hour:         now.Format("15:04:02 AM"),
 		Items:        items,
@@ -118,12 +127,12 @@ func compileReceiptHtml(location Location, order Order, customer Customer) (stri
 		Total:        centsToString(order.TotalAmount.Value),
 	}
 
-	err = t.Execute(f, receipt)
-	if err != nil {
-		return "", err
-	}
+	return compileHtml("core/receipt/order.html", receipt)
+}
 
-	return filename, nil
+// receiptNumber is the number printed in the order receipt
+func receiptNumber(orderID ID) string {
+	return string(orderID[len(orderID)-7:])
 }
 
 func centsToString(c int64) string {
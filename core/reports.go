@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -22,6 +23,7 @@ const (
 	GroupingWeekday       GroupingType = "weekday"
 	GroupingHourOfDay     GroupingType = "hour_of_day"
 	GroupingMonth         GroupingType = "month"
+	GroupingEmployee      GroupingType = "employee"
 )
 
 type GroupingType string
@@ -37,7 +39,8 @@ func (g *GroupingType) Validate() bool {
 		GroupingDay,
 		GroupingWeekday,
 		GroupingHourOfDay,
-		GroupingMonth:
+		GroupingMonth,
+		GroupingEmployee:
 		return true
 	default:
 		return false
@@ -56,6 +59,7 @@ func GroupingTypes() string {
 		string(GroupingWeekday),
 		string(GroupingHourOfDay),
 		string(GroupingMonth),
+		string(GroupingEmployee),
 	}, ",")
 }
 
@@ -65,6 +69,10 @@ type ReportService struct {
 	ItemVariationStorage ItemVariationStorage
 	InventoryStorage     InventoryStorage
 	CategoryStorage      CategoryStorage
+	CashDrawerStorage    CashDrawerStorage
+	PaymentStorage       PaymentStorage
+	LocationStorage      LocationStorage
+	Uploader             Uploader
 }
 
 type ReportFilter struct {
@@ -111,6 +119,48 @@ type StockReport struct {
 	TotalProfit Money
 }
 
+type ExpiringStock struct {
+	ItemVariationID ID
+	Name            string
+	LocationID      ID
+	LotNumber       string
+	ExpiresAt       int64
+	Quantity        int64
+}
+
+type ExpiringStockReport struct {
+	Lots []ExpiringStock
+}
+
+type ShrinkageGroup struct {
+	Reason          InventoryReason
+	AdjustmentCount int64
+	Quantity        int64
+	CostAmount      Money
+}
+
+type ShrinkageReport struct {
+	Groups          []ShrinkageGroup
+	TotalQuantity   int64
+	TotalCostAmount Money
+}
+
+type CashDrawerSummary struct {
+	CashDrawerID ID
+	Name         string
+	LocationID   ID
+	// Current balance of the drawer
+	Balance           Money
+	CashPaymentCount  int64
+	CashPaymentAmount Money
+	PaidInAmount      Money
+	PaidOutAmount     Money
+}
+
+type CashDrawerReport struct {
+	Drawers []CashDrawerSummary
+}
+
 type CustomReportRequest struct {
 	GroupType []GroupingType
 	Timezone  string
@@ -118,17 +168,47 @@ type CustomReportRequest struct {
 }
 
 type StockReportRequest struct {
+	// Generate the report with the stock on hand at this time instead of the current one
+	At     int64
+	Filter StockFilter
+}
+
+type ShrinkageReportRequest struct {
+	// Reasons to include, defaults to ShrinkageReasons
+	Reasons   []InventoryReason
+	BeginTime int64
+	EndTime   int64
+	Filter    StockFilter
+}
+
+type CashDrawerReportRequest struct {
+	MerchantID    ID
+	LocationIDs   []ID
+	CashDrawerIDs []ID
+	BeginTime     int64
+	EndTime       int64
+}
+
+type ExpiringStockReportRequest struct {
+	// Include lots expiring within this number of days, already expired lots are always included
+	Days   int64
 	Filter StockFilter
 }
 
 func (svc *ReportService) GenerateStockReport(ctx context.Context, req StockReportRequest) (StockReport, error) {
 	const op = errors.Op("core/ReportService.GenerateStockReport")
 
-	inventory, _, err := svc.InventoryStorage.ListCount(ctx, InventoryFilter{
-		ItemVariationIDs: req.Filter.ItemVariationIDs,
-		LocationIDs:      req.Filter.LocationIDs,
-		MerchantID:       req.Filter.MerchantID,
-	})
+	var inventory []InventoryCount
+	var err error
+	if req.At != 0 {
+		inventory, err = inventorySnapshot(ctx, svc.InventoryStorage, req.Filter, req.At)
+	} else {
+		inventory, _, err = svc.InventoryStorage.ListCount(ctx, InventoryFilter{
+			ItemVariationIDs: req.Filter.ItemVariationIDs,
+			LocationIDs:      req.Filter.LocationIDs,
+			MerchantID:       req.Filter.MerchantID,
+		})
+	}
 	if err != nil {
 		return StockReport{}, errors.E(op, err)
 	}
@@ -185,6 +265,233 @@ func (svc *ReportService) GenerateStockReport(ctx context.Context, req StockRepo
 	return report, nil
 }
 
+func (svc *ReportService) GenerateExpiringStockReport(ctx context.Context, req ExpiringStockReportRequest) (ExpiringStockReport, error) {
+	const op = errors.Op("core/ReportService.GenerateExpiringStockReport")
+
+	inventory, _, err := svc.InventoryStorage.ListCount(ctx, InventoryFilter{
+		ItemVariationIDs: req.Filter.ItemVariationIDs,
+		LocationIDs:      req.Filter.LocationIDs,
+		MerchantID:       req.Filter.MerchantID,
+	})
+	if err != nil {
+		return ExpiringStockReport{}, errors.E(op, err)
+	}
+
+	variations, _, err := svc.ItemVariationStorage.List(ctx, ItemVariationQuery{
+		Filter: ItemVariationFilter{
+			IDs:        req.Filter.ItemVariationIDs,
+			MerchantID: req.Filter.MerchantID,
+		},
+	})
+	if err != nil {
+		return ExpiringStockReport{}, errors.E(op, err)
+	}
+
+	names := map[ID]string{}
+	for _, v := range variations {
+		names[v.ID] = v.Name
+	}
+
+	limit := time.Now().AddDate(0, 0, int(req.Days)).Unix()
+	report := ExpiringStockReport{Lots: []ExpiringStock{}}
+	for _, inv := range inventory {
+		for _, lot := range inv.Lots {
+			if lot.ExpiresAt == 0 || lot.ExpiresAt > limit || lot.Quantity <= 0 {
+				continue
+			}
+			report.Lots = append(report.Lots, ExpiringStock{
+				ItemVariationID: inv.ItemVariationID,
+				Name:            names[inv.ItemVariationID],
+				LocationID:      inv.LocationID,
+				LotNumber:       lot.LotNumber,
+				ExpiresAt:       lot.ExpiresAt,
+				Quantity:        lot.Quantity,
+			})
+		}
+	}
+
+	sort.SliceStable(report.Lots, func(i, j int) bool {
+		return report.Lots[i].ExpiresAt < report.Lots[j].ExpiresAt
+	})
+
+	return report, nil
+}
+
+// GenerateShrinkageReport groups inventory adjustments by reason and
+// calculates the quantity and cost of the stock lost, both manual and auto
+// generated adjustments are included.
+// Stock added back (e.g. a positive count correction) reduces the shrinkage,
+// resets are ignored as they don't record the change in quantity.
+func (svc *ReportService) GenerateShrinkageReport(ctx context.Context, req ShrinkageReportRequest) (ShrinkageReport, error) {
+	const op = errors.Op("core/ReportService.GenerateShrinkageReport")
+
+	reasons := req.Reasons
+	if len(reasons) == 0 {
+		reasons = ShrinkageReasons()
+	}
+
+	adjs, err := listAdjustmentHistory(ctx, svc.InventoryStorage, InventoryFilter{
+		ItemVariationIDs: req.Filter.ItemVariationIDs,
+		LocationIDs:      req.Filter.LocationIDs,
+		MerchantID:       req.Filter.MerchantID,
+		Reasons:          reasons,
+		CreatedAt: DateFilter{
+			Gte: req.BeginTime,
+			Lte: req.EndTime,
+		},
+	})
+	if err != nil {
+		return ShrinkageReport{}, errors.E(op, err)
+	}
+
+	variations, _, err := svc.ItemVariationStorage.List(ctx, ItemVariationQuery{
+		Filter: ItemVariationFilter{
+			IDs:        req.Filter.ItemVariationIDs,
+			MerchantID: req.Filter.MerchantID,
+		},
+	})
+	if err != nil {
+		return ShrinkageReport{}, errors.E(op, err)
+	}
+
+	lookup := map[ID]ItemVariation{}
+	for _, v := range variations {
+		lookup[v.ID] = v
+	}
+
+	currency := reportCurrency(ctx)
+	groups := map[InventoryReason]*ShrinkageGroup{}
+	for _, reason := range reasons {
+		groups[reason] = &ShrinkageGroup{
+			Reason:     reason,
+			CostAmount: NewMoney(0, currency),
+		}
+	}
+
+	report := ShrinkageReport{
+		Groups:          []ShrinkageGroup{},
+		TotalCostAmount: NewMoney(0, currency),
+	}
+	for _, adj := range adjs {
+		group, ok := groups[adj.Reason]
+		if !ok {
+			continue
+		}
+
+		var quantity int64
+		switch adj.Op {
+		case InventoryOpRemoveStock, InventoryOpWasteStock:
+			quantity = adj.Quantity
+		case InventoryOpAddStock:
+			quantity = -adj.Quantity
+		default:
+			continue
+		}
+
+		var cost int64
+		if variation, ok := lookup[adj.ItemVariationID]; ok && variation.Cost != nil {
+			cost = stockAmount(variation.Measurement, variation.Cost.Value, quantity)
+		}
+
+		group.AdjustmentCount++
+		group.Quantity += quantity
+		group.CostAmount.Value += cost
+		report.TotalQuantity += quantity
+		report.TotalCostAmount.Value += cost
+	}
+
+	for _, reason := range reasons {
+		if group, ok := groups[reason]; ok {
+			report.Groups = append(report.Groups, *group)
+			delete(groups, reason)
+		}
+	}
+
+	return report, nil
+}
+
+// stockAmount calculates the value of a quantity of stock given its unit value
+func stockAmount(measurement MeasurementUnit, unitValue, quantity int64) int64 {
+	if measurement == PerItem {
+		return unitValue * quantity
+	}
+	// Use 3 decimals of precision
+	q := d.NewFromInt(quantity).Div(thousand)
+	return q.Mul(d.NewFromInt(unitValue)).RoundBank(0).IntPart()
+}
+
+// GenerateCashDrawerReport summarizes the cash movements of each drawer in the time range
+func (svc *ReportService) GenerateCashDrawerReport(ctx context.Context, req CashDrawerReportRequest) (CashDrawerReport, error) {
+	const op = errors.Op("core/ReportService.GenerateCashDrawerReport")
+
+	drawers, _, err := svc.CashDrawerStorage.List(ctx, CashDrawerQuery{
+		Filter: CashDrawerFilter{
+			IDs:         req.CashDrawerIDs,
+			LocationIDs: req.LocationIDs,
+			MerchantID:  req.MerchantID,
+		},
+	})
+	if err != nil {
+		return CashDrawerReport{}, errors.E(op, err)
+	}
+
+	report := CashDrawerReport{Drawers: []CashDrawerSummary{}}
+	if len(drawers) == 0 {
+		return report, nil
+	}
+
+	drawerIDs := make([]ID, len(drawers))
+	for i, drawer := range drawers {
+		drawerIDs[i] = drawer.ID
+	}
+	var adjs []CashDrawerAdjustment
+	for _, auto := range []bool{false, true} {
+		res, _, err := svc.CashDrawerStorage.ListAdjustment(ctx, CashDrawerQuery{
+			Filter: CashDrawerFilter{
+				CashDrawerIDs: drawerIDs,
+				CreatedAt:     DateFilter{Gte: req.BeginTime, Lte: req.EndTime},
+				AutoGenerated: auto,
+				MerchantID:    req.MerchantID,
+			},
+		})
+		if err != nil {
+			return CashDrawerReport{}, errors.E(op, err)
+		}
+		adjs = append(adjs, res...)
+	}
+
+	for _, drawer := range drawers {
+		currency := drawer.Amount.Currency
+		summary := CashDrawerSummary{
+			CashDrawerID:      drawer.ID,
+			Name:              drawer.Name,
+			LocationID:        drawer.LocationID,
+			Balance:           drawer.Amount,
+			CashPaymentAmount: NewMoney(0, currency),
+			PaidInAmount:      NewMoney(0, currency),
+			PaidOutAmount:     NewMoney(0, currency),
+		}
+		for _, adj := range adjs {
+			// Shift floats and count corrections are not cash movements
+			if adj.CashDrawerID != drawer.ID || adj.Reason != "" {
+				continue
+			}
+			switch {
+			case adj.Op == CashDrawerOpAdd && adj.AutoGenerated:
+				summary.CashPaymentCount++
+				summary.CashPaymentAmount.Value += adj.Amount.Value
+			case adj.Op == CashDrawerOpAdd:
+				summary.PaidInAmount.Value += adj.Amount.Value
+			case adj.Op == CashDrawerOpRemove:
+				summary.PaidOutAmount.Value += adj.Amount.Value
+			}
+		}
+		report.Drawers = append(report.Drawers, summary)
+	}
+
+	return report, nil
+}
+
 func (svc *ReportService) GenerateCustom(ctx context.Context, req CustomReportRequest) ([]CustomReport, error) {
 	const op = errors.Op("core/ReportService.GenerateCustom")
 
@@ -369,6 +676,8 @@ func groupOrders(orders []WrappedOrder, groupType GroupingType, timezone string)
 		orderGroups = groupOrdersByHourOfDay(orders, timezone)
 	case GroupingMonth:
 		orderGroups = groupOrdersByMonth(orders, timezone)
+	case GroupingEmployee:
+		orderGroups = groupOrdersByEmployee(orders)
 	default:
 		return nil, errors.E("Unsupported groupType")
 	}
@@ -457,6 +766,27 @@ func groupOrdersByCustomer(orders []WrappedOrder) map[string][]WrappedOrder {
 	return orderGroups
 }
 
+func groupOrdersByEmployee(orders []WrappedOrder) map[string][]WrappedOrder {
+	orderGroups := make(map[string][]WrappedOrder)
+
+	for _, order := range orders {
+		uidGroups := map[string][]string{}
+
+		name := string(order.Order.EmployeeID)
+		for _, variation := range order.Order.ItemVariations {
+			if order.Contains(variation.UID) {
+				uidGroups[name] = append(uidGroups[name], variation.UID)
+			}
+		}
+
+		for name, uids := range uidGroups {
+			orderGroups[name] = append(orderGroups[name], order.CloneWith(uids))
+		}
+	}
+
+	return orderGroups
+}
+
 func groupOrdersByState(orders []WrappedOrder) map[string][]WrappedOrder {
 	orderGroups := make(map[string][]WrappedOrder)
 
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -165,6 +166,108 @@ func TestGroupOrdersByCategory(t *testing.T) {
 	}
 }
 
+func TestGroupOrdersByEmployee(t *testing.T) {
+	wrappedOrders := []WrappedOrder{
+		NewWrappedOrder(&Order{
+			ID:         NewID("order"),
+			EmployeeID: "emp_anna",
+			ItemVariations: []OrderItemVariation{
+				{UID: "item1", Name: "burguer"},
+				{UID: "item2", Name: "soda"},
+			},
+		}),
+		NewWrappedOrder(&Order{
+			ID:         NewID("order"),
+			EmployeeID: "emp_bob",
+			ItemVariations: []OrderItemVariation{
+				{UID: "item1", Name: "soda"},
+			},
+		}),
+	}
+
+	orderGroups := groupOrdersByEmployee(wrappedOrders)
+
+	assert.Len(t, orderGroups, 2)
+	assert.Equal(t, []WrappedOrder{
+		{Order: wrappedOrders[0].Order, included: map[string]bool{"item1": true, "item2": true}},
+	}, orderGroups["emp_anna"])
+	assert.Equal(t, []WrappedOrder{
+		{Order: wrappedOrders[1].Order, included: map[string]bool{"item1": true}},
+	}, orderGroups["emp_bob"])
+}
+
+func TestGenerateEmployeePerformanceReport(t *testing.T) {
+	orders := []Order{
+		{
+			ID: "order_1", EmployeeID: "emp_anna", State: OrderStateCompleted,
+			TotalAmount:         NewMoney(900, PEN),
+			TotalDiscountAmount: NewMoney(100, PEN),
+			Discounts:           []OrderDiscount{{UID: "discount1"}},
+			ItemVariations:      []OrderItemVariation{{UID: "item1", GrossSales: NewMoney(1000, PEN)}},
+		},
+		{
+			ID: "order_2", EmployeeID: "emp_anna", State: OrderStateCompleted,
+			TotalAmount:    NewMoney(500, PEN),
+			ItemVariations: []OrderItemVariation{{UID: "item1", GrossSales: NewMoney(500, PEN)}},
+		},
+		{
+			ID: "order_3", EmployeeID: "emp_bob", State: OrderStateCompleted,
+			TotalAmount:    NewMoney(2000, PEN),
+			ItemVariations: []OrderItemVariation{{UID: "item1", GrossSales: NewMoney(2000, PEN)}},
+		},
+		// Canceled by anna before being paid
+		{
+			ID: "order_4", EmployeeID: "emp_bob", CanceledBy: "emp_anna", State: OrderStateCanceled,
+			TotalAmount: NewMoney(300, PEN),
+		},
+		// Canceled after being paid
+		{
+			ID: "order_5", EmployeeID: "emp_bob", State: OrderStateCanceled,
+			TotalAmount:  NewMoney(700, PEN),
+			PaymentTypes: []PaymentType{PaymentCash},
+		},
+	}
+	orderStorage := NewMockOrderStorage()
+	orderStorage.ListFn = func(ctx context.Context, q OrderQuery) ([]Order, int64, error) {
+		return orders, int64(len(orders)), nil
+	}
+	svc := ReportService{OrderStorage: orderStorage}
+
+	report, err := svc.GenerateEmployeePerformanceReport(context.Background(), EmployeePerformanceRequest{
+		MerchantID: NewID("merch"),
+		BeginTime:  1,
+		EndTime:    1000,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, report.Employees, 2)
+
+	bob, anna := report.Employees[0], report.Employees[1]
+	assert.Equal(t, ID("emp_bob"), bob.EmployeeID)
+	assert.Equal(t, int64(1), bob.OrderCount)
+	assert.Equal(t, int64(2000), bob.SalesAmount.Value)
+	assert.Equal(t, int64(0), bob.CanceledOrderCount)
+	assert.Equal(t, int64(1), bob.RefundCount)
+	assert.Equal(t, int64(700), bob.RefundAmount.Value)
+
+	assert.Equal(t, ID("emp_anna"), anna.EmployeeID)
+	assert.Equal(t, int64(2), anna.OrderCount)
+	assert.Equal(t, int64(1400), anna.SalesAmount.Value)
+	assert.Equal(t, int64(700), anna.AverageTicket.Value)
+	assert.Equal(t, int64(1), anna.DiscountCount)
+	assert.Equal(t, int64(100), anna.DiscountAmount.Value)
+	assert.InDelta(t, 0.0667, anna.DiscountRate, 0.0001)
+	assert.Equal(t, int64(1), anna.CanceledOrderCount)
+	assert.Equal(t, int64(300), anna.CanceledOrderAmount.Value)
+
+	report, err = svc.GenerateEmployeePerformanceReport(context.Background(), EmployeePerformanceRequest{
+		MerchantID:  NewID("merch"),
+		EmployeeIDs: []ID{"emp_anna"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, report.Employees, 1)
+	assert.Equal(t, ID("emp_anna"), report.Employees[0].EmployeeID)
+}
+
 func TestGroupOrdersByDay(t *testing.T) {
 	timezone := "America/Bogota"
 	wrappedOrders := []WrappedOrder{
@@ -301,3 +404,145 @@ func TestGroupOrdersByHourOfDay(t *testing.T) {
 		}
 	}
 }
+
+func TestZReportTotals(t *testing.T) {
+	currency := PEN
+	vat := OrderTax{ID: NewID("tax"), Name: "IGV", AppliedAmount: NewMoney(180, currency)}
+	promo := OrderDiscount{ID: NewID("discount"), Name: "Promo", AppliedAmount: NewMoney(100, currency)}
+	first := Order{
+		ID:             NewID("order"),
+		State:          OrderStateCompleted,
+		ItemVariations: []OrderItemVariation{{GrossSales: NewMoney(1000, currency)}},
+		Taxes:          []OrderTax{vat},
+		Discounts:      []OrderDiscount{promo},
+		TotalTaxAmount: NewMoney(180, currency),
+		TotalAmount:    NewMoney(1080, currency),
+		CreatedAt:      100,
+	}
+	last := Order{
+		ID:             NewID("order"),
+		State:          OrderStateCompleted,
+		ItemVariations: []OrderItemVariation{{GrossSales: NewMoney(500, currency)}},
+		Taxes:          []OrderTax{vat},
+		TotalTaxAmount: NewMoney(180, currency),
+		TotalAmount:    NewMoney(680, currency),
+		CreatedAt:      300,
+	}
+	canceled := Order{
+		ID:          NewID("order"),
+		State:       OrderStateCanceled,
+		TotalAmount: NewMoney(400, currency),
+		CreatedAt:   200,
+	}
+
+	report := ZReport{
+		GrossSalesAmount:     NewMoney(0, currency),
+		DiscountAmount:       NewMoney(0, currency),
+		TaxAmount:            NewMoney(0, currency),
+		TipAmount:            NewMoney(0, currency),
+		TotalAmount:          NewMoney(0, currency),
+		CanceledOrderAmount:  NewMoney(0, currency),
+		CashExpectedAmount:   NewMoney(0, currency),
+		CashCountedAmount:    NewMoney(0, currency),
+		CashDifferenceAmount: NewMoney(0, currency),
+	}
+	report.addOrders([]Order{last, canceled, first})
+	report.addPayments([]Payment{
+		{OrderID: first.ID, Type: PaymentCash, Amount: NewMoney(1080, currency), TipAmount: NewMoney(100, currency)},
+		{OrderID: last.ID, Type: PaymentCard, Amount: NewMoney(680, currency)},
+		{OrderID: canceled.ID, Type: PaymentCash, Amount: NewMoney(400, currency), TipAmount: NewMoney(50, currency)},
+	}, map[ID]bool{canceled.ID: true})
+	report.addShifts([]CashDrawerShift{
+		{State: CashDrawerShiftStateClosed, ExpectedAmount: NewMoney(1180, currency),
+			CountedAmount: NewMoney(1150, currency), DifferenceAmount: NewMoney(-30, currency)},
+		{State: CashDrawerShiftStateOpen},
+	})
+
+	assert.Equal(t, int64(2), report.OrderCount)
+	assert.Equal(t, int64(1500), report.GrossSalesAmount.Value)
+	assert.Equal(t, int64(1760), report.TotalAmount.Value)
+	assert.Equal(t, int64(1), report.CanceledOrderCount)
+	assert.Equal(t, int64(400), report.CanceledOrderAmount.Value)
+	assert.Len(t, report.Taxes, 1)
+	assert.Equal(t, int64(360), report.Taxes[0].Amount.Value)
+	assert.Len(t, report.Discounts, 1)
+	assert.Equal(t, int64(100), report.Discounts[0].Amount.Value)
+	assert.Len(t, report.Payments, 2)
+	assert.Equal(t, int64(1080), report.Payments[0].Amount.Value)
+	assert.Len(t, report.Refunds, 1)
+	assert.Equal(t, int64(400), report.Refunds[0].Amount.Value)
+	assert.Equal(t, int64(100), report.TipAmount.Value)
+	assert.Equal(t, int64(-30), report.CashDifferenceAmount.Value)
+	assert.Equal(t, int64(1), report.OpenShiftCount)
+	assert.Equal(t, receiptNumber(first.ID), report.FirstReceiptNumber)
+	assert.Equal(t, receiptNumber(last.ID), report.LastReceiptNumber)
+}
+
+func TestGenerateShrinkageReport(t *testing.T) {
+	merchant := NewMerchant()
+	merchant.Currency = PEN
+	variation := NewItemVariation("Milk", NewID("item"), merchant.ID)
+	variation.Measurement = PerItem
+	cost := NewMoney(300, PEN)
+	variation.Cost = &cost
+	locationID := NewID("loc")
+
+	newAdj := func(op InventoryOp, reason InventoryReason, quantity int64, auto bool) InventoryAdjustment {
+		adj := NewInventoryAdjustment(variation.ID, locationID, merchant.ID)
+		adj.Op = op
+		adj.Reason = reason
+		adj.Quantity = quantity
+		adj.AutoGenerated = auto
+		return adj
+	}
+	adjs := []InventoryAdjustment{
+		newAdj(InventoryOpWasteStock, InventoryReasonSpoilage, 4, false),
+		newAdj(InventoryOpWasteStock, InventoryReasonSpoilage, 1, true),
+		newAdj(InventoryOpRemoveStock, InventoryReasonTheft, 2, false),
+		newAdj(InventoryOpAddStock, InventoryReasonCountCorrection, 1, false),
+		newAdj(InventoryOpResetStock, InventoryReasonCountCorrection, 10, false),
+		newAdj(InventoryOpRemoveStock, InventoryReasonSale, 7, true),
+	}
+
+	inventoryStorage := NewMockInventoryStorage()
+	inventoryStorage.ListAdjustmentFn = func(ctx context.Context, f InventoryFilter) ([]InventoryAdjustment, int64, error) {
+		var res []InventoryAdjustment
+		for _, adj := range adjs {
+			for _, reason := range f.Reasons {
+				if adj.AutoGenerated == f.AutoGenerated && adj.Reason == reason {
+					res = append(res, adj)
+				}
+			}
+		}
+		return res, int64(len(res)), nil
+	}
+	variationStorage := NewMockItemVariationStorage()
+	variationStorage.ListFn = func(ctx context.Context, q ItemVariationQuery) ([]ItemVariation, int64, error) {
+		return []ItemVariation{variation}, 1, nil
+	}
+	svc := ReportService{InventoryStorage: inventoryStorage, ItemVariationStorage: variationStorage}
+	ctx := ContextWithMerchant(context.Background(), &merchant)
+
+	report, err := svc.GenerateShrinkageReport(ctx, ShrinkageReportRequest{
+		Filter: StockFilter{MerchantID: merchant.ID},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []ShrinkageGroup{
+		{Reason: InventoryReasonDamage, CostAmount: NewMoney(0, PEN)},
+		{Reason: InventoryReasonTheft, AdjustmentCount: 1, Quantity: 2, CostAmount: NewMoney(600, PEN)},
+		// Auto generated waste counts too
+		{Reason: InventoryReasonSpoilage, AdjustmentCount: 2, Quantity: 5, CostAmount: NewMoney(1500, PEN)},
+		// Stock found reduces the shrinkage, resets are ignored
+		{Reason: InventoryReasonCountCorrection, AdjustmentCount: 1, Quantity: -1, CostAmount: NewMoney(-300, PEN)},
+	}, report.Groups)
+	assert.Equal(t, int64(6), report.TotalQuantity)
+	assert.Equal(t, NewMoney(1800, PEN), report.TotalCostAmount)
+
+	report, err = svc.GenerateShrinkageReport(ctx, ShrinkageReportRequest{
+		Reasons: []InventoryReason{InventoryReasonTheft},
+		Filter:  StockFilter{MerchantID: merchant.ID},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, report.Groups, 1)
+	assert.Equal(t, NewMoney(600, PEN), report.TotalCostAmount)
+}
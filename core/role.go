@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+
+	"github.com/backium/backend/errors"
+)
+
+// Role is a named set of permissions, employees get the permissions of their
+// roles so changing a role changes the permissions of all its employees
+type Role struct {
+	ID          ID           `bson:"_id"`
+	Name        string       `bson:"name"`
+	Permissions []Permission `bson:"permissions"`
+	MerchantID  ID           `bson:"merchant_id"`
+	CreatedAt   int64        `bson:"created_at"`
+	UpdatedAt   int64        `bson:"updated_at"`
+	Status      Status       `bson:"status"`
+}
+
+func NewRole(name string, merchantID ID) Role {
+	return Role{
+		ID:          NewID("role"),
+		Name:        name,
+		Permissions: []Permission{},
+		MerchantID:  merchantID,
+		Status:      StatusActive,
+	}
+}
+
+// DefaultRoles returns the roles every business starts with
+func DefaultRoles(merchantID ID) []Role {
+	cashier := NewRole("Cashier", merchantID)
+	cashier.Permissions = []Permission{
+		HomeAccess,
+		SalesAccess,
+		CashDrawerAccess,
+		GuestbookAccess,
+		CatalogRead,
+		CustomerRead,
+		CustomerWrite,
+		LocationRead,
+		OrderRead,
+		OrderWrite,
+		TimesheetAccess,
+	}
+
+	manager := NewRole("Manager", merchantID)
+	manager.Permissions = []Permission{
+		HomeAccess,
+		LocationAccess,
+		CashDrawerAccess,
+		SalesAccess,
+		InventoryAccess,
+		StockAccess,
+		GuestbookAccess,
+		CatalogWrite,
+		CatalogRead,
+		InventoryWrite,
+		InventoryRead,
+		CustomerWrite,
+		CustomerRead,
+		LocationWrite,
+		LocationRead,
+		OrderWrite,
+		OrderRead,
+		TimesheetAccess,
+		TimesheetApprove,
+		EmployeeRead,
+		ReportRead,
+	}
+
+	return []Role{cashier, manager}
+}
+
+type RoleFilter struct {
+	IDs        []ID
+	MerchantID ID
+}
+
+type RoleQuery struct {
+	Limit  int64
+	Offset int64
+	Filter RoleFilter
+}
+
+type RoleStorage interface {
+	Put(context.Context, Role) error
+	PutBatch(context.Context, []Role) error
+	Get(context.Context, ID) (Role, error)
+	List(context.Context, RoleQuery) ([]Role, int64, error)
+}
+
+type RoleService struct {
+	RoleStorage RoleStorage
+}
+
+func (svc *RoleService) PutRole(ctx context.Context, role Role) (Role, error) {
+	const op = errors.Op("core/RoleService.PutRole")
+
+	if employee := EmployeeFromContext(ctx); employee == nil || !employee.IsOwner {
+		return Role{}, errors.E(op, errors.KindNoPermission, "Only owners can manage roles")
+	}
+	for _, p := range role.Permissions {
+		if !p.Validate() {
+			return Role{}, errors.E(op, errors.KindValidation, "Unknown permission "+string(p))
+		}
+	}
+
+	if err := svc.RoleStorage.Put(ctx, role); err != nil {
+		return Role{}, errors.E(op, err)
+	}
+
+	role, err := svc.RoleStorage.Get(ctx, role.ID)
+	if err != nil {
+		return Role{}, errors.E(op, err)
+	}
+
+	return role, nil
+}
+
+func (svc *RoleService) GetRole(ctx context.Context, id ID) (Role, error) {
+	const op = errors.Op("core/RoleService.GetRole")
+
+	merchant := MerchantFromContext(ctx)
+	if merchant == nil {
+		return Role{}, errors.E(op, errors.KindUnexpected, "Unknown merchant")
+	}
+
+	role, err := svc.RoleStorage.Get(ctx, id)
+	if err != nil {
+		return Role{}, errors.E(op, err)
+	}
+	if role.MerchantID != merchant.ID {
+		return Role{}, errors.E(op, errors.KindNotFound, "Role not found")
+	}
+
+	return role, nil
+}
+
+func (svc *RoleService) ListRole(ctx context.Context, q RoleQuery) ([]Role, int64, error) {
+	const op = errors.Op("core/RoleService.ListRole")
+
+	roles, count, err := svc.RoleStorage.List(ctx, q)
+	if err != nil {
+		return nil, 0, errors.E(op, err)
+	}
+
+	return roles, count, nil
+}
+
+// DeleteRole removes the role, its employees stop getting its permissions
+func (svc *RoleService) DeleteRole(ctx context.Context, id ID) (Role, error) {
+	const op = errors.Op("core/RoleService.DeleteRole")
+
+	role, err := svc.GetRole(ctx, id)
+	if err != nil {
+		return Role{}, errors.E(op, err)
+	}
+
+	role.Status = StatusShadowDeleted
+	role, err = svc.PutRole(ctx, role)
+	if err != nil {
+		return Role{}, errors.E(op, err)
+	}
+
+	return role, nil
+}
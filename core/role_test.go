@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func roleTestStorage(roles map[ID]*Role) *mockRoleStorage {
+	roleStorage := NewMockRoleStorage()
+	roleStorage.GetFn = func(ctx context.Context, id ID) (Role, error) {
+		r, ok := roles[id]
+		if !ok {
+			return Role{}, errors.E(errors.KindNotFound)
+		}
+		return *r, nil
+	}
+	roleStorage.PutFn = func(ctx context.Context, r Role) error {
+		roles[r.ID] = &r
+		return nil
+	}
+	roleStorage.ListFn = func(ctx context.Context, q RoleQuery) ([]Role, int64, error) {
+		var result []Role
+		for _, id := range q.Filter.IDs {
+			r, ok := roles[id]
+			if !ok || r.MerchantID != q.Filter.MerchantID || r.Status == StatusShadowDeleted {
+				continue
+			}
+			result = append(result, *r)
+		}
+		return result, int64(len(result)), nil
+	}
+	return roleStorage
+}
+
+func TestEffectivePermissions(t *testing.T) {
+	merchantID := NewID("merch")
+	cashier := NewRole("Cashier", merchantID)
+	cashier.Permissions = []Permission{SalesAccess, OrderWrite}
+	other := NewRole("Manager", NewID("merch"))
+	other.Permissions = []Permission{CatalogWrite}
+	roles := map[ID]*Role{cashier.ID: &cashier, other.ID: &other}
+
+	owner := NewEmployee("Jhon", "Doe", merchantID)
+	owner.IsOwner = true
+	ctx := ContextWithMerchant(context.Background(), &Merchant{ID: merchantID})
+	ctx = ContextWithEmployee(ctx, &owner)
+
+	roleService := RoleService{RoleStorage: roleTestStorage(roles)}
+	auth := Authorizer{RoleStorage: roleService.RoleStorage}
+
+	anna := NewEmployee("Anna", "Smith", merchantID)
+	anna.Permissions = []Permission{OrderRead}
+	anna.RoleIDs = []ID{cashier.ID, other.ID}
+
+	permissions, err := auth.EffectivePermissions(ctx, anna)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Permission{OrderRead, SalesAccess, OrderWrite}, permissions)
+
+	// Changing the role changes the permissions of its employees
+	cashier.Permissions = append(cashier.Permissions, CustomerWrite)
+	_, err = roleService.PutRole(ctx, cashier)
+	assert.NoError(t, err)
+	permissions, err = auth.EffectivePermissions(ctx, anna)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Permission{OrderRead, SalesAccess, OrderWrite, CustomerWrite}, permissions)
+
+	// Deleted roles no longer give permissions
+	_, err = roleService.DeleteRole(ctx, cashier.ID)
+	assert.NoError(t, err)
+	permissions, err = auth.EffectivePermissions(ctx, anna)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Permission{OrderRead}, permissions)
+}
+
+func TestPutRole(t *testing.T) {
+	merchantID := NewID("merch")
+	roleService := RoleService{RoleStorage: roleTestStorage(map[ID]*Role{})}
+
+	manager := NewEmployee("Anna", "Smith", merchantID)
+	manager.Permissions = []Permission{LocationWrite}
+	ctx := ContextWithMerchant(context.Background(), &Merchant{ID: merchantID})
+	ctx = ContextWithEmployee(ctx, &manager)
+
+	role := NewRole("Cashier", merchantID)
+	role.Permissions = []Permission{SalesAccess}
+	_, err := roleService.PutRole(ctx, role)
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+
+	manager.IsOwner = true
+	role.Permissions = []Permission{"everything"}
+	_, err = roleService.PutRole(ctx, role)
+	assert.True(t, errors.Is(err, errors.KindValidation))
+
+	role.Permissions = []Permission{SalesAccess}
+	role, err = roleService.PutRole(ctx, role)
+	assert.NoError(t, err)
+	assert.Equal(t, []Permission{SalesAccess}, role.Permissions)
+}
@@ -2,15 +2,35 @@ package core
 
 import (
 	"context"
+	"time"
 
 	"github.com/backium/backend/errors"
 	"github.com/dgrijalva/jwt-go"
 )
 
+const (
+	// Sessions expire after this time even if they are in use
+	SessionAbsoluteTimeout = 30 * 24 * time.Hour
+	// Sessions expire when not used for this time
+	SessionIdleTimeout = 12 * time.Hour
+	// Minimum time between renewals of the idle timeout of a session
+	SessionRenewInterval = time.Minute
+	// Time given to complete the second login step
+	PendingSessionTimeout = 5 * time.Minute
+)
+
 type SessionStorage interface {
+	// Set stores the session until it expires and indexes it by user
 	Set(context.Context, Session) error
+	// Renew stores the session only if it's still stored, sessions deleted
+	// while they were being renewed are not stored again
+	Renew(context.Context, Session) error
 	Get(context.Context, ID) (Session, error)
 	Delete(context.Context, ID) error
+	// ListByUser returns the active sessions of a user
+	ListByUser(context.Context, ID) ([]Session, error)
+	// DeleteByUser removes all the sessions of a user
+	DeleteByUser(context.Context, ID) error
 }
 
 type Session struct {
@@ -18,24 +38,147 @@ type Session struct {
 	UserID     ID
 	MerchantID ID
 	Kind       UserKind
+	// Set for sessions started with a PIN on a registered device, these are
+	// restricted to the device location
+	DeviceID    ID
+	EmployeeID  ID
+	LocationIDs []ID
+	// Set while the session waits for the second login step, pending sessions
+	// only give access to it
+	TwoFactorStep TwoFactorStep
+	// Client that started the session and its last known address
+	UserAgent  string
+	IP         string
+	CreatedAt  int64
+	LastSeenAt int64
+	ExpiresAt  int64
 }
 
 func NewSession(u User) Session {
 	id := NewID("sess")
+	now := time.Now()
 	return Session{
 		ID:         id,
 		UserID:     u.ID,
 		MerchantID: u.MerchantID,
 		Kind:       u.Kind,
+		CreatedAt:  now.Unix(),
+		LastSeenAt: now.Unix(),
+		ExpiresAt:  now.Add(SessionAbsoluteTimeout).Unix(),
+	}
+}
+
+func NewDeviceSession(d Device, e Employee) Session {
+	now := time.Now()
+	return Session{
+		ID:          NewID("sess"),
+		MerchantID:  d.MerchantID,
+		Kind:        UserKindEmployee,
+		DeviceID:    d.ID,
+		EmployeeID:  e.ID,
+		LocationIDs: []ID{d.LocationID},
+		CreatedAt:   now.Unix(),
+		LastSeenAt:  now.Unix(),
+		ExpiresAt:   now.Add(SessionAbsoluteTimeout).Unix(),
+	}
+}
+
+// RequireTwoFactor makes the session wait for the second login step, it has to
+// be completed shortly
+func (s *Session) RequireTwoFactor(step TwoFactorStep) {
+	s.TwoFactorStep = step
+	s.ExpiresAt = time.Unix(s.CreatedAt, 0).Add(PendingSessionTimeout).Unix()
+}
+
+func (s *Session) Pending() bool {
+	return s.TwoFactorStep != TwoFactorNone
+}
+
+// TTL returns the time left before the session expires, either by reaching
+// its absolute timeout or by being idle
+func (s *Session) TTL(now time.Time) time.Duration {
+	absolute := time.Unix(s.ExpiresAt, 0).Sub(now)
+	idle := time.Unix(s.LastSeenAt, 0).Add(SessionIdleTimeout).Sub(now)
+	if idle < absolute {
+		return idle
+	}
+	return absolute
+}
+
+func (s *Session) Expired(now time.Time) bool {
+	return s.TTL(now) <= 0
+}
+
+// Touch records the use of the session, it returns true if the session has to
+// be stored again to renew its idle timeout
+func (s *Session) Touch(now time.Time, ip string) bool {
+	if now.Unix()-s.LastSeenAt < int64(SessionRenewInterval.Seconds()) && s.IP == ip {
+		return false
 	}
+	s.LastSeenAt = now.Unix()
+	s.IP = ip
+	return true
 }
 
-func DecodeSession(encodedSession string) (Session, error) {
+// DeviceUser returns the user acting in a device session, employees logging in with
+// a PIN are not required to have a user account
+func (s *Session) DeviceUser() User {
+	return User{
+		ID:         s.UserID,
+		Kind:       UserKindEmployee,
+		EmployeeID: s.EmployeeID,
+		MerchantID: s.MerchantID,
+	}
+}
+
+// SessionKeyMinLength is the minimum size in bytes of a session signing key
+const SessionKeyMinLength = 32
+
+// SessionKeys are the keys used to sign session tokens. Tokens are signed with
+// the current key and carry its ID in the header, they are verified with the
+// key of that ID so keys can be rotated without invalidating the sessions
+// signed with the previous ones. Tokens signed with a key that is no longer
+// in the set are rejected.
+type SessionKeys struct {
+	CurrentID string
+	Keys      map[string][]byte
+}
+
+func (k *SessionKeys) Validate() error {
+	if len(k.Keys) == 0 {
+		return errors.E(errors.KindValidation, "no session signing keys")
+	}
+	for id, key := range k.Keys {
+		if id == "" {
+			return errors.E(errors.KindValidation, "session signing key without id")
+		}
+		if len(key) < SessionKeyMinLength {
+			return errors.E(errors.KindValidation, "session signing key "+id+" is too short")
+		}
+	}
+	if _, ok := k.Keys[k.CurrentID]; !ok {
+		return errors.E(errors.KindValidation, "unknown current session signing key "+k.CurrentID)
+	}
+	return nil
+}
+
+func DecodeSession(encodedSession string, keys SessionKeys) (Session, error) {
 	const op = "handler.DecodeSession"
 
 	claims := jwt.MapClaims{}
 	_, err := jwt.ParseWithClaims(encodedSession, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte("backium"), nil
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, errors.Errorf("missing key id")
+		}
+		key, ok := keys.Keys[kid]
+		if !ok {
+			return nil, errors.Errorf("unknown or retired key %v", kid)
+		}
+		return key, nil
 	})
 	if err != nil {
 		return Session{}, errors.E(op, errors.KindInvalidSession, err)
@@ -58,22 +201,39 @@ func DecodeSession(encodedSession string) (Session, error) {
 		return Session{}, errors.E(op, errors.KindInvalidSession, "missing or invalid kind")
 	}
 
+	// Tokens without expiration were issued before sessions expired
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return Session{}, errors.E(op, errors.KindInvalidSession, "missing or invalid exp")
+	}
+	iat, _ := claims["iat"].(float64)
+
 	return Session{
 		ID:         ID(id),
 		UserID:     ID(userID),
 		MerchantID: ID(merchantID),
 		Kind:       UserKind(kind),
+		CreatedAt:  int64(iat),
+		ExpiresAt:  int64(exp),
 	}, nil
 }
 
-func (s *Session) Encode(key []byte) (string, error) {
+func (s *Session) Encode(keys SessionKeys) (string, error) {
 	const op = errors.Op("Session.encode")
+
+	key, ok := keys.Keys[keys.CurrentID]
+	if !ok {
+		return "", errors.E(op, errors.KindUnexpected, "unknown current session signing key")
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"session_id":  s.ID,
 		"user_id":     s.UserID,
 		"merchant_id": s.MerchantID,
 		"kind":        s.Kind,
+		"iat":         s.CreatedAt,
+		"exp":         s.ExpiresAt,
 	})
+	token.Header["kid"] = keys.CurrentID
 	sig, err := token.SignedString(key)
 	if err != nil {
 		return "", errors.E(op, errors.KindInvalidSession, err)
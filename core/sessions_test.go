@@ -0,0 +1,121 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/backium/backend/errors"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionKeyRotation(t *testing.T) {
+	oldKey := []byte("old-signing-key-0000000000000000")
+	newKey := []byte("new-signing-key-0000000000000000")
+	now := time.Now()
+	session := Session{
+		ID:         NewID("sess"),
+		UserID:     NewID("user"),
+		MerchantID: NewID("merch"),
+		Kind:       UserKindOwner,
+		CreatedAt:  now.Unix(),
+		ExpiresAt:  now.Add(SessionAbsoluteTimeout).Unix(),
+	}
+
+	before := SessionKeys{CurrentID: "k1", Keys: map[string][]byte{"k1": oldKey}}
+	oldToken, err := session.Encode(before)
+	assert.NoError(t, err)
+
+	// Both keys are active while rotating, new tokens use the new key
+	rotating := SessionKeys{CurrentID: "k2", Keys: map[string][]byte{"k1": oldKey, "k2": newKey}}
+	decoded, err := DecodeSession(oldToken, rotating)
+	assert.NoError(t, err)
+	assert.Equal(t, session, decoded)
+
+	newToken, err := session.Encode(rotating)
+	assert.NoError(t, err)
+	parsed, _, err := new(jwt.Parser).ParseUnverified(newToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "k2", parsed.Header["kid"])
+
+	// Tokens signed with the retired key are rejected
+	after := SessionKeys{CurrentID: "k2", Keys: map[string][]byte{"k2": newKey}}
+	_, err = DecodeSession(oldToken, after)
+	assert.True(t, errors.Is(err, errors.KindInvalidSession))
+	decoded, err = DecodeSession(newToken, after)
+	assert.NoError(t, err)
+	assert.Equal(t, session, decoded)
+}
+
+func TestDecodeSessionRejectsUnknownKeys(t *testing.T) {
+	keys := SessionKeys{CurrentID: "k1", Keys: map[string][]byte{"k1": []byte("signing-key-00000000000000000000")}}
+	claims := jwt.MapClaims{
+		"session_id":  "sess_1",
+		"user_id":     "user_1",
+		"merchant_id": "merch_1",
+		"kind":        "owner",
+		"exp":         time.Now().Add(time.Hour).Unix(),
+	}
+
+	// Signed with the right secret but without key id
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(keys.Keys["k1"])
+	assert.NoError(t, err)
+	_, err = DecodeSession(token, keys)
+	assert.True(t, errors.Is(err, errors.KindInvalidSession))
+
+	// Signed with the previously hardcoded key
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "k1"
+	token, err = forged.SignedString([]byte("backium"))
+	assert.NoError(t, err)
+	_, err = DecodeSession(token, keys)
+	assert.True(t, errors.Is(err, errors.KindInvalidSession))
+}
+
+func TestSessionKeysValidate(t *testing.T) {
+	key := []byte("signing-key-00000000000000000000")
+
+	keys := SessionKeys{CurrentID: "k1", Keys: map[string][]byte{"k1": key}}
+	assert.NoError(t, keys.Validate())
+
+	keys = SessionKeys{CurrentID: "k2", Keys: map[string][]byte{"k1": key}}
+	assert.Error(t, keys.Validate())
+
+	keys = SessionKeys{CurrentID: "k1", Keys: map[string][]byte{"k1": []byte("short")}}
+	assert.Error(t, keys.Validate())
+
+	keys = SessionKeys{}
+	assert.Error(t, keys.Validate())
+}
+
+func TestSessionTimeouts(t *testing.T) {
+	keys := SessionKeys{CurrentID: "k1", Keys: map[string][]byte{"k1": []byte("signing-key-00000000000000000000")}}
+	session := NewSession(NewUserOwner())
+	now := time.Unix(session.CreatedAt, 0)
+
+	assert.False(t, session.Expired(now))
+	assert.Equal(t, SessionIdleTimeout, session.TTL(now))
+	assert.True(t, session.Expired(now.Add(SessionIdleTimeout)))
+
+	// Using the session renews its idle timeout, at most once per interval
+	assert.False(t, session.Touch(now.Add(time.Second), ""))
+	later := now.Add(SessionIdleTimeout - time.Minute)
+	assert.True(t, session.Touch(later, "10.0.0.1"))
+	assert.False(t, session.Expired(now.Add(SessionIdleTimeout)))
+	// A new address is recorded right away
+	assert.True(t, session.Touch(later.Add(time.Second), "10.0.0.2"))
+	assert.Equal(t, "10.0.0.2", session.IP)
+
+	// The absolute timeout can't be extended
+	end := now.Add(SessionAbsoluteTimeout)
+	session.LastSeenAt = end.Add(-time.Minute).Unix()
+	assert.Equal(t, 2*time.Minute, session.TTL(end.Add(-2*time.Minute)))
+	assert.True(t, session.Expired(end))
+
+	// Expired tokens are rejected even if the session is still stored
+	session.ExpiresAt = now.Add(-time.Second).Unix()
+	token, err := session.Encode(keys)
+	assert.NoError(t, err)
+	_, err = DecodeSession(token, keys)
+	assert.True(t, errors.Is(err, errors.KindInvalidSession))
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"time"
 )
 
 type mockOrderStorage struct {
@@ -167,6 +168,8 @@ type mockInventoryStorage struct {
 	PutBatchAdjFn    func(context.Context, []InventoryAdjustment) error
 	ListCountFn      func(context.Context, InventoryFilter) ([]InventoryCount, int64, error)
 	ListAdjustmentFn func(context.Context, InventoryFilter) ([]InventoryAdjustment, int64, error)
+	ApplyAdjFn       func(context.Context, []InventoryAdjustment) ([]InventoryCount, error)
+	SetSoldOutFn     func(context.Context, ID, ID, ID, bool) (InventoryCount, error)
 }
 
 func NewMockInventoryStorage() *mockInventoryStorage {
@@ -193,6 +196,14 @@ func (m *mockInventoryStorage) ListAdjustment(ctx context.Context, fil Inventory
 	return m.ListAdjustmentFn(ctx, fil)
 }
 
+func (m *mockInventoryStorage) ApplyAdjustments(ctx context.Context, adjs []InventoryAdjustment) ([]InventoryCount, error) {
+	return m.ApplyAdjFn(ctx, adjs)
+}
+
+func (m *mockInventoryStorage) SetSoldOut(ctx context.Context, variationID, locationID, merchantID ID, soldOut bool) (InventoryCount, error) {
+	return m.SetSoldOutFn(ctx, variationID, locationID, merchantID, soldOut)
+}
+
 type mockCustomerStorage struct {
 	PutFn      func(context.Context, Customer) error
 	PutBatchFn func(context.Context, []Customer) error
@@ -226,6 +237,12 @@ type mockCashDrawerStorage struct {
 	GetFn            func(context.Context, ID) (CashDrawer, error)
 	ListFn           func(context.Context, CashDrawerQuery) ([]CashDrawer, int64, error)
 	ListAdjustmentFn func(context.Context, CashDrawerQuery) ([]CashDrawerAdjustment, int64, error)
+	ApplyAdjFn       func(context.Context, CashDrawerAdjustment) (CashDrawer, error)
+	OpenShiftFn      func(context.Context, CashDrawerShift) (CashDrawer, error)
+	CloseShiftFn     func(context.Context, CashDrawerShift) (CashDrawerShift, error)
+	PutShiftFn       func(context.Context, CashDrawerShift) error
+	GetShiftFn       func(context.Context, ID) (CashDrawerShift, error)
+	ListShiftFn      func(context.Context, CashDrawerShiftQuery) ([]CashDrawerShift, int64, error)
 }
 
 func NewMockCashDrawerStorage() *mockCashDrawerStorage {
@@ -251,3 +268,390 @@ func (m *mockCashDrawerStorage) List(ctx context.Context, fil CashDrawerQuery) (
 func (m *mockCashDrawerStorage) ListAdjustment(ctx context.Context, fil CashDrawerQuery) ([]CashDrawerAdjustment, int64, error) {
 	return m.ListAdjustmentFn(ctx, fil)
 }
+
+func (m *mockCashDrawerStorage) ApplyAdjustment(ctx context.Context, adj CashDrawerAdjustment) (CashDrawer, error) {
+	return m.ApplyAdjFn(ctx, adj)
+}
+
+func (m *mockCashDrawerStorage) OpenShift(ctx context.Context, shift CashDrawerShift) (CashDrawer, error) {
+	return m.OpenShiftFn(ctx, shift)
+}
+
+func (m *mockCashDrawerStorage) CloseShift(ctx context.Context, shift CashDrawerShift) (CashDrawerShift, error) {
+	return m.CloseShiftFn(ctx, shift)
+}
+
+func (m *mockCashDrawerStorage) PutShift(ctx context.Context, t CashDrawerShift) error {
+	return m.PutShiftFn(ctx, t)
+}
+
+func (m *mockCashDrawerStorage) GetShift(ctx context.Context, id ID) (CashDrawerShift, error) {
+	return m.GetShiftFn(ctx, id)
+}
+
+func (m *mockCashDrawerStorage) ListShift(ctx context.Context, fil CashDrawerShiftQuery) ([]CashDrawerShift, int64, error) {
+	return m.ListShiftFn(ctx, fil)
+}
+
+type mockPaymentStorage struct {
+	PutFn  func(context.Context, Payment) error
+	GetFn  func(context.Context, ID) (Payment, error)
+	ListFn func(context.Context, PaymentQuery) ([]Payment, int64, error)
+}
+
+func NewMockPaymentStorage() *mockPaymentStorage {
+	return &mockPaymentStorage{}
+}
+
+func (s *mockPaymentStorage) Put(ctx context.Context, payment Payment) error {
+	return s.PutFn(ctx, payment)
+}
+
+func (s *mockPaymentStorage) Get(ctx context.Context, id ID) (Payment, error) {
+	return s.GetFn(ctx, id)
+}
+
+func (s *mockPaymentStorage) List(ctx context.Context, q PaymentQuery) ([]Payment, int64, error) {
+	return s.ListFn(ctx, q)
+}
+
+type mockTipStorage struct {
+	PutPoolFn    func(context.Context, TipPool) error
+	GetPoolFn    func(context.Context, ID) (TipPool, error)
+	ListPoolFn   func(context.Context, TipPoolQuery) ([]TipPool, int64, error)
+	PutPayoutFn  func(context.Context, TipPayout) error
+	GetPayoutFn  func(context.Context, ID) (TipPayout, error)
+	ListPayoutFn func(context.Context, TipPayoutQuery) ([]TipPayout, int64, error)
+}
+
+func NewMockTipStorage() *mockTipStorage {
+	return &mockTipStorage{}
+}
+
+func (m *mockTipStorage) PutPool(ctx context.Context, pool TipPool) error {
+	return m.PutPoolFn(ctx, pool)
+}
+
+func (m *mockTipStorage) GetPool(ctx context.Context, id ID) (TipPool, error) {
+	return m.GetPoolFn(ctx, id)
+}
+
+func (m *mockTipStorage) ListPool(ctx context.Context, q TipPoolQuery) ([]TipPool, int64, error) {
+	return m.ListPoolFn(ctx, q)
+}
+
+func (m *mockTipStorage) PutPayout(ctx context.Context, payout TipPayout) error {
+	return m.PutPayoutFn(ctx, payout)
+}
+
+func (m *mockTipStorage) GetPayout(ctx context.Context, id ID) (TipPayout, error) {
+	return m.GetPayoutFn(ctx, id)
+}
+
+func (m *mockTipStorage) ListPayout(ctx context.Context, q TipPayoutQuery) ([]TipPayout, int64, error) {
+	return m.ListPayoutFn(ctx, q)
+}
+
+type mockTimesheetStorage struct {
+	PutFn  func(context.Context, Timesheet) error
+	GetFn  func(context.Context, ID) (Timesheet, error)
+	ListFn func(context.Context, TimesheetQuery) ([]Timesheet, int64, error)
+}
+
+func NewMockTimesheetStorage() *mockTimesheetStorage {
+	return &mockTimesheetStorage{}
+}
+
+func (m *mockTimesheetStorage) Put(ctx context.Context, ts Timesheet) error {
+	return m.PutFn(ctx, ts)
+}
+
+func (m *mockTimesheetStorage) Get(ctx context.Context, id ID) (Timesheet, error) {
+	return m.GetFn(ctx, id)
+}
+
+func (m *mockTimesheetStorage) List(ctx context.Context, q TimesheetQuery) ([]Timesheet, int64, error) {
+	return m.ListFn(ctx, q)
+}
+
+type mockEmployeeStorage struct {
+	PutFn              func(context.Context, Employee) error
+	GetFn              func(context.Context, ID) (Employee, error)
+	ListFn             func(context.Context, EmployeeQuery) ([]Employee, int64, error)
+	AddPINFailureFn    func(context.Context, ID, int, int64) (Employee, error)
+	ResetPINFailuresFn func(context.Context, ID) error
+}
+
+func NewMockEmployeeStorage() *mockEmployeeStorage {
+	return &mockEmployeeStorage{}
+}
+
+func (m *mockEmployeeStorage) Put(ctx context.Context, employee Employee) error {
+	return m.PutFn(ctx, employee)
+}
+
+func (m *mockEmployeeStorage) Get(ctx context.Context, id ID) (Employee, error) {
+	return m.GetFn(ctx, id)
+}
+
+func (m *mockEmployeeStorage) List(ctx context.Context, q EmployeeQuery) ([]Employee, int64, error) {
+	return m.ListFn(ctx, q)
+}
+
+func (m *mockEmployeeStorage) AddPINFailure(ctx context.Context, id ID, maxAttempts int, lockedUntil int64) (Employee, error) {
+	return m.AddPINFailureFn(ctx, id, maxAttempts, lockedUntil)
+}
+
+func (m *mockEmployeeStorage) ResetPINFailures(ctx context.Context, id ID) error {
+	return m.ResetPINFailuresFn(ctx, id)
+}
+
+type mockDeviceStorage struct {
+	PutFn  func(context.Context, Device) error
+	GetFn  func(context.Context, ID) (Device, error)
+	ListFn func(context.Context, DeviceQuery) ([]Device, int64, error)
+}
+
+func NewMockDeviceStorage() *mockDeviceStorage {
+	return &mockDeviceStorage{}
+}
+
+func (m *mockDeviceStorage) Put(ctx context.Context, device Device) error {
+	return m.PutFn(ctx, device)
+}
+
+func (m *mockDeviceStorage) Get(ctx context.Context, id ID) (Device, error) {
+	return m.GetFn(ctx, id)
+}
+
+func (m *mockDeviceStorage) List(ctx context.Context, q DeviceQuery) ([]Device, int64, error) {
+	return m.ListFn(ctx, q)
+}
+
+type mockSessionStorage struct {
+	SetFn          func(context.Context, Session) error
+	RenewFn        func(context.Context, Session) error
+	GetFn          func(context.Context, ID) (Session, error)
+	DeleteFn       func(context.Context, ID) error
+	ListByUserFn   func(context.Context, ID) ([]Session, error)
+	DeleteByUserFn func(context.Context, ID) error
+}
+
+func NewMockSessionStorage() *mockSessionStorage {
+	return &mockSessionStorage{}
+}
+
+func (m *mockSessionStorage) Set(ctx context.Context, session Session) error {
+	return m.SetFn(ctx, session)
+}
+
+func (m *mockSessionStorage) Renew(ctx context.Context, session Session) error {
+	return m.RenewFn(ctx, session)
+}
+
+func (m *mockSessionStorage) Get(ctx context.Context, id ID) (Session, error) {
+	return m.GetFn(ctx, id)
+}
+
+func (m *mockSessionStorage) Delete(ctx context.Context, id ID) error {
+	return m.DeleteFn(ctx, id)
+}
+
+func (m *mockSessionStorage) ListByUser(ctx context.Context, userID ID) ([]Session, error) {
+	return m.ListByUserFn(ctx, userID)
+}
+
+func (m *mockSessionStorage) DeleteByUser(ctx context.Context, userID ID) error {
+	return m.DeleteByUserFn(ctx, userID)
+}
+
+type mockUserStorage struct {
+	PutFn        func(context.Context, User) error
+	GetFn        func(context.Context, ID) (User, error)
+	GetByEmailFn func(context.Context, string) (User, error)
+}
+
+func NewMockUserStorage() *mockUserStorage {
+	return &mockUserStorage{}
+}
+
+func (m *mockUserStorage) Put(ctx context.Context, user User) error {
+	return m.PutFn(ctx, user)
+}
+
+func (m *mockUserStorage) Get(ctx context.Context, id ID) (User, error) {
+	return m.GetFn(ctx, id)
+}
+
+func (m *mockUserStorage) GetByEmail(ctx context.Context, email string) (User, error) {
+	return m.GetByEmailFn(ctx, email)
+}
+
+type mockMerchantStorage struct {
+	PutFn            func(context.Context, Merchant) error
+	PutKeyFn         func(context.Context, ID, Key) error
+	TouchKeyFn       func(context.Context, ID, ID, int64) error
+	RevokeKeyFn      func(context.Context, ID, ID, int64) error
+	MigrateKeyFn     func(context.Context, ID, string, Key) error
+	UpdateSettingsFn func(context.Context, Merchant) error
+	GetFn            func(context.Context, ID) (Merchant, error)
+	GetByKeyFn       func(context.Context, string) (Merchant, error)
+}
+
+func NewMockMerchantStorage() *mockMerchantStorage {
+	return &mockMerchantStorage{}
+}
+
+func (m *mockMerchantStorage) Put(ctx context.Context, merchant Merchant) error {
+	return m.PutFn(ctx, merchant)
+}
+
+func (m *mockMerchantStorage) PutKey(ctx context.Context, merchantID ID, key Key) error {
+	return m.PutKeyFn(ctx, merchantID, key)
+}
+
+func (m *mockMerchantStorage) TouchKey(ctx context.Context, merchantID, keyID ID, usedAt int64) error {
+	return m.TouchKeyFn(ctx, merchantID, keyID, usedAt)
+}
+
+func (m *mockMerchantStorage) RevokeKey(ctx context.Context, merchantID, keyID ID, revokedAt int64) error {
+	return m.RevokeKeyFn(ctx, merchantID, keyID, revokedAt)
+}
+
+func (m *mockMerchantStorage) MigrateKey(ctx context.Context, merchantID ID, token string, key Key) error {
+	return m.MigrateKeyFn(ctx, merchantID, token, key)
+}
+
+func (m *mockMerchantStorage) UpdateSettings(ctx context.Context, merchant Merchant) error {
+	return m.UpdateSettingsFn(ctx, merchant)
+}
+
+func (m *mockMerchantStorage) Get(ctx context.Context, id ID) (Merchant, error) {
+	return m.GetFn(ctx, id)
+}
+
+func (m *mockMerchantStorage) GetByKey(ctx context.Context, token string) (Merchant, error) {
+	return m.GetByKeyFn(ctx, token)
+}
+
+type mockRoleStorage struct {
+	PutFn      func(context.Context, Role) error
+	PutBatchFn func(context.Context, []Role) error
+	GetFn      func(context.Context, ID) (Role, error)
+	ListFn     func(context.Context, RoleQuery) ([]Role, int64, error)
+}
+
+func NewMockRoleStorage() *mockRoleStorage {
+	return &mockRoleStorage{}
+}
+
+func (m *mockRoleStorage) Put(ctx context.Context, role Role) error {
+	return m.PutFn(ctx, role)
+}
+
+func (m *mockRoleStorage) PutBatch(ctx context.Context, roles []Role) error {
+	return m.PutBatchFn(ctx, roles)
+}
+
+func (m *mockRoleStorage) Get(ctx context.Context, id ID) (Role, error) {
+	return m.GetFn(ctx, id)
+}
+
+func (m *mockRoleStorage) List(ctx context.Context, q RoleQuery) ([]Role, int64, error) {
+	return m.ListFn(ctx, q)
+}
+
+type mockLocationStorage struct {
+	PutFn      func(context.Context, Location) error
+	PutBatchFn func(context.Context, []Location) error
+	GetFn      func(context.Context, ID) (Location, error)
+	ListFn     func(context.Context, LocationQuery) ([]Location, int64, error)
+}
+
+func NewMockLocationStorage() *mockLocationStorage {
+	return &mockLocationStorage{}
+}
+
+func (m *mockLocationStorage) Put(ctx context.Context, location Location) error {
+	return m.PutFn(ctx, location)
+}
+
+func (m *mockLocationStorage) PutBatch(ctx context.Context, batch []Location) error {
+	return m.PutBatchFn(ctx, batch)
+}
+
+func (m *mockLocationStorage) Get(ctx context.Context, id ID) (Location, error) {
+	return m.GetFn(ctx, id)
+}
+
+func (m *mockLocationStorage) List(ctx context.Context, q LocationQuery) ([]Location, int64, error) {
+	return m.ListFn(ctx, q)
+}
+
+type mockUserTokenStorage struct {
+	PutFn          func(context.Context, UserToken) error
+	ClaimFn        func(context.Context, string, UserTokenKind, time.Time) (UserToken, error)
+	DeleteByUserFn func(context.Context, ID, UserTokenKind) error
+}
+
+func NewMockUserTokenStorage() *mockUserTokenStorage {
+	return &mockUserTokenStorage{}
+}
+
+func (m *mockUserTokenStorage) Put(ctx context.Context, token UserToken) error {
+	return m.PutFn(ctx, token)
+}
+
+func (m *mockUserTokenStorage) Claim(ctx context.Context, hash string, kind UserTokenKind, at time.Time) (UserToken, error) {
+	return m.ClaimFn(ctx, hash, kind, at)
+}
+
+func (m *mockUserTokenStorage) DeleteByUser(ctx context.Context, userID ID, kind UserTokenKind) error {
+	return m.DeleteByUserFn(ctx, userID, kind)
+}
+
+type mockMailer struct {
+	SendFn func(context.Context, Mail) error
+}
+
+func NewMockMailer() *mockMailer {
+	return &mockMailer{}
+}
+
+func (m *mockMailer) Send(ctx context.Context, mail Mail) error {
+	return m.SendFn(ctx, mail)
+}
+
+type mockLoginAttemptStorage struct {
+	RecordFailureFn func(context.Context, string, time.Time) (LoginAttempts, error)
+	CancelFailureFn func(context.Context, string, time.Time, LoginAttempts) error
+	ResetFn         func(context.Context, string) error
+}
+
+func NewMockLoginAttemptStorage() *mockLoginAttemptStorage {
+	return &mockLoginAttemptStorage{}
+}
+
+func (m *mockLoginAttemptStorage) RecordFailure(ctx context.Context, key string, now time.Time) (LoginAttempts, error) {
+	return m.RecordFailureFn(ctx, key, now)
+}
+
+func (m *mockLoginAttemptStorage) CancelFailure(ctx context.Context, key string, at time.Time, before LoginAttempts) error {
+	return m.CancelFailureFn(ctx, key, at, before)
+}
+
+func (m *mockLoginAttemptStorage) Reset(ctx context.Context, key string) error {
+	return m.ResetFn(ctx, key)
+}
+
+type mockFailedLoginStorage struct {
+	PutFn func(context.Context, FailedLogin) error
+}
+
+func NewMockFailedLoginStorage() *mockFailedLoginStorage {
+	return &mockFailedLoginStorage{}
+}
+
+func (m *mockFailedLoginStorage) Put(ctx context.Context, login FailedLogin) error {
+	return m.PutFn(ctx, login)
+}
@@ -0,0 +1,578 @@
+package core
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/backium/backend/errors"
+)
+
+type TimesheetState string
+
+const (
+	// The employee is clocked in
+	TimesheetStateOpen TimesheetState = "open"
+	// The employee clocked out, the timesheet is waiting for approval
+	TimesheetStateClosed   TimesheetState = "closed"
+	TimesheetStateApproved TimesheetState = "approved"
+)
+
+type TimesheetBreak struct {
+	StartAt int64 `bson:"start_at"`
+	EndAt   int64 `bson:"end_at"`
+}
+
+// Timesheet is the time an employee worked at a location between clocking in and out
+type Timesheet struct {
+	ID         ID               `bson:"_id"`
+	EmployeeID ID               `bson:"employee_id"`
+	State      TimesheetState   `bson:"state"`
+	ClockInAt  int64            `bson:"clock_in_at"`
+	ClockOutAt int64            `bson:"clock_out_at"`
+	Breaks     []TimesheetBreak `bson:"breaks"`
+	Note       string           `bson:"note"`
+	// Manager that last edited the timesheet
+	EditedBy   ID    `bson:"edited_by"`
+	ApprovedBy ID    `bson:"approved_by"`
+	ApprovedAt int64 `bson:"approved_at"`
+	LocationID ID    `bson:"location_id"`
+	MerchantID ID    `bson:"merchant_id"`
+	CreatedAt  int64 `bson:"created_at"`
+	UpdatedAt  int64 `bson:"updated_at"`
+}
+
+func NewTimesheet(employeeID, locationID, merchantID ID) Timesheet {
+	return Timesheet{
+		ID:         NewID("timesheet"),
+		EmployeeID: employeeID,
+		State:      TimesheetStateOpen,
+		ClockInAt:  time.Now().Unix(),
+		Breaks:     []TimesheetBreak{},
+		LocationID: locationID,
+		MerchantID: merchantID,
+	}
+}
+
+func (ts Timesheet) onBreak() bool {
+	return len(ts.Breaks) != 0 && ts.Breaks[len(ts.Breaks)-1].EndAt == 0
+}
+
+// WorkedSeconds returns the time worked without breaks, open timesheets are counted up to now
+func (ts Timesheet) WorkedSeconds(now int64) int64 {
+	end := ts.ClockOutAt
+	if ts.State == TimesheetStateOpen {
+		end = now
+	}
+	worked := end - ts.ClockInAt
+	for _, b := range ts.Breaks {
+		breakEnd := b.EndAt
+		if breakEnd == 0 {
+			breakEnd = end
+		}
+		worked -= breakEnd - b.StartAt
+	}
+	if worked < 0 {
+		return 0
+	}
+	return worked
+}
+
+func (ts Timesheet) validate() error {
+	const op = errors.Op("core/Timesheet.validate")
+
+	if ts.State != TimesheetStateOpen && ts.ClockOutAt < ts.ClockInAt {
+		return errors.E(op, errors.KindValidation, "Clock out time should be after clock in time")
+	}
+	last := ts.ClockInAt
+	for i, b := range ts.Breaks {
+		if b.StartAt < last {
+			return errors.E(op, errors.KindValidation, "Breaks should be sorted, not overlap and start after clock in")
+		}
+		if b.EndAt == 0 {
+			if ts.State != TimesheetStateOpen || i != len(ts.Breaks)-1 {
+				return errors.E(op, errors.KindValidation, "Only the last break of an open timesheet can be unfinished")
+			}
+			continue
+		}
+		if b.EndAt < b.StartAt {
+			return errors.E(op, errors.KindValidation, "Break end time should be after its start time")
+		}
+		if ts.State != TimesheetStateOpen && b.EndAt > ts.ClockOutAt {
+			return errors.E(op, errors.KindValidation, "Breaks should end before clock out")
+		}
+		last = b.EndAt
+	}
+	return nil
+}
+
+// canManageTimesheet reports whether the employee in the context can edit and
+// approve the timesheet of others, managers only manage their locations
+func canManageTimesheet(ctx context.Context, ts Timesheet) (bool, error) {
+	return canAt(ctx, TimesheetApprove, ts.MerchantID, ts.LocationID)
+}
+
+// scopeTimesheets limits the filter to the timesheets the employee in the
+// context can see, employees that can't manage timesheets only see their own
+// and managers restricted to some locations only see those
+func scopeTimesheets(ctx context.Context, merchantID ID, employeeIDs, locationIDs []ID) ([]ID, []ID, error) {
+	employee, err := actor(ctx, merchantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !employee.HasPermission(TimesheetApprove) {
+		return []ID{employee.ID}, locationIDs, nil
+	}
+	if !employee.locationRestricted() {
+		return employeeIDs, locationIDs, nil
+	}
+	if len(locationIDs) == 0 {
+		return employeeIDs, employee.LocationIDs, nil
+	}
+	if !employee.canUseLocations(locationIDs) {
+		return nil, nil, errors.E(errors.KindNoPermission, "Employee can't access timesheets of these locations")
+	}
+	return employeeIDs, locationIDs, nil
+}
+
+type TimesheetFilter struct {
+	IDs         []ID
+	EmployeeIDs []ID
+	LocationIDs []ID
+	States      []TimesheetState
+	MerchantID  ID
+	ClockInAt   DateFilter
+}
+
+type TimesheetQuery struct {
+	Limit  int64
+	Offset int64
+	Filter TimesheetFilter
+}
+
+type TimesheetStorage interface {
+	Put(context.Context, Timesheet) error
+	Get(context.Context, ID) (Timesheet, error)
+	List(context.Context, TimesheetQuery) ([]Timesheet, int64, error)
+}
+
+// ClockIn opens a timesheet for the employee of the session
+func (svc *EmployeeService) ClockIn(ctx context.Context, locationID ID, note string) (Timesheet, error) {
+	const op = errors.Op("core/EmployeeService.ClockIn")
+
+	employee := EmployeeFromContext(ctx)
+	if employee == nil {
+		return Timesheet{}, errors.E(op, errors.KindUnexpected, "Unknown employee")
+	}
+	if !employee.canUseLocations([]ID{locationID}) {
+		return Timesheet{}, errors.E(op, errors.KindNoPermission, "Employee can't clock in at this location")
+	}
+	location, err := svc.LocationStorage.Get(ctx, locationID)
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+	if location.MerchantID != employee.MerchantID || location.Status == StatusShadowDeleted {
+		return Timesheet{}, errors.E(op, errors.KindNotFound, "Location not found")
+	}
+
+	if _, err := svc.openTimesheet(ctx, employee); err == nil {
+		return Timesheet{}, errors.E(op, errors.KindValidation, "Employee is already clocked in")
+	} else if !errors.Is(err, errors.KindNotFound) {
+		return Timesheet{}, errors.E(op, err)
+	}
+
+	// The storage rejects a second open timesheet of the employee when
+	// clocking in concurrently
+	ts := NewTimesheet(employee.ID, locationID, employee.MerchantID)
+	ts.Note = note
+	if err := svc.TimesheetStorage.Put(ctx, ts); err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+
+	return ts, nil
+}
+
+// ClockOut closes the open timesheet of the employee of the session, ending any break in course
+func (svc *EmployeeService) ClockOut(ctx context.Context, note string) (Timesheet, error) {
+	const op = errors.Op("core/EmployeeService.ClockOut")
+
+	employee := EmployeeFromContext(ctx)
+	if employee == nil {
+		return Timesheet{}, errors.E(op, errors.KindUnexpected, "Unknown employee")
+	}
+
+	ts, err := svc.openTimesheet(ctx, employee)
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+
+	now := time.Now().Unix()
+	if ts.onBreak() {
+		ts.Breaks[len(ts.Breaks)-1].EndAt = now
+	}
+	ts.State = TimesheetStateClosed
+	ts.ClockOutAt = now
+	if note != "" {
+		ts.Note = note
+	}
+	if err := svc.TimesheetStorage.Put(ctx, ts); err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+
+	return ts, nil
+}
+
+func (svc *EmployeeService) StartBreak(ctx context.Context) (Timesheet, error) {
+	const op = errors.Op("core/EmployeeService.StartBreak")
+
+	employee := EmployeeFromContext(ctx)
+	if employee == nil {
+		return Timesheet{}, errors.E(op, errors.KindUnexpected, "Unknown employee")
+	}
+
+	ts, err := svc.openTimesheet(ctx, employee)
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+	if ts.onBreak() {
+		return Timesheet{}, errors.E(op, errors.KindValidation, "Employee is already on a break")
+	}
+
+	ts.Breaks = append(ts.Breaks, TimesheetBreak{StartAt: time.Now().Unix()})
+	if err := svc.TimesheetStorage.Put(ctx, ts); err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+
+	return ts, nil
+}
+
+func (svc *EmployeeService) EndBreak(ctx context.Context) (Timesheet, error) {
+	const op = errors.Op("core/EmployeeService.EndBreak")
+
+	employee := EmployeeFromContext(ctx)
+	if employee == nil {
+		return Timesheet{}, errors.E(op, errors.KindUnexpected, "Unknown employee")
+	}
+
+	ts, err := svc.openTimesheet(ctx, employee)
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+	if !ts.onBreak() {
+		return Timesheet{}, errors.E(op, errors.KindValidation, "Employee is not on a break")
+	}
+
+	ts.Breaks[len(ts.Breaks)-1].EndAt = time.Now().Unix()
+	if err := svc.TimesheetStorage.Put(ctx, ts); err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+
+	return ts, nil
+}
+
+// GetTimesheet returns the timesheet if it belongs to the employee of the session
+// or the employee can manage timesheets
+func (svc *EmployeeService) GetTimesheet(ctx context.Context, id ID) (Timesheet, error) {
+	const op = errors.Op("core/EmployeeService.GetTimesheet")
+
+	employee := EmployeeFromContext(ctx)
+	if employee == nil {
+		return Timesheet{}, errors.E(op, errors.KindUnexpected, "Unknown employee")
+	}
+
+	ts, err := svc.TimesheetStorage.Get(ctx, id)
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+	if ts.MerchantID != employee.MerchantID {
+		return Timesheet{}, errors.E(op, errors.KindNotFound, "Timesheet not found")
+	}
+	if ts.EmployeeID != employee.ID {
+		ok, err := canManageTimesheet(ctx, ts)
+		if err != nil {
+			return Timesheet{}, errors.E(op, err)
+		}
+		if !ok {
+			return Timesheet{}, errors.E(op, errors.KindNoPermission, "Employee can't access other timesheets")
+		}
+	}
+
+	return ts, nil
+}
+
+// ListTimesheet lists the timesheets, employees that can't manage timesheets only see their own
+func (svc *EmployeeService) ListTimesheet(ctx context.Context, q TimesheetQuery) ([]Timesheet, int64, error) {
+	const op = errors.Op("core/EmployeeService.ListTimesheet")
+
+	var err error
+	q.Filter.EmployeeIDs, q.Filter.LocationIDs, err = scopeTimesheets(ctx, q.Filter.MerchantID, q.Filter.EmployeeIDs, q.Filter.LocationIDs)
+	if err != nil {
+		return nil, 0, errors.E(op, err)
+	}
+
+	timesheets, count, err := svc.TimesheetStorage.List(ctx, q)
+	if err != nil {
+		return nil, 0, errors.E(op, err)
+	}
+
+	return timesheets, count, nil
+}
+
+// UpdateTimesheet saves a manager edit of a finished timesheet, approved
+// timesheets need to be approved again
+func (svc *EmployeeService) UpdateTimesheet(ctx context.Context, ts Timesheet) (Timesheet, error) {
+	const op = errors.Op("core/EmployeeService.UpdateTimesheet")
+
+	employee := EmployeeFromContext(ctx)
+	if employee == nil {
+		return Timesheet{}, errors.E(op, errors.KindUnexpected, "Unknown employee")
+	}
+
+	current, err := svc.GetTimesheet(ctx, ts.ID)
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+	ts.MerchantID = current.MerchantID
+	for _, t := range []Timesheet{current, ts} {
+		ok, err := canManageTimesheet(ctx, t)
+		if err != nil {
+			return Timesheet{}, errors.E(op, err)
+		}
+		if !ok {
+			return Timesheet{}, errors.E(op, errors.KindNoPermission, "Only managers can edit timesheets")
+		}
+	}
+	if current.State == TimesheetStateOpen {
+		return Timesheet{}, errors.E(op, errors.KindValidation, "Open timesheets can't be edited")
+	}
+
+	ts.State = TimesheetStateClosed
+	ts.EditedBy = employee.ID
+	ts.ApprovedBy = ""
+	ts.ApprovedAt = 0
+	if err := ts.validate(); err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+	if err := svc.TimesheetStorage.Put(ctx, ts); err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+
+	ts, err = svc.TimesheetStorage.Get(ctx, ts.ID)
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+
+	return ts, nil
+}
+
+// ApproveTimesheet approves a finished timesheet, managers can't approve their own timesheets
+func (svc *EmployeeService) ApproveTimesheet(ctx context.Context, id ID) (Timesheet, error) {
+	const op = errors.Op("core/EmployeeService.ApproveTimesheet")
+
+	employee := EmployeeFromContext(ctx)
+	if employee == nil {
+		return Timesheet{}, errors.E(op, errors.KindUnexpected, "Unknown employee")
+	}
+
+	ts, err := svc.GetTimesheet(ctx, id)
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+	ok, err := canManageTimesheet(ctx, ts)
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+	if !ok {
+		return Timesheet{}, errors.E(op, errors.KindNoPermission, "Only managers can approve timesheets")
+	}
+	if ts.EmployeeID == employee.ID && !employee.IsOwner {
+		return Timesheet{}, errors.E(op, errors.KindNoPermission, "Managers can't approve their own timesheets")
+	}
+	if ts.State != TimesheetStateClosed {
+		return Timesheet{}, errors.E(op, errors.KindValidation, "Only closed timesheets can be approved")
+	}
+
+	ts.State = TimesheetStateApproved
+	ts.ApprovedBy = employee.ID
+	ts.ApprovedAt = time.Now().Unix()
+	if err := svc.TimesheetStorage.Put(ctx, ts); err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+
+	return ts, nil
+}
+
+func (svc *EmployeeService) openTimesheet(ctx context.Context, employee *Employee) (Timesheet, error) {
+	const op = errors.Op("core/EmployeeService.openTimesheet")
+
+	timesheets, _, err := svc.TimesheetStorage.List(ctx, TimesheetQuery{
+		Limit: 1,
+		Filter: TimesheetFilter{
+			EmployeeIDs: []ID{employee.ID},
+			States:      []TimesheetState{TimesheetStateOpen},
+			MerchantID:  employee.MerchantID,
+		},
+	})
+	if err != nil {
+		return Timesheet{}, errors.E(op, err)
+	}
+	if len(timesheets) == 0 {
+		return Timesheet{}, errors.E(op, errors.KindNotFound, "Employee is not clocked in")
+	}
+
+	return timesheets[0], nil
+}
+
+// RateAt returns the hourly rate that was effective at the given time, the
+// first rate is used for times before it
+func (e *Employee) RateAt(at int64) *Money {
+	if len(e.RateHistory) == 0 {
+		return e.Rate
+	}
+	history := make([]RateEntry, len(e.RateHistory))
+	copy(history, e.RateHistory)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt < history[j].CreatedAt
+	})
+
+	rate := history[0].Rate
+	for _, entry := range history {
+		if entry.CreatedAt > at {
+			break
+		}
+		rate = entry.Rate
+	}
+	return &rate
+}
+
+// OvertimePolicy sets the daily hours after which work is paid as overtime
+type OvertimePolicy struct {
+	DailyHours float64
+	// Multiplier of the hourly rate for overtime hours
+	Multiplier float64
+}
+
+var DefaultOvertimePolicy = OvertimePolicy{DailyHours: 8, Multiplier: 1.25}
+
+// TimesheetSummary is the hours worked by an employee during a period and their pay
+type TimesheetSummary struct {
+	EmployeeID     ID
+	TimesheetCount int64
+	RegularHours   float64
+	OvertimeHours  float64
+	RegularAmount  Money
+	OvertimeAmount Money
+	TotalAmount    Money
+}
+
+type TimesheetSummaryRequest struct {
+	EmployeeIDs []ID
+	LocationIDs []ID
+	MerchantID  ID
+	BeginTime   int64
+	EndTime     int64
+	// Timezone used to split the hours worked by day
+	Timezone string
+	// Only approved timesheets are summarized, as for payroll
+	ApprovedOnly bool
+}
+
+// SummarizeTimesheets returns the hours and pay of each employee, timesheets
+// belong to the day they were clocked in
+func (svc *EmployeeService) SummarizeTimesheets(ctx context.Context, req TimesheetSummaryRequest) ([]TimesheetSummary, error) {
+	const op = errors.Op("core/EmployeeService.SummarizeTimesheets")
+
+	var err error
+	req.EmployeeIDs, req.LocationIDs, err = scopeTimesheets(ctx, req.MerchantID, req.EmployeeIDs, req.LocationIDs)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	timezone, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return nil, errors.E(op, errors.KindValidation, "Invalid timezone")
+	}
+	if req.EndTime == 0 {
+		req.EndTime = time.Now().Unix()
+	}
+
+	states := []TimesheetState{TimesheetStateClosed, TimesheetStateApproved}
+	if req.ApprovedOnly {
+		states = []TimesheetState{TimesheetStateApproved}
+	}
+	timesheets, _, err := svc.TimesheetStorage.List(ctx, TimesheetQuery{
+		Filter: TimesheetFilter{
+			EmployeeIDs: req.EmployeeIDs,
+			LocationIDs: req.LocationIDs,
+			States:      states,
+			MerchantID:  req.MerchantID,
+			ClockInAt:   DateFilter{Gte: req.BeginTime, Lte: req.EndTime},
+		},
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	byEmployee := map[ID][]Timesheet{}
+	var employeeIDs []ID
+	for _, ts := range timesheets {
+		if _, ok := byEmployee[ts.EmployeeID]; !ok {
+			employeeIDs = append(employeeIDs, ts.EmployeeID)
+		}
+		byEmployee[ts.EmployeeID] = append(byEmployee[ts.EmployeeID], ts)
+	}
+	if len(employeeIDs) == 0 {
+		return []TimesheetSummary{}, nil
+	}
+
+	employees, _, err := svc.EmployeeStorage.List(ctx, EmployeeQuery{
+		Filter: EmployeeFilter{IDs: employeeIDs, MerchantID: req.MerchantID},
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	currency := reportCurrency(ctx)
+	summaries := []TimesheetSummary{}
+	for _, employee := range employees {
+		summary := summarizeTimesheets(employee, byEmployee[employee.ID], DefaultOvertimePolicy, timezone, currency)
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func summarizeTimesheets(employee Employee, timesheets []Timesheet, policy OvertimePolicy, timezone *time.Location, currency Currency) TimesheetSummary {
+	sort.SliceStable(timesheets, func(i, j int) bool {
+		return timesheets[i].ClockInAt < timesheets[j].ClockInAt
+	})
+
+	summary := TimesheetSummary{
+		EmployeeID:     employee.ID,
+		RegularAmount:  NewMoney(0, currency),
+		OvertimeAmount: NewMoney(0, currency),
+		TotalAmount:    NewMoney(0, currency),
+	}
+	// Hours already worked on each day
+	worked := map[string]float64{}
+	for _, ts := range timesheets {
+		day := time.Unix(ts.ClockInAt, 0).In(timezone).Format("2006-01-02")
+		hours := float64(ts.WorkedSeconds(ts.ClockOutAt)) / 3600
+
+		regular := math.Max(0, math.Min(hours, policy.DailyHours-worked[day]))
+		overtime := hours - regular
+		worked[day] += hours
+
+		summary.TimesheetCount++
+		summary.RegularHours += regular
+		summary.OvertimeHours += overtime
+		if rate := employee.RateAt(ts.ClockInAt); rate != nil {
+			summary.RegularAmount.Value += int64(math.Round(regular * float64(rate.Value)))
+			summary.OvertimeAmount.Value += int64(math.Round(overtime * float64(rate.Value) * policy.Multiplier))
+		}
+	}
+	summary.TotalAmount.Value = summary.RegularAmount.Value + summary.OvertimeAmount.Value
+
+	return summary
+}
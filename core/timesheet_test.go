@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func timesheetService(timesheets map[ID]Timesheet, locations map[ID]Location) *EmployeeService {
+	storage := NewMockTimesheetStorage()
+	storage.PutFn = func(ctx context.Context, ts Timesheet) error {
+		timesheets[ts.ID] = ts
+		return nil
+	}
+	storage.GetFn = func(ctx context.Context, id ID) (Timesheet, error) {
+		ts, ok := timesheets[id]
+		if !ok {
+			return Timesheet{}, errors.E(errors.KindNotFound, "timesheet not found")
+		}
+		return ts, nil
+	}
+	storage.ListFn = func(ctx context.Context, q TimesheetQuery) ([]Timesheet, int64, error) {
+		var res []Timesheet
+		for _, ts := range timesheets {
+			if ts.MerchantID != q.Filter.MerchantID {
+				continue
+			}
+			if len(q.Filter.EmployeeIDs) != 0 && !ContainsID(q.Filter.EmployeeIDs, ts.EmployeeID) {
+				continue
+			}
+			if len(q.Filter.LocationIDs) != 0 && !ContainsID(q.Filter.LocationIDs, ts.LocationID) {
+				continue
+			}
+			if len(q.Filter.States) != 0 && q.Filter.States[0] != ts.State {
+				continue
+			}
+			res = append(res, ts)
+		}
+		return res, int64(len(res)), nil
+	}
+	locationStorage := NewMockLocationStorage()
+	locationStorage.GetFn = func(ctx context.Context, id ID) (Location, error) {
+		l, ok := locations[id]
+		if !ok {
+			return Location{}, errors.E(errors.KindNotFound, "location not found")
+		}
+		return l, nil
+	}
+	return &EmployeeService{TimesheetStorage: storage, LocationStorage: locationStorage}
+}
+
+func TestTimesheetClock(t *testing.T) {
+	merchantID, locationID := NewID("merch"), NewID("loc")
+	cashier := &Employee{ID: "empl_cashier", MerchantID: merchantID, LocationIDs: []ID{locationID}}
+	manager := &Employee{ID: "empl_manager", MerchantID: merchantID, Permissions: []Permission{TimesheetApprove}}
+	otherManager := &Employee{ID: "empl_other", MerchantID: merchantID, LocationIDs: []ID{NewID("loc")},
+		Permissions: []Permission{TimesheetApprove}}
+	ctx := ContextWithMerchant(context.Background(), &Merchant{ID: merchantID})
+	cashierCtx := ContextWithEmployee(ctx, cashier)
+	managerCtx := ContextWithEmployee(ctx, manager)
+	otherManagerCtx := ContextWithEmployee(ctx, otherManager)
+
+	location := NewLocation("Downtown", merchantID)
+	location.ID = locationID
+	otherLocation := NewLocation("Elsewhere", NewID("merch"))
+	timesheets := map[ID]Timesheet{}
+	svc := timesheetService(timesheets, map[ID]Location{location.ID: location, otherLocation.ID: otherLocation})
+
+	_, err := svc.ClockIn(cashierCtx, NewID("loc"), "")
+	assert.True(t, errors.Is(err, errors.KindNoPermission), "employees clock in only at their locations")
+	_, err = svc.ClockIn(managerCtx, otherLocation.ID, "")
+	assert.True(t, errors.Is(err, errors.KindNotFound), "employees clock in only at locations of their merchant")
+	_, err = svc.ClockIn(managerCtx, NewID("loc"), "")
+	assert.True(t, errors.Is(err, errors.KindNotFound))
+
+	ts, err := svc.ClockIn(cashierCtx, locationID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, TimesheetStateOpen, ts.State)
+	_, err = svc.ClockIn(cashierCtx, locationID, "")
+	assert.Error(t, err, "employees can't clock in twice")
+
+	_, err = svc.EndBreak(cashierCtx)
+	assert.Error(t, err)
+	ts, err = svc.StartBreak(cashierCtx)
+	assert.NoError(t, err)
+	assert.True(t, ts.onBreak())
+
+	ts, err = svc.ClockOut(cashierCtx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, TimesheetStateClosed, ts.State)
+	assert.False(t, ts.onBreak(), "clocking out ends the break")
+
+	_, err = svc.ApproveTimesheet(cashierCtx, ts.ID)
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+	_, err = svc.ApproveTimesheet(otherManagerCtx, ts.ID)
+	assert.True(t, errors.Is(err, errors.KindNoPermission), "managers only approve timesheets of their locations")
+	list, _, err := svc.ListTimesheet(otherManagerCtx, TimesheetQuery{Filter: TimesheetFilter{MerchantID: merchantID}})
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+
+	ts.ClockInAt -= 3600
+	ts.Breaks = []TimesheetBreak{{StartAt: ts.ClockInAt + 600, EndAt: ts.ClockInAt + 1200}}
+	ts, err = svc.UpdateTimesheet(managerCtx, ts)
+	assert.NoError(t, err)
+	assert.Equal(t, manager.ID, ts.EditedBy)
+	assert.Equal(t, int64(3000), ts.WorkedSeconds(0))
+
+	ts, err = svc.ApproveTimesheet(managerCtx, ts.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, TimesheetStateApproved, ts.State)
+
+	ts.ClockOutAt = ts.ClockInAt - 1
+	_, err = svc.UpdateTimesheet(managerCtx, ts)
+	assert.Error(t, err, "clock out before clock in")
+}
+
+func TestSummarizeTimesheets(t *testing.T) {
+	day := time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC).Unix()
+	hour := int64(3600)
+	employee := Employee{
+		ID: "empl_1",
+		RateHistory: []RateEntry{
+			{Rate: NewMoney(1000, PEN), CreatedAt: day - 24*hour},
+			{Rate: NewMoney(2000, PEN), CreatedAt: day + 24*hour},
+		},
+	}
+	timesheets := []Timesheet{
+		// 6 hours on the first day, then 4 more with 2 of them as overtime
+		{State: TimesheetStateClosed, ClockInAt: day, ClockOutAt: day + 6*hour},
+		{State: TimesheetStateClosed, ClockInAt: day + 7*hour, ClockOutAt: day + 11*hour},
+		// 9 hours with a 1 hour break on the second day, after the rate changed
+		{State: TimesheetStateClosed, ClockInAt: day + 24*hour, ClockOutAt: day + 33*hour,
+			Breaks: []TimesheetBreak{{StartAt: day + 28*hour, EndAt: day + 29*hour}}},
+	}
+
+	summary := summarizeTimesheets(employee, timesheets, DefaultOvertimePolicy, time.UTC, PEN)
+	assert.Equal(t, int64(3), summary.TimesheetCount)
+	assert.Equal(t, 16.0, summary.RegularHours)
+	assert.Equal(t, 2.0, summary.OvertimeHours)
+	assert.Equal(t, int64(8*1000+8*2000), summary.RegularAmount.Value)
+	assert.Equal(t, int64(2*1250), summary.OvertimeAmount.Value)
+	assert.Equal(t, int64(26500), summary.TotalAmount.Value)
+}
@@ -0,0 +1,671 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/backium/backend/errors"
+)
+
+type TipPoolMethod string
+
+const (
+	// Tips are split in equal parts among the employees on shift
+	TipPoolMethodEqual TipPoolMethod = "equal"
+	// Tips are split proportionally to the hours worked by each employee
+	TipPoolMethodHours TipPoolMethod = "hours"
+)
+
+func (m *TipPoolMethod) Validate() bool {
+	switch *m {
+	case TipPoolMethodEqual,
+		TipPoolMethodHours:
+		return true
+	}
+	return false
+}
+
+func TipPoolMethods() string {
+	return strings.Join([]string{
+		string(TipPoolMethodEqual),
+		string(TipPoolMethodHours),
+	}, ", ")
+}
+
+// TipPool collects the tips received at its locations to be split among employees
+type TipPool struct {
+	ID     ID            `bson:"_id"`
+	Name   string        `bson:"name"`
+	Method TipPoolMethod `bson:"method"`
+	// Employees that take part of the pool, all employees on shift when empty
+	EmployeeIDs []ID   `bson:"employee_ids"`
+	LocationIDs []ID   `bson:"location_ids"`
+	MerchantID  ID     `bson:"merchant_id"`
+	CreatedAt   int64  `bson:"created_at"`
+	UpdatedAt   int64  `bson:"updated_at"`
+	Status      Status `bson:"status"`
+}
+
+func NewTipPool(name string, method TipPoolMethod, merchantID ID) TipPool {
+	return TipPool{
+		ID:          NewID("tippool"),
+		Name:        name,
+		Method:      method,
+		EmployeeIDs: []ID{},
+		LocationIDs: []ID{},
+		MerchantID:  merchantID,
+		Status:      StatusActive,
+	}
+}
+
+func (pool TipPool) hasEmployee(id ID) bool {
+	if len(pool.EmployeeIDs) == 0 {
+		return true
+	}
+	for _, eid := range pool.EmployeeIDs {
+		if eid == id {
+			return true
+		}
+	}
+	return false
+}
+
+type TipPayoutLine struct {
+	EmployeeID ID      `bson:"employee_id"`
+	Hours      float64 `bson:"hours"`
+	Amount     Money   `bson:"amount"`
+}
+
+// TipPayout records the tips of a pool paid out to employees for a period
+type TipPayout struct {
+	ID          ID              `bson:"_id"`
+	TipPoolID   ID              `bson:"tip_pool_id"`
+	Method      TipPoolMethod   `bson:"method"`
+	BeginTime   int64           `bson:"begin_time"`
+	EndTime     int64           `bson:"end_time"`
+	TotalAmount Money           `bson:"total_amount"`
+	Lines       []TipPayoutLine `bson:"lines"`
+	Note        string          `bson:"note"`
+	// Employee that made the payout
+	EmployeeID ID    `bson:"employee_id"`
+	MerchantID ID    `bson:"merchant_id"`
+	CreatedAt  int64 `bson:"created_at"`
+}
+
+// TipPoolMember is an employee taking part of a payout
+type TipPoolMember struct {
+	EmployeeID ID
+	Hours      float64
+}
+
+type TipPayoutRequest struct {
+	TipPoolID ID
+	BeginTime int64
+	EndTime   int64
+	// Employees to split the tips among, the employees on shift if empty.
+	// Hours worked are taken from the timesheets when not given.
+	Members []TipPoolMember
+	Note    string
+}
+
+type TipPoolFilter struct {
+	IDs         []ID
+	LocationIDs []ID
+	MerchantID  ID
+}
+
+type TipPoolQuery struct {
+	Limit  int64
+	Offset int64
+	Filter TipPoolFilter
+}
+
+type TipPayoutFilter struct {
+	IDs         []ID
+	TipPoolIDs  []ID
+	EmployeeIDs []ID
+	MerchantID  ID
+	CreatedAt   DateFilter
+}
+
+type TipPayoutQuery struct {
+	Limit  int64
+	Offset int64
+	Filter TipPayoutFilter
+}
+
+type TipStorage interface {
+	PutPool(context.Context, TipPool) error
+	GetPool(context.Context, ID) (TipPool, error)
+	ListPool(context.Context, TipPoolQuery) ([]TipPool, int64, error)
+	PutPayout(context.Context, TipPayout) error
+	GetPayout(context.Context, ID) (TipPayout, error)
+	ListPayout(context.Context, TipPayoutQuery) ([]TipPayout, int64, error)
+}
+
+type TipService struct {
+	TipStorage        TipStorage
+	OrderStorage      OrderStorage
+	PaymentStorage    PaymentStorage
+	CashDrawerStorage CashDrawerStorage
+	TimesheetStorage  TimesheetStorage
+}
+
+func (svc *TipService) PutTipPool(ctx context.Context, pool TipPool) (TipPool, error) {
+	const op = errors.Op("core/TipService.PutTipPool")
+
+	if err := svc.TipStorage.PutPool(ctx, pool); err != nil {
+		return TipPool{}, errors.E(op, err)
+	}
+
+	pool, err := svc.TipStorage.GetPool(ctx, pool.ID)
+	if err != nil {
+		return TipPool{}, errors.E(op, err)
+	}
+
+	return pool, nil
+}
+
+func (svc *TipService) GetTipPool(ctx context.Context, id ID) (TipPool, error) {
+	const op = errors.Op("core/TipService.GetTipPool")
+
+	pool, err := svc.TipStorage.GetPool(ctx, id)
+	if err != nil {
+		return TipPool{}, errors.E(op, err)
+	}
+	if merchant := MerchantFromContext(ctx); merchant != nil && pool.MerchantID != merchant.ID {
+		return TipPool{}, errors.E(op, errors.KindNotFound, "Tip pool not found")
+	}
+
+	return pool, nil
+}
+
+func (svc *TipService) ListTipPool(ctx context.Context, q TipPoolQuery) ([]TipPool, int64, error) {
+	const op = errors.Op("core/TipService.ListTipPool")
+
+	pools, count, err := svc.TipStorage.ListPool(ctx, q)
+	if err != nil {
+		return nil, 0, errors.E(op, err)
+	}
+
+	return pools, count, nil
+}
+
+func (svc *TipService) DeleteTipPool(ctx context.Context, id ID) (TipPool, error) {
+	const op = errors.Op("core/TipService.DeleteTipPool")
+
+	pool, err := svc.GetTipPool(ctx, id)
+	if err != nil {
+		return TipPool{}, errors.E(op, err)
+	}
+
+	pool.Status = StatusShadowDeleted
+	pool, err = svc.PutTipPool(ctx, pool)
+	if err != nil {
+		return TipPool{}, errors.E(op, err)
+	}
+
+	return pool, nil
+}
+
+// CalculateTipPayout splits the tips received by the pool during the period
+// without recording the payout
+func (svc *TipService) CalculateTipPayout(ctx context.Context, req TipPayoutRequest) (TipPayout, error) {
+	const op = errors.Op("core/TipService.CalculateTipPayout")
+
+	pool, err := svc.GetTipPool(ctx, req.TipPoolID)
+	if err != nil {
+		return TipPayout{}, errors.E(op, err)
+	}
+	if req.EndTime == 0 {
+		req.EndTime = time.Now().Unix()
+	}
+	if req.BeginTime > req.EndTime {
+		return TipPayout{}, errors.E(op, errors.KindValidation, "Begin time should be before end time")
+	}
+
+	tips, err := listTips(ctx, svc.OrderStorage, svc.PaymentStorage, TipReportRequest{
+		MerchantID:  pool.MerchantID,
+		LocationIDs: pool.LocationIDs,
+		BeginTime:   req.BeginTime,
+		EndTime:     req.EndTime,
+	})
+	if err != nil {
+		return TipPayout{}, errors.E(op, err)
+	}
+
+	members := req.Members
+	if len(members) == 0 {
+		members, err = svc.membersOnShift(ctx, pool, req, tips)
+		if err != nil {
+			return TipPayout{}, errors.E(op, err)
+		}
+	}
+	for _, m := range members {
+		if !pool.hasEmployee(m.EmployeeID) {
+			return TipPayout{}, errors.E(op, errors.KindValidation, "Employee doesn't take part of the tip pool")
+		}
+		if m.Hours < 0 {
+			return TipPayout{}, errors.E(op, errors.KindValidation, "Hours worked can't be negative")
+		}
+	}
+
+	total := NewMoney(0, reportCurrency(ctx))
+	for _, t := range tips {
+		total.Value += t.Amount.Value
+	}
+
+	weights := make([]float64, len(members))
+	for i, m := range members {
+		switch pool.Method {
+		case TipPoolMethodHours:
+			weights[i] = m.Hours
+		default:
+			weights[i] = 1
+		}
+	}
+	amounts, err := splitTips(total.Value, weights)
+	if err != nil {
+		return TipPayout{}, errors.E(op, err)
+	}
+
+	payout := TipPayout{
+		ID:          NewID("tippayout"),
+		TipPoolID:   pool.ID,
+		Method:      pool.Method,
+		BeginTime:   req.BeginTime,
+		EndTime:     req.EndTime,
+		TotalAmount: total,
+		Lines:       make([]TipPayoutLine, len(members)),
+		Note:        req.Note,
+		MerchantID:  pool.MerchantID,
+	}
+	for i, m := range members {
+		payout.Lines[i] = TipPayoutLine{
+			EmployeeID: m.EmployeeID,
+			Hours:      m.Hours,
+			Amount:     NewMoney(amounts[i], total.Currency),
+		}
+	}
+
+	return payout, nil
+}
+
+// CreateTipPayout calculates and records the payout, a period can be paid out only once per pool
+func (svc *TipService) CreateTipPayout(ctx context.Context, req TipPayoutRequest) (TipPayout, error) {
+	const op = errors.Op("core/TipService.CreateTipPayout")
+
+	user := UserFromContext(ctx)
+	if user == nil {
+		return TipPayout{}, errors.E(op, errors.KindUnexpected, "Unknown user")
+	}
+
+	payout, err := svc.CalculateTipPayout(ctx, req)
+	if err != nil {
+		return TipPayout{}, errors.E(op, err)
+	}
+
+	previous, _, err := svc.TipStorage.ListPayout(ctx, TipPayoutQuery{
+		Filter: TipPayoutFilter{
+			TipPoolIDs: []ID{payout.TipPoolID},
+			MerchantID: payout.MerchantID,
+		},
+	})
+	if err != nil {
+		return TipPayout{}, errors.E(op, err)
+	}
+	for _, p := range previous {
+		if p.BeginTime < payout.EndTime && payout.BeginTime < p.EndTime {
+			return TipPayout{}, errors.E(op, errors.KindValidation, "The period overlaps a previous payout of the tip pool")
+		}
+	}
+
+	payout.EmployeeID = user.EmployeeID
+	payout.CreatedAt = time.Now().Unix()
+	if err := svc.TipStorage.PutPayout(ctx, payout); err != nil {
+		return TipPayout{}, errors.E(op, err)
+	}
+
+	return payout, nil
+}
+
+func (svc *TipService) ListTipPayout(ctx context.Context, q TipPayoutQuery) ([]TipPayout, int64, error) {
+	const op = errors.Op("core/TipService.ListTipPayout")
+
+	payouts, count, err := svc.TipStorage.ListPayout(ctx, q)
+	if err != nil {
+		return nil, 0, errors.E(op, err)
+	}
+
+	return payouts, count, nil
+}
+
+// membersOnShift returns the employees of the pool that clocked in, took orders
+// or opened or closed a cash drawer shift during the period, with the hours
+// worked from their timesheets
+func (svc *TipService) membersOnShift(ctx context.Context, pool TipPool, req TipPayoutRequest, tips []tip) ([]TipPoolMember, error) {
+	const op = errors.Op("core/TipService.membersOnShift")
+
+	seen := map[ID]int{}
+	var members []TipPoolMember
+	add := func(id ID) int {
+		if id == "" || !pool.hasEmployee(id) {
+			return -1
+		}
+		if i, ok := seen[id]; ok {
+			return i
+		}
+		seen[id] = len(members)
+		members = append(members, TipPoolMember{EmployeeID: id})
+		return len(members) - 1
+	}
+
+	timesheets, _, err := svc.TimesheetStorage.List(ctx, TimesheetQuery{
+		Filter: TimesheetFilter{
+			LocationIDs: pool.LocationIDs,
+			MerchantID:  pool.MerchantID,
+			ClockInAt:   DateFilter{Gte: req.BeginTime, Lte: req.EndTime},
+		},
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	now := time.Now().Unix()
+	for _, ts := range timesheets {
+		if i := add(ts.EmployeeID); i >= 0 {
+			members[i].Hours += float64(ts.WorkedSeconds(now)) / 3600
+		}
+	}
+	if pool.Method == TipPoolMethodHours {
+		if len(members) == 0 {
+			return nil, errors.E(op, errors.KindValidation, "There are no timesheets to split the tips by hours")
+		}
+		return members, nil
+	}
+
+	orders, _, err := svc.OrderStorage.List(ctx, OrderQuery{
+		Filter: OrderFilter{
+			LocationIDs: pool.LocationIDs,
+			MerchantID:  pool.MerchantID,
+			CreatedAt:   DateFilter{Gte: req.BeginTime, Lte: req.EndTime},
+		},
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	for _, t := range tips {
+		add(t.EmployeeID)
+	}
+	for _, order := range orders {
+		add(order.EmployeeID)
+	}
+
+	shifts, _, err := svc.CashDrawerStorage.ListShift(ctx, CashDrawerShiftQuery{
+		Filter: CashDrawerShiftFilter{
+			LocationIDs: pool.LocationIDs,
+			MerchantID:  pool.MerchantID,
+			OpenedAt:    DateFilter{Gte: req.BeginTime, Lte: req.EndTime},
+		},
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	for _, shift := range shifts {
+		add(shift.OpeningEmployeeID)
+		add(shift.ClosingEmployeeID)
+	}
+
+	if len(members) == 0 {
+		return nil, errors.E(op, errors.KindValidation, "There are no employees on shift to split the tips")
+	}
+
+	return members, nil
+}
+
+// splitTips splits the amount proportionally to the weights, the cents left by
+// rounding down go one by one to the members in order
+func splitTips(amount int64, weights []float64) ([]int64, error) {
+	const op = errors.Op("core/splitTips")
+
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return nil, errors.E(op, errors.KindValidation, "Tips can't be split among members without hours worked")
+	}
+
+	amounts := make([]int64, len(weights))
+	var assigned int64
+	for i, w := range weights {
+		amounts[i] = int64(float64(amount) * w / total)
+		assigned += amounts[i]
+	}
+	for i := 0; assigned < amount; i = (i + 1) % len(amounts) {
+		if weights[i] == 0 {
+			continue
+		}
+		amounts[i]++
+		assigned++
+	}
+
+	return amounts, nil
+}
+
+// tip is the tip of a payment attributed to the employee that took the order
+type tip struct {
+	PaymentID    ID
+	OrderID      ID
+	EmployeeID   ID
+	CashDrawerID ID
+	LocationID   ID
+	Amount       Money
+	CreatedAt    int64
+}
+
+func listTips(ctx context.Context, orderStorage OrderStorage, paymentStorage PaymentStorage, req TipReportRequest) ([]tip, error) {
+	payments, _, err := paymentStorage.List(ctx, PaymentQuery{
+		Filter: PaymentFilter{
+			LocationIDs: req.LocationIDs,
+			MerchantID:  req.MerchantID,
+			CreatedAt:   DateFilter{Gte: req.BeginTime, Lte: req.EndTime},
+		},
+		Sort: PaymentSort{CreatedAt: SortAscending},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var orderIDs []ID
+	for _, p := range payments {
+		if p.TipAmount.Value != 0 {
+			orderIDs = append(orderIDs, p.OrderID)
+		}
+	}
+	if len(orderIDs) == 0 {
+		return []tip{}, nil
+	}
+	orders, _, err := orderStorage.List(ctx, OrderQuery{
+		Filter: OrderFilter{IDs: orderIDs, MerchantID: req.MerchantID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	employees := map[ID]ID{}
+	for _, order := range orders {
+		employees[order.ID] = order.EmployeeID
+	}
+
+	tips := []tip{}
+	for _, p := range payments {
+		if p.TipAmount.Value == 0 {
+			continue
+		}
+		employeeID := employees[p.OrderID]
+		if len(req.EmployeeIDs) != 0 && !ContainsID(req.EmployeeIDs, employeeID) {
+			continue
+		}
+		tips = append(tips, tip{
+			PaymentID:    p.ID,
+			OrderID:      p.OrderID,
+			EmployeeID:   employeeID,
+			CashDrawerID: p.CashDrawerID,
+			LocationID:   p.LocationID,
+			Amount:       p.TipAmount,
+			CreatedAt:    p.CreatedAt,
+		})
+	}
+
+	return tips, nil
+}
+
+func reportCurrency(ctx context.Context) Currency {
+	if merchant := MerchantFromContext(ctx); merchant != nil && merchant.Currency != "" {
+		return merchant.Currency
+	}
+	return PEN
+}
+
+type EmployeeTips struct {
+	EmployeeID   ID
+	PaymentCount int64
+	TipAmount    Money
+}
+
+type ShiftTips struct {
+	ShiftID      ID
+	CashDrawerID ID
+	LocationID   ID
+	OpenedAt     int64
+	ClosedAt     int64
+	TipAmount    Money
+	Employees    []EmployeeTips
+}
+
+type TipReport struct {
+	TipAmount Money
+	Employees []EmployeeTips
+	Shifts    []ShiftTips
+}
+
+type TipReportRequest struct {
+	MerchantID  ID
+	LocationIDs []ID
+	EmployeeIDs []ID
+	BeginTime   int64
+	EndTime     int64
+}
+
+// Shifts opened before the period are looked up this far back to attribute
+// the tips received at the beginning of the period
+const maxShiftDuration = 24 * 60 * 60
+
+// GenerateTipReport reports the tips received by employee and by cash drawer shift.
+// Cash tips belong to the shift of the drawer that received them, other tips
+// to the first shift open at the location when they were paid.
+func (svc *ReportService) GenerateTipReport(ctx context.Context, req TipReportRequest) (TipReport, error) {
+	const op = errors.Op("core/ReportService.GenerateTipReport")
+
+	if req.EndTime == 0 {
+		req.EndTime = time.Now().Unix()
+	}
+
+	tips, err := listTips(ctx, svc.OrderStorage, svc.PaymentStorage, req)
+	if err != nil {
+		return TipReport{}, errors.E(op, err)
+	}
+
+	shifts, _, err := svc.CashDrawerStorage.ListShift(ctx, CashDrawerShiftQuery{
+		Filter: CashDrawerShiftFilter{
+			LocationIDs: req.LocationIDs,
+			MerchantID:  req.MerchantID,
+			OpenedAt:    DateFilter{Gte: req.BeginTime - maxShiftDuration, Lte: req.EndTime},
+		},
+	})
+	if err != nil {
+		return TipReport{}, errors.E(op, err)
+	}
+	sort.SliceStable(shifts, func(i, j int) bool {
+		return shifts[i].OpenedAt < shifts[j].OpenedAt
+	})
+
+	currency := reportCurrency(ctx)
+	report := TipReport{
+		TipAmount: NewMoney(0, currency),
+		Employees: []EmployeeTips{},
+		Shifts:    []ShiftTips{},
+	}
+	employees := map[ID]int{}
+	shiftIndex := map[ID]int{}
+	shiftEmployees := map[ID]map[ID]int{}
+	addTip := func(list []EmployeeTips, index map[ID]int, t tip) []EmployeeTips {
+		i, ok := index[t.EmployeeID]
+		if !ok {
+			i = len(list)
+			index[t.EmployeeID] = i
+			list = append(list, EmployeeTips{
+				EmployeeID: t.EmployeeID,
+				TipAmount:  NewMoney(0, currency),
+			})
+		}
+		list[i].PaymentCount++
+		list[i].TipAmount.Value += t.Amount.Value
+		return list
+	}
+
+	for _, t := range tips {
+		report.TipAmount.Value += t.Amount.Value
+		report.Employees = addTip(report.Employees, employees, t)
+
+		shift, ok := tipShift(shifts, t)
+		if !ok {
+			continue
+		}
+		i, ok := shiftIndex[shift.ID]
+		if !ok {
+			i = len(report.Shifts)
+			shiftIndex[shift.ID] = i
+			shiftEmployees[shift.ID] = map[ID]int{}
+			report.Shifts = append(report.Shifts, ShiftTips{
+				ShiftID:      shift.ID,
+				CashDrawerID: shift.CashDrawerID,
+				LocationID:   shift.LocationID,
+				OpenedAt:     shift.OpenedAt,
+				ClosedAt:     shift.ClosedAt,
+				TipAmount:    NewMoney(0, currency),
+				Employees:    []EmployeeTips{},
+			})
+		}
+		report.Shifts[i].TipAmount.Value += t.Amount.Value
+		report.Shifts[i].Employees = addTip(report.Shifts[i].Employees, shiftEmployees[shift.ID], t)
+	}
+
+	return report, nil
+}
+
+func tipShift(shifts []CashDrawerShift, t tip) (CashDrawerShift, bool) {
+	var found *CashDrawerShift
+	for i, shift := range shifts {
+		if shift.LocationID != t.LocationID || t.CreatedAt < shift.OpenedAt {
+			continue
+		}
+		if shift.ClosedAt != 0 && t.CreatedAt > shift.ClosedAt {
+			continue
+		}
+		if t.CashDrawerID != "" {
+			if shift.CashDrawerID == t.CashDrawerID {
+				return shift, true
+			}
+			continue
+		}
+		if found == nil {
+			found = &shifts[i]
+		}
+	}
+	if found == nil {
+		return CashDrawerShift{}, false
+	}
+	return *found, true
+}
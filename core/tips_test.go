@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitTips(t *testing.T) {
+	amounts, err := splitTips(1000, []float64{1, 1, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{334, 333, 333}, amounts)
+
+	amounts, err = splitTips(1000, []float64{6, 2, 0})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{750, 250, 0}, amounts)
+
+	_, err = splitTips(1000, []float64{0, 0})
+	assert.Error(t, err)
+}
+
+func tipTestData(merchantID, locationID ID) (*mockOrderStorage, *mockPaymentStorage, *mockCashDrawerStorage) {
+	anna, bob := ID("emp_anna"), ID("emp_bob")
+	drawerID := NewID("cashdrawer")
+	orders := []Order{
+		{ID: "order_1", EmployeeID: anna, LocationID: locationID, MerchantID: merchantID},
+		{ID: "order_2", EmployeeID: bob, LocationID: locationID, MerchantID: merchantID},
+		{ID: "order_3", EmployeeID: anna, LocationID: locationID, MerchantID: merchantID},
+	}
+	payments := []Payment{
+		{ID: "payment_1", OrderID: "order_1", Type: PaymentCash, CashDrawerID: drawerID,
+			TipAmount: NewMoney(500, PEN), LocationID: locationID, CreatedAt: 100},
+		{ID: "payment_2", OrderID: "order_2", Type: PaymentCard,
+			TipAmount: NewMoney(300, PEN), LocationID: locationID, CreatedAt: 200},
+		{ID: "payment_3", OrderID: "order_3", Type: PaymentCard,
+			TipAmount: NewMoney(200, PEN), LocationID: locationID, CreatedAt: 400},
+	}
+	shift := CashDrawerShift{ID: "cashshift_1", CashDrawerID: drawerID, LocationID: locationID,
+		OpeningEmployeeID: bob, OpenedAt: 50, ClosedAt: 300}
+
+	orderStorage := NewMockOrderStorage()
+	orderStorage.ListFn = func(ctx context.Context, q OrderQuery) ([]Order, int64, error) {
+		return orders, int64(len(orders)), nil
+	}
+	paymentStorage := NewMockPaymentStorage()
+	paymentStorage.ListFn = func(ctx context.Context, q PaymentQuery) ([]Payment, int64, error) {
+		return payments, int64(len(payments)), nil
+	}
+	drawerStorage := NewMockCashDrawerStorage()
+	drawerStorage.ListShiftFn = func(ctx context.Context, q CashDrawerShiftQuery) ([]CashDrawerShift, int64, error) {
+		return []CashDrawerShift{shift}, 1, nil
+	}
+	return orderStorage, paymentStorage, drawerStorage
+}
+
+func TestGenerateTipReport(t *testing.T) {
+	merchantID, locationID := NewID("merch"), NewID("loc")
+	orderStorage, paymentStorage, drawerStorage := tipTestData(merchantID, locationID)
+	svc := ReportService{
+		OrderStorage:      orderStorage,
+		PaymentStorage:    paymentStorage,
+		CashDrawerStorage: drawerStorage,
+	}
+
+	report, err := svc.GenerateTipReport(context.Background(), TipReportRequest{
+		MerchantID: merchantID,
+		BeginTime:  1,
+		EndTime:    1000,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), report.TipAmount.Value)
+	assert.Equal(t, []EmployeeTips{
+		{EmployeeID: "emp_anna", PaymentCount: 2, TipAmount: NewMoney(700, PEN)},
+		{EmployeeID: "emp_bob", PaymentCount: 1, TipAmount: NewMoney(300, PEN)},
+	}, report.Employees)
+	// The last payment was made after the shift was closed
+	assert.Len(t, report.Shifts, 1)
+	assert.Equal(t, int64(800), report.Shifts[0].TipAmount.Value)
+	assert.Len(t, report.Shifts[0].Employees, 2)
+}
+
+func TestCreateTipPayout(t *testing.T) {
+	merchantID, locationID := NewID("merch"), NewID("loc")
+	orderStorage, paymentStorage, drawerStorage := tipTestData(merchantID, locationID)
+	pool := NewTipPool("Floor", TipPoolMethodEqual, merchantID)
+	var payouts []TipPayout
+	tipStorage := NewMockTipStorage()
+	tipStorage.GetPoolFn = func(ctx context.Context, id ID) (TipPool, error) {
+		return pool, nil
+	}
+	tipStorage.ListPayoutFn = func(ctx context.Context, q TipPayoutQuery) ([]TipPayout, int64, error) {
+		return payouts, int64(len(payouts)), nil
+	}
+	tipStorage.PutPayoutFn = func(ctx context.Context, payout TipPayout) error {
+		payouts = append(payouts, payout)
+		return nil
+	}
+	timesheets := []Timesheet{
+		{EmployeeID: "emp_anna", State: TimesheetStateClosed, ClockInAt: 0, ClockOutAt: 6 * 3600},
+		{EmployeeID: "emp_bob", State: TimesheetStateClosed, ClockInAt: 0, ClockOutAt: 2 * 3600},
+	}
+	timesheetStorage := NewMockTimesheetStorage()
+	timesheetStorage.ListFn = func(ctx context.Context, q TimesheetQuery) ([]Timesheet, int64, error) {
+		return timesheets, int64(len(timesheets)), nil
+	}
+	svc := TipService{
+		TipStorage:        tipStorage,
+		OrderStorage:      orderStorage,
+		PaymentStorage:    paymentStorage,
+		CashDrawerStorage: drawerStorage,
+		TimesheetStorage:  timesheetStorage,
+	}
+	ctx := ContextWithUser(context.Background(), &User{MerchantID: merchantID, EmployeeID: "emp_manager"})
+
+	payout, err := svc.CreateTipPayout(ctx, TipPayoutRequest{TipPoolID: pool.ID, BeginTime: 1, EndTime: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), payout.TotalAmount.Value)
+	assert.Equal(t, ID("emp_manager"), payout.EmployeeID)
+	assert.Len(t, payout.Lines, 2)
+	for _, line := range payout.Lines {
+		assert.Equal(t, int64(500), line.Amount.Value)
+	}
+
+	_, err = svc.CreateTipPayout(ctx, TipPayoutRequest{TipPoolID: pool.ID, BeginTime: 500, EndTime: 2000})
+	assert.Error(t, err, "periods of a pool can't be paid out twice")
+
+	// Hours worked are taken from the timesheets
+	pool.Method = TipPoolMethodHours
+	payout, err = svc.CalculateTipPayout(ctx, TipPayoutRequest{TipPoolID: pool.ID, BeginTime: 1, EndTime: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, payout.Lines[0].Hours)
+	assert.Equal(t, int64(750), payout.Lines[0].Amount.Value)
+	assert.Equal(t, int64(250), payout.Lines[1].Amount.Value)
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Time step and length of the codes, the defaults of authenticator apps
+	TOTPPeriod = 30 * time.Second
+	TOTPDigits = 6
+	// Steps before and after the current one also accepted to allow for
+	// clock drift
+	TOTPSkew = 1
+	// Name shown by authenticator apps next to the account
+	TOTPIssuer = "Backium"
+	// Recovery codes given on enrollment, each can be used once instead of a
+	// code of the authenticator
+	RecoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorStep is the second login step a session is waiting for
+type TwoFactorStep string
+
+const (
+	TwoFactorNone TwoFactorStep = ""
+	// The user has to enter a code of its authenticator or a recovery code
+	TwoFactorVerify TwoFactorStep = "verify"
+	// The business requires two-factor authentication and the user has to
+	// enroll before getting access
+	TwoFactorEnroll TwoFactorStep = "enroll"
+)
+
+// NewTOTPSecret returns a random secret encoded in base32
+func NewTOTPSecret() string {
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return totpEncoding.EncodeToString(key)
+}
+
+// TOTPURI returns the otpauth URI of the secret, authenticator apps enroll it
+// by scanning it as a QR code
+func TOTPURI(secret, account string) string {
+	label := url.PathEscape(TOTPIssuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", TOTPIssuer)
+	q.Set("digits", fmt.Sprint(TOTPDigits))
+	q.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// totpCode returns the code of the key for the time step as described in
+// RFC 6238
+func totpCode(key []byte, step int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod)
+}
+
+func totpStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// matchTOTP returns the time step of the secret matching the code, steps up to
+// last are rejected so a code can't be used twice
+func matchTOTP(secret, code string, now time.Time, last int64) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != TOTPDigits {
+		return 0, false
+	}
+	current := totpStep(now)
+	for step := current - TOTPSkew; step <= current+TOTPSkew; step++ {
+		if step <= last {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// newRecoveryCodes returns the recovery codes along with the hashes to store
+func newRecoveryCodes() ([]string, []string) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors of RFC 6238 truncated to 6 digits
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, totpCode(key, totpStep(time.Unix(tt.time, 0))))
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret := NewTOTPSecret()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	step := totpStep(now)
+
+	// Codes of the adjacent steps are accepted to allow for clock drift
+	for _, s := range []int64{step - 1, step, step + 1} {
+		matched, ok := matchTOTP(secret, totpCode(key, s), now, 0)
+		assert.True(t, ok)
+		assert.Equal(t, s, matched)
+	}
+	_, ok := matchTOTP(secret, totpCode(key, step+2), now, 0)
+	assert.False(t, ok)
+
+	// Codes of steps already used are rejected
+	_, ok = matchTOTP(secret, totpCode(key, step), now, step)
+	assert.False(t, ok)
+	_, ok = matchTOTP(secret, "12345", now, 0)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("JBSWY3DPEHPK3PXP", "anna@mail.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Backium:anna@mail.com", uri.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	assert.Equal(t, "Backium", uri.Query().Get("issuer"))
+}
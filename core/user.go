@@ -2,6 +2,12 @@ package core
 
 import (
 	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/backium/backend/errors"
 	"golang.org/x/crypto/bcrypt"
@@ -22,7 +28,19 @@ type User struct {
 	PasswordHash string   `bson:"password_hash,omitempty"`
 	Kind         UserKind `bson:"kind"`
 	EmployeeID   ID       `bson:"employee_id"`
-	MerchantID   ID       `bson:"merchant_id"`
+	// Customer record of customer users, they have no employee
+	CustomerID ID `bson:"customer_id"`
+	MerchantID ID `bson:"merchant_id"`
+	// Time the user proved it owns the email, zero until then
+	EmailVerifiedAt int64 `bson:"email_verified_at"`
+	// Two-factor authentication secret, it is set on enrollment but only
+	// required to log in once confirmed with a code
+	TOTPSecret    string `bson:"totp_secret"`
+	TOTPEnabledAt int64  `bson:"totp_enabled_at"`
+	// Time step of the last code used, codes can't be used twice
+	TOTPLastStep int64 `bson:"totp_last_step"`
+	// Hashes of the unused recovery codes
+	RecoveryCodes []string `bson:"recovery_codes"`
 }
 
 func NewUserOwner() User {
@@ -41,10 +59,47 @@ func NewUserEmployee(merchantID, employeeID ID) User {
 	}
 }
 
+func NewUserCustomer(merchantID, customerID ID) User {
+	return User{
+		ID:         NewID("user"),
+		Kind:       UserKindCustomer,
+		CustomerID: customerID,
+		MerchantID: merchantID,
+	}
+}
+
 func (u *User) PasswordEquals(password string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
 }
 
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != 0
+}
+
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != 0
+}
+
+// verifySecondFactor reports whether the code is a valid code of the user
+// authenticator or one of its recovery codes, the code is consumed
+func (u *User) verifySecondFactor(code string, now time.Time) bool {
+	if !u.TwoFactorEnabled() {
+		return false
+	}
+	if step, ok := matchTOTP(u.TOTPSecret, strings.TrimSpace(code), now, u.TOTPLastStep); ok {
+		u.TOTPLastStep = step
+		return true
+	}
+	hash := hashToken(normalizeRecoveryCode(code))
+	for i, h := range u.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			u.RecoveryCodes = append(u.RecoveryCodes[:i:i], u.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 func (u *User) HashPassword(password string) error {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -61,11 +116,20 @@ type UserStorage interface {
 }
 
 type UserService struct {
-	UserStorage       UserStorage
-	MerchantStorage   MerchantStorage
-	LocationStorage   LocationStorage
-	EmployeeStorage   EmployeeStorage
-	CashDrawerStorage CashDrawerStorage
+	UserStorage         UserStorage
+	MerchantStorage     MerchantStorage
+	LocationStorage     LocationStorage
+	EmployeeStorage     EmployeeStorage
+	CustomerStorage     CustomerStorage
+	CashDrawerStorage   CashDrawerStorage
+	SessionStorage      SessionStorage
+	RoleStorage         RoleStorage
+	UserTokenStorage    UserTokenStorage
+	LoginAttemptStorage LoginAttemptStorage
+	FailedLoginStorage  FailedLoginStorage
+	Mailer              Mailer
+	// Base URL of the web app, the links mailed to users point to it
+	AppURL string
 }
 
 func (svc *UserService) Create(ctx context.Context, user User, password string) (User, error) {
@@ -102,6 +166,10 @@ func (svc *UserService) Create(ctx context.Context, user User, password string)
 			return User{}, errors.E(op, errors.KindUnexpected, err)
 		}
 
+		if err := svc.RoleStorage.PutBatch(ctx, DefaultRoles(merchant.ID)); err != nil {
+			return User{}, errors.E(op, errors.KindUnexpected, err)
+		}
+
 		cash := NewCashDrawer(location.ID, merchant.ID)
 		cash.Amount = NewMoney(0, merchant.Currency)
 
@@ -125,6 +193,15 @@ func (svc *UserService) Create(ctx context.Context, user User, password string)
 		if err := svc.EmployeeStorage.Put(ctx, employee); err != nil {
 			return User{}, errors.E(op, errors.KindUnexpected)
 		}
+	case UserKindCustomer:
+		customer, err := svc.CustomerStorage.Get(ctx, user.CustomerID)
+		if err != nil {
+			return User{}, errors.E(op, errors.KindValidation, "Provided customer not found")
+		}
+
+		if customer.MerchantID != user.MerchantID {
+			return User{}, errors.E(op, errors.KindValidation, "Provided customer doesn't belong to the business")
+		}
 	default:
 		return User{}, errors.E(op, errors.KindValidation, "Unknown user kind")
 	}
@@ -138,20 +215,583 @@ func (svc *UserService) Create(ctx context.Context, user User, password string)
 		return User{}, errors.E(op, err)
 	}
 
+	// The user is already stored, a failed mail must not fail the signup as
+	// the verification link can be requested again
+	if err := svc.sendEmailVerification(ctx, user); err != nil {
+		log.Printf("send email verification: %v", errors.E(op, err))
+	}
+
+	return user, nil
+}
+
+// CreateCustomer signs up a customer of the merchant, the user is linked to
+// the customer record with its email or to a new record when there is none.
+// Customers only get access to the data of the record once they verify the
+// email
+func (svc *UserService) CreateCustomer(ctx context.Context, merchantID ID, customer Customer, email, password string) (User, error) {
+	const op = errors.Op("core/UserService.CreateCustomer")
+
+	if _, err := svc.UserStorage.GetByEmail(ctx, email); err == nil {
+		return User{}, errors.E(op, errors.KindUserExist, "user email used")
+	}
+
+	merchant, err := svc.MerchantStorage.Get(ctx, merchantID)
+	if err != nil {
+		return User{}, errors.E(op, errors.KindValidation, "Provided business not found")
+	}
+
+	customers, _, err := svc.CustomerStorage.List(ctx, CustomerQuery{
+		Limit:  1,
+		Filter: CustomerFilter{Email: email, MerchantID: merchant.ID},
+	})
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+	if len(customers) != 0 {
+		customer = customers[0]
+	} else {
+		customer.Email = email
+		customer.MerchantID = merchant.ID
+		if err := svc.CustomerStorage.Put(ctx, customer); err != nil {
+			return User{}, errors.E(op, err)
+		}
+	}
+
+	user := NewUserCustomer(merchant.ID, customer.ID)
+	user.Email = email
+	user, err = svc.Create(ctx, user, password)
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+
 	return user, nil
 }
 
-func (svc *UserService) Login(ctx context.Context, email, password string) (User, error) {
+// Login checks the password of the user with the email, failed attempts are
+// recorded and logins of the email or from the client are throttled after
+// many of them
+func (svc *UserService) Login(ctx context.Context, email, password string, client LoginClient) (User, error) {
 	const op = errors.Op("controller.User.Login")
 
+	attempt, err := svc.recordLoginAttempt(ctx, email, User{}, client)
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+
 	user, err := svc.UserStorage.GetByEmail(ctx, email)
 	if err != nil {
+		if err := svc.auditFailedLogin(ctx, email, User{}, client, FailedLoginUnknownEmail); err != nil {
+			return User{}, errors.E(op, err)
+		}
 		return User{}, errors.E(op, errors.KindInvalidCredentials, err)
 	}
 
 	if !user.PasswordEquals(password) {
+		if err := svc.auditFailedLogin(ctx, email, user, client, FailedLoginWrongPassword); err != nil {
+			return User{}, errors.E(op, err)
+		}
+		return User{}, errors.E(op, errors.KindInvalidCredentials, "invalid password")
+	}
+
+	if err := svc.cancelLoginAttempt(ctx, attempt); err != nil {
+		return User{}, errors.E(op, err)
+	}
+	// Users with two-factor authentication aren't logged in until they enter
+	// a code, their failures are kept until then
+	if !user.TwoFactorEnabled() {
+		if err := svc.LoginAttemptStorage.Reset(ctx, loginEmailKey(email)); err != nil {
+			return User{}, errors.E(op, err)
+		}
+	}
+
+	return user, nil
+}
+
+// loginAttempt is a login attempt counted as a failure until its credentials
+// are checked, with the attempts of each key made before it
+type loginAttempt struct {
+	at     time.Time
+	before map[string]LoginAttempts
+}
+
+// recordLoginAttempt counts the attempt as a failure of the email and the
+// client before the credentials are checked, so concurrent attempts can't get
+// past the limits, and returns a KindTooManyAttempts error when logins are
+// throttled. Throttled attempts are audited but don't count as failures
+func (svc *UserService) recordLoginAttempt(ctx context.Context, email string, user User, client LoginClient) (loginAttempt, error) {
+	attempt := loginAttempt{at: time.Now(), before: map[string]LoginAttempts{}}
+	var wait time.Duration
+	for _, l := range loginLimits(email, client) {
+		attempts, err := svc.LoginAttemptStorage.RecordFailure(ctx, l.key, attempt.at)
+		if err != nil {
+			return loginAttempt{}, err
+		}
+		attempt.before[l.key] = attempts
+		if w := l.policy.Wait(attempts, attempt.at); w > wait {
+			wait = w
+		}
+	}
+	if wait == 0 {
+		return attempt, nil
+	}
+
+	if err := svc.cancelLoginAttempt(ctx, attempt); err != nil {
+		return loginAttempt{}, err
+	}
+	if err := svc.auditFailedLogin(ctx, email, user, client, FailedLoginThrottled); err != nil {
+		return loginAttempt{}, err
+	}
+	return loginAttempt{}, errors.E(errors.KindTooManyAttempts, &LoginThrottledError{RetryAfter: wait})
+}
+
+// cancelLoginAttempt takes back the failure counted by recordLoginAttempt when
+// the attempt turns out not to be one, the wait of the keys doesn't restart
+func (svc *UserService) cancelLoginAttempt(ctx context.Context, attempt loginAttempt) error {
+	for key, before := range attempt.before {
+		if err := svc.LoginAttemptStorage.CancelFailure(ctx, key, attempt.at, before); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (svc *UserService) auditFailedLogin(ctx context.Context, email string, user User, client LoginClient, reason FailedLoginReason) error {
+	return svc.FailedLoginStorage.Put(ctx, FailedLogin{
+		ID:         NewID("flog"),
+		Email:      email,
+		UserID:     user.ID,
+		MerchantID: user.MerchantID,
+		Reason:     reason,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		CreatedAt:  time.Now().Unix(),
+	})
+}
+
+type loginLimit struct {
+	key    string
+	policy LoginPolicy
+}
+
+func loginLimits(email string, client LoginClient) []loginLimit {
+	limits := []loginLimit{{key: loginEmailKey(email), policy: EmailLoginPolicy}}
+	if client.IP != "" {
+		limits = append(limits, loginLimit{key: loginIPKey(client.IP), policy: IPLoginPolicy})
+	}
+	return limits
+}
+
+// ChangePassword changes the password of the user in the context and logs it
+// out of all its sessions
+func (svc *UserService) ChangePassword(ctx context.Context, current, password string) (User, error) {
+	const op = errors.Op("core/UserService.ChangePassword")
+
+	user, err := svc.currentUser(ctx)
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+	if !user.PasswordEquals(current) {
 		return User{}, errors.E(op, errors.KindInvalidCredentials, "invalid password")
 	}
 
+	if err := user.HashPassword(password); err != nil {
+		return User{}, errors.E(op, errors.KindUnexpected, err)
+	}
+	if err := svc.UserStorage.Put(ctx, user); err != nil {
+		return User{}, errors.E(op, err)
+	}
+	if err := svc.SessionStorage.DeleteByUser(ctx, user.ID); err != nil {
+		return User{}, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// ListSessions returns the active sessions of the user in the context
+func (svc *UserService) ListSessions(ctx context.Context) ([]Session, error) {
+	const op = errors.Op("core/UserService.ListSessions")
+
+	user, err := svc.currentUser(ctx)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	sessions, err := svc.SessionStorage.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt > sessions[j].LastSeenAt
+	})
+
+	return sessions, nil
+}
+
+// RevokeSession logs out one of the sessions of the user in the context
+func (svc *UserService) RevokeSession(ctx context.Context, id ID) error {
+	const op = errors.Op("core/UserService.RevokeSession")
+
+	user, err := svc.currentUser(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	session, err := svc.SessionStorage.Get(ctx, id)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if session.UserID != user.ID {
+		return errors.E(op, errors.KindNotFound, "Session not found")
+	}
+	if err := svc.SessionStorage.Delete(ctx, id); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions logs out the user in the context everywhere
+func (svc *UserService) RevokeAllSessions(ctx context.Context) error {
+	const op = errors.Op("core/UserService.RevokeAllSessions")
+
+	user, err := svc.currentUser(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if err := svc.SessionStorage.DeleteByUser(ctx, user.ID); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// RequestPasswordReset mails a password reset link to the user with the email,
+// unknown emails are ignored so they can't be told apart from registered ones
+func (svc *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = errors.Op("core/UserService.RequestPasswordReset")
+
+	user, err := svc.UserStorage.GetByEmail(ctx, email)
+	if errors.Is(err, errors.KindNotFound) {
+		return nil
+	}
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	token, err := svc.issueToken(ctx, UserTokenPasswordReset, user.ID, PasswordResetTTL)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = svc.Mailer.Send(ctx, Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your account, "+
+			"follow the link below to choose a new one. The link expires in %v.\n\n%s/reset-password?token=%s\n\n"+
+			"If it wasn't you, you can ignore this email.", PasswordResetTTL, svc.AppURL, token),
+	})
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets the password of the user the reset token was issued to
+// and logs it out of all its sessions
+func (svc *UserService) ResetPassword(ctx context.Context, token, password string) (User, error) {
+	const op = errors.Op("core/UserService.ResetPassword")
+
+	user, err := svc.redeemToken(ctx, UserTokenPasswordReset, token)
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+
+	if err := user.HashPassword(password); err != nil {
+		return User{}, errors.E(op, errors.KindUnexpected, err)
+	}
+	// The reset link was received at the user email
+	if !user.EmailVerified() {
+		user.EmailVerifiedAt = time.Now().Unix()
+	}
+	if err := svc.UserStorage.Put(ctx, user); err != nil {
+		return User{}, errors.E(op, err)
+	}
+	if err := svc.SessionStorage.DeleteByUser(ctx, user.ID); err != nil {
+		return User{}, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// ResendEmailVerification mails a new verification link to the user in the
+// context
+func (svc *UserService) ResendEmailVerification(ctx context.Context) error {
+	const op = errors.Op("core/UserService.ResendEmailVerification")
+
+	user, err := svc.currentUser(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if user.EmailVerified() {
+		return errors.E(op, errors.KindValidation, "Email already verified")
+	}
+
+	if err := svc.sendEmailVerification(ctx, user); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email of the user the verification token was issued
+// to as verified
+func (svc *UserService) VerifyEmail(ctx context.Context, token string) (User, error) {
+	const op = errors.Op("core/UserService.VerifyEmail")
+
+	user, err := svc.redeemToken(ctx, UserTokenEmailVerification, token)
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+
+	if !user.EmailVerified() {
+		user.EmailVerifiedAt = time.Now().Unix()
+		if err := svc.UserStorage.Put(ctx, user); err != nil {
+			return User{}, errors.E(op, err)
+		}
+	}
+
+	return user, nil
+}
+
+func (svc *UserService) sendEmailVerification(ctx context.Context, user User) error {
+	const op = errors.Op("core/UserService.sendEmailVerification")
+
+	token, err := svc.issueToken(ctx, UserTokenEmailVerification, user.ID, EmailVerificationTTL)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = svc.Mailer.Send(ctx, Mail{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Follow the link below to verify your email.\n\n%s/verify-email?token=%s",
+			svc.AppURL, token),
+	})
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
+
+// issueToken stores a new token of the kind for the user replacing the ones
+// issued before, and returns its plaintext value
+func (svc *UserService) issueToken(ctx context.Context, kind UserTokenKind, userID ID, ttl time.Duration) (string, error) {
+	if err := svc.UserTokenStorage.DeleteByUser(ctx, userID, kind); err != nil {
+		return "", err
+	}
+	userToken, token := NewUserToken(kind, userID, ttl)
+	if err := svc.UserTokenStorage.Put(ctx, userToken); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// redeemToken returns the user the token of the kind was issued to, the token
+// can't be used again
+func (svc *UserService) redeemToken(ctx context.Context, kind UserTokenKind, token string) (User, error) {
+	userToken, err := svc.UserTokenStorage.Claim(ctx, hashToken(token), kind, time.Now())
+	if errors.Is(err, errors.KindNotFound) {
+		return User{}, errors.E(errors.KindInvalidCredentials, "Invalid or expired token")
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := svc.UserTokenStorage.DeleteByUser(ctx, userToken.UserID, kind); err != nil {
+		return User{}, err
+	}
+
+	return svc.UserStorage.Get(ctx, userToken.UserID)
+}
+
+// TwoFactorStep returns the second login step required to the user after
+// entering its password
+func (svc *UserService) TwoFactorStep(ctx context.Context, user User) (TwoFactorStep, error) {
+	const op = errors.Op("core/UserService.TwoFactorStep")
+
+	if user.TwoFactorEnabled() {
+		return TwoFactorVerify, nil
+	}
+	required, err := svc.twoFactorRequired(ctx, user)
+	if err != nil {
+		return TwoFactorNone, errors.E(op, err)
+	}
+	if required {
+		return TwoFactorEnroll, nil
+	}
+
+	return TwoFactorNone, nil
+}
+
+// VerifyTwoFactor completes the login of the user in the context with a code
+// of its authenticator or a recovery code, wrong codes count as failed logins
+func (svc *UserService) VerifyTwoFactor(ctx context.Context, code string, client LoginClient) (User, error) {
+	const op = errors.Op("core/UserService.VerifyTwoFactor")
+
+	user, err := svc.currentUser(ctx)
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+	attempt, err := svc.recordLoginAttempt(ctx, user.Email, user, client)
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+	if !user.verifySecondFactor(code, time.Now()) {
+		if err := svc.auditFailedLogin(ctx, user.Email, user, client, FailedLoginWrongCode); err != nil {
+			return User{}, errors.E(op, err)
+		}
+		return User{}, errors.E(op, errors.KindInvalidCredentials, "invalid code")
+	}
+	if err := svc.UserStorage.Put(ctx, user); err != nil {
+		return User{}, errors.E(op, err)
+	}
+	if err := svc.cancelLoginAttempt(ctx, attempt); err != nil {
+		return User{}, errors.E(op, err)
+	}
+	if err := svc.LoginAttemptStorage.Reset(ctx, loginEmailKey(user.Email)); err != nil {
+		return User{}, errors.E(op, err)
+	}
+
+	return user, nil
+}
+
+// EnrollTwoFactor sets a new two-factor secret for the user in the context and
+// returns it along with its otpauth URI, it is not required to log in until
+// confirmed
+func (svc *UserService) EnrollTwoFactor(ctx context.Context) (string, string, error) {
+	const op = errors.Op("core/UserService.EnrollTwoFactor")
+
+	user, err := svc.currentUser(ctx)
+	if err != nil {
+		return "", "", errors.E(op, err)
+	}
+	if user.TwoFactorEnabled() {
+		return "", "", errors.E(op, errors.KindValidation, "Two-factor authentication already enabled")
+	}
+
+	user.TOTPSecret = NewTOTPSecret()
+	if err := svc.UserStorage.Put(ctx, user); err != nil {
+		return "", "", errors.E(op, err)
+	}
+
+	return user.TOTPSecret, TOTPURI(user.TOTPSecret, user.Email), nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication for the user in the
+// context once it enters a code of the enrolled secret, the recovery codes are
+// returned only here
+func (svc *UserService) ConfirmTwoFactor(ctx context.Context, code string) (User, []string, error) {
+	const op = errors.Op("core/UserService.ConfirmTwoFactor")
+
+	user, err := svc.currentUser(ctx)
+	if err != nil {
+		return User{}, nil, errors.E(op, err)
+	}
+	if user.TwoFactorEnabled() {
+		return User{}, nil, errors.E(op, errors.KindValidation, "Two-factor authentication already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return User{}, nil, errors.E(op, errors.KindValidation, "Two-factor authentication not enrolled")
+	}
+
+	now := time.Now()
+	step, ok := matchTOTP(user.TOTPSecret, strings.TrimSpace(code), now, 0)
+	if !ok {
+		return User{}, nil, errors.E(op, errors.KindInvalidCredentials, "invalid code")
+	}
+	codes, hashes := newRecoveryCodes()
+	user.TOTPEnabledAt = now.Unix()
+	user.TOTPLastStep = step
+	user.RecoveryCodes = hashes
+	if err := svc.UserStorage.Put(ctx, user); err != nil {
+		return User{}, nil, errors.E(op, err)
+	}
+
+	return user, codes, nil
+}
+
+// DisableTwoFactor turns off two-factor authentication for the user in the
+// context, it can't be turned off when the business requires it
+func (svc *UserService) DisableTwoFactor(ctx context.Context, password, code string) (User, error) {
+	const op = errors.Op("core/UserService.DisableTwoFactor")
+
+	user, err := svc.currentUser(ctx)
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+	if !user.TwoFactorEnabled() {
+		return User{}, errors.E(op, errors.KindValidation, "Two-factor authentication not enabled")
+	}
+	if !user.PasswordEquals(password) || !user.verifySecondFactor(code, time.Now()) {
+		return User{}, errors.E(op, errors.KindInvalidCredentials, "invalid password or code")
+	}
+	required, err := svc.twoFactorRequired(ctx, user)
+	if err != nil {
+		return User{}, errors.E(op, err)
+	}
+	if required {
+		return User{}, errors.E(op, errors.KindValidation, "Your business requires two-factor authentication")
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = 0
+	user.TOTPLastStep = 0
+	user.RecoveryCodes = nil
+	if err := svc.UserStorage.Put(ctx, user); err != nil {
+		return User{}, errors.E(op, err)
+	}
+
 	return user, nil
 }
+
+// twoFactorRequired reports whether the business of the user requires it to
+// use two-factor authentication
+func (svc *UserService) twoFactorRequired(ctx context.Context, user User) (bool, error) {
+	if user.EmployeeID == "" {
+		return false, nil
+	}
+	merchant, err := svc.MerchantStorage.Get(ctx, user.MerchantID)
+	if err != nil {
+		return false, err
+	}
+	if !merchant.RequireTwoFactor {
+		return false, nil
+	}
+
+	employee, err := svc.EmployeeStorage.Get(ctx, user.EmployeeID)
+	if err != nil {
+		return false, err
+	}
+	auth := Authorizer{RoleStorage: svc.RoleStorage}
+	employee.Permissions, err = auth.EffectivePermissions(ctx, employee)
+	if err != nil {
+		return false, err
+	}
+
+	return employee.IsManager(), nil
+}
+
+// currentUser returns the stored user of the context, device sessions and API
+// keys aren't linked to a user account
+func (svc *UserService) currentUser(ctx context.Context) (User, error) {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return User{}, errors.E(errors.KindUnexpected, "Unknown user")
+	}
+	if user.ID == "" {
+		return User{}, errors.E(errors.KindNoPermission, "Only available for user sessions")
+	}
+	return svc.UserStorage.Get(ctx, user.ID)
+}
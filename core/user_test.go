@@ -0,0 +1,660 @@
+package core
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func userTestService(users map[ID]*User, sessions map[ID]Session) UserService {
+	userStorage := NewMockUserStorage()
+	userStorage.GetFn = func(ctx context.Context, id ID) (User, error) {
+		u, ok := users[id]
+		if !ok {
+			return User{}, errors.E(errors.KindNotFound)
+		}
+		return *u, nil
+	}
+	userStorage.PutFn = func(ctx context.Context, u User) error {
+		*users[u.ID] = u
+		return nil
+	}
+	sessionStorage := NewMockSessionStorage()
+	sessionStorage.GetFn = func(ctx context.Context, id ID) (Session, error) {
+		s, ok := sessions[id]
+		if !ok {
+			return Session{}, errors.E(errors.KindNotFound)
+		}
+		return s, nil
+	}
+	sessionStorage.DeleteFn = func(ctx context.Context, id ID) error {
+		delete(sessions, id)
+		return nil
+	}
+	sessionStorage.ListByUserFn = func(ctx context.Context, userID ID) ([]Session, error) {
+		var list []Session
+		for _, s := range sessions {
+			if s.UserID == userID {
+				list = append(list, s)
+			}
+		}
+		return list, nil
+	}
+	sessionStorage.DeleteByUserFn = func(ctx context.Context, userID ID) error {
+		for id, s := range sessions {
+			if s.UserID == userID {
+				delete(sessions, id)
+			}
+		}
+		return nil
+	}
+	return UserService{UserStorage: userStorage, SessionStorage: sessionStorage}
+}
+
+func TestUserSessions(t *testing.T) {
+	anna, bob := NewUserOwner(), NewUserOwner()
+	assert.NoError(t, anna.HashPassword("secret-1"))
+	annaPhone, annaLaptop, bobLaptop := NewSession(anna), NewSession(anna), NewSession(bob)
+	annaPhone.LastSeenAt += 10
+
+	users := map[ID]*User{anna.ID: &anna, bob.ID: &bob}
+	sessions := map[ID]Session{annaPhone.ID: annaPhone, annaLaptop.ID: annaLaptop, bobLaptop.ID: bobLaptop}
+	svc := userTestService(users, sessions)
+	ctx := ContextWithUser(context.Background(), &anna)
+
+	list, err := svc.ListSessions(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Session{annaPhone, annaLaptop}, list)
+
+	// Sessions of other users can't be revoked
+	err = svc.RevokeSession(ctx, bobLaptop.ID)
+	assert.True(t, errors.Is(err, errors.KindNotFound))
+	assert.Contains(t, sessions, bobLaptop.ID)
+
+	assert.NoError(t, svc.RevokeSession(ctx, annaPhone.ID))
+	assert.NotContains(t, sessions, annaPhone.ID)
+	assert.Contains(t, sessions, annaLaptop.ID)
+
+	// Device sessions are not linked to a user
+	deviceUser := User{Kind: UserKindEmployee, EmployeeID: NewID("empl")}
+	_, err = svc.ListSessions(ContextWithUser(context.Background(), &deviceUser))
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+}
+
+func TestChangePassword(t *testing.T) {
+	anna, bob := NewUserOwner(), NewUserOwner()
+	assert.NoError(t, anna.HashPassword("secret-1"))
+	annaPhone, annaLaptop, bobLaptop := NewSession(anna), NewSession(anna), NewSession(bob)
+
+	users := map[ID]*User{anna.ID: &anna, bob.ID: &bob}
+	sessions := map[ID]Session{annaPhone.ID: annaPhone, annaLaptop.ID: annaLaptop, bobLaptop.ID: bobLaptop}
+	svc := userTestService(users, sessions)
+	ctx := ContextWithUser(context.Background(), &anna)
+
+	_, err := svc.ChangePassword(ctx, "wrong", "secret-2")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	assert.Len(t, sessions, 3)
+
+	_, err = svc.ChangePassword(ctx, "secret-1", "secret-2")
+	assert.NoError(t, err)
+	assert.True(t, anna.PasswordEquals("secret-2"))
+	// Logged out everywhere
+	assert.Equal(t, map[ID]Session{bobLaptop.ID: bobLaptop}, sessions)
+}
+
+// userTokenTestService is like userTestService but also keeps the issued
+// tokens and the sent mails
+func userTokenTestService(users map[ID]*User, sessions map[ID]Session, mails *[]Mail) UserService {
+	svc := userTestService(users, sessions)
+	svc.UserStorage.(*mockUserStorage).GetByEmailFn = func(ctx context.Context, email string) (User, error) {
+		for _, u := range users {
+			if u.Email == email {
+				return *u, nil
+			}
+		}
+		return User{}, errors.E(errors.KindNotFound)
+	}
+
+	tokens := map[ID]UserToken{}
+	tokenStorage := NewMockUserTokenStorage()
+	tokenStorage.PutFn = func(ctx context.Context, t UserToken) error {
+		tokens[t.ID] = t
+		return nil
+	}
+	tokenStorage.ClaimFn = func(ctx context.Context, hash string, kind UserTokenKind, at time.Time) (UserToken, error) {
+		for id, t := range tokens {
+			if t.Hash == hash && t.Kind == kind && !t.Expired(at) {
+				delete(tokens, id)
+				return t, nil
+			}
+		}
+		return UserToken{}, errors.E(errors.KindNotFound)
+	}
+	tokenStorage.DeleteByUserFn = func(ctx context.Context, userID ID, kind UserTokenKind) error {
+		for id, t := range tokens {
+			if t.UserID == userID && t.Kind == kind {
+				delete(tokens, id)
+			}
+		}
+		return nil
+	}
+	mailer := NewMockMailer()
+	mailer.SendFn = func(ctx context.Context, m Mail) error {
+		*mails = append(*mails, m)
+		return nil
+	}
+
+	svc.UserTokenStorage = tokenStorage
+	svc.Mailer = mailer
+	svc.AppURL = "https://app.backium.co"
+	return svc
+}
+
+var mailTokenRegexp = regexp.MustCompile(`token=(\S+)`)
+
+func mailToken(t *testing.T, m Mail) string {
+	match := mailTokenRegexp.FindStringSubmatch(m.Body)
+	if !assert.Len(t, match, 2) {
+		return ""
+	}
+	return match[1]
+}
+
+func TestPasswordReset(t *testing.T) {
+	anna := NewUserOwner()
+	anna.Email = "anna@mail.com"
+	assert.NoError(t, anna.HashPassword("secret-1"))
+	annaLaptop := NewSession(anna)
+
+	var mails []Mail
+	users := map[ID]*User{anna.ID: &anna}
+	sessions := map[ID]Session{annaLaptop.ID: annaLaptop}
+	svc := userTokenTestService(users, sessions, &mails)
+	ctx := context.Background()
+
+	// Unknown emails look the same as registered ones
+	assert.NoError(t, svc.RequestPasswordReset(ctx, "nobody@mail.com"))
+	assert.Empty(t, mails)
+
+	assert.NoError(t, svc.RequestPasswordReset(ctx, anna.Email))
+	assert.NoError(t, svc.RequestPasswordReset(ctx, anna.Email))
+	assert.Len(t, mails, 2)
+	assert.Equal(t, anna.Email, mails[1].To)
+	assert.Contains(t, mails[1].Body, "https://app.backium.co/reset-password?token=")
+	first, second := mailToken(t, mails[0]), mailToken(t, mails[1])
+
+	// Requesting a new reset link invalidates the previous ones
+	_, err := svc.ResetPassword(ctx, first, "secret-2")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+
+	user, err := svc.ResetPassword(ctx, second, "secret-2")
+	assert.NoError(t, err)
+	assert.True(t, anna.PasswordEquals("secret-2"))
+	assert.True(t, user.EmailVerified())
+	assert.Empty(t, sessions)
+
+	// Tokens are single use
+	_, err = svc.ResetPassword(ctx, second, "secret-3")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	assert.True(t, anna.PasswordEquals("secret-2"))
+}
+
+func TestPasswordResetExpired(t *testing.T) {
+	anna := NewUserOwner()
+	anna.Email = "anna@mail.com"
+
+	var mails []Mail
+	users := map[ID]*User{anna.ID: &anna}
+	svc := userTokenTestService(users, map[ID]Session{}, &mails)
+	ctx := context.Background()
+
+	expired, token := NewUserToken(UserTokenPasswordReset, anna.ID, -time.Minute)
+	assert.NoError(t, svc.UserTokenStorage.Put(ctx, expired))
+	_, err := svc.ResetPassword(ctx, token, "secret-2")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+
+	// Verification tokens can't reset passwords
+	verification, token := NewUserToken(UserTokenEmailVerification, anna.ID, time.Hour)
+	assert.NoError(t, svc.UserTokenStorage.Put(ctx, verification))
+	_, err = svc.ResetPassword(ctx, token, "secret-2")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+}
+
+func TestEmailVerification(t *testing.T) {
+	anna := NewUserOwner()
+	anna.Email = "anna@mail.com"
+
+	var mails []Mail
+	users := map[ID]*User{anna.ID: &anna}
+	svc := userTokenTestService(users, map[ID]Session{}, &mails)
+	ctx := ContextWithUser(context.Background(), &anna)
+
+	assert.NoError(t, svc.ResendEmailVerification(ctx))
+	assert.Len(t, mails, 1)
+	assert.Contains(t, mails[0].Body, "https://app.backium.co/verify-email?token=")
+
+	_, err := svc.VerifyEmail(context.Background(), "utk_unknown")
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	assert.False(t, anna.EmailVerified())
+
+	user, err := svc.VerifyEmail(context.Background(), mailToken(t, mails[0]))
+	assert.NoError(t, err)
+	assert.True(t, user.EmailVerified())
+	assert.True(t, anna.EmailVerified())
+
+	err = svc.ResendEmailVerification(ctx)
+	assert.True(t, errors.Is(err, errors.KindValidation))
+	assert.Len(t, mails, 1)
+}
+
+func totpAt(t *testing.T, secret string, step int64) string {
+	key, err := totpEncoding.DecodeString(secret)
+	assert.NoError(t, err)
+	return totpCode(key, step)
+}
+
+func twoFactorTestService(users map[ID]*User, merchant Merchant, employees map[ID]Employee) UserService {
+	svc := userTestService(users, map[ID]Session{})
+	merchantStorage := NewMockMerchantStorage()
+	merchantStorage.GetFn = func(ctx context.Context, id ID) (Merchant, error) {
+		return merchant, nil
+	}
+	employeeStorage := NewMockEmployeeStorage()
+	employeeStorage.GetFn = func(ctx context.Context, id ID) (Employee, error) {
+		return employees[id], nil
+	}
+	svc.MerchantStorage = merchantStorage
+	svc.EmployeeStorage = employeeStorage
+	svc.RoleStorage = NewMockRoleStorage()
+	loginAttemptTestStorages(&svc)
+	return svc
+}
+
+// loginAttemptTestStorages sets memory storages for the failed logins of the
+// service and returns their contents
+func loginAttemptTestStorages(svc *UserService) (map[string]LoginAttempts, *[]FailedLogin) {
+	var mu sync.Mutex
+	attempts := map[string]LoginAttempts{}
+	audit := &[]FailedLogin{}
+
+	attemptStorage := NewMockLoginAttemptStorage()
+	attemptStorage.RecordFailureFn = func(ctx context.Context, key string, now time.Time) (LoginAttempts, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		before := attempts[key]
+		attempts[key] = LoginAttempts{Failures: before.Failures + 1, LastFailureAt: now.Unix()}
+		return before, nil
+	}
+	attemptStorage.CancelFailureFn = func(ctx context.Context, key string, at time.Time, before LoginAttempts) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if a, ok := attempts[key]; ok {
+			a.Failures--
+			if a.LastFailureAt == at.Unix() {
+				a.LastFailureAt = before.LastFailureAt
+			}
+			attempts[key] = a
+		}
+		return nil
+	}
+	attemptStorage.ResetFn = func(ctx context.Context, key string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(attempts, key)
+		return nil
+	}
+	failedLoginStorage := NewMockFailedLoginStorage()
+	failedLoginStorage.PutFn = func(ctx context.Context, login FailedLogin) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*audit = append(*audit, login)
+		return nil
+	}
+
+	svc.LoginAttemptStorage = attemptStorage
+	svc.FailedLoginStorage = failedLoginStorage
+	return attempts, audit
+}
+
+func TestTwoFactor(t *testing.T) {
+	merchant := NewMerchant()
+	owner := NewEmployee("Anna", "Smith", merchant.ID)
+	owner.IsOwner = true
+	anna := NewUserOwner()
+	anna.Email = "anna@mail.com"
+	anna.EmployeeID, anna.MerchantID = owner.ID, merchant.ID
+	assert.NoError(t, anna.HashPassword("secret-1"))
+
+	users := map[ID]*User{anna.ID: &anna}
+	svc := twoFactorTestService(users, merchant, map[ID]Employee{owner.ID: owner})
+	ctx := ContextWithUser(context.Background(), &anna)
+
+	step, err := svc.TwoFactorStep(ctx, anna)
+	assert.NoError(t, err)
+	assert.Equal(t, TwoFactorNone, step)
+
+	_, _, err = svc.ConfirmTwoFactor(ctx, "123456")
+	assert.True(t, errors.Is(err, errors.KindValidation))
+
+	secret, uri, err := svc.EnrollTwoFactor(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, uri, "secret="+secret)
+	// Not required until confirmed
+	step, _ = svc.TwoFactorStep(ctx, anna)
+	assert.Equal(t, TwoFactorNone, step)
+
+	now := totpStep(time.Now())
+	_, _, err = svc.ConfirmTwoFactor(ctx, totpAt(t, secret, now+5))
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	user, codes, err := svc.ConfirmTwoFactor(ctx, totpAt(t, secret, now))
+	assert.NoError(t, err)
+	assert.True(t, user.TwoFactorEnabled())
+	assert.Len(t, codes, RecoveryCodeCount)
+	assert.NotContains(t, anna.RecoveryCodes, codes[0])
+
+	step, _ = svc.TwoFactorStep(ctx, anna)
+	assert.Equal(t, TwoFactorVerify, step)
+
+	// Codes can't be used twice
+	_, err = svc.VerifyTwoFactor(ctx, totpAt(t, secret, now), LoginClient{})
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	_, err = svc.VerifyTwoFactor(ctx, totpAt(t, secret, now+1), LoginClient{})
+	assert.NoError(t, err)
+
+	// Recovery codes work once
+	_, err = svc.VerifyTwoFactor(ctx, " "+codes[3]+" ", LoginClient{})
+	assert.NoError(t, err)
+	assert.Len(t, anna.RecoveryCodes, RecoveryCodeCount-1)
+	_, err = svc.VerifyTwoFactor(ctx, codes[3], LoginClient{})
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+
+	_, err = svc.DisableTwoFactor(ctx, "wrong", codes[4])
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	user, err = svc.DisableTwoFactor(ctx, "secret-1", codes[4])
+	assert.NoError(t, err)
+	assert.False(t, user.TwoFactorEnabled())
+	assert.Empty(t, anna.TOTPSecret)
+	assert.Empty(t, anna.RecoveryCodes)
+}
+
+func TestTwoFactorRequired(t *testing.T) {
+	merchant := NewMerchant()
+	merchant.RequireTwoFactor = true
+	manager := NewEmployee("Anna", "Smith", merchant.ID)
+	manager.Permissions = []Permission{ReportRead}
+	cashier := NewEmployee("Luis", "Diaz", merchant.ID)
+	cashier.Permissions = []Permission{SalesAccess, OrderWrite}
+	anna := NewUserEmployee(merchant.ID, manager.ID)
+	assert.NoError(t, anna.HashPassword("secret-1"))
+	luis := NewUserEmployee(merchant.ID, cashier.ID)
+
+	users := map[ID]*User{anna.ID: &anna, luis.ID: &luis}
+	employees := map[ID]Employee{manager.ID: manager, cashier.ID: cashier}
+	svc := twoFactorTestService(users, merchant, employees)
+	ctx := ContextWithUser(context.Background(), &anna)
+
+	step, err := svc.TwoFactorStep(ctx, anna)
+	assert.NoError(t, err)
+	assert.Equal(t, TwoFactorEnroll, step)
+	step, err = svc.TwoFactorStep(ctx, luis)
+	assert.NoError(t, err)
+	assert.Equal(t, TwoFactorNone, step)
+
+	secret, _, err := svc.EnrollTwoFactor(ctx)
+	assert.NoError(t, err)
+	_, codes, err := svc.ConfirmTwoFactor(ctx, totpAt(t, secret, totpStep(time.Now())))
+	assert.NoError(t, err)
+	step, _ = svc.TwoFactorStep(ctx, anna)
+	assert.Equal(t, TwoFactorVerify, step)
+
+	// Managers can't turn it off while the business requires it
+	_, err = svc.DisableTwoFactor(ctx, "secret-1", codes[0])
+	assert.True(t, errors.Is(err, errors.KindValidation))
+	assert.True(t, anna.TwoFactorEnabled())
+}
+
+func TestPendingSession(t *testing.T) {
+	anna := NewUserOwner()
+	session := NewSession(anna)
+	assert.False(t, session.Pending())
+
+	session.RequireTwoFactor(TwoFactorVerify)
+	assert.True(t, session.Pending())
+	now := time.Unix(session.CreatedAt, 0)
+	assert.False(t, session.Expired(now.Add(PendingSessionTimeout-time.Second)))
+	assert.True(t, session.Expired(now.Add(PendingSessionTimeout)))
+}
+
+func TestLoginPolicy(t *testing.T) {
+	policy := LoginPolicy{FreeAttempts: 3, Backoff: time.Second, LockoutAttempts: 6, Lockout: time.Minute}
+	now := time.Unix(1700000000, 0)
+	last := now.Unix()
+
+	assert.Equal(t, time.Duration(0), policy.Wait(LoginAttempts{Failures: 2, LastFailureAt: last}, now))
+	// The backoff doubles with every failure until the lockout
+	assert.Equal(t, time.Second, policy.Wait(LoginAttempts{Failures: 3, LastFailureAt: last}, now))
+	assert.Equal(t, 2*time.Second, policy.Wait(LoginAttempts{Failures: 4, LastFailureAt: last}, now))
+	assert.Equal(t, 4*time.Second, policy.Wait(LoginAttempts{Failures: 5, LastFailureAt: last}, now))
+	assert.Equal(t, time.Minute, policy.Wait(LoginAttempts{Failures: 6, LastFailureAt: last}, now))
+	assert.Equal(t, time.Minute, policy.Wait(LoginAttempts{Failures: 500, LastFailureAt: last}, now))
+	// Time since the last failure counts
+	assert.Equal(t, 30*time.Second, policy.Wait(LoginAttempts{Failures: 6, LastFailureAt: last}, now.Add(30*time.Second)))
+	assert.Equal(t, time.Duration(0), policy.Wait(LoginAttempts{Failures: 6, LastFailureAt: last}, now.Add(time.Minute)))
+}
+
+// longLoginBackoff makes the email backoff long enough not to pass while the
+// passwords are checked, failures are stored with a precision of seconds
+func longLoginBackoff() func() {
+	policy := EmailLoginPolicy
+	EmailLoginPolicy.Backoff = time.Minute
+	return func() { EmailLoginPolicy = policy }
+}
+
+func TestLoginThrottling(t *testing.T) {
+	defer longLoginBackoff()()
+	anna := NewUserOwner()
+	anna.Email = "anna@mail.com"
+	assert.NoError(t, anna.HashPassword("secret-1"))
+
+	var mails []Mail
+	users := map[ID]*User{anna.ID: &anna}
+	svc := userTokenTestService(users, map[ID]Session{}, &mails)
+	attempts, audit := loginAttemptTestStorages(&svc)
+	ctx := context.Background()
+	client := LoginClient{IP: "10.0.0.1", UserAgent: "Firefox"}
+
+	_, err := svc.Login(ctx, "nobody@mail.com", "secret-1", client)
+	assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	for i := 1; i < EmailLoginPolicy.FreeAttempts; i++ {
+		_, err = svc.Login(ctx, anna.Email, "wrong", client)
+		assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	}
+	assert.Equal(t, EmailLoginPolicy.FreeAttempts-1, attempts[loginEmailKey(anna.Email)].Failures)
+	assert.Equal(t, EmailLoginPolicy.FreeAttempts, attempts[loginIPKey(client.IP)].Failures)
+
+	// A successful login forgets the failures of the email but not of the IP
+	_, err = svc.Login(ctx, anna.Email, "secret-1", client)
+	assert.NoError(t, err)
+	assert.NotContains(t, attempts, loginEmailKey(anna.Email))
+	assert.Equal(t, EmailLoginPolicy.FreeAttempts, attempts[loginIPKey(client.IP)].Failures)
+
+	for i := 0; i < EmailLoginPolicy.FreeAttempts; i++ {
+		_, err = svc.Login(ctx, anna.Email, "wrong", LoginClient{IP: "10.0.0.2"})
+		assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	}
+	// Throttled even with the right password, and throttled attempts don't
+	// count as failures
+	_, err = svc.Login(ctx, anna.Email, "secret-1", LoginClient{IP: "10.0.0.3"})
+	assert.True(t, errors.Is(err, errors.KindTooManyAttempts))
+	var throttled *LoginThrottledError
+	assert.True(t, errors.As(err, &throttled))
+	assert.Greater(t, int64(throttled.RetryAfter), int64(0))
+	assert.Equal(t, EmailLoginPolicy.FreeAttempts, attempts[loginEmailKey(anna.Email)].Failures)
+
+	reasons := []FailedLoginReason{}
+	for _, l := range *audit {
+		reasons = append(reasons, l.Reason)
+	}
+	assert.Equal(t, FailedLoginUnknownEmail, reasons[0])
+	assert.Equal(t, FailedLoginWrongPassword, reasons[1])
+	assert.Equal(t, FailedLoginThrottled, reasons[len(reasons)-1])
+	assert.Equal(t, anna.ID, (*audit)[1].UserID)
+	assert.Equal(t, "Firefox", (*audit)[1].UserAgent)
+	assert.Empty(t, (*audit)[0].UserID)
+}
+
+func TestLoginThrottledRetry(t *testing.T) {
+	defer longLoginBackoff()()
+	anna := NewUserOwner()
+	anna.Email = "anna@mail.com"
+	assert.NoError(t, anna.HashPassword("secret-1"))
+
+	var mails []Mail
+	users := map[ID]*User{anna.ID: &anna}
+	svc := userTokenTestService(users, map[ID]Session{}, &mails)
+	attempts, _ := loginAttemptTestStorages(&svc)
+	ctx := context.Background()
+
+	for i := 0; i < EmailLoginPolicy.FreeAttempts; i++ {
+		_, err := svc.Login(ctx, anna.Email, "wrong", LoginClient{})
+		assert.True(t, errors.Is(err, errors.KindInvalidCredentials))
+	}
+	// The last failure was 40 seconds ago
+	key := loginEmailKey(anna.Email)
+	failed := attempts[key]
+	failed.LastFailureAt -= 40
+	attempts[key] = failed
+
+	// Retrying while throttled doesn't restart the wait
+	for i := 0; i < 3; i++ {
+		_, err := svc.Login(ctx, anna.Email, "secret-1", LoginClient{})
+		var throttled *LoginThrottledError
+		if assert.True(t, errors.As(err, &throttled)) {
+			assert.LessOrEqual(t, int64(throttled.RetryAfter), int64(20*time.Second))
+		}
+	}
+	assert.Equal(t, failed, attempts[key])
+}
+
+func TestLoginThrottlingConcurrent(t *testing.T) {
+	defer longLoginBackoff()()
+	anna := NewUserOwner()
+	anna.Email = "anna@mail.com"
+	assert.NoError(t, anna.HashPassword("secret-1"))
+
+	var mails []Mail
+	users := map[ID]*User{anna.ID: &anna}
+	svc := userTokenTestService(users, map[ID]Session{}, &mails)
+	attempts, _ := loginAttemptTestStorages(&svc)
+	ctx := context.Background()
+
+	// Concurrent guesses can't check more passwords than the free attempts
+	var checked, throttled int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Login(ctx, anna.Email, "wrong", LoginClient{})
+			if errors.Is(err, errors.KindTooManyAttempts) {
+				atomic.AddInt32(&throttled, 1)
+			} else if errors.Is(err, errors.KindInvalidCredentials) {
+				atomic.AddInt32(&checked, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(EmailLoginPolicy.FreeAttempts), checked)
+	assert.Equal(t, int32(20-EmailLoginPolicy.FreeAttempts), throttled)
+	assert.Equal(t, EmailLoginPolicy.FreeAttempts, attempts[loginEmailKey(anna.Email)].Failures)
+}
+
+func TestCreateCustomer(t *testing.T) {
+	merchant := NewMerchant()
+	known := NewCustomer("Anna", "Anna@Mail.com", merchant.ID)
+	other := NewCustomer("Anna", "anna@mail.com", NewID("merch"))
+	customers := map[ID]Customer{known.ID: known, other.ID: other}
+
+	var mails []Mail
+	users := map[ID]*User{}
+	svc := userTokenTestService(users, map[ID]Session{}, &mails)
+	svc.UserStorage.(*mockUserStorage).PutFn = func(ctx context.Context, u User) error {
+		users[u.ID] = &u
+		return nil
+	}
+	merchantStorage := NewMockMerchantStorage()
+	merchantStorage.GetFn = func(ctx context.Context, id ID) (Merchant, error) {
+		if id != merchant.ID {
+			return Merchant{}, errors.E(errors.KindNotFound)
+		}
+		return merchant, nil
+	}
+	customerStorage := NewMockCustomerStorage()
+	customerStorage.GetFn = func(ctx context.Context, id ID) (Customer, error) {
+		c, ok := customers[id]
+		if !ok {
+			return Customer{}, errors.E(errors.KindNotFound)
+		}
+		return c, nil
+	}
+	customerStorage.PutFn = func(ctx context.Context, c Customer) error {
+		customers[c.ID] = c
+		return nil
+	}
+	customerStorage.ListFn = func(ctx context.Context, q CustomerQuery) ([]Customer, int64, error) {
+		var list []Customer
+		for _, c := range customers {
+			if c.MerchantID == q.Filter.MerchantID && strings.EqualFold(c.Email, q.Filter.Email) {
+				list = append(list, c)
+			}
+		}
+		return list, int64(len(list)), nil
+	}
+	svc.MerchantStorage = merchantStorage
+	svc.CustomerStorage = customerStorage
+	ctx := context.Background()
+
+	_, err := svc.CreateCustomer(ctx, NewID("merch"), NewCustomer("Bob", "", ""), "bob@mail.com", "secret-1")
+	assert.True(t, errors.Is(err, errors.KindValidation))
+
+	// Linked to the customer of the business with the email
+	anna, err := svc.CreateCustomer(ctx, merchant.ID, NewCustomer("Anna B.", "", ""), "anna@mail.com", "secret-1")
+	assert.NoError(t, err)
+	assert.Equal(t, UserKindCustomer, anna.Kind)
+	assert.Equal(t, known.ID, anna.CustomerID)
+	assert.Equal(t, merchant.ID, anna.MerchantID)
+	assert.Empty(t, anna.EmployeeID)
+	assert.Equal(t, "Anna", customers[known.ID].Name)
+	assert.Len(t, mails, 1)
+
+	_, err = svc.CreateCustomer(ctx, merchant.ID, NewCustomer("Anna", "", ""), "anna@mail.com", "secret-1")
+	assert.True(t, errors.Is(err, errors.KindUserExist))
+
+	// A new customer otherwise
+	bob, err := svc.CreateCustomer(ctx, merchant.ID, NewCustomer("Bob", "", ""), "bob@mail.com", "secret-1")
+	assert.NoError(t, err)
+	assert.Len(t, customers, 3)
+	assert.Equal(t, "Bob", customers[bob.CustomerID].Name)
+	assert.Equal(t, "bob@mail.com", customers[bob.CustomerID].Email)
+	assert.Equal(t, merchant.ID, customers[bob.CustomerID].MerchantID)
+
+	// The signup doesn't fail when the verification mail can't be sent
+	svc.Mailer.(*mockMailer).SendFn = func(ctx context.Context, m Mail) error {
+		return errors.E(errors.KindUnexpected, "smtp unavailable")
+	}
+	carl, err := svc.CreateCustomer(ctx, merchant.ID, NewCustomer("Carl", "", ""), "carl@mail.com", "secret-1")
+	assert.NoError(t, err)
+	assert.Contains(t, users, carl.ID)
+
+	// Customer users never need a second factor
+	step, err := svc.TwoFactorStep(ctx, bob)
+	assert.NoError(t, err)
+	assert.Equal(t, TwoFactorNone, step)
+}
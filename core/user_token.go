@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+type UserTokenKind string
+
+const (
+	UserTokenPasswordReset     UserTokenKind = "password_reset"
+	UserTokenEmailVerification UserTokenKind = "email_verification"
+)
+
+const (
+	PasswordResetTTL     = time.Hour
+	EmailVerificationTTL = 7 * 24 * time.Hour
+)
+
+// UserToken is a single use token mailed to a user to prove it owns its email,
+// only the hash of the token is stored
+type UserToken struct {
+	ID        ID            `bson:"_id"`
+	Kind      UserTokenKind `bson:"kind"`
+	Hash      string        `bson:"hash"`
+	UserID    ID            `bson:"user_id"`
+	CreatedAt int64         `bson:"created_at"`
+	ExpiresAt int64         `bson:"expires_at"`
+}
+
+// NewUserToken returns the token along with its plaintext value, the value
+// can't be recovered afterwards
+func NewUserToken(kind UserTokenKind, userID ID, ttl time.Duration) (UserToken, string) {
+	token := string(NewIDWithSize("utk", 32))
+	now := time.Now()
+	return UserToken{
+		ID:        NewID("utok"),
+		Kind:      kind,
+		Hash:      hashToken(token),
+		UserID:    userID,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}, token
+}
+
+func (t *UserToken) Expired(now time.Time) bool {
+	return now.Unix() >= t.ExpiresAt
+}
+
+type UserTokenStorage interface {
+	Put(context.Context, UserToken) error
+	// Claim removes and returns the token of the kind with the hash if it
+	// hasn't expired at the given time, a token can be claimed only once
+	Claim(ctx context.Context, hash string, kind UserTokenKind, at time.Time) (UserToken, error)
+	// DeleteByUser removes the tokens of the kind issued to the user
+	DeleteByUser(context.Context, ID, UserTokenKind) error
+}
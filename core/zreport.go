@@ -0,0 +1,405 @@
+package core
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/backium/backend/errors"
+)
+
+type ZReportPayment struct {
+	Type      PaymentType
+	Count     int64
+	Amount    Money
+	TipAmount Money
+}
+
+type ZReportTax struct {
+	TaxID      ID
+	Name       string
+	Percentage float64
+	Amount     Money
+}
+
+type ZReportDiscount struct {
+	DiscountID ID
+	Name       string
+	Count      int64
+	Amount     Money
+}
+
+// ZReport summarizes the sales of a location during a business day
+type ZReport struct {
+	LocationID   ID
+	LocationName string
+	Date         string
+	BeginTime    int64
+	EndTime      int64
+	// Completed orders
+	OrderCount       int64
+	GrossSalesAmount Money
+	DiscountAmount   Money
+	TaxAmount        Money
+	TipAmount        Money
+	TotalAmount      Money
+	// Canceled orders are reported apart and are not part of the sales, their
+	// payments are refunds and are not part of the payment totals
+	CanceledOrderCount  int64
+	CanceledOrderAmount Money
+	Payments            []ZReportPayment
+	Refunds             []ZReportPayment
+	Taxes               []ZReportTax
+	Discounts           []ZReportDiscount
+	// Cash of the drawer shifts opened during the day
+	CashExpectedAmount   Money
+	CashCountedAmount    Money
+	CashDifferenceAmount Money
+	OpenShiftCount       int64
+	FirstReceiptNumber   string
+	LastReceiptNumber    string
+}
+
+type ZReportRequest struct {
+	LocationID ID
+	MerchantID ID
+	// Business day in YYYY-MM-DD format
+	Date     string
+	Timezone string
+}
+
+func (svc *ReportService) GenerateZReport(ctx context.Context, req ZReportRequest) (ZReport, error) {
+	const op = errors.Op("core/ReportService.GenerateZReport")
+
+	timezone, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return ZReport{}, errors.E(op, errors.KindValidation, "Invalid timezone")
+	}
+	day, err := time.ParseInLocation("2006-01-02", req.Date, timezone)
+	if err != nil {
+		return ZReport{}, errors.E(op, errors.KindValidation, "Invalid date, it should have the format YYYY-MM-DD")
+	}
+
+	location, err := svc.LocationStorage.Get(ctx, req.LocationID)
+	if err != nil {
+		return ZReport{}, errors.E(op, err)
+	}
+	if location.MerchantID != req.MerchantID {
+		return ZReport{}, errors.E(op, errors.KindNotFound, "Location not found")
+	}
+
+	currency := reportCurrency(ctx)
+	beginTime := startOfDay(day).Unix()
+	endTime := endOfDay(day).Unix()
+	report := ZReport{
+		LocationID:           location.ID,
+		LocationName:         location.Name,
+		Date:                 req.Date,
+		BeginTime:            beginTime,
+		EndTime:              endTime,
+		GrossSalesAmount:     NewMoney(0, currency),
+		DiscountAmount:       NewMoney(0, currency),
+		TaxAmount:            NewMoney(0, currency),
+		TipAmount:            NewMoney(0, currency),
+		TotalAmount:          NewMoney(0, currency),
+		CanceledOrderAmount:  NewMoney(0, currency),
+		CashExpectedAmount:   NewMoney(0, currency),
+		CashCountedAmount:    NewMoney(0, currency),
+		CashDifferenceAmount: NewMoney(0, currency),
+		Payments:             []ZReportPayment{},
+		Refunds:              []ZReportPayment{},
+		Taxes:                []ZReportTax{},
+		Discounts:            []ZReportDiscount{},
+	}
+
+	orders, _, err := svc.OrderStorage.List(ctx, OrderQuery{
+		Filter: OrderFilter{
+			LocationIDs: []ID{location.ID},
+			MerchantID:  req.MerchantID,
+			CreatedAt:   DateFilter{Gte: beginTime, Lte: endTime},
+		},
+		Sort: OrderSort{CreatedAt: SortAscending},
+	})
+	if err != nil {
+		return ZReport{}, errors.E(op, err)
+	}
+	report.addOrders(orders)
+
+	payments, _, err := svc.PaymentStorage.List(ctx, PaymentQuery{
+		Filter: PaymentFilter{
+			LocationIDs: []ID{location.ID},
+			MerchantID:  req.MerchantID,
+			CreatedAt:   DateFilter{Gte: beginTime, Lte: endTime},
+		},
+	})
+	if err != nil {
+		return ZReport{}, errors.E(op, err)
+	}
+	canceled, err := svc.canceledOrders(ctx, req.MerchantID, orders, payments)
+	if err != nil {
+		return ZReport{}, errors.E(op, err)
+	}
+	report.addPayments(payments, canceled)
+
+	shifts, _, err := svc.CashDrawerStorage.ListShift(ctx, CashDrawerShiftQuery{
+		Filter: CashDrawerShiftFilter{
+			LocationIDs: []ID{location.ID},
+			MerchantID:  req.MerchantID,
+			OpenedAt:    DateFilter{Gte: beginTime, Lte: endTime},
+		},
+	})
+	if err != nil {
+		return ZReport{}, errors.E(op, err)
+	}
+	report.addShifts(shifts)
+
+	return report, nil
+}
+
+// canceledOrders returns the canceled orders of the payments, payments of
+// orders created on another day are looked up
+func (svc *ReportService) canceledOrders(ctx context.Context, merchantID ID, orders []Order, payments []Payment) (map[ID]bool, error) {
+	canceled := map[ID]bool{}
+	listed := map[ID]bool{}
+	for _, order := range orders {
+		listed[order.ID] = true
+		if order.State == OrderStateCanceled {
+			canceled[order.ID] = true
+		}
+	}
+
+	var missing []ID
+	for _, payment := range payments {
+		if !listed[payment.OrderID] {
+			listed[payment.OrderID] = true
+			missing = append(missing, payment.OrderID)
+		}
+	}
+	if len(missing) == 0 {
+		return canceled, nil
+	}
+
+	others, _, err := svc.OrderStorage.List(ctx, OrderQuery{
+		Filter: OrderFilter{
+			IDs:        missing,
+			MerchantID: merchantID,
+			States:     []OrderState{OrderStateCanceled},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range others {
+		canceled[order.ID] = true
+	}
+	return canceled, nil
+}
+
+func (r *ZReport) addOrders(orders []Order) {
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].CreatedAt < orders[j].CreatedAt
+	})
+
+	taxes := map[ID]int{}
+	discounts := map[ID]int{}
+	for _, order := range orders {
+		switch order.State {
+		case OrderStateCanceled:
+			r.CanceledOrderCount++
+			r.CanceledOrderAmount.Value += order.TotalAmount.Value
+			continue
+		case OrderStateCompleted:
+		default:
+			continue
+		}
+
+		if r.FirstReceiptNumber == "" {
+			r.FirstReceiptNumber = receiptNumber(order.ID)
+		}
+		r.LastReceiptNumber = receiptNumber(order.ID)
+
+		r.OrderCount++
+		for _, v := range order.ItemVariations {
+			r.GrossSalesAmount.Value += v.GrossSales.Value
+		}
+		r.DiscountAmount.Value += order.TotalDiscountAmount.Value
+		r.TaxAmount.Value += order.TotalTaxAmount.Value
+		r.TotalAmount.Value += order.TotalAmount.Value
+
+		for _, tax := range order.Taxes {
+			i, ok := taxes[tax.ID]
+			if !ok {
+				i = len(r.Taxes)
+				taxes[tax.ID] = i
+				r.Taxes = append(r.Taxes, ZReportTax{
+					TaxID:      tax.ID,
+					Name:       tax.Name,
+					Percentage: tax.Percentage,
+					Amount:     NewMoney(0, r.TaxAmount.Currency),
+				})
+			}
+			r.Taxes[i].Amount.Value += tax.AppliedAmount.Value
+		}
+		for _, discount := range order.Discounts {
+			i, ok := discounts[discount.ID]
+			if !ok {
+				i = len(r.Discounts)
+				discounts[discount.ID] = i
+				r.Discounts = append(r.Discounts, ZReportDiscount{
+					DiscountID: discount.ID,
+					Name:       discount.Name,
+					Amount:     NewMoney(0, r.DiscountAmount.Currency),
+				})
+			}
+			r.Discounts[i].Count++
+			r.Discounts[i].Amount.Value += discount.AppliedAmount.Value
+		}
+	}
+}
+
+// addPayments adds the payments by type, payments of canceled orders are
+// reported as refunds
+func (r *ZReport) addPayments(payments []Payment, canceled map[ID]bool) {
+	types := map[PaymentType]int{}
+	refunds := map[PaymentType]int{}
+	for _, payment := range payments {
+		if canceled[payment.OrderID] {
+			r.Refunds = addZReportPayment(r.Refunds, refunds, payment, r.TotalAmount.Currency)
+			continue
+		}
+		r.Payments = addZReportPayment(r.Payments, types, payment, r.TotalAmount.Currency)
+		r.TipAmount.Value += payment.TipAmount.Value
+	}
+}
+
+// addZReportPayment adds the payment to the line of its type, types maps
+// each payment type to its line
+func addZReportPayment(lines []ZReportPayment, types map[PaymentType]int, payment Payment, currency Currency) []ZReportPayment {
+	i, ok := types[payment.Type]
+	if !ok {
+		i = len(lines)
+		types[payment.Type] = i
+		lines = append(lines, ZReportPayment{
+			Type:      payment.Type,
+			Amount:    NewMoney(0, currency),
+			TipAmount: NewMoney(0, currency),
+		})
+	}
+	lines[i].Count++
+	lines[i].Amount.Value += payment.Amount.Value
+	lines[i].TipAmount.Value += payment.TipAmount.Value
+	return lines
+}
+
+// addShifts adds the cash of the closed shifts, open shifts are only counted
+// since their expected cash is still changing
+func (r *ZReport) addShifts(shifts []CashDrawerShift) {
+	for _, shift := range shifts {
+		if shift.State != CashDrawerShiftStateClosed {
+			r.OpenShiftCount++
+			continue
+		}
+		r.CashExpectedAmount.Value += shift.ExpectedAmount.Value
+		r.CashCountedAmount.Value += shift.CountedAmount.Value
+		r.CashDifferenceAmount.Value += shift.DifferenceAmount.Value
+	}
+}
+
+type zReportContent struct {
+	LocationName       string
+	Date               string
+	OrderCount         int64
+	GrossSales         string
+	Discounts          string
+	Taxes              string
+	Tips               string
+	Total              string
+	CanceledOrderCount int64
+	CanceledOrders     string
+	Payments           []zReportLine
+	Refunds            []zReportLine
+	TaxLines           []zReportLine
+	DiscountLines      []zReportLine
+	CashExpected       string
+	CashCounted        string
+	CashDifference     string
+	FirstReceiptNumber string
+	LastReceiptNumber  string
+}
+
+type zReportLine struct {
+	Name   string
+	Amount string
+}
+
+// GenerateZReportPDF renders the Z-report with the receipt template and uploads it
+func (svc *ReportService) GenerateZReportPDF(ctx context.Context, req ZReportRequest) (string, error) {
+	const op = errors.Op("core/ReportService.GenerateZReportPDF")
+
+	report, err := svc.GenerateZReport(ctx, req)
+	if err != nil {
+		return "", errors.E(op, err)
+	}
+
+	content := zReportContent{
+		LocationName:       report.LocationName,
+		Date:               report.Date,
+		OrderCount:         report.OrderCount,
+		GrossSales:         centsToString(report.GrossSalesAmount.Value),
+		Discounts:          centsToString(report.DiscountAmount.Value),
+		Taxes:              centsToString(report.TaxAmount.Value),
+		Tips:               centsToString(report.TipAmount.Value),
+		Total:              centsToString(report.TotalAmount.Value),
+		CanceledOrderCount: report.CanceledOrderCount,
+		CanceledOrders:     centsToString(report.CanceledOrderAmount.Value),
+		CashExpected:       centsToString(report.CashExpectedAmount.Value),
+		CashCounted:        centsToString(report.CashCountedAmount.Value),
+		CashDifference:     centsToString(report.CashDifferenceAmount.Value),
+		FirstReceiptNumber: report.FirstReceiptNumber,
+		LastReceiptNumber:  report.LastReceiptNumber,
+	}
+	for _, p := range report.Payments {
+		content.Payments = append(content.Payments, zReportLine{
+			Name:   string(p.Type),
+			Amount: centsToString(p.Amount.Value),
+		})
+	}
+	for _, p := range report.Refunds {
+		content.Refunds = append(content.Refunds, zReportLine{
+			Name:   string(p.Type),
+			Amount: centsToString(p.Amount.Value),
+		})
+	}
+	for _, t := range report.Taxes {
+		content.TaxLines = append(content.TaxLines, zReportLine{
+			Name:   t.Name,
+			Amount: centsToString(t.Amount.Value),
+		})
+	}
+	for _, d := range report.Discounts {
+		content.DiscountLines = append(content.DiscountLines, zReportLine{
+			Name:   d.Name,
+			Amount: centsToString(d.Amount.Value),
+		})
+	}
+
+	htmlFilename, err := compileHtml("core/receipt/zreport.html", content)
+	if err != nil {
+		return "", errors.E(op, errors.KindUnexpected, errors.Errorf("compiling z-report: %v", err))
+	}
+	defer os.Remove(htmlFilename)
+
+	pdfFilename, err := htmlToPdf(htmlFilename, "900px")
+	if err != nil {
+		return "", errors.E(op, errors.KindUnexpected, errors.Errorf("html to pdf: %v", err))
+	}
+	defer os.Remove(pdfFilename)
+
+	url, err := svc.Uploader.Upload(ctx, pdfFilename)
+	if err != nil {
+		return "", errors.E(op, errors.KindUnexpected, errors.Errorf("uploading z-report: %v", err))
+	}
+
+	return url, nil
+}
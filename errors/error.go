@@ -16,6 +16,7 @@ const (
 	KindNoPermission
 	KindInvalidCredentials
 	KindInvalidSession
+	KindTooManyAttempts
 )
 
 type Error struct {
@@ -28,6 +29,10 @@ func (e *Error) Error() string {
 	return e.Err.Error()
 }
 
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 func E(args ...interface{}) error {
 	e := &Error{}
 	for _, arg := range args {
@@ -58,6 +63,12 @@ func Is(err error, kind Kind) bool {
 	return Is(e.Err, kind)
 }
 
+// As is equivalent to errors.As, but allows clients to import only this
+// package for all error handling.
+func As(err error, target interface{}) bool {
+	return errors.As(err, target)
+}
+
 // errorString is a trivial implementation of error.
 type errorString struct {
 	s string
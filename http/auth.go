@@ -1,51 +1,72 @@
 package http
 
 import (
-	"context"
 	"strings"
+	"time"
 
 	"github.com/backium/backend/core"
 	"github.com/backium/backend/errors"
 	"github.com/labstack/echo/v4"
 )
 
-type AuthContext struct {
-	echo.Context
-	Session    core.Session
-	MerchantID core.ID
+// RequireAuth authenticates the requests with an API key when they have an
+// Authorization header and with the session cookie otherwise
+func RequireAuth(apiKey, session echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withKey, withSession := apiKey(next), session(next)
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("Authorization") != "" {
+				return withKey(c)
+			}
+			return withSession(c)
+		}
+	}
 }
 
-func RequireAPIKey(merchantStorage core.MerchantStorage, sessionStorage core.SessionStorage) echo.MiddlewareFunc {
+func RequireAPIKey(merchantService core.MerchantService) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			const op = errors.Op("http/RequireAPIKey")
+			req := c.Request()
+			ctx := req.Context()
 
-			header := c.Request().Header
-			bearer := header.Get("Authorization")
-			apiKey := strings.TrimPrefix(bearer, "Bearer ")
-
-			if strings.HasPrefix(apiKey, "sk_") {
-				merch, err := merchantStorage.GetByKey(context.TODO(), apiKey)
-				if err != nil {
-					return errors.E(op, errors.KindInvalidSession, err)
-				}
+			bearer := req.Header.Get("Authorization")
+			if !strings.HasPrefix(bearer, "Bearer ") {
+				return errors.E(op, errors.KindInvalidSession, "invalid authorization header")
+			}
+			merchant, key, err := merchantService.AuthenticateKey(ctx, strings.TrimPrefix(bearer, "Bearer "))
+			if err != nil {
+				return errors.E(op, errors.KindInvalidSession, err)
+			}
 
-				return next(&AuthContext{
-					Context:    c,
-					Session:    core.Session{},
-					MerchantID: merch.ID,
-				})
+			// Requests made with keys act as an employee limited to the key scopes
+			employee := key.Employee(merchant.ID)
+			user := core.User{
+				Kind:       core.UserKindEmployee,
+				EmployeeID: employee.ID,
+				MerchantID: merchant.ID,
 			}
-			return nil
+
+			ctx = core.ContextWithMerchant(ctx, &merchant)
+			ctx = core.ContextWithUser(ctx, &user)
+			ctx = core.ContextWithEmployee(ctx, &employee)
+			ctx = core.ContextWithKey(ctx, &key)
+			c.SetRequest(req.Clone(ctx))
+
+			return next(c)
 		}
 	}
 }
 
 func RequireSession(
+	keys core.SessionKeys,
+	authorizer core.Authorizer,
 	merchantStorage core.MerchantStorage,
 	sessionStorage core.SessionStorage,
 	userStorage core.UserStorage,
 	employeeStorage core.EmployeeStorage,
+	customerStorage core.CustomerStorage,
+	deviceStorage core.DeviceStorage,
 ) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -53,35 +74,135 @@ func RequireSession(
 			req := c.Request()
 			ctx := req.Context()
 
-			cookie, err := c.Cookie("web_session")
+			session, err := sessionFromCookie(c, keys, sessionStorage)
 			if err != nil {
-				return errors.E(op, errors.KindInvalidSession, err)
+				return errors.E(op, err)
+			}
+			if session.Pending() {
+				return errors.E(op, errors.KindInvalidSession, "two-factor authentication required")
 			}
-			session, err := core.DecodeSession(cookie.Value)
+			now := time.Now()
+			// Renew the idle timeout, the request goes on if it fails since the
+			// session is still valid, unless it was revoked in the meantime
+			if session.Touch(now, c.RealIP()) {
+				err := sessionStorage.Renew(ctx, session)
+				if errors.Is(err, errors.KindNotFound) {
+					return errors.E(op, errors.KindInvalidSession, "session was revoked")
+				}
+				if err != nil {
+					c.Logger().Errorf("renewing session %v: %v", session.ID, err)
+				}
+			}
+			merchant, err := merchantStorage.Get(ctx, session.MerchantID)
 			if err != nil {
 				return errors.E(op, errors.KindInvalidSession, err)
 			}
-			session, err = sessionStorage.Get(ctx, session.ID)
+			var user core.User
+			var employee core.Employee
+			if session.DeviceID != "" {
+				device, err := deviceStorage.Get(ctx, session.DeviceID)
+				if err != nil {
+					return errors.E(op, errors.KindInvalidSession, err)
+				}
+				if device.Status != core.StatusActive {
+					return errors.E(op, errors.KindInvalidSession, "device is no longer registered")
+				}
+				employee, err = employeeStorage.Get(ctx, session.EmployeeID)
+				if err != nil {
+					return errors.E(op, errors.KindInvalidSession, err)
+				}
+				if employee.Status != core.StatusActive {
+					return errors.E(op, errors.KindInvalidSession, "employee is no longer active")
+				}
+				// Device sessions only give access to the device location
+				employee.LocationIDs = session.LocationIDs
+				user = session.DeviceUser()
+			} else {
+				user, err = userStorage.Get(ctx, session.UserID)
+				if err != nil {
+					return errors.E(op, errors.KindInvalidSession, err)
+				}
+				// Customer users have no employee, they only get their
+				// customer record
+				if user.EmployeeID == "" {
+					if user.Kind != core.UserKindCustomer {
+						return errors.E(op, errors.KindInvalidSession, "user has no employee")
+					}
+					customer, err := customerStorage.Get(ctx, user.CustomerID)
+					if err != nil {
+						return errors.E(op, errors.KindInvalidSession, err)
+					}
+					if customer.Status != core.StatusActive || customer.MerchantID != merchant.ID {
+						return errors.E(op, errors.KindInvalidSession, "customer is no longer active")
+					}
+
+					ctx = core.ContextWithMerchant(ctx, &merchant)
+					ctx = core.ContextWithUser(ctx, &user)
+					ctx = core.ContextWithCustomer(ctx, &customer)
+					ctx = core.ContextWithSession(ctx, &session)
+					c.SetRequest(req.Clone(ctx))
+
+					return next(c)
+				}
+				employee, err = employeeStorage.Get(ctx, user.EmployeeID)
+				if err != nil {
+					return errors.E(op, errors.KindInvalidSession, err)
+				}
+			}
+			// Permissions are resolved on every request so role changes apply
+			// to the open sessions
+			employee.Permissions, err = authorizer.EffectivePermissions(ctx, employee)
 			if err != nil {
-				return errors.E(op, errors.KindInvalidSession, err)
+				return errors.E(op, err)
 			}
-			merchant, err := merchantStorage.Get(ctx, session.MerchantID)
+			if session.DeviceID != "" {
+				employee.LimitToDevice()
+			}
+			c.Logger().Infof("session found: %+v", session)
+
+			ctx = core.ContextWithMerchant(ctx, &merchant)
+			ctx = core.ContextWithUser(ctx, &user)
+			ctx = core.ContextWithEmployee(ctx, &employee)
+			ctx = core.ContextWithSession(ctx, &session)
+			c.SetRequest(req.Clone(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+// RequirePendingSession authenticates the sessions waiting for the second
+// login step, they only give access to the two-factor authentication routes
+func RequirePendingSession(
+	keys core.SessionKeys,
+	merchantStorage core.MerchantStorage,
+	sessionStorage core.SessionStorage,
+	userStorage core.UserStorage,
+) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = errors.Op("http/RequirePendingSession")
+			req := c.Request()
+			ctx := req.Context()
+
+			session, err := sessionFromCookie(c, keys, sessionStorage)
 			if err != nil {
-				return errors.E(op, errors.KindInvalidSession, err)
+				return errors.E(op, err)
 			}
-			user, err := userStorage.Get(ctx, session.UserID)
+			if !session.Pending() {
+				return errors.E(op, errors.KindInvalidSession, "no login pending")
+			}
+			merchant, err := merchantStorage.Get(ctx, session.MerchantID)
 			if err != nil {
 				return errors.E(op, errors.KindInvalidSession, err)
 			}
-			employee, err := employeeStorage.Get(ctx, user.EmployeeID)
+			user, err := userStorage.Get(ctx, session.UserID)
 			if err != nil {
 				return errors.E(op, errors.KindInvalidSession, err)
 			}
-			c.Logger().Infof("session found: %+v", session)
 
 			ctx = core.ContextWithMerchant(ctx, &merchant)
 			ctx = core.ContextWithUser(ctx, &user)
-			ctx = core.ContextWithEmployee(ctx, &employee)
 			ctx = core.ContextWithSession(ctx, &session)
 			c.SetRequest(req.Clone(ctx))
 
@@ -89,3 +210,139 @@ func RequireSession(
 		}
 	}
 }
+
+// sessionFromCookie returns the stored session of the request cookie if it
+// hasn't expired
+func sessionFromCookie(c echo.Context, keys core.SessionKeys, sessionStorage core.SessionStorage) (core.Session, error) {
+	cookie, err := c.Cookie("web_session")
+	if err != nil {
+		return core.Session{}, errors.E(errors.KindInvalidSession, err)
+	}
+	session, err := core.DecodeSession(cookie.Value, keys)
+	if err != nil {
+		return core.Session{}, errors.E(errors.KindInvalidSession, err)
+	}
+	session, err = sessionStorage.Get(c.Request().Context(), session.ID)
+	if err != nil {
+		return core.Session{}, errors.E(errors.KindInvalidSession, err)
+	}
+	if session.Expired(time.Now()) {
+		return core.Session{}, errors.E(errors.KindInvalidSession, "session expired")
+	}
+	return session, nil
+}
+
+// RequirePermission rejects the requests of employees without all the
+// permissions, owners have all of them
+func RequirePermission(permissions ...core.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = errors.Op("http/RequirePermission")
+
+			employee := core.EmployeeFromContext(c.Request().Context())
+			if employee == nil {
+				return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+			}
+			for _, p := range permissions {
+				if !employee.HasPermission(p) {
+					return errors.E(op, errors.KindNoPermission, "missing permission "+string(p))
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireOwner rejects the requests not made by the business owner
+func RequireOwner() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = errors.Op("http/RequireOwner")
+
+			employee := core.EmployeeFromContext(c.Request().Context())
+			if employee == nil {
+				return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+			}
+			if !employee.IsOwner {
+				return errors.E(op, errors.KindNoPermission, "only available for the business owner")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireSelfOrOwner rejects the requests about another employee, given by the
+// id path parameter, unless made by the business owner
+func RequireSelfOrOwner() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = errors.Op("http/RequireSelfOrOwner")
+
+			employee := core.EmployeeFromContext(c.Request().Context())
+			if employee == nil {
+				return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+			}
+			if !employee.IsOwner && string(employee.ID) != c.Param("id") {
+				return errors.E(op, errors.KindNoPermission, "only available for the employee or the business owner")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireDeviceSession rejects the requests not made from a session started
+// with a PIN on a registered device
+func RequireDeviceSession() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = errors.Op("http/RequireDeviceSession")
+
+			session := core.SessionFromContext(c.Request().Context())
+			if session == nil || session.DeviceID == "" {
+				return errors.E(op, errors.KindNoPermission, "only available on registered devices")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireEmployee rejects the requests not made by an employee of the
+// business, like the ones of customer users
+func RequireEmployee() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = errors.Op("http/RequireEmployee")
+
+			if core.EmployeeFromContext(c.Request().Context()) == nil {
+				return errors.E(op, errors.KindNoPermission, "only available for employees")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireCustomer rejects the requests not made by a customer user, customers
+// are linked to their record by email so they have to verify it first
+func RequireCustomer() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = errors.Op("http/RequireCustomer")
+
+			ctx := c.Request().Context()
+			user := core.UserFromContext(ctx)
+			if user == nil || core.CustomerFromContext(ctx) == nil {
+				return errors.E(op, errors.KindNoPermission, "only available for customers")
+			}
+			if !user.EmailVerified() {
+				return errors.E(op, errors.KindNoPermission, "email verification required")
+			}
+
+			return next(c)
+		}
+	}
+}
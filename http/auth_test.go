@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// runMiddleware runs the middleware for a request made with the context and
+// reports whether the request reached the handler
+func runMiddleware(ctx context.Context, mw echo.MiddlewareFunc, params ...string) (bool, error) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+	if len(params) == 2 {
+		c.SetParamNames(params[0])
+		c.SetParamValues(params[1])
+	}
+
+	reached := false
+	err := mw(func(c echo.Context) error {
+		reached = true
+		return nil
+	})(c)
+	return reached, err
+}
+
+func employeeContext(employee core.Employee) context.Context {
+	return core.ContextWithEmployee(context.Background(), &employee)
+}
+
+func TestRequirePermission(t *testing.T) {
+	merchantID := core.NewID("merch")
+	owner := core.NewEmployee("Jhon", "Doe", merchantID)
+	owner.IsOwner = true
+	cashier := core.NewEmployee("Anna", "Smith", merchantID)
+	cashier.Permissions = []core.Permission{core.OrderRead}
+
+	reached, err := runMiddleware(employeeContext(cashier), RequirePermission(core.OrderRead))
+	assert.NoError(t, err)
+	assert.True(t, reached)
+
+	reached, err = runMiddleware(employeeContext(cashier), RequirePermission(core.OrderRead, core.OrderWrite))
+	assert.True(t, errors.Is(err, errors.KindNoPermission), "all the permissions are required")
+	assert.False(t, reached)
+
+	reached, err = runMiddleware(employeeContext(owner), RequirePermission(core.ReportRead))
+	assert.NoError(t, err)
+	assert.True(t, reached, "owners have all the permissions")
+
+	reached, err = runMiddleware(context.Background(), RequirePermission(core.OrderRead))
+	assert.Error(t, err)
+	assert.False(t, reached)
+}
+
+func TestRequireOwner(t *testing.T) {
+	merchantID := core.NewID("merch")
+	owner := core.NewEmployee("Jhon", "Doe", merchantID)
+	owner.IsOwner = true
+	manager := core.NewEmployee("Anna", "Smith", merchantID)
+	manager.Permissions = core.ManagerPermissions
+
+	reached, err := runMiddleware(employeeContext(owner), RequireOwner())
+	assert.NoError(t, err)
+	assert.True(t, reached)
+
+	reached, err = runMiddleware(employeeContext(manager), RequireOwner())
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+	assert.False(t, reached)
+
+	reached, err = runMiddleware(context.Background(), RequireOwner())
+	assert.Error(t, err)
+	assert.False(t, reached)
+}
+
+func TestRequireEmployee(t *testing.T) {
+	merchantID := core.NewID("merch")
+	cashier := core.NewEmployee("Anna", "Smith", merchantID)
+
+	reached, err := runMiddleware(employeeContext(cashier), RequireEmployee())
+	assert.NoError(t, err)
+	assert.True(t, reached)
+
+	customer := core.NewCustomer("Luis", "luis@example.com", merchantID)
+	ctx := core.ContextWithCustomer(context.Background(), &customer)
+	reached, err = runMiddleware(ctx, RequireEmployee())
+	assert.True(t, errors.Is(err, errors.KindNoPermission), "customers are not employees")
+	assert.False(t, reached)
+}
+
+func TestRequireSelfOrOwner(t *testing.T) {
+	merchantID := core.NewID("merch")
+	owner := core.NewEmployee("Jhon", "Doe", merchantID)
+	owner.IsOwner = true
+	cashier := core.NewEmployee("Anna", "Smith", merchantID)
+
+	reached, err := runMiddleware(employeeContext(cashier), RequireSelfOrOwner(), "id", string(cashier.ID))
+	assert.NoError(t, err)
+	assert.True(t, reached)
+
+	reached, err = runMiddleware(employeeContext(cashier), RequireSelfOrOwner(), "id", string(owner.ID))
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+	assert.False(t, reached)
+
+	reached, err = runMiddleware(employeeContext(owner), RequireSelfOrOwner(), "id", string(cashier.ID))
+	assert.NoError(t, err)
+	assert.True(t, reached)
+}
+
+func TestRequireDeviceSession(t *testing.T) {
+	merchantID := core.NewID("merch")
+	device := core.NewDevice("Front counter", core.NewID("loc"), merchantID)
+	cashier := core.NewEmployee("Anna", "Smith", merchantID)
+
+	session := core.NewDeviceSession(device, cashier)
+	reached, err := runMiddleware(core.ContextWithSession(context.Background(), &session), RequireDeviceSession())
+	assert.NoError(t, err)
+	assert.True(t, reached)
+
+	session = core.NewSession(core.User{ID: core.NewID("user"), MerchantID: merchantID})
+	reached, err = runMiddleware(core.ContextWithSession(context.Background(), &session), RequireDeviceSession())
+	assert.True(t, errors.Is(err, errors.KindNoPermission))
+	assert.False(t, reached)
+}
@@ -18,10 +18,11 @@ func (h *Handler) HandleChangeCashDrawer(c echo.Context) error {
 	const op = errors.Op("http/Handler.ChangeCashDrawer")
 
 	type request struct {
-		ID     core.ID           `param:"id" validate:"required"`
-		Op     core.CashDrawerOp `json:"op" validate:"required"`
-		Amount *MoneyRequest     `json:"amount" validate:"required"`
-		Note   string            `json:"note"`
+		ID        core.ID           `param:"id" validate:"required"`
+		Op        core.CashDrawerOp `json:"op" validate:"required"`
+		Amount    *MoneyRequest     `json:"amount" validate:"required_without=CashCount"`
+		CashCount *CashCountRequest `json:"cash_count" validate:"omitempty"`
+		Note      string            `json:"note"`
 	}
 
 	ctx := c.Request().Context()
@@ -39,7 +40,20 @@ func (h *Handler) HandleChangeCashDrawer(c echo.Context) error {
 	adj := core.NewCashDrawerAdjustment(req.ID, merchant.ID)
 	adj.Op = req.Op
 	adj.Note = req.Note
-	adj.Amount = core.NewMoney(ptr.GetInt64(req.Amount.Value), req.Amount.Currency)
+	if req.Amount != nil {
+		adj.Amount = core.NewMoney(ptr.GetInt64(req.Amount.Value), req.Amount.Currency)
+	}
+	if req.CashCount != nil {
+		adj.CashCount = req.CashCount.CashCount()
+	}
+
+	ok, err := h.Authorizer.CanUseCashDrawer(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to use this cash drawer")
+	}
 
 	cash, err := h.LocationService.AdjustCashDrawer(ctx, adj)
 	if err != nil {
@@ -51,10 +65,50 @@ func (h *Handler) HandleChangeCashDrawer(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+func (h *Handler) HandleCreateCashDrawer(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleCreateCashDrawer")
+
+	type request struct {
+		Name       string  `json:"name" validate:"required"`
+		LocationID core.ID `json:"location_id" validate:"required"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	drawer := core.NewCashDrawer(req.LocationID, merchant.ID)
+	drawer.Name = req.Name
+
+	ok, err := h.Authorizer.CanCreateCashDrawer(ctx, drawer)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage cash drawers of this location")
+	}
+
+	drawer, err = h.LocationService.CreateCashDrawer(ctx, drawer)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewCashDrawer(drawer))
+}
+
 func (h *Handler) HandleSearchCashDrawer(c echo.Context) error {
 	const op = errors.Op("http/Handler.HandleSearchCashDrawer")
 
 	type filter struct {
+		IDs         []core.ID `json:"ids" validate:"omitempty,dive,id"`
 		LocationIDs []core.ID `json:"location_ids" validate:"omitempty,dive,id"`
 		Name        string    `json:"name"`
 	}
@@ -89,11 +143,21 @@ func (h *Handler) HandleSearchCashDrawer(c echo.Context) error {
 		limit = CashDrawerCountListMaxSize
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.CashDrawerAccess, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the cash drawers of these locations")
+	}
+
 	drawers, totalCount, err := h.LocationService.ListCashDrawer(ctx, core.CashDrawerQuery{
 		Limit:  limit,
 		Offset: req.Offset,
 		Filter: core.CashDrawerFilter{
-			LocationIDs: req.Filter.LocationIDs,
+			IDs:         req.Filter.IDs,
+			LocationIDs: locationIDs,
 			MerchantID:  merchant.ID,
 		},
 	})
@@ -113,8 +177,10 @@ func (h *Handler) HandleSearchCashDrawerAdjustment(c echo.Context) error {
 	const op = errors.Op("http/Handler.HandleSearchCashDrawer")
 
 	type filter struct {
-		LocationIDs []core.ID `json:"location_ids" validate:"omitempty,dive,id"`
-		Name        string    `json:"name"`
+		CashDrawerIDs []core.ID `json:"cash_drawer_ids" validate:"omitempty,dive,id"`
+		ShiftIDs      []core.ID `json:"shift_ids" validate:"omitempty,dive,id"`
+		LocationIDs   []core.ID `json:"location_ids" validate:"omitempty,dive,id"`
+		Name          string    `json:"name"`
 	}
 
 	type request struct {
@@ -147,12 +213,23 @@ func (h *Handler) HandleSearchCashDrawerAdjustment(c echo.Context) error {
 		limit = CashDrawerCountListMaxSize
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.CashDrawerAccess, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the cash drawers of these locations")
+	}
+
 	adjs, totalCount, err := h.LocationService.ListCashDrawerAdjustment(ctx, core.CashDrawerQuery{
 		Limit:  limit,
 		Offset: req.Offset,
 		Filter: core.CashDrawerFilter{
-			LocationIDs: req.Filter.LocationIDs,
-			MerchantID:  merchant.ID,
+			CashDrawerIDs: req.Filter.CashDrawerIDs,
+			ShiftIDs:      req.Filter.ShiftIDs,
+			LocationIDs:   locationIDs,
+			MerchantID:    merchant.ID,
 		},
 	})
 	if err != nil {
@@ -167,28 +244,293 @@ func (h *Handler) HandleSearchCashDrawerAdjustment(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+func (h *Handler) HandleOpenCashDrawerShift(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleOpenCashDrawerShift")
+
+	type request struct {
+		CashDrawerID  core.ID       `param:"id" validate:"required"`
+		OpeningAmount *MoneyRequest `json:"opening_amount" validate:"required"`
+		Note          string        `json:"note"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ok, err := h.Authorizer.CanUseCashDrawer(ctx, req.CashDrawerID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to use this cash drawer")
+	}
+
+	amount := core.NewMoney(ptr.GetInt64(req.OpeningAmount.Value), req.OpeningAmount.Currency)
+	shift, err := h.LocationService.OpenCashDrawerShift(ctx, req.CashDrawerID, amount, req.Note)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewCashDrawerShift(shift))
+}
+
+func (h *Handler) HandleCloseCashDrawerShift(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleCloseCashDrawerShift")
+
+	type request struct {
+		ID            core.ID           `param:"id" validate:"required"`
+		CountedAmount *MoneyRequest     `json:"counted_amount" validate:"required_without=CashCount"`
+		CashCount     *CashCountRequest `json:"cash_count" validate:"omitempty"`
+		Note          string            `json:"note"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ok, err := h.Authorizer.CanUseCashDrawerShift(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to use this cash drawer")
+	}
+
+	var amount core.Money
+	if req.CountedAmount != nil {
+		amount = core.NewMoney(ptr.GetInt64(req.CountedAmount.Value), req.CountedAmount.Currency)
+	}
+	var count *core.CashCount
+	if req.CashCount != nil {
+		count = req.CashCount.CashCount()
+	}
+	shift, err := h.LocationService.CloseCashDrawerShift(ctx, req.ID, amount, count, req.Note)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewCashDrawerShift(shift))
+}
+
+func (h *Handler) HandleRetrieveCashDrawerShift(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRetrieveCashDrawerShift")
+
+	type request struct {
+		ID core.ID `param:"id"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ok, err := h.Authorizer.CanUseCashDrawerShift(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this cash drawer shift")
+	}
+
+	shift, err := h.LocationService.GetCashDrawerShift(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewCashDrawerShift(shift))
+}
+
+func (h *Handler) HandleSearchCashDrawerShift(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSearchCashDrawerShift")
+
+	type dateFilter struct {
+		Gte int64 `json:"gte" validate:"gte=0"`
+		Lte int64 `json:"lte" validate:"gte=0"`
+	}
+
+	type filter struct {
+		CashDrawerIDs []core.ID                   `json:"cash_drawer_ids" validate:"omitempty,dive,id"`
+		LocationIDs   []core.ID                   `json:"location_ids" validate:"omitempty,dive,id"`
+		EmployeeIDs   []core.ID                   `json:"employee_ids" validate:"omitempty,dive,id"`
+		States        []core.CashDrawerShiftState `json:"states" validate:"omitempty,dive,oneof=open closed"`
+		OpenedAt      dateFilter                  `json:"opened_at"`
+	}
+
+	type request struct {
+		Limit  int64  `json:"limit" validate:"gte=0"`
+		Offset int64  `json:"offset" validate:"gte=0"`
+		Filter filter `json:"filter"`
+	}
+
+	type response struct {
+		Shifts []CashDrawerShift `json:"shifts"`
+		Total  int64             `json:"total_count"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var limit int64 = CashDrawerCountListDefaultSize
+	if req.Limit <= CashDrawerCountListMaxSize {
+		limit = req.Limit
+	} else {
+		limit = CashDrawerCountListMaxSize
+	}
+
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.CashDrawerAccess, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the cash drawers of these locations")
+	}
+
+	shifts, totalCount, err := h.LocationService.ListCashDrawerShift(ctx, core.CashDrawerShiftQuery{
+		Limit:  limit,
+		Offset: req.Offset,
+		Filter: core.CashDrawerShiftFilter{
+			CashDrawerIDs: req.Filter.CashDrawerIDs,
+			LocationIDs:   locationIDs,
+			EmployeeIDs:   req.Filter.EmployeeIDs,
+			States:        req.Filter.States,
+			OpenedAt:      core.DateFilter{Gte: req.Filter.OpenedAt.Gte, Lte: req.Filter.OpenedAt.Lte},
+			MerchantID:    merchant.ID,
+		},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Shifts: NewCashDrawerShifts(shifts),
+		Total:  totalCount,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleListDenominations(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleListDenominations")
+
+	type request struct {
+		Currency core.Currency `param:"currency" validate:"required"`
+	}
+
+	type response struct {
+		Denominations []Denomination `json:"denominations"`
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	denominations := core.Denominations(req.Currency)
+	if len(denominations) == 0 {
+		return errors.E(op, errors.KindNotFound, "Currency not found")
+	}
+
+	resp := response{
+		Denominations: make([]Denomination, len(denominations)),
+	}
+	for i, d := range denominations {
+		resp.Denominations[i] = Denomination{Value: d.Value, Kind: d.Kind}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+type DenominationCount struct {
+	Value int64 `json:"value" validate:"gt=0"`
+	Count int64 `json:"count" validate:"gte=0"`
+}
+
+type CashCountRequest struct {
+	Denominations []DenominationCount `json:"denominations" validate:"required,dive"`
+}
+
+func (r *CashCountRequest) CashCount() *core.CashCount {
+	count := &core.CashCount{
+		Denominations: make([]core.DenominationCount, len(r.Denominations)),
+	}
+	for i, d := range r.Denominations {
+		count.Denominations[i] = core.DenominationCount{Value: d.Value, Count: d.Count}
+	}
+	return count
+}
+
+type Denomination struct {
+	Value int64                 `json:"value"`
+	Kind  core.DenominationKind `json:"kind"`
+}
+
+type CashCount struct {
+	Denominations []DenominationCount `json:"denominations"`
+	Total         Money               `json:"total"`
+}
+
+func NewCashCount(count *core.CashCount) *CashCount {
+	if count == nil {
+		return nil
+	}
+	resp := &CashCount{
+		Denominations: make([]DenominationCount, len(count.Denominations)),
+		Total:         NewMoney(count.Total),
+	}
+	for i, d := range count.Denominations {
+		resp.Denominations[i] = DenominationCount{Value: d.Value, Count: d.Count}
+	}
+	return resp
+}
+
 type CashDrawer struct {
 	ID           core.ID `json:"id"`
+	Name         string  `json:"name"`
 	Amount       Money   `json:"amount"`
+	ShiftID      core.ID `json:"shift_id,omitempty"`
 	CalculatedAt int64   `json:"calculated_at"`
 	LocationID   core.ID `json:"location_id"`
 }
 
 type CashDrawerAdjustment struct {
-	CashDrawerID core.ID           `json:"cash_drawer_id"`
-	Amount       Money             `json:"amount"`
-	Op           core.CashDrawerOp `json:"operation"`
-	Note         string            `json:"note"`
-	EmployeeID   core.ID           `json:"employee_id"`
-	LocationID   core.ID           `json:"location_id"`
-	CreatedAt    int64             `json:"created_at"`
+	CashDrawerID core.ID               `json:"cash_drawer_id"`
+	ShiftID      core.ID               `json:"shift_id,omitempty"`
+	Amount       Money                 `json:"amount"`
+	Op           core.CashDrawerOp     `json:"operation"`
+	Reason       core.CashDrawerReason `json:"reason,omitempty"`
+	CashCount    *CashCount            `json:"cash_count,omitempty"`
+	Note         string                `json:"note"`
+	EmployeeID   core.ID               `json:"employee_id"`
+	LocationID   core.ID               `json:"location_id"`
+	CreatedAt    int64                 `json:"created_at"`
 }
 
 func NewCashDrawerAdjustment(adj core.CashDrawerAdjustment) CashDrawerAdjustment {
 	return CashDrawerAdjustment{
 		CashDrawerID: adj.CashDrawerID,
+		ShiftID:      adj.ShiftID,
 		Amount:       NewMoney(adj.Amount),
 		Op:           adj.Op,
+		Reason:       adj.Reason,
+		CashCount:    NewCashCount(adj.CashCount),
 		Note:         adj.Note,
 		EmployeeID:   adj.EmployeeID,
 		LocationID:   adj.LocationID,
@@ -199,7 +541,9 @@ func NewCashDrawerAdjustment(adj core.CashDrawerAdjustment) CashDrawerAdjustment
 func NewCashDrawer(count core.CashDrawer) CashDrawer {
 	return CashDrawer{
 		ID:           count.ID,
+		Name:         count.Name,
 		Amount:       NewMoney(count.Amount),
+		ShiftID:      count.ShiftID,
 		CalculatedAt: count.CalculatedAt,
 		LocationID:   count.LocationID,
 	}
@@ -220,3 +564,55 @@ func NewCashDrawerAdjustments(adjs []core.CashDrawerAdjustment) []CashDrawerAdju
 	}
 	return resp
 }
+
+type CashDrawerShift struct {
+	ID                core.ID                   `json:"id"`
+	CashDrawerID      core.ID                   `json:"cash_drawer_id"`
+	State             core.CashDrawerShiftState `json:"state"`
+	OpeningAmount     Money                     `json:"opening_amount"`
+	CashPaymentAmount Money                     `json:"cash_payment_amount"`
+	PaidInAmount      Money                     `json:"paid_in_amount"`
+	PaidOutAmount     Money                     `json:"paid_out_amount"`
+	ExpectedAmount    Money                     `json:"expected_amount"`
+	CountedAmount     Money                     `json:"counted_amount"`
+	ClosingCount      *CashCount                `json:"closing_count,omitempty"`
+	DifferenceAmount  Money                     `json:"difference_amount"`
+	OpeningNote       string                    `json:"opening_note"`
+	ClosingNote       string                    `json:"closing_note"`
+	OpeningEmployeeID core.ID                   `json:"opening_employee_id"`
+	ClosingEmployeeID core.ID                   `json:"closing_employee_id,omitempty"`
+	OpenedAt          int64                     `json:"opened_at"`
+	ClosedAt          int64                     `json:"closed_at,omitempty"`
+	LocationID        core.ID                   `json:"location_id"`
+}
+
+func NewCashDrawerShift(shift core.CashDrawerShift) CashDrawerShift {
+	return CashDrawerShift{
+		ID:                shift.ID,
+		CashDrawerID:      shift.CashDrawerID,
+		State:             shift.State,
+		OpeningAmount:     NewMoney(shift.OpeningAmount),
+		CashPaymentAmount: NewMoney(shift.CashPaymentAmount),
+		PaidInAmount:      NewMoney(shift.PaidInAmount),
+		PaidOutAmount:     NewMoney(shift.PaidOutAmount),
+		ExpectedAmount:    NewMoney(shift.ExpectedAmount),
+		CountedAmount:     NewMoney(shift.CountedAmount),
+		ClosingCount:      NewCashCount(shift.ClosingCount),
+		DifferenceAmount:  NewMoney(shift.DifferenceAmount),
+		OpeningNote:       shift.OpeningNote,
+		ClosingNote:       shift.ClosingNote,
+		OpeningEmployeeID: shift.OpeningEmployeeID,
+		ClosingEmployeeID: shift.ClosingEmployeeID,
+		OpenedAt:          shift.OpenedAt,
+		ClosedAt:          shift.ClosedAt,
+		LocationID:        shift.LocationID,
+	}
+}
+
+func NewCashDrawerShifts(shifts []core.CashDrawerShift) []CashDrawerShift {
+	resp := make([]CashDrawerShift, len(shifts))
+	for i, shift := range shifts {
+		resp[i] = NewCashDrawerShift(shift)
+	}
+	return resp
+}
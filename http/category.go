@@ -31,7 +31,15 @@ func (h *Handler) HandleCreateCategory(c echo.Context) error {
 	category := core.NewCategory(req.Name, merchant.ID)
 	category.Image = req.Image
 
-	category, err := h.CatalogService.PutCategory(ctx, category)
+	ok, err := h.Authorizer.CanCreateCategory(ctx, category)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage categories")
+	}
+
+	category, err = h.CatalogService.PutCategory(ctx, category)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -60,6 +68,14 @@ func (h *Handler) HandleUpdateCategory(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateCategory(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this category")
+	}
+
 	category, err := h.CatalogService.GetCategory(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -98,6 +114,14 @@ func (h *Handler) HandleRetrieveCategory(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanGetCategory(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this category")
+	}
+
 	category, err := h.CatalogService.GetCategory(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -142,6 +166,10 @@ func (h *Handler) HandleSearchCategory(c echo.Context) error {
 		return err
 	}
 
+	if !h.Authorizer.HasPermission(ctx, core.CatalogRead) {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search categories")
+	}
+
 	categories, count, err := h.CatalogService.ListCategory(ctx, core.CategoryQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
@@ -193,6 +221,10 @@ func (h *Handler) HandleListCategories(c echo.Context) error {
 		return err
 	}
 
+	if !h.Authorizer.HasPermission(ctx, core.CatalogRead) {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search categories")
+	}
+
 	categories, count, err := h.CatalogService.ListCategory(ctx, core.CategoryQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
@@ -232,6 +264,14 @@ func (h *Handler) HandleDeleteCategory(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanUpdateCategory(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this category")
+	}
+
 	category, err := h.CatalogService.DeleteCategory(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
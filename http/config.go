@@ -1,6 +1,15 @@
 package http
 
-import "github.com/spf13/viper"
+import (
+	"net"
+	"strings"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/backium/backend/mail"
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+)
 
 type Config struct {
 	DBURI         string `mapstructure:"BACKIUM_DB_URI"`
@@ -9,6 +18,30 @@ type Config struct {
 	RedisPassword string `mapstructure:"BACKIUM_REDIS_PASSWORD"`
 	Port          string `mapstructure:"BACKIUM_APP_PORT"`
 	CloudinaryURI string `mapstructure:"BACKIUM_CLOUDINARY_URI"`
+	// Session signing keys as a comma separated list of id:secret pairs,
+	// removing a key from the list retires it
+	SessionKeys string `mapstructure:"BACKIUM_SESSION_KEYS"`
+	// ID of the key used to sign new sessions
+	SessionKeyID string `mapstructure:"BACKIUM_SESSION_KEY_ID"`
+	// Base URL of the web app used in the links mailed to users
+	AppURL string `mapstructure:"BACKIUM_APP_URL"`
+	// Mailer delivering the mails, "smtp" to send them through SMTPAddr,
+	// "file" to append them to MailFile or "log" to write them to the server
+	// log. Mails carry login links so there is no default, the file and log
+	// mailers are only meant for development
+	Mailer string `mapstructure:"BACKIUM_MAILER"`
+	// File the mails are written to by the file mailer
+	MailFile string `mapstructure:"BACKIUM_MAIL_FILE"`
+	// SMTP server of the smtp mailer as host:port, the credentials are
+	// optional
+	SMTPAddr     string `mapstructure:"BACKIUM_SMTP_ADDR"`
+	SMTPUsername string `mapstructure:"BACKIUM_SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"BACKIUM_SMTP_PASSWORD"`
+	// Sender address of the mails sent with the smtp mailer
+	MailFrom string `mapstructure:"BACKIUM_MAIL_FROM"`
+	// Comma separated CIDRs of the proxies in front of the server, the client
+	// IP is only taken from X-Forwarded-For when the request comes from them
+	TrustedProxies string `mapstructure:"BACKIUM_TRUSTED_PROXIES"`
 }
 
 func LoadConfig(path string) (Config, error) {
@@ -27,3 +60,94 @@ func LoadConfig(path string) (Config, error) {
 	}
 	return config, nil
 }
+
+// NewMailer returns the mailer of the config
+func (c *Config) NewMailer() (core.Mailer, error) {
+	const op = errors.Op("http/Config.NewMailer")
+
+	switch c.Mailer {
+	case "smtp":
+		if c.SMTPAddr == "" || c.MailFrom == "" {
+			return nil, errors.E(op, errors.KindValidation, "the smtp mailer needs BACKIUM_SMTP_ADDR and BACKIUM_MAIL_FROM")
+		}
+		mailer, err := mail.NewSMTPMailer(c.SMTPAddr, c.SMTPUsername, c.SMTPPassword, c.MailFrom)
+		if err != nil {
+			return nil, errors.E(op, errors.KindValidation, err)
+		}
+		return mailer, nil
+	case "file":
+		if c.MailFile == "" {
+			return nil, errors.E(op, errors.KindValidation, "the file mailer needs BACKIUM_MAIL_FILE")
+		}
+		mailer, err := mail.NewFileMailer(c.MailFile)
+		if err != nil {
+			return nil, errors.E(op, errors.KindUnexpected, err)
+		}
+		return mailer, nil
+	case "log":
+		return mail.NewLogMailer(), nil
+	case "":
+		return nil, errors.E(op, errors.KindValidation, "missing BACKIUM_MAILER")
+	default:
+		return nil, errors.E(op, errors.KindValidation, "unknown mailer "+c.Mailer)
+	}
+}
+
+// IPExtractor returns the extractor of the client IPs, the IP of the
+// connection is used unless the request comes from a trusted proxy
+func (c *Config) IPExtractor() (echo.IPExtractor, error) {
+	const op = errors.Op("http/Config.IPExtractor")
+
+	var proxies []echo.TrustOption
+	for _, cidr := range strings.Split(c.TrustedProxies, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.E(op, errors.KindValidation, "invalid trusted proxy "+cidr)
+		}
+		proxies = append(proxies, echo.TrustIPRange(ipRange))
+	}
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	// Echo trusts the local networks by default, only the proxies are
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	return echo.ExtractIPFromXFFHeader(append(options, proxies...)...), nil
+}
+
+// SessionSigningKeys parses the session signing keys of the config
+func (c *Config) SessionSigningKeys() (core.SessionKeys, error) {
+	const op = errors.Op("http/Config.SessionSigningKeys")
+
+	keys := core.SessionKeys{
+		CurrentID: c.SessionKeyID,
+		Keys:      map[string][]byte{},
+	}
+	for _, pair := range strings.Split(c.SessionKeys, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		p := strings.SplitN(pair, ":", 2)
+		if len(p) != 2 {
+			return core.SessionKeys{}, errors.E(op, errors.KindValidation, "session keys should have the format id:secret")
+		}
+		if _, ok := keys.Keys[p[0]]; ok {
+			return core.SessionKeys{}, errors.E(op, errors.KindValidation, "duplicated session key "+p[0])
+		}
+		keys.Keys[p[0]] = []byte(p[1])
+	}
+	if err := keys.Validate(); err != nil {
+		return core.SessionKeys{}, errors.E(op, err)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/backium/backend/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigNewMailer(t *testing.T) {
+	_, err := (&Config{}).NewMailer()
+	assert.True(t, errors.Is(err, errors.KindValidation), "there is no default mailer")
+
+	_, err = (&Config{Mailer: "sendmail"}).NewMailer()
+	assert.True(t, errors.Is(err, errors.KindValidation))
+
+	_, err = (&Config{Mailer: "smtp", SMTPAddr: "smtp.example.com:587"}).NewMailer()
+	assert.True(t, errors.Is(err, errors.KindValidation), "the smtp mailer needs a sender")
+
+	_, err = (&Config{Mailer: "smtp", SMTPAddr: "smtp.example.com", MailFrom: "no-reply@backium.co"}).NewMailer()
+	assert.True(t, errors.Is(err, errors.KindValidation), "the smtp address needs a port")
+
+	mailer, err := (&Config{Mailer: "smtp", SMTPAddr: "smtp.example.com:587", MailFrom: "no-reply@backium.co"}).NewMailer()
+	assert.NoError(t, err)
+	assert.NotNil(t, mailer)
+
+	_, err = (&Config{Mailer: "file"}).NewMailer()
+	assert.True(t, errors.Is(err, errors.KindValidation), "the file mailer needs a file")
+
+	mailer, err = (&Config{Mailer: "file", MailFile: filepath.Join(t.TempDir(), "mails")}).NewMailer()
+	assert.NoError(t, err)
+	assert.NotNil(t, mailer)
+
+	mailer, err = (&Config{Mailer: "log"}).NewMailer()
+	assert.NoError(t, err)
+	assert.NotNil(t, mailer)
+}
+
+func TestConfigIPExtractor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", nil)
+	req.RemoteAddr = "10.0.0.5:4321"
+	req.Header.Set(echo.HeaderXForwardedFor, "1.2.3.4, 203.0.113.7")
+
+	// Forwarded IPs are ignored without trusted proxies
+	extract, err := (&Config{}).IPExtractor()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.5", extract(req))
+
+	extract, err = (&Config{TrustedProxies: "10.0.0.0/24"}).IPExtractor()
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", extract(req), "the IPs set by the client are not trusted")
+
+	req.RemoteAddr = "10.0.1.5:4321"
+	assert.Equal(t, "10.0.1.5", extract(req))
+
+	_, err = (&Config{TrustedProxies: "10.0.0.0"}).IPExtractor()
+	assert.True(t, errors.Is(err, errors.KindValidation))
+}
@@ -49,7 +49,15 @@ func (h *Handler) HandleCreateCustomer(c echo.Context) error {
 		}
 	}
 
-	customer, err := h.CustomerService.PutCustomer(ctx, customer)
+	ok, err := h.Authorizer.CanCreateCustomer(ctx, customer)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage customers")
+	}
+
+	customer, err = h.CustomerService.PutCustomer(ctx, customer)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -81,6 +89,14 @@ func (h *Handler) HandleUpdateCustomer(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateCustomer(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this customer")
+	}
+
 	customer, err := h.CustomerService.GetCustomer(ctx, req.ID)
 	if req.Address != nil {
 		customer.Address = &core.Address{
@@ -131,6 +147,14 @@ func (h *Handler) HandleRetrieveCustomer(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanGetCustomer(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this customer")
+	}
+
 	customer, err := h.CustomerService.GetCustomer(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -171,6 +195,10 @@ func (h *Handler) HandleListCustomers(c echo.Context) error {
 		limit = CustomerListMaxSize
 	}
 
+	if !h.Authorizer.HasPermission(ctx, core.CustomerRead) {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search customers")
+	}
+
 	customers, count, err := h.CustomerService.ListCustomer(ctx, core.CustomerQuery{
 		Limit:  limit,
 		Offset: offset,
@@ -235,6 +263,10 @@ func (h *Handler) HandleSearchCustomer(c echo.Context) error {
 		limit = CustomerListMaxSize
 	}
 
+	if !h.Authorizer.HasPermission(ctx, core.CustomerRead) {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search customers")
+	}
+
 	customers, count, err := h.CustomerService.ListCustomer(ctx, core.CustomerQuery{
 		Limit:  limit,
 		Offset: offset,
@@ -280,6 +312,14 @@ func (h *Handler) HandleDeleteCustomer(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanUpdateCustomer(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this customer")
+	}
+
 	customer, err := h.CustomerService.DeleteCustomer(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
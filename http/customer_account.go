@@ -0,0 +1,175 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	CustomerOrderListDefaultSize = 10
+	CustomerOrderListMaxSize     = 50
+)
+
+// HandleRetrieveCustomerAccount returns the customer record of the user
+func (h *Handler) HandleRetrieveCustomerAccount(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRetrieveCustomerAccount")
+
+	customer := core.CustomerFromContext(c.Request().Context())
+	if customer == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	return c.JSON(http.StatusOK, NewCustomer(*customer))
+}
+
+func (h *Handler) HandleSearchCustomerOrder(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSearchCustomerOrder")
+
+	type dateFilter struct {
+		Gte int64 `json:"gte" validate:"gte=0"`
+		Lte int64 `json:"lte" validate:"gte=0"`
+	}
+
+	type filter struct {
+		States    []core.OrderState `json:"states"`
+		CreatedAt dateFilter        `json:"created_at"`
+	}
+
+	type sort struct {
+		CreatedAt core.SortOrder `json:"created_at"`
+	}
+
+	type request struct {
+		Limit  int64  `json:"limit" validate:"gte=0"`
+		Offset int64  `json:"offset" validate:"gte=0"`
+		Filter filter `json:"filter"`
+		Sort   sort   `json:"sort"`
+	}
+
+	type response struct {
+		Orders []Order `json:"orders"`
+		Total  int64   `json:"total_count"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var limit int64 = CustomerOrderListDefaultSize
+	if req.Limit <= CustomerOrderListMaxSize {
+		limit = req.Limit
+	} else {
+		limit = CustomerOrderListMaxSize
+	}
+
+	orders, count, err := h.CustomerAccountService.ListOrder(ctx, core.OrderQuery{
+		Limit:  limit,
+		Offset: req.Offset,
+		Filter: core.OrderFilter{
+			States: req.Filter.States,
+			CreatedAt: core.DateFilter{
+				Gte: req.Filter.CreatedAt.Gte,
+				Lte: req.Filter.CreatedAt.Lte,
+			},
+		},
+		Sort: core.OrderSort{CreatedAt: req.Sort.CreatedAt},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Orders: make([]Order, len(orders)),
+		Total:  count,
+	}
+	for i, order := range orders {
+		resp.Orders[i] = NewOrder(order)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleRetrieveCustomerOrder(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRetrieveCustomerOrder")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"id"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	order, err := h.CustomerAccountService.GetOrder(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewOrder(order))
+}
+
+func (h *Handler) HandleGenerateCustomerReceipt(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleGenerateCustomerReceipt")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"id"`
+	}
+
+	type response struct {
+		URL string `json:"url"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	// Only the orders of the customer have receipts for it
+	order, err := h.CustomerAccountService.GetOrder(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	url, err := h.OrderingService.GenerateOrderReceipt(ctx, order.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, response{URL: url})
+}
+
+func (h *Handler) HandleRetrieveCustomerLoyalty(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRetrieveCustomerLoyalty")
+
+	type response struct {
+		Visits       int64 `json:"visits"`
+		TotalSpent   Money `json:"total_spent"`
+		FirstVisitAt int64 `json:"first_visit_at,omitempty"`
+		LastVisitAt  int64 `json:"last_visit_at,omitempty"`
+	}
+
+	ctx := c.Request().Context()
+
+	loyalty, err := h.CustomerAccountService.GetLoyalty(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Visits:       loyalty.Visits,
+		TotalSpent:   NewMoney(loyalty.TotalSpent),
+		FirstVisitAt: loyalty.FirstVisitAt,
+		LastVisitAt:  loyalty.LastVisitAt,
+	})
+}
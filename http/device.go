@@ -0,0 +1,279 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	DeviceListDefaultSize = 10
+	DeviceListMaxSize     = 50
+)
+
+func (h *Handler) HandleRegisterDevice(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRegisterDevice")
+
+	type request struct {
+		Name       string  `json:"name" validate:"required"`
+		LocationID core.ID `json:"location_id" validate:"required,id"`
+	}
+
+	type response struct {
+		Device
+		// Only returned on registration, the device must keep it to start
+		// PIN sessions
+		Token string `json:"token"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	device := core.NewDevice(req.Name, req.LocationID, merchant.ID)
+	device, token, err := h.DeviceService.RegisterDevice(ctx, device)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		Device: NewDevice(device),
+		Token:  token,
+	})
+}
+
+func (h *Handler) HandleRetrieveDevice(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRetrieveDevice")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	device, err := h.DeviceService.GetDevice(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewDevice(device))
+}
+
+func (h *Handler) HandleSearchDevice(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSearchDevice")
+
+	type filter struct {
+		IDs         []core.ID `json:"ids" validate:"omitempty,dive,id"`
+		LocationIDs []core.ID `json:"location_ids" validate:"omitempty,dive,id"`
+	}
+
+	type request struct {
+		Limit  int64  `json:"limit" validate:"gte=0"`
+		Offset int64  `json:"offset" validate:"gte=0"`
+		Filter filter `json:"filter"`
+	}
+
+	type response struct {
+		Devices []Device `json:"devices"`
+		Total   int64    `json:"total_count"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var limit int64 = DeviceListDefaultSize
+	if req.Limit <= DeviceListMaxSize {
+		limit = req.Limit
+	} else {
+		limit = DeviceListMaxSize
+	}
+
+	devices, count, err := h.DeviceService.ListDevice(ctx, core.DeviceQuery{
+		Limit:  limit,
+		Offset: req.Offset,
+		Filter: core.DeviceFilter{
+			IDs:         req.Filter.IDs,
+			LocationIDs: req.Filter.LocationIDs,
+			MerchantID:  merchant.ID,
+		},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Devices: make([]Device, len(devices)),
+		Total:   count,
+	}
+	for i, d := range devices {
+		resp.Devices[i] = NewDevice(d)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleDeleteDevice(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleDeleteDevice")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	device, err := h.DeviceService.DeleteDevice(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewDevice(device))
+}
+
+func (h *Handler) HandleSetEmployeePIN(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSetEmployeePIN")
+
+	type request struct {
+		ID  core.ID `param:"id" validate:"required,id"`
+		PIN string  `json:"pin" validate:"required,numeric,min=4,max=6"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.DeviceService.SetEmployeePIN(ctx, req.ID, req.PIN); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) HandlePINLogin(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandlePINLogin")
+
+	type request struct {
+		DeviceID   core.ID `param:"id" validate:"required,id"`
+		Token      string  `json:"token" validate:"required"`
+		EmployeeID core.ID `json:"employee_id" validate:"required,id"`
+		PIN        string  `json:"pin" validate:"required"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	session, err := h.DeviceService.PINLogin(ctx, req.DeviceID, req.Token, req.EmployeeID, req.PIN)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := h.setSessionCookie(c, session); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewDeviceSession(session))
+}
+
+func (h *Handler) HandleSwitchEmployee(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSwitchEmployee")
+
+	type request struct {
+		EmployeeID core.ID `json:"employee_id" validate:"required,id"`
+		PIN        string  `json:"pin" validate:"required"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	session, err := h.DeviceService.SwitchEmployee(ctx, req.EmployeeID, req.PIN)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := h.setSessionCookie(c, session); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewDeviceSession(session))
+}
+
+type Device struct {
+	ID           core.ID     `json:"id"`
+	Name         string      `json:"name"`
+	LocationID   core.ID     `json:"location_id"`
+	MerchantID   core.ID     `json:"merchant_id"`
+	RegisteredBy core.ID     `json:"registered_by"`
+	LastSeenAt   int64       `json:"last_seen_at"`
+	CreatedAt    int64       `json:"created_at"`
+	UpdatedAt    int64       `json:"updated_at"`
+	Status       core.Status `json:"status"`
+}
+
+func NewDevice(device core.Device) Device {
+	return Device{
+		ID:           device.ID,
+		Name:         device.Name,
+		LocationID:   device.LocationID,
+		MerchantID:   device.MerchantID,
+		RegisteredBy: device.RegisteredBy,
+		LastSeenAt:   device.LastSeenAt,
+		CreatedAt:    device.CreatedAt,
+		UpdatedAt:    device.UpdatedAt,
+		Status:       device.Status,
+	}
+}
+
+type DeviceSession struct {
+	DeviceID    core.ID   `json:"device_id"`
+	EmployeeID  core.ID   `json:"employee_id"`
+	MerchantID  core.ID   `json:"merchant_id"`
+	LocationIDs []core.ID `json:"location_ids"`
+}
+
+func NewDeviceSession(session core.Session) DeviceSession {
+	return DeviceSession{
+		DeviceID:    session.DeviceID,
+		EmployeeID:  session.EmployeeID,
+		MerchantID:  session.MerchantID,
+		LocationIDs: session.LocationIDs,
+	}
+}
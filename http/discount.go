@@ -46,7 +46,15 @@ func (h *Handler) HandleCreateDiscount(c echo.Context) error {
 		}
 	}
 
-	discount, err := h.CatalogService.PutDiscount(ctx, discount)
+	ok, err := h.Authorizer.CanCreateDiscount(ctx, discount)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage discounts of these locations")
+	}
+
+	discount, err = h.CatalogService.PutDiscount(ctx, discount)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -78,6 +86,14 @@ func (h *Handler) HandleUpdateDiscount(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateDiscount(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this discount")
+	}
+
 	discount, err := h.CatalogService.GetDiscount(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -102,6 +118,14 @@ func (h *Handler) HandleUpdateDiscount(c echo.Context) error {
 		}
 	}
 
+	ok, err = h.Authorizer.CanCreateDiscount(ctx, discount)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage discounts of these locations")
+	}
+
 	discount, err = h.CatalogService.PutDiscount(ctx, discount)
 	if err != nil {
 		return errors.E(op, err)
@@ -129,6 +153,14 @@ func (h *Handler) HandleRetrieveDiscount(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanGetDiscount(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this discount")
+	}
+
 	discount, err := h.CatalogService.GetDiscount(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -162,10 +194,19 @@ func (h *Handler) HandleListDiscounts(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, nil)
+	ok, err := h.Authorizer.CanSearch(ctx, core.CatalogRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the discounts of these locations")
+	}
+
 	discounts, count, err := h.CatalogService.ListDiscount(ctx, core.DiscountQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
-		Filter: core.DiscountFilter{MerchantID: merchant.ID},
+		Filter: core.DiscountFilter{LocationIDs: locationIDs, MerchantID: merchant.ID},
 	})
 	if err != nil {
 		return errors.E(op, err)
@@ -219,12 +260,21 @@ func (h *Handler) HandleSearchDiscount(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.CatalogRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the discounts of these locations")
+	}
+
 	discounts, count, err := h.CatalogService.ListDiscount(ctx, core.DiscountQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
 		Filter: core.DiscountFilter{
 			Name:        req.Filter.Name,
-			LocationIDs: req.Filter.LocationIDs,
+			LocationIDs: locationIDs,
 			MerchantID:  merchant.ID,
 		},
 		Sort: core.DiscountSort{
@@ -265,6 +315,14 @@ func (h *Handler) HandleDeleteDiscount(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanUpdateDiscount(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this discount")
+	}
+
 	discount, err := h.CatalogService.DeleteDiscount(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
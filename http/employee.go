@@ -31,6 +31,7 @@ func (h *Handler) HandleCreateEmployee(c echo.Context) error {
 		Rate        *MoneyRequest     `json:"rate" validate:"omitempty"`
 		Salary      *salary           `json:"salary" validate:"omitempty"`
 		Permissions []core.Permission `json:"permissions" validate:"omitempty"`
+		RoleIDs     []core.ID         `json:"role_ids" validate:"omitempty,dive,id"`
 		LocationIDs []core.ID         `json:"location_ids" validate:"omitempty,dive,required"`
 	}
 
@@ -51,6 +52,9 @@ func (h *Handler) HandleCreateEmployee(c echo.Context) error {
 	employee.Phone = req.Phone
 	employee.Image = req.Image
 	employee.Permissions = req.Permissions
+	if len(req.RoleIDs) != 0 {
+		employee.RoleIDs = req.RoleIDs
+	}
 	if req.Rate != nil {
 		rate := core.NewMoney(ptr.GetInt64(req.Rate.Value), req.Rate.Currency)
 		employee.ChangeRate(rate)
@@ -63,7 +67,15 @@ func (h *Handler) HandleCreateEmployee(c echo.Context) error {
 		employee.LocationIDs = req.LocationIDs
 	}
 
-	employee, err := h.EmployeeService.Put(ctx, employee)
+	ok, err := h.Authorizer.CanCreateEmployee(ctx, employee)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage employees")
+	}
+
+	employee, err = h.EmployeeService.Put(ctx, employee)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -89,6 +101,7 @@ func (h *Handler) HandleUpdateEmployee(c echo.Context) error {
 		Rate        *MoneyRequest      `json:"rate" validate:"omitempty"`
 		Salary      *salary            `json:"salary" validate:"omitempty"`
 		Permissions *[]core.Permission `json:"permissions" validate:"omitempty"`
+		RoleIDs     *[]core.ID         `json:"role_ids" validate:"omitempty,dive,id"`
 		LocationIDs *[]core.ID         `json:"location_ids" validate:"omitempty,dive,required"`
 	}
 
@@ -104,6 +117,14 @@ func (h *Handler) HandleUpdateEmployee(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateEmployee(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this employee")
+	}
+
 	employee, err := h.EmployeeService.Get(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -111,6 +132,9 @@ func (h *Handler) HandleUpdateEmployee(c echo.Context) error {
 	if req.Permissions != nil {
 		employee.Permissions = *req.Permissions
 	}
+	if req.RoleIDs != nil {
+		employee.RoleIDs = *req.RoleIDs
+	}
 	if req.FirstName != nil {
 		employee.FirstName = *req.FirstName
 	}
@@ -165,10 +189,21 @@ func (h *Handler) HandleRetrieveEmployee(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanGetEmployee(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this employee")
+	}
+
 	employee, err := h.EmployeeService.Get(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
 	}
+	if !h.Authorizer.CanSeePay(ctx, employee) {
+		employee.HidePay()
+	}
 
 	return c.JSON(http.StatusOK, NewEmployee(employee))
 }
@@ -238,6 +273,9 @@ func (h *Handler) HandleSearchEmployee(c echo.Context) error {
 		Total:     count,
 	}
 	for i, employee := range employees {
+		if !h.Authorizer.CanSeePay(ctx, employee) {
+			employee.HidePay()
+		}
 		resp.Employees[i] = NewEmployee(employee)
 	}
 
@@ -263,6 +301,14 @@ func (h *Handler) HandleDeleteEmployee(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanUpdateEmployee(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this employee")
+	}
+
 	employee, err := h.EmployeeService.DeleteEmployee(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -296,7 +342,9 @@ type Employee struct {
 	Salary        *MoneyRequest     `json:"salary,omitempty"`
 	SalaryHistory []SalaryEntry     `json:"salary_history"`
 	Permissions   []core.Permission `json:"permissions"`
+	RoleIDs       []core.ID         `json:"role_ids"`
 	LocationIDs   []core.ID         `json:"location_ids"`
+	HasPIN        bool              `json:"has_pin"`
 	MerchantID    core.ID           `json:"merchant_id"`
 	CreatedAt     int64             `json:"created_at"`
 	UpdatedAt     int64             `json:"updated_at"`
@@ -356,7 +404,9 @@ func NewEmployee(employee core.Employee) Employee {
 		Salary:        salary,
 		SalaryHistory: sHistory,
 		Permissions:   employee.Permissions,
+		RoleIDs:       employee.RoleIDs,
 		LocationIDs:   employee.LocationIDs,
+		HasPIN:        employee.PINHash != "",
 		MerchantID:    employee.MerchantID,
 		CreatedAt:     employee.CreatedAt,
 		UpdatedAt:     employee.UpdatedAt,
@@ -1,8 +1,11 @@
 package http
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/backium/backend/core"
 	"github.com/backium/backend/errors"
 	"github.com/labstack/echo/v4"
 )
@@ -11,14 +14,16 @@ const (
 	ErrTypeInvalidRequest ErrorType = "invalid_request_error"
 	ErrTypeAuthentication ErrorType = "authentication_error"
 	ErrTypePermission     ErrorType = "permission_error"
+	ErrTypeRateLimit      ErrorType = "rate_limit_error"
 	ErrTypeApi            ErrorType = "api_error"
 )
 
 type ErrorType string
 
 type Error struct {
-	Type    ErrorType `json:"type"`
-	Message string    `json:"message"`
+	Type              ErrorType           `json:"type"`
+	Message           string              `json:"message"`
+	InsufficientStock []InsufficientStock `json:"insufficient_stock,omitempty"`
 }
 
 func errorHandler(err error, c echo.Context) {
@@ -26,7 +31,13 @@ func errorHandler(err error, c echo.Context) {
 	c.Logger().Error(err)
 
 	serr := Error{}
+	var stockErr *core.InsufficientStockError
 	switch true {
+	case errors.As(err, &stockErr):
+		code = http.StatusConflict
+		serr.Type = ErrTypeInvalidRequest
+		serr.Message = "Not enough stock for some order items"
+		serr.InsufficientStock = NewInsufficientStock(stockErr.Lines)
 	case errors.Is(err, errors.KindNotFound):
 		code = http.StatusNotFound
 		serr.Type = ErrTypeInvalidRequest
@@ -36,7 +47,7 @@ func errorHandler(err error, c echo.Context) {
 		serr.Type = ErrTypeInvalidRequest
 		serr.Message = "Validation error: " + err.Error()
 	case errors.Is(err, errors.KindNoPermission):
-		code = http.StatusUnauthorized
+		code = http.StatusForbidden
 		serr.Type = ErrTypePermission
 		serr.Message = "You do not have enough permissions to perform that action"
 	case errors.Is(err, errors.KindInvalidCredentials):
@@ -47,6 +58,15 @@ func errorHandler(err error, c echo.Context) {
 		code = http.StatusUnauthorized
 		serr.Type = ErrTypeAuthentication
 		serr.Message = "Authentication required"
+	case errors.Is(err, errors.KindTooManyAttempts):
+		code = http.StatusTooManyRequests
+		serr.Type = ErrTypeRateLimit
+		serr.Message = "Too many failed attempts. Please try again later"
+		var throttled *core.LoginThrottledError
+		if errors.As(err, &throttled) {
+			retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+			c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		}
 	default:
 		if he, ok := err.(*echo.HTTPError); ok {
 			code = he.Code
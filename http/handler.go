@@ -11,18 +11,24 @@ import (
 )
 
 type Handler struct {
-	UserService       core.UserService
-	EmployeeService   core.EmployeeService
-	CatalogService    core.CatalogService
-	MerchantService   core.MerchantService
-	LocationService   core.LocationService
-	CustomerService   core.CustomerService
-	OrderingService   core.OrderingService
-	PaymentService    core.PaymentService
-	ReportService     core.ReportService
-	ExportService     core.ExportService
-	Authorizer        core.Authorizer
-	SessionRepository core.SessionStorage
+	UserService            core.UserService
+	EmployeeService        core.EmployeeService
+	CatalogService         core.CatalogService
+	MerchantService        core.MerchantService
+	LocationService        core.LocationService
+	CustomerService        core.CustomerService
+	CustomerAccountService core.CustomerAccountService
+	OrderingService        core.OrderingService
+	PaymentService         core.PaymentService
+	ReportService          core.ReportService
+	ExportService          core.ExportService
+	TipService             core.TipService
+	PayrollService         core.PayrollService
+	DeviceService          core.DeviceService
+	RoleService            core.RoleService
+	Authorizer             core.Authorizer
+	SessionRepository      core.SessionStorage
+	SessionKeys            core.SessionKeys
 }
 
 func bindAndValidate(c echo.Context, req interface{}) error {
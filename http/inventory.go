@@ -1,6 +1,7 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/backium/backend/core"
@@ -17,11 +18,14 @@ func (h *Handler) HandleChangeInventory(c echo.Context) error {
 	const op = errors.Op("http/Handler.ChangeInventory")
 
 	type adjustment struct {
-		ItemVariationID core.ID          `json:"item_variation_id" validate:"required"`
-		Op              core.InventoryOp `json:"op" validate:"required"`
-		Quantity        *int64           `json:"quantity" validate:"required"`
-		Note            string           `json:"note"`
-		LocationID      core.ID          `json:"location_id" validate:"required"`
+		ItemVariationID core.ID              `json:"item_variation_id" validate:"required"`
+		Op              core.InventoryOp     `json:"op" validate:"required"`
+		Reason          core.InventoryReason `json:"reason" validate:"required"`
+		Quantity        *int64               `json:"quantity" validate:"required"`
+		Note            string               `json:"note"`
+		LotNumber       string               `json:"lot_number"`
+		ExpiresAt       int64                `json:"expires_at" validate:"gte=0"`
+		LocationID      core.ID              `json:"location_id" validate:"required"`
 	}
 
 	type request struct {
@@ -44,15 +48,41 @@ func (h *Handler) HandleChangeInventory(c echo.Context) error {
 		return err
 	}
 
+	for i, adj := range req.Adjustments {
+		if ok := adj.Reason.Validate(); !ok {
+			msg := fmt.Sprintf("request field 'adjustments[%v].reason' is not valid, it should be one of: %v",
+				i,
+				core.InventoryReasons())
+			return errors.E(op, errors.KindValidation, msg)
+		}
+	}
+
 	adjs := make([]core.InventoryAdjustment, len(req.Adjustments))
 	for i, adj := range req.Adjustments {
 		adjs[i] = core.NewInventoryAdjustment(adj.ItemVariationID, adj.LocationID, user.MerchantID)
 		adjs[i].Op = adj.Op
+		adjs[i].Reason = adj.Reason
 		adjs[i].Quantity = *adj.Quantity
 		adjs[i].Note = adj.Note
+		adjs[i].LotNumber = adj.LotNumber
+		adjs[i].ExpiresAt = adj.ExpiresAt
 		adjs[i].EmployeeID = user.EmployeeID
 	}
 
+	variationIDs := make([]core.ID, len(adjs))
+	locationIDs := make([]core.ID, len(adjs))
+	for i, adj := range adjs {
+		variationIDs[i] = adj.ItemVariationID
+		locationIDs[i] = adj.LocationID
+	}
+	ok, err := h.Authorizer.CanChangeInventory(ctx, variationIDs, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change the inventory of these items")
+	}
+
 	counts, err := h.CatalogService.ApplyInventoryAdjustments(ctx, adjs)
 	if err != nil {
 		return errors.E(op, err)
@@ -65,6 +95,43 @@ func (h *Handler) HandleChangeInventory(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+func (h *Handler) HandleSetSoldOut(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSetSoldOut")
+
+	type request struct {
+		ItemVariationID core.ID `json:"item_variation_id" validate:"required"`
+		LocationID      core.ID `json:"location_id" validate:"required"`
+		SoldOut         *bool   `json:"sold_out" validate:"required"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ok, err := h.Authorizer.CanChangeInventory(ctx, []core.ID{req.ItemVariationID}, []core.ID{req.LocationID})
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change the inventory of this item")
+	}
+
+	count, err := h.CatalogService.SetSoldOut(ctx, req.ItemVariationID, req.LocationID, merchant.ID, *req.SoldOut)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewInventoryCount(count))
+}
+
 func (h *Handler) HandleBatchRetrieveInventory(c echo.Context) error {
 	const op = errors.Op("http/Handler.ListInventoryCounts")
 
@@ -99,11 +166,20 @@ func (h *Handler) HandleBatchRetrieveInventory(c echo.Context) error {
 		limit = InventoryCountListMaxSize
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.InventoryRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access the inventory of these locations")
+	}
+
 	counts, totalCount, err := h.CatalogService.ListInventoryCounts(ctx, core.InventoryFilter{
 		Limit:            limit,
 		Offset:           req.Offset,
 		MerchantID:       merchant.ID,
-		LocationIDs:      req.LocationIDs,
+		LocationIDs:      locationIDs,
 		ItemVariationIDs: req.ItemVariationIDs,
 	})
 	if err != nil {
@@ -127,12 +203,13 @@ func (h *Handler) HandleSearchInventoryAdjustment(c echo.Context) error {
 	}
 
 	type request struct {
-		ItemVariationIDs []core.ID  `json:"item_variation_ids"`
-		EmployeeIDs      []core.ID  `json:"employee_ids"`
-		LocationIDs      []core.ID  `json:"location_ids"`
-		CreatedAt        dateFilter `json:"created_at"`
-		Limit            int64      `json:"limit" validate:"gte=0"`
-		Offset           int64      `json:"offset" validate:"gte=0"`
+		ItemVariationIDs []core.ID              `json:"item_variation_ids"`
+		EmployeeIDs      []core.ID              `json:"employee_ids"`
+		LocationIDs      []core.ID              `json:"location_ids"`
+		Reasons          []core.InventoryReason `json:"reasons"`
+		CreatedAt        dateFilter             `json:"created_at"`
+		Limit            int64                  `json:"limit" validate:"gte=0"`
+		Offset           int64                  `json:"offset" validate:"gte=0"`
 	}
 
 	type response struct {
@@ -159,13 +236,23 @@ func (h *Handler) HandleSearchInventoryAdjustment(c echo.Context) error {
 		limit = InventoryCountListMaxSize
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.InventoryRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access the inventory of these locations")
+	}
+
 	adjs, totalCount, err := h.CatalogService.ListInventoryAdjustment(ctx, core.InventoryFilter{
 		Limit:            limit,
 		Offset:           req.Offset,
 		MerchantID:       merchant.ID,
-		LocationIDs:      req.LocationIDs,
+		LocationIDs:      locationIDs,
 		ItemVariationIDs: req.ItemVariationIDs,
 		EmployeeIDs:      req.EmployeeIDs,
+		Reasons:          req.Reasons,
 		CreatedAt:        core.DateFilter{Gte: req.CreatedAt.Gte, Lte: req.CreatedAt.Lte},
 	})
 	if err != nil {
@@ -180,21 +267,84 @@ func (h *Handler) HandleSearchInventoryAdjustment(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+func (h *Handler) HandleGetInventoryHistory(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleGetInventoryHistory")
+
+	type request struct {
+		ItemVariationID core.ID `json:"item_variation_id" validate:"required"`
+		LocationID      core.ID `json:"location_id" validate:"required"`
+		BeginTime       int64   `json:"begin_time" validate:"gte=0"`
+		EndTime         int64   `json:"end_time" validate:"gte=0"`
+	}
+
+	type response struct {
+		History InventoryHistory `json:"history"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ok, err := h.Authorizer.CanSearch(ctx, core.InventoryRead, []core.ID{req.LocationID})
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access the inventory of this location")
+	}
+
+	history, err := h.CatalogService.GetInventoryHistory(ctx, core.InventoryHistoryRequest{
+		ItemVariationID: req.ItemVariationID,
+		LocationID:      req.LocationID,
+		MerchantID:      merchant.ID,
+		BeginTime:       req.BeginTime,
+		EndTime:         req.EndTime,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		History: NewInventoryHistory(history),
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+type InventoryLot struct {
+	LotNumber string `json:"lot_number"`
+	ExpiresAt int64  `json:"expires_at"`
+	Quantity  int64  `json:"quantity"`
+}
+
 type InventoryCount struct {
-	ItemVariationID core.ID `json:"item_variation_id"`
-	Quantity        int64   `json:"quantity"`
-	CalculatedAt    int64   `json:"calculated_at"`
-	LocationID      core.ID `json:"location_id"`
+	ItemVariationID core.ID        `json:"item_variation_id"`
+	Quantity        int64          `json:"quantity"`
+	Lots            []InventoryLot `json:"lots"`
+	SoldOut         bool           `json:"sold_out"`
+	CalculatedAt    int64          `json:"calculated_at"`
+	LocationID      core.ID        `json:"location_id"`
 }
 
 type InventoryAdjustment struct {
-	ItemVariationID core.ID          `json:"item_variation_id"`
-	Quantity        int64            `json:"quantity"`
-	Op              core.InventoryOp `json:"operation"`
-	Note            string           `json:"note"`
-	EmployeeID      core.ID          `json:"employee_id"`
-	LocationID      core.ID          `json:"location_id"`
-	CreatedAt       int64            `json:"created_at"`
+	ItemVariationID core.ID              `json:"item_variation_id"`
+	Quantity        int64                `json:"quantity"`
+	Op              core.InventoryOp     `json:"operation"`
+	Reason          core.InventoryReason `json:"reason"`
+	Note            string               `json:"note"`
+	LotNumber       string               `json:"lot_number,omitempty"`
+	ExpiresAt       int64                `json:"expires_at,omitempty"`
+	EmployeeID      core.ID              `json:"employee_id"`
+	LocationID      core.ID              `json:"location_id"`
+	CreatedAt       int64                `json:"created_at"`
 }
 
 func NewInventoryAdjustment(adj core.InventoryAdjustment) InventoryAdjustment {
@@ -202,7 +352,10 @@ func NewInventoryAdjustment(adj core.InventoryAdjustment) InventoryAdjustment {
 		ItemVariationID: adj.ItemVariationID,
 		Quantity:        adj.Quantity,
 		Op:              adj.Op,
+		Reason:          adj.Reason,
 		Note:            adj.Note,
+		LotNumber:       adj.LotNumber,
+		ExpiresAt:       adj.ExpiresAt,
 		EmployeeID:      adj.EmployeeID,
 		LocationID:      adj.LocationID,
 		CreatedAt:       adj.CreatedAt,
@@ -218,9 +371,19 @@ func NewInventoryAdjustments(adjs []core.InventoryAdjustment) []InventoryAdjustm
 }
 
 func NewInventoryCount(count core.InventoryCount) InventoryCount {
+	lots := make([]InventoryLot, len(count.Lots))
+	for i, lot := range count.Lots {
+		lots[i] = InventoryLot{
+			LotNumber: lot.LotNumber,
+			ExpiresAt: lot.ExpiresAt,
+			Quantity:  lot.Quantity,
+		}
+	}
 	return InventoryCount{
 		ItemVariationID: count.ItemVariationID,
 		Quantity:        count.Quantity,
+		Lots:            lots,
+		SoldOut:         count.SoldOut,
 		CalculatedAt:    count.CalculatedAt,
 		LocationID:      count.LocationID,
 	}
@@ -233,3 +396,33 @@ func NewInventoryCounts(counts []core.InventoryCount) []InventoryCount {
 	}
 	return resp
 }
+
+type InventoryHistoryEntry struct {
+	Adjustment InventoryAdjustment `json:"adjustment"`
+	Balance    int64               `json:"balance"`
+}
+
+type InventoryHistory struct {
+	ItemVariationID core.ID                 `json:"item_variation_id"`
+	LocationID      core.ID                 `json:"location_id"`
+	OpeningQuantity int64                   `json:"opening_quantity"`
+	Quantity        int64                   `json:"quantity"`
+	Entries         []InventoryHistoryEntry `json:"entries"`
+}
+
+func NewInventoryHistory(history core.InventoryHistory) InventoryHistory {
+	entries := make([]InventoryHistoryEntry, len(history.Entries))
+	for i, entry := range history.Entries {
+		entries[i] = InventoryHistoryEntry{
+			Adjustment: NewInventoryAdjustment(entry.Adjustment),
+			Balance:    entry.Balance,
+		}
+	}
+	return InventoryHistory{
+		ItemVariationID: history.ItemVariationID,
+		LocationID:      history.LocationID,
+		OpeningQuantity: history.OpeningQuantity,
+		Quantity:        history.Quantity,
+		Entries:         entries,
+	}
+}
@@ -44,7 +44,15 @@ func (h *Handler) HandleCreateItem(c echo.Context) error {
 		item.LocationIDs = *req.LocationIDs
 	}
 
-	item, err := h.CatalogService.PutItem(c.Request().Context(), item)
+	ok, err := h.Authorizer.CanCreateItem(ctx, item)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage items of these locations")
+	}
+
+	item, err = h.CatalogService.PutItem(c.Request().Context(), item)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -84,6 +92,14 @@ func (h *Handler) HandleUpdateItem(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateItem(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this item")
+	}
+
 	item, err := h.CatalogService.GetItem(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -105,6 +121,14 @@ func (h *Handler) HandleUpdateItem(c echo.Context) error {
 		item.EnabledInPOS = *req.EnabledInPOS
 	}
 
+	ok, err = h.Authorizer.CanCreateItem(ctx, item)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage items of these locations")
+	}
+
 	item, err = h.CatalogService.PutItem(ctx, item)
 	if err != nil {
 		return errors.E(op, err)
@@ -138,6 +162,14 @@ func (h *Handler) HandleRetrieveItem(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanGetItem(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this item")
+	}
+
 	item, err := h.CatalogService.GetItem(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -179,10 +211,20 @@ func (h *Handler) HandleListItems(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, nil)
+
 	query := core.ItemQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
-		Filter: core.ItemFilter{MerchantID: merchant.ID},
+		Filter: core.ItemFilter{LocationIDs: locationIDs, MerchantID: merchant.ID},
+	}
+
+	ok, err := h.Authorizer.CanSearchItem(ctx, query.Filter)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the items of these locations")
 	}
 
 	items, count, err := h.CatalogService.ListItem(ctx, query)
@@ -250,13 +292,15 @@ func (h *Handler) HandleSearchItem(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+
 	query := core.ItemQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
 		Filter: core.ItemFilter{
 			Name:        req.Filter.Name,
 			CategoryIDs: req.Filter.CategoryIDs,
-			LocationIDs: req.Filter.LocationIDs,
+			LocationIDs: locationIDs,
 			MerchantID:  merchant.ID,
 		},
 		Sort: core.ItemSort{
@@ -264,6 +308,14 @@ func (h *Handler) HandleSearchItem(c echo.Context) error {
 		},
 	}
 
+	ok, err := h.Authorizer.CanSearchItem(ctx, query.Filter)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the items of these locations")
+	}
+
 	items, count, err := h.CatalogService.ListItem(ctx, query)
 	if err != nil {
 		return errors.E(op, err)
@@ -312,6 +364,14 @@ func (h *Handler) HandleDeleteItem(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanUpdateItem(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this item")
+	}
+
 	item, err := h.CatalogService.DeleteItem(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -20,6 +20,7 @@ func (h *Handler) HandleCreateItemVariation(c echo.Context) error {
 		Cost                 *MoneyRequest        `json:"cost" validate:"omitempty"`
 		Measurement          core.MeasurementUnit `json:"measurement" validate:"required"`
 		MinimumRequiredStock int64                `json:"minimum_required_stock"`
+		StockPolicy          core.StockPolicy     `json:"stock_policy" validate:"omitempty,oneof=allow warn block"`
 		Image                string               `json:"image"`
 		LocationIDs          *[]core.ID           `json:"location_ids" validate:"omitempty,dive,required"`
 	}
@@ -41,6 +42,7 @@ func (h *Handler) HandleCreateItemVariation(c echo.Context) error {
 	variation.Image = req.Image
 	variation.Measurement = req.Measurement
 	variation.MinimumRequiredStock = req.MinimumRequiredStock
+	variation.StockPolicy = req.StockPolicy
 	variation.Price = core.Money{
 		Value:    ptr.GetInt64(req.Price.Value),
 		Currency: req.Price.Currency,
@@ -55,7 +57,15 @@ func (h *Handler) HandleCreateItemVariation(c echo.Context) error {
 		variation.LocationIDs = *req.LocationIDs
 	}
 
-	variation, err := h.CatalogService.PutItemVariation(ctx, variation)
+	ok, err := h.Authorizer.CanCreateItemVariation(ctx, variation)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage items of these locations")
+	}
+
+	variation, err = h.CatalogService.PutItemVariation(ctx, variation)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -75,6 +85,7 @@ func (h *Handler) HandleUpdateItemVariation(c echo.Context) error {
 		Measurement          *core.MeasurementUnit `json:"measurement"`
 		Image                *string               `json:"image"`
 		MinimumRequiredStock *int64                `json:"minimum_required_stock"`
+		StockPolicy          *core.StockPolicy     `json:"stock_policy" validate:"omitempty,oneof=allow warn block"`
 		LocationIDs          *[]core.ID            `json:"location_ids" validate:"omitempty,dive,required"`
 	}
 
@@ -90,6 +101,14 @@ func (h *Handler) HandleUpdateItemVariation(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateItemVariation(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this item variation")
+	}
+
 	variation, err := h.CatalogService.GetItemVariation(ctx, req.ID)
 	if req.Price != nil {
 		variation.Price = core.Money{
@@ -118,10 +137,21 @@ func (h *Handler) HandleUpdateItemVariation(c echo.Context) error {
 	if req.MinimumRequiredStock != nil {
 		variation.MinimumRequiredStock = *req.MinimumRequiredStock
 	}
+	if req.StockPolicy != nil {
+		variation.StockPolicy = *req.StockPolicy
+	}
 	if req.LocationIDs != nil {
 		variation.LocationIDs = *req.LocationIDs
 	}
 
+	ok, err = h.Authorizer.CanCreateItemVariation(ctx, variation)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage items of these locations")
+	}
+
 	variation, err = h.CatalogService.PutItemVariation(ctx, variation)
 	if err != nil {
 		return errors.E(op, err)
@@ -149,6 +179,14 @@ func (h *Handler) HandleRetrieveItemVariation(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanGetItemVariation(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this item variation")
+	}
+
 	variation, err := h.CatalogService.GetItemVariation(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -182,10 +220,19 @@ func (h *Handler) HandleListItemVariations(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, nil)
+	ok, err := h.Authorizer.CanSearch(ctx, core.CatalogRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the items of these locations")
+	}
+
 	variations, count, err := h.CatalogService.ListItemVariation(ctx, core.ItemVariationQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
-		Filter: core.ItemVariationFilter{MerchantID: merchant.ID},
+		Filter: core.ItemVariationFilter{LocationIDs: locationIDs, MerchantID: merchant.ID},
 	})
 	if err != nil {
 		return errors.E(op, err)
@@ -239,12 +286,21 @@ func (h *Handler) HandleSearchItemVariation(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.CatalogRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the items of these locations")
+	}
+
 	variations, count, err := h.CatalogService.ListItemVariation(ctx, core.ItemVariationQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
 		Filter: core.ItemVariationFilter{
 			Name:        req.Filter.Name,
-			LocationIDs: req.Filter.LocationIDs,
+			LocationIDs: locationIDs,
 			MerchantID:  merchant.ID,
 		},
 		Sort: core.ItemVariationSort{
@@ -285,6 +341,14 @@ func (h *Handler) HandleDeleteItemVariation(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanUpdateItemVariation(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this item variation")
+	}
+
 	variation, err := h.CatalogService.DeleteItemVariation(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -303,6 +367,7 @@ type ItemVariation struct {
 	Image                string               `json:"image,omitempty"`
 	Measurement          core.MeasurementUnit `json:"measurement"`
 	MinimumRequiredStock int64                `json:"minimum_required_stock"`
+	StockPolicy          core.StockPolicy     `json:"stock_policy,omitempty"`
 	LocationIDs          []core.ID            `json:"location_ids"`
 	MerchantID           core.ID              `json:"merchant_id"`
 	CreatedAt            int64                `json:"created_at"`
@@ -325,6 +390,7 @@ func NewItemVariation(variation core.ItemVariation) ItemVariation {
 		Image:                variation.Image,
 		Measurement:          variation.Measurement,
 		MinimumRequiredStock: variation.MinimumRequiredStock,
+		StockPolicy:          variation.StockPolicy,
 		LocationIDs:          variation.LocationIDs,
 		MerchantID:           variation.MerchantID,
 		CreatedAt:            variation.CreatedAt,
@@ -38,7 +38,15 @@ func (h *Handler) HandleCreateLocation(c echo.Context) error {
 	location.BusinessName = req.BusinessName
 	location.Image = req.Image
 
-	location, err := h.LocationService.CreateLocation(ctx, location)
+	ok, err := h.Authorizer.CanCreateLocation(ctx, location)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to create locations")
+	}
+
+	location, err = h.LocationService.CreateLocation(ctx, location)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -68,6 +76,14 @@ func (h *Handler) HandleUpdateLocation(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateLocation(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this location")
+	}
+
 	location, err := h.LocationService.GetLocation(ctx, req.ID)
 	if err != nil {
 		return err
@@ -109,6 +125,14 @@ func (h *Handler) HandleRetrieveLocation(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanGetLocation(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this location")
+	}
+
 	location, err := h.LocationService.GetLocation(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -160,11 +184,20 @@ func (h *Handler) HandleSearchLocation(c echo.Context) error {
 		limit = LocationListMaxSize
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.IDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.LocationRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search these locations")
+	}
+
 	locations, count, err := h.LocationService.ListLocation(ctx, core.LocationQuery{
 		Limit:  limit,
 		Offset: req.Offset,
 		Filter: core.LocationFilter{
-			IDs:        req.Filter.IDs,
+			IDs:        locationIDs,
 			Name:       req.Filter.Name,
 			MerchantID: merchant.ID,
 		},
@@ -219,10 +252,20 @@ func (h *Handler) HandleListLocations(c echo.Context) error {
 		limit = LocationListMaxSize
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, nil)
+	ok, err := h.Authorizer.CanSearch(ctx, core.LocationRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search these locations")
+	}
+
 	locations, count, err := h.LocationService.ListLocation(ctx, core.LocationQuery{
 		Limit:  limit,
 		Offset: req.Offset,
 		Filter: core.LocationFilter{
+			IDs:        locationIDs,
 			MerchantID: merchant.ID,
 		},
 	})
@@ -260,6 +303,14 @@ func (h *Handler) HandleDeleteLocation(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanUpdateLocation(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this location")
+	}
+
 	location, err := h.LocationService.DeleteLocation(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
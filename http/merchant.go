@@ -1,6 +1,7 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/backium/backend/core"
@@ -12,30 +13,85 @@ func (h *Handler) HandleCreateAPIKey(c echo.Context) error {
 	const op = errors.Op("http/Handler.CreateAPIKey")
 
 	type request struct {
-		Name string `json:"name" validate:"required"`
+		Name   string            `json:"name" validate:"required"`
+		Scopes []core.Permission `json:"scopes"`
+	}
+
+	type response struct {
+		APIKey
+		// Only returned on creation
+		Token string `json:"token"`
 	}
 
 	ctx := c.Request().Context()
 
-	merchant := core.MerchantFromContext(ctx)
-	if merchant == nil {
-		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	for _, scope := range req.Scopes {
+		if ok := scope.Validate(); !ok {
+			msg := fmt.Sprintf("request field 'scopes' is not valid, it should be one of: %v", core.Permissions())
+			return errors.E(op, errors.KindValidation, msg)
+		}
+	}
+	if req.Scopes == nil {
+		req.Scopes = []core.Permission{}
+	}
+
+	key, token, err := h.MerchantService.CreateKey(ctx, req.Name, req.Scopes)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		APIKey: NewAPIKey(key),
+		Token:  token,
+	})
+}
+
+func (h *Handler) HandleListAPIKeys(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleListAPIKeys")
+
+	type response struct {
+		Keys []APIKey `json:"keys"`
+	}
+
+	ctx := c.Request().Context()
+
+	keys, err := h.MerchantService.ListKeys(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{Keys: make([]APIKey, len(keys))}
+	for i, k := range keys {
+		resp.Keys[i] = NewAPIKey(k)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleRevokeAPIKey(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRevokeAPIKey")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
 	}
 
+	ctx := c.Request().Context()
+
 	req := request{}
-	if err := c.Bind(&req); err != nil {
+	if err := bindAndValidate(c, &req); err != nil {
 		return err
 	}
 
-	key, err := h.MerchantService.CreateKey(ctx, req.Name, merchant.ID)
+	key, err := h.MerchantService.RevokeKey(ctx, req.ID)
 	if err != nil {
-		return err
+		return errors.E(op, err)
 	}
 
-	return c.JSON(http.StatusOK, APIKey{
-		Name:  key.Name,
-		Token: key.Token,
-	})
+	return c.JSON(http.StatusOK, NewAPIKey(key))
 }
 
 func (h *Handler) HandleRetrieveMerchant(c echo.Context) error {
@@ -51,25 +107,93 @@ func (h *Handler) HandleRetrieveMerchant(c echo.Context) error {
 	return c.JSON(http.StatusOK, NewMerchant(*merchant))
 }
 
+func (h *Handler) HandleUpdateMerchant(c echo.Context) error {
+	const op = errors.Op("http/Handler.UpdateMerchant")
+
+	type request struct {
+		ID           core.ID           `param:"id" validate:"required"`
+		BusinessName *string           `json:"business_name" validate:"omitempty,min=1"`
+		StockPolicy  *core.StockPolicy `json:"stock_policy" validate:"omitempty,oneof=allow warn block"`
+		// Require two-factor authentication to managers
+		RequireTwoFactor *bool `json:"require_two_factor"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if req.ID != merchant.ID {
+		return errors.E(op, errors.KindNotFound, "merchant not found")
+	}
+
+	updated := *merchant
+	if req.BusinessName != nil {
+		updated.BusinessName = *req.BusinessName
+	}
+	if req.StockPolicy != nil {
+		updated.StockPolicy = *req.StockPolicy
+	}
+	if req.RequireTwoFactor != nil {
+		updated.RequireTwoFactor = *req.RequireTwoFactor
+	}
+
+	updated, err := h.MerchantService.UpdateSettings(ctx, updated)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewMerchant(updated))
+}
+
 type Merchant struct {
-	ID           core.ID       `json:"id"`
-	FirstName    string        `json:"first_name"`
-	LastName     string        `json:"last_name"`
-	BusinessName string        `json:"business_name"`
-	Currency     core.Currency `json:"currency"`
+	ID               core.ID          `json:"id"`
+	FirstName        string           `json:"first_name"`
+	LastName         string           `json:"last_name"`
+	BusinessName     string           `json:"business_name"`
+	Currency         core.Currency    `json:"currency"`
+	StockPolicy      core.StockPolicy `json:"stock_policy"`
+	RequireTwoFactor bool             `json:"require_two_factor"`
 }
 
 func NewMerchant(m core.Merchant) Merchant {
 	return Merchant{
-		ID:           m.ID,
-		FirstName:    m.FirstName,
-		LastName:     m.LastName,
-		BusinessName: m.BusinessName,
-		Currency:     m.Currency,
+		ID:               m.ID,
+		FirstName:        m.FirstName,
+		LastName:         m.LastName,
+		BusinessName:     m.BusinessName,
+		Currency:         m.Currency,
+		StockPolicy:      m.StockPolicy,
+		RequireTwoFactor: m.RequireTwoFactor,
 	}
 }
 
 type APIKey struct {
-	Name  string  `json:"name"`
-	Token core.ID `json:"token"`
+	ID         core.ID           `json:"id"`
+	Name       string            `json:"name"`
+	Prefix     string            `json:"prefix"`
+	Scopes     []core.Permission `json:"scopes"`
+	CreatedBy  core.ID           `json:"created_by"`
+	CreatedAt  int64             `json:"created_at"`
+	LastUsedAt int64             `json:"last_used_at"`
+	RevokedAt  int64             `json:"revoked_at"`
+}
+
+func NewAPIKey(key core.Key) APIKey {
+	return APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
 }
@@ -49,9 +49,18 @@ func (h *Handler) HandleExportOrders(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.OrderRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to export the orders of these locations")
+	}
+
 	url, err := h.ExportService.ExportOrders(ctx, core.OrderQuery{
 		Filter: core.OrderFilter{
-			LocationIDs:  req.Filter.LocationIDs,
+			LocationIDs:  locationIDs,
 			EmployeeIDs:  req.Filter.EmployeeIDs,
 			MerchantID:   merchant.ID,
 			PaymentTypes: req.Filter.PaymentTypes,
@@ -94,6 +103,14 @@ func (h *Handler) HandleGenerateReceipt(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanGetOrder(ctx, req.OrderID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this order")
+	}
+
 	url, err := h.OrderingService.GenerateOrderReceipt(ctx, req.OrderID)
 	if err != nil {
 		return errors.E(op, err)
@@ -152,11 +169,20 @@ func (h *Handler) HandleSearchOrder(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.OrderRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the orders of these locations")
+	}
+
 	orders, count, err := h.OrderingService.ListOrder(ctx, core.OrderQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
 		Filter: core.OrderFilter{
-			LocationIDs:  req.Filter.LocationIDs,
+			LocationIDs:  locationIDs,
 			EmployeeIDs:  req.Filter.EmployeeIDs,
 			CustomerIDs:  req.Filter.CustomerIDs,
 			MerchantID:   merchant.ID,
@@ -257,6 +283,14 @@ func (h *Handler) HandleCalculateOrder(c echo.Context) error {
 		})
 	}
 
+	ok, err := h.Authorizer.CanCreateOrder(ctx, schema)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to take orders at this location")
+	}
+
 	order, err := h.OrderingService.CalculateOrder(ctx, schema)
 	if err != nil {
 		return errors.E(op, err)
@@ -331,6 +365,14 @@ func (h *Handler) HandleCreateOrder(c echo.Context) error {
 		})
 	}
 
+	ok, err := h.Authorizer.CanCreateOrder(ctx, schema)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to take orders at this location")
+	}
+
 	order, err := h.OrderingService.CreateOrder(ctx, schema)
 	if err != nil {
 		return errors.E(op, err)
@@ -354,6 +396,14 @@ func (h *Handler) HandleCancelOrder(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateOrder(ctx, req.OrderID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this order")
+	}
+
 	order, err := h.OrderingService.CancelOrder(ctx, req.OrderID, req.Reason)
 	if err != nil {
 		return errors.E(op, err)
@@ -382,6 +432,14 @@ func (h *Handler) HandlePayOrder(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateOrder(ctx, req.OrderID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this order")
+	}
+
 	order, err := h.OrderingService.PayOrder(ctx, req.OrderID, req.PaymentIDs)
 	if err != nil {
 		return errors.E(op, err)
@@ -391,22 +449,24 @@ func (h *Handler) HandlePayOrder(c echo.Context) error {
 }
 
 type Order struct {
-	ID                  core.ID            `json:"id"`
-	Items               []OrderItem        `json:"items"`
-	TotalAmount         MoneyRequest       `json:"total_amount"`
-	TotalDiscountAmount MoneyRequest       `json:"total_discount_amount"`
-	TotalTaxAmount      MoneyRequest       `json:"total_tax_amount"`
-	Taxes               []OrderTax         `json:"taxes"`
-	Discounts           []OrderDiscount    `json:"discounts"`
-	State               core.OrderState    `json:"state"`
-	PaymentTypes        []core.PaymentType `json:"payment_types"`
-	CancelReason        string             `json:"cancel_reason"`
-	EmployeeID          core.ID            `json:"employee_id"`
-	CustomerID          core.ID            `json:"customer_id,omitempty"`
-	LocationID          core.ID            `json:"location_id"`
-	MerchantID          core.ID            `json:"merchant_id"`
-	CreatedAt           int64              `json:"created_at,omitempty"`
-	UpdatedAt           int64              `json:"updated_at,omitempty"`
+	ID                  core.ID             `json:"id"`
+	Items               []OrderItem         `json:"items"`
+	TotalAmount         MoneyRequest        `json:"total_amount"`
+	TotalDiscountAmount MoneyRequest        `json:"total_discount_amount"`
+	TotalTaxAmount      MoneyRequest        `json:"total_tax_amount"`
+	Taxes               []OrderTax          `json:"taxes"`
+	Discounts           []OrderDiscount     `json:"discounts"`
+	State               core.OrderState     `json:"state"`
+	PaymentTypes        []core.PaymentType  `json:"payment_types"`
+	CancelReason        string              `json:"cancel_reason"`
+	CanceledBy          core.ID             `json:"canceled_by,omitempty"`
+	InsufficientStock   []InsufficientStock `json:"insufficient_stock,omitempty"`
+	EmployeeID          core.ID             `json:"employee_id"`
+	CustomerID          core.ID             `json:"customer_id,omitempty"`
+	LocationID          core.ID             `json:"location_id"`
+	MerchantID          core.ID             `json:"merchant_id"`
+	CreatedAt           int64               `json:"created_at,omitempty"`
+	UpdatedAt           int64               `json:"updated_at,omitempty"`
 }
 
 func NewOrder(order core.Order) Order {
@@ -440,15 +500,41 @@ func NewOrder(order core.Order) Order {
 			Value:    ptr.Int64(order.TotalAmount.Value),
 			Currency: order.TotalAmount.Currency,
 		},
-		PaymentTypes: order.PaymentTypes,
-		CancelReason: order.CancelReason,
-		EmployeeID:   order.EmployeeID,
-		CustomerID:   order.CustomerID,
-		LocationID:   order.LocationID,
-		MerchantID:   order.MerchantID,
-		CreatedAt:    order.CreatedAt,
-		UpdatedAt:    order.UpdatedAt,
+		PaymentTypes:      order.PaymentTypes,
+		CancelReason:      order.CancelReason,
+		CanceledBy:        order.CanceledBy,
+		InsufficientStock: NewInsufficientStock(order.InsufficientStock),
+		EmployeeID:        order.EmployeeID,
+		CustomerID:        order.CustomerID,
+		LocationID:        order.LocationID,
+		MerchantID:        order.MerchantID,
+		CreatedAt:         order.CreatedAt,
+		UpdatedAt:         order.UpdatedAt,
+	}
+}
+
+type InsufficientStock struct {
+	UID             string           `json:"uid"`
+	ItemVariationID core.ID          `json:"item_variation_id"`
+	Name            string           `json:"name"`
+	Requested       int64            `json:"requested"`
+	Available       int64            `json:"available"`
+	Policy          core.StockPolicy `json:"policy"`
+}
+
+func NewInsufficientStock(lines []core.InsufficientStock) []InsufficientStock {
+	resp := make([]InsufficientStock, len(lines))
+	for i, line := range lines {
+		resp[i] = InsufficientStock{
+			UID:             line.UID,
+			ItemVariationID: line.ItemVariationID,
+			Name:            line.Name,
+			Requested:       line.Requested,
+			Available:       line.Available,
+			Policy:          line.Policy,
+		}
 	}
+	return resp
 }
 
 type OrderItem struct {
@@ -13,11 +13,12 @@ func (h *Handler) HandleCreatePayment(c echo.Context) error {
 	const op = errors.Op("http/Handler.CreatePayment")
 
 	type request struct {
-		OrderID    core.ID          `json:"order_id" validate:"required"`
-		Type       core.PaymentType `json:"type" validate:"required"`
-		Amount     *MoneyRequest    `json:"amount" validate:"required,dive"`
-		TipAmount  *MoneyRequest    `json:"tip_amount" validate:"omitempty,dive"`
-		LocationID core.ID          `json:"location_id" validate:"required"`
+		OrderID      core.ID          `json:"order_id" validate:"required"`
+		Type         core.PaymentType `json:"type" validate:"required"`
+		Amount       *MoneyRequest    `json:"amount" validate:"required,dive"`
+		TipAmount    *MoneyRequest    `json:"tip_amount" validate:"omitempty,dive"`
+		CashDrawerID core.ID          `json:"cash_drawer_id"`
+		LocationID   core.ID          `json:"location_id" validate:"required"`
 	}
 
 	ctx := c.Request().Context()
@@ -35,11 +36,20 @@ func (h *Handler) HandleCreatePayment(c echo.Context) error {
 	payment := core.NewPayment(req.Type, req.OrderID, merchant.ID, req.LocationID)
 	payment.Amount = core.NewMoney(*req.Amount.Value, req.Amount.Currency)
 	payment.TipAmount = core.NewMoney(0, req.Amount.Currency)
+	payment.CashDrawerID = req.CashDrawerID
 	if req.TipAmount != nil {
 		payment.TipAmount = core.NewMoney(*req.TipAmount.Value, req.TipAmount.Currency)
 	}
 
-	payment, err := h.PaymentService.CreatePayment(ctx, payment)
+	ok, err := h.Authorizer.CanCreatePayment(ctx, payment)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to take payments for this order")
+	}
+
+	payment, err = h.PaymentService.CreatePayment(ctx, payment)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -56,11 +66,12 @@ func (h *Handler) HandleSearchPayment(c echo.Context) error {
 	}
 
 	type filter struct {
-		IDs         []core.ID          `json:"ids" validate:"omitempty,dive,id"`
-		OrderIDs    []core.ID          `json:"order_ids" validate:"omitempty,dive,id"`
-		LocationIDs []core.ID          `json:"location_ids" validate:"omitempty,dive,id"`
-		Types       []core.PaymentType `json:"types"`
-		CreatedAt   dateFilter         `json:"created_at"`
+		IDs           []core.ID          `json:"ids" validate:"omitempty,dive,id"`
+		OrderIDs      []core.ID          `json:"order_ids" validate:"omitempty,dive,id"`
+		LocationIDs   []core.ID          `json:"location_ids" validate:"omitempty,dive,id"`
+		CashDrawerIDs []core.ID          `json:"cash_drawer_ids" validate:"omitempty,dive,id"`
+		Types         []core.PaymentType `json:"types"`
+		CreatedAt     dateFilter         `json:"created_at"`
 	}
 
 	type sort struct {
@@ -91,14 +102,24 @@ func (h *Handler) HandleSearchPayment(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.OrderRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the payments of these locations")
+	}
+
 	payments, count, err := h.PaymentService.ListPayment(ctx, core.PaymentQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
 		Filter: core.PaymentFilter{
-			OrderIDs:    req.Filter.OrderIDs,
-			LocationIDs: req.Filter.LocationIDs,
-			Types:       req.Filter.Types,
-			MerchantID:  merchant.ID,
+			OrderIDs:      req.Filter.OrderIDs,
+			LocationIDs:   locationIDs,
+			CashDrawerIDs: req.Filter.CashDrawerIDs,
+			Types:         req.Filter.Types,
+			MerchantID:    merchant.ID,
 			CreatedAt: core.DateFilter{
 				Gte: req.Filter.CreatedAt.Gte,
 				Lte: req.Filter.CreatedAt.Lte,
@@ -124,14 +145,15 @@ func (h *Handler) HandleSearchPayment(c echo.Context) error {
 }
 
 type Payment struct {
-	ID         core.ID          `json:"id"`
-	OrderID    core.ID          `json:"order_id"`
-	Type       core.PaymentType `json:"type"`
-	Amount     MoneyRequest     `json:"amount"`
-	TipAmount  MoneyRequest     `json:"tip_amount"`
-	LocationID core.ID          `json:"location_id"`
-	CreatedAt  int64            `json:"created_at"`
-	UpdatedAt  int64            `json:"updated_at"`
+	ID           core.ID          `json:"id"`
+	OrderID      core.ID          `json:"order_id"`
+	Type         core.PaymentType `json:"type"`
+	Amount       MoneyRequest     `json:"amount"`
+	TipAmount    MoneyRequest     `json:"tip_amount"`
+	CashDrawerID core.ID          `json:"cash_drawer_id,omitempty"`
+	LocationID   core.ID          `json:"location_id"`
+	CreatedAt    int64            `json:"created_at"`
+	UpdatedAt    int64            `json:"updated_at"`
 }
 
 func NewPayment(payment core.Payment) Payment {
@@ -147,8 +169,9 @@ func NewPayment(payment core.Payment) Payment {
 			Value:    ptr.Int64(payment.TipAmount.Value),
 			Currency: payment.TipAmount.Currency,
 		},
-		LocationID: payment.LocationID,
-		CreatedAt:  payment.CreatedAt,
-		UpdatedAt:  payment.UpdatedAt,
+		CashDrawerID: payment.CashDrawerID,
+		LocationID:   payment.LocationID,
+		CreatedAt:    payment.CreatedAt,
+		UpdatedAt:    payment.UpdatedAt,
 	}
 }
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/labstack/echo/v4"
+)
+
+type PayrollRequest struct {
+	EmployeeIDs []core.ID             `json:"employee_ids" validate:"omitempty,dive,id"`
+	BeginDate   string                `json:"begin_date" validate:"required"`
+	EndDate     string                `json:"end_date" validate:"required"`
+	Timezone    string                `json:"timezone" validate:"required"`
+	TipSource   core.PayrollTipSource `json:"tip_source"`
+}
+
+func (req PayrollRequest) PayrollRequest(merchantID core.ID) (core.PayrollRequest, error) {
+	const op = errors.Op("http/PayrollRequest.PayrollRequest")
+
+	if req.TipSource != "" {
+		if ok := req.TipSource.Validate(); !ok {
+			msg := fmt.Sprintf("request field 'tip_source' is not valid, it should be one of: %v",
+				core.PayrollTipSources())
+			return core.PayrollRequest{}, errors.E(op, errors.KindValidation, msg)
+		}
+	}
+
+	return core.PayrollRequest{
+		MerchantID:  merchantID,
+		EmployeeIDs: req.EmployeeIDs,
+		BeginDate:   req.BeginDate,
+		EndDate:     req.EndDate,
+		Timezone:    req.Timezone,
+		TipSource:   req.TipSource,
+	}, nil
+}
+
+func (h *Handler) HandleRunPayroll(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRunPayroll")
+
+	type response struct {
+		Payroll Payroll `json:"payroll"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := PayrollRequest{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	payrollReq, err := req.PayrollRequest(merchant.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	payroll, err := h.PayrollService.RunPayroll(ctx, payrollReq)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, response{Payroll: NewPayroll(payroll)})
+}
+
+func (h *Handler) HandleExportPayroll(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleExportPayroll")
+
+	type response struct {
+		URL string `json:"url"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := PayrollRequest{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	payrollReq, err := req.PayrollRequest(merchant.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	url, err := h.PayrollService.ExportPayroll(ctx, payrollReq)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, response{URL: url})
+}
+
+type PayrollLine struct {
+	EmployeeID     core.ID `json:"employee_id"`
+	EmployeeName   string  `json:"employee_name"`
+	SalaryAmount   Money   `json:"salary_amount"`
+	RegularHours   float64 `json:"regular_hours"`
+	OvertimeHours  float64 `json:"overtime_hours"`
+	RegularAmount  Money   `json:"regular_amount"`
+	OvertimeAmount Money   `json:"overtime_amount"`
+	TipAmount      Money   `json:"tip_amount"`
+	TotalAmount    Money   `json:"total_amount"`
+}
+
+type Payroll struct {
+	BeginDate   string        `json:"begin_date"`
+	EndDate     string        `json:"end_date"`
+	BeginTime   int64         `json:"begin_time"`
+	EndTime     int64         `json:"end_time"`
+	Lines       []PayrollLine `json:"lines"`
+	TotalAmount Money         `json:"total_amount"`
+}
+
+func NewPayroll(payroll core.Payroll) Payroll {
+	lines := make([]PayrollLine, len(payroll.Lines))
+	for i, l := range payroll.Lines {
+		lines[i] = PayrollLine{
+			EmployeeID:     l.EmployeeID,
+			EmployeeName:   l.EmployeeName,
+			SalaryAmount:   NewMoney(l.SalaryAmount),
+			RegularHours:   l.RegularHours,
+			OvertimeHours:  l.OvertimeHours,
+			RegularAmount:  NewMoney(l.RegularAmount),
+			OvertimeAmount: NewMoney(l.OvertimeAmount),
+			TipAmount:      NewMoney(l.TipAmount),
+			TotalAmount:    NewMoney(l.TotalAmount),
+		}
+	}
+	return Payroll{
+		BeginDate:   payroll.BeginDate,
+		EndDate:     payroll.EndDate,
+		BeginTime:   payroll.BeginTime,
+		EndTime:     payroll.EndTime,
+		Lines:       lines,
+		TotalAmount: NewMoney(payroll.TotalAmount),
+	}
+}
@@ -15,6 +15,7 @@ func (h *Handler) HandleGenerateStockReport(c echo.Context) error {
 	type request struct {
 		LocationIDs      []core.ID `json:"location_ids" validate:"omitempty,dive,required"`
 		ItemVariationIDs []core.ID `json:"item_variation_ids" validate:"omitempty,dive,required"`
+		At               int64     `json:"at" validate:"gte=0"`
 	}
 
 	type response struct {
@@ -33,10 +34,20 @@ func (h *Handler) HandleGenerateStockReport(c echo.Context) error {
 		return errors.E(op, err)
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.LocationIDs)
+	ok, err := h.Authorizer.CanGenerateReport(ctx, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see the reports of these locations")
+	}
+
 	report, err := h.ReportService.GenerateStockReport(ctx, core.StockReportRequest{
+		At: req.At,
 		Filter: core.StockFilter{
 			MerchantID:       merchant.ID,
-			LocationIDs:      req.LocationIDs,
+			LocationIDs:      locationIDs,
 			ItemVariationIDs: req.ItemVariationIDs,
 		},
 	})
@@ -51,6 +62,382 @@ func (h *Handler) HandleGenerateStockReport(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+func (h *Handler) HandleGenerateExpiringStockReport(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleGenerateExpiringStockReport")
+
+	type request struct {
+		Days             int64     `json:"days" validate:"gte=0"`
+		LocationIDs      []core.ID `json:"location_ids" validate:"omitempty,dive,required"`
+		ItemVariationIDs []core.ID `json:"item_variation_ids" validate:"omitempty,dive,required"`
+	}
+
+	type response struct {
+		Report ExpiringStockReport `json:"report"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return errors.E(op, err)
+	}
+
+	locationIDs := h.Authorizer.LocationScope(ctx, req.LocationIDs)
+	ok, err := h.Authorizer.CanGenerateReport(ctx, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see the reports of these locations")
+	}
+
+	report, err := h.ReportService.GenerateExpiringStockReport(ctx, core.ExpiringStockReportRequest{
+		Days: req.Days,
+		Filter: core.StockFilter{
+			MerchantID:       merchant.ID,
+			LocationIDs:      locationIDs,
+			ItemVariationIDs: req.ItemVariationIDs,
+		},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Report: NewExpiringStockReport(report),
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleGenerateShrinkageReport(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleGenerateShrinkageReport")
+
+	type request struct {
+		Reasons          []core.InventoryReason `json:"reasons" validate:"omitempty,dive,required"`
+		LocationIDs      []core.ID              `json:"location_ids" validate:"omitempty,dive,required"`
+		ItemVariationIDs []core.ID              `json:"item_variation_ids" validate:"omitempty,dive,required"`
+		BeginTime        int64                  `json:"begin_time" validate:"gte=0"`
+		EndTime          int64                  `json:"end_time" validate:"gte=0"`
+	}
+
+	type response struct {
+		Report ShrinkageReport `json:"report"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return errors.E(op, err)
+	}
+
+	for i, reason := range req.Reasons {
+		if ok := reason.Validate(); !ok {
+			msg := fmt.Sprintf("request field 'reasons[%v]' is not valid, it should be one of: %v",
+				i,
+				core.InventoryReasons())
+			return errors.E(op, errors.KindValidation, msg)
+		}
+	}
+
+	locationIDs := h.Authorizer.LocationScope(ctx, req.LocationIDs)
+	ok, err := h.Authorizer.CanGenerateReport(ctx, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see the reports of these locations")
+	}
+
+	report, err := h.ReportService.GenerateShrinkageReport(ctx, core.ShrinkageReportRequest{
+		Reasons:   req.Reasons,
+		BeginTime: req.BeginTime,
+		EndTime:   req.EndTime,
+		Filter: core.StockFilter{
+			MerchantID:       merchant.ID,
+			LocationIDs:      locationIDs,
+			ItemVariationIDs: req.ItemVariationIDs,
+		},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Report: NewShrinkageReport(report),
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleGenerateCashDrawerReport(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleGenerateCashDrawerReport")
+
+	type request struct {
+		LocationIDs   []core.ID `json:"location_ids" validate:"omitempty,dive,required"`
+		CashDrawerIDs []core.ID `json:"cash_drawer_ids" validate:"omitempty,dive,required"`
+		BeginTime     int64     `json:"begin_time" validate:"gte=0"`
+		EndTime       int64     `json:"end_time" validate:"gte=0"`
+	}
+
+	type response struct {
+		Report CashDrawerReport `json:"report"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return errors.E(op, err)
+	}
+
+	locationIDs := h.Authorizer.LocationScope(ctx, req.LocationIDs)
+	ok, err := h.Authorizer.CanGenerateReport(ctx, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see the reports of these locations")
+	}
+
+	report, err := h.ReportService.GenerateCashDrawerReport(ctx, core.CashDrawerReportRequest{
+		MerchantID:    merchant.ID,
+		LocationIDs:   locationIDs,
+		CashDrawerIDs: req.CashDrawerIDs,
+		BeginTime:     req.BeginTime,
+		EndTime:       req.EndTime,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Report: NewCashDrawerReport(report),
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleGenerateTipReport(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleGenerateTipReport")
+
+	type request struct {
+		LocationIDs []core.ID `json:"location_ids" validate:"omitempty,dive,required"`
+		EmployeeIDs []core.ID `json:"employee_ids" validate:"omitempty,dive,required"`
+		BeginTime   int64     `json:"begin_time" validate:"gte=0"`
+		EndTime     int64     `json:"end_time" validate:"gte=0"`
+	}
+
+	type response struct {
+		Report TipReport `json:"report"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return errors.E(op, err)
+	}
+
+	locationIDs := h.Authorizer.LocationScope(ctx, req.LocationIDs)
+	ok, err := h.Authorizer.CanGenerateReport(ctx, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see the reports of these locations")
+	}
+
+	report, err := h.ReportService.GenerateTipReport(ctx, core.TipReportRequest{
+		MerchantID:  merchant.ID,
+		LocationIDs: locationIDs,
+		EmployeeIDs: req.EmployeeIDs,
+		BeginTime:   req.BeginTime,
+		EndTime:     req.EndTime,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Report: NewTipReport(report),
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleGenerateEmployeePerformanceReport(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleGenerateEmployeePerformanceReport")
+
+	type request struct {
+		LocationIDs []core.ID `json:"location_ids" validate:"omitempty,dive,required"`
+		EmployeeIDs []core.ID `json:"employee_ids" validate:"omitempty,dive,required"`
+		BeginTime   int64     `json:"begin_time" validate:"gte=0"`
+		EndTime     int64     `json:"end_time" validate:"gte=0"`
+	}
+
+	type response struct {
+		Report EmployeePerformanceReport `json:"report"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return errors.E(op, err)
+	}
+
+	locationIDs := h.Authorizer.LocationScope(ctx, req.LocationIDs)
+	ok, err := h.Authorizer.CanGenerateReport(ctx, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see the reports of these locations")
+	}
+
+	report, err := h.ReportService.GenerateEmployeePerformanceReport(ctx, core.EmployeePerformanceRequest{
+		MerchantID:  merchant.ID,
+		LocationIDs: locationIDs,
+		EmployeeIDs: req.EmployeeIDs,
+		BeginTime:   req.BeginTime,
+		EndTime:     req.EndTime,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Report: NewEmployeePerformanceReport(report),
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleGenerateZReport(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleGenerateZReport")
+
+	type request struct {
+		LocationID core.ID `json:"location_id" validate:"required"`
+		Date       string  `json:"date" validate:"required"`
+		Timezone   string  `json:"timezone" validate:"required"`
+	}
+
+	type response struct {
+		Report ZReport `json:"report"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return errors.E(op, err)
+	}
+
+	ok, err := h.Authorizer.CanGenerateReport(ctx, []core.ID{req.LocationID})
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see the reports of this location")
+	}
+
+	report, err := h.ReportService.GenerateZReport(ctx, core.ZReportRequest{
+		LocationID: req.LocationID,
+		MerchantID: merchant.ID,
+		Date:       req.Date,
+		Timezone:   req.Timezone,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Report: NewZReport(report),
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleGenerateZReportPDF(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleGenerateZReportPDF")
+
+	type request struct {
+		LocationID core.ID `json:"location_id" validate:"required"`
+		Date       string  `json:"date" validate:"required"`
+		Timezone   string  `json:"timezone" validate:"required"`
+	}
+
+	type response struct {
+		URL string `json:"url"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return errors.E(op, err)
+	}
+
+	ok, err := h.Authorizer.CanGenerateReport(ctx, []core.ID{req.LocationID})
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see the reports of this location")
+	}
+
+	url, err := h.ReportService.GenerateZReportPDF(ctx, core.ZReportRequest{
+		LocationID: req.LocationID,
+		MerchantID: merchant.ID,
+		Date:       req.Date,
+		Timezone:   req.Timezone,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{URL: url}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
 func (h *Handler) HandleGenerateCustomReport(c echo.Context) error {
 	const op = errors.Op("http/Handler.HandleGenerateCustomReport")
 
@@ -91,12 +478,21 @@ func (h *Handler) HandleGenerateCustomReport(c echo.Context) error {
 		}
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.LocationIDs)
+	ok, err := h.Authorizer.CanGenerateReport(ctx, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see the reports of these locations")
+	}
+
 	reports, err := h.ReportService.GenerateCustom(ctx, core.CustomReportRequest{
 		GroupType: req.GroupByType,
 		Timezone:  req.Timezone,
 		Filter: core.ReportFilter{
 			MerchantID:   merchant.ID,
-			LocationIDs:  req.LocationIDs,
+			LocationIDs:  locationIDs,
 			EmployeeIDs:  req.EmployeeIDs,
 			CustomerIDs:  req.CustomerIDs,
 			OrderStates:  req.OrderStates,
@@ -146,6 +542,64 @@ func NewStockReport(report core.StockReport) StockReport {
 	}
 }
 
+type ExpiringStock struct {
+	ItemVariationID core.ID `json:"item_variation_id"`
+	Name            string  `json:"name"`
+	LocationID      core.ID `json:"location_id"`
+	LotNumber       string  `json:"lot_number"`
+	ExpiresAt       int64   `json:"expires_at"`
+	Quantity        int64   `json:"quantity"`
+}
+
+type ExpiringStockReport struct {
+	Lots []ExpiringStock `json:"lots"`
+}
+
+func NewExpiringStockReport(report core.ExpiringStockReport) ExpiringStockReport {
+	lots := make([]ExpiringStock, len(report.Lots))
+	for i, lot := range report.Lots {
+		lots[i] = ExpiringStock{
+			ItemVariationID: lot.ItemVariationID,
+			Name:            lot.Name,
+			LocationID:      lot.LocationID,
+			LotNumber:       lot.LotNumber,
+			ExpiresAt:       lot.ExpiresAt,
+			Quantity:        lot.Quantity,
+		}
+	}
+	return ExpiringStockReport{Lots: lots}
+}
+
+type ShrinkageGroup struct {
+	Reason          core.InventoryReason `json:"reason"`
+	AdjustmentCount int64                `json:"adjustment_count"`
+	Quantity        int64                `json:"quantity"`
+	CostAmount      Money                `json:"cost_amount"`
+}
+
+type ShrinkageReport struct {
+	Groups          []ShrinkageGroup `json:"groups"`
+	TotalQuantity   int64            `json:"total_quantity"`
+	TotalCostAmount Money            `json:"total_cost_amount"`
+}
+
+func NewShrinkageReport(report core.ShrinkageReport) ShrinkageReport {
+	groups := make([]ShrinkageGroup, len(report.Groups))
+	for i, group := range report.Groups {
+		groups[i] = ShrinkageGroup{
+			Reason:          group.Reason,
+			AdjustmentCount: group.AdjustmentCount,
+			Quantity:        group.Quantity,
+			CostAmount:      NewMoney(group.CostAmount),
+		}
+	}
+	return ShrinkageReport{
+		Groups:          groups,
+		TotalQuantity:   report.TotalQuantity,
+		TotalCostAmount: NewMoney(report.TotalCostAmount),
+	}
+}
+
 type CustomReport struct {
 	GroupType    core.GroupingType `json:"group_type"`
 	GroupValue   string            `json:"group_value"`
@@ -177,3 +631,240 @@ func NewCustomReport(report core.CustomReport) CustomReport {
 		},
 	}
 }
+
+type CashDrawerSummary struct {
+	CashDrawerID      core.ID `json:"cash_drawer_id"`
+	Name              string  `json:"name"`
+	LocationID        core.ID `json:"location_id"`
+	Balance           Money   `json:"balance"`
+	CashPaymentCount  int64   `json:"cash_payment_count"`
+	CashPaymentAmount Money   `json:"cash_payment_amount"`
+	PaidInAmount      Money   `json:"paid_in_amount"`
+	PaidOutAmount     Money   `json:"paid_out_amount"`
+}
+
+type CashDrawerReport struct {
+	Drawers []CashDrawerSummary `json:"drawers"`
+}
+
+func NewCashDrawerReport(report core.CashDrawerReport) CashDrawerReport {
+	drawers := make([]CashDrawerSummary, len(report.Drawers))
+	for i, d := range report.Drawers {
+		drawers[i] = CashDrawerSummary{
+			CashDrawerID:      d.CashDrawerID,
+			Name:              d.Name,
+			LocationID:        d.LocationID,
+			Balance:           NewMoney(d.Balance),
+			CashPaymentCount:  d.CashPaymentCount,
+			CashPaymentAmount: NewMoney(d.CashPaymentAmount),
+			PaidInAmount:      NewMoney(d.PaidInAmount),
+			PaidOutAmount:     NewMoney(d.PaidOutAmount),
+		}
+	}
+	return CashDrawerReport{Drawers: drawers}
+}
+
+type ZReportPayment struct {
+	Type      core.PaymentType `json:"type"`
+	Count     int64            `json:"count"`
+	Amount    Money            `json:"amount"`
+	TipAmount Money            `json:"tip_amount"`
+}
+
+type ZReportTax struct {
+	TaxID      core.ID `json:"tax_id"`
+	Name       string  `json:"name"`
+	Percentage float64 `json:"percentage"`
+	Amount     Money   `json:"amount"`
+}
+
+type ZReportDiscount struct {
+	DiscountID core.ID `json:"discount_id"`
+	Name       string  `json:"name"`
+	Count      int64   `json:"count"`
+	Amount     Money   `json:"amount"`
+}
+
+type ZReport struct {
+	LocationID           core.ID           `json:"location_id"`
+	LocationName         string            `json:"location_name"`
+	Date                 string            `json:"date"`
+	BeginTime            int64             `json:"begin_time"`
+	EndTime              int64             `json:"end_time"`
+	OrderCount           int64             `json:"order_count"`
+	GrossSalesAmount     Money             `json:"gross_sales_amount"`
+	DiscountAmount       Money             `json:"discount_amount"`
+	TaxAmount            Money             `json:"tax_amount"`
+	TipAmount            Money             `json:"tip_amount"`
+	TotalAmount          Money             `json:"total_amount"`
+	CanceledOrderCount   int64             `json:"canceled_order_count"`
+	CanceledOrderAmount  Money             `json:"canceled_order_amount"`
+	Payments             []ZReportPayment  `json:"payments"`
+	Refunds              []ZReportPayment  `json:"refunds"`
+	Taxes                []ZReportTax      `json:"taxes"`
+	Discounts            []ZReportDiscount `json:"discounts"`
+	CashExpectedAmount   Money             `json:"cash_expected_amount"`
+	CashCountedAmount    Money             `json:"cash_counted_amount"`
+	CashDifferenceAmount Money             `json:"cash_difference_amount"`
+	OpenShiftCount       int64             `json:"open_shift_count"`
+	FirstReceiptNumber   string            `json:"first_receipt_number"`
+	LastReceiptNumber    string            `json:"last_receipt_number"`
+}
+
+func NewZReport(report core.ZReport) ZReport {
+	payments := make([]ZReportPayment, len(report.Payments))
+	for i, p := range report.Payments {
+		payments[i] = ZReportPayment{
+			Type:      p.Type,
+			Count:     p.Count,
+			Amount:    NewMoney(p.Amount),
+			TipAmount: NewMoney(p.TipAmount),
+		}
+	}
+	refunds := make([]ZReportPayment, len(report.Refunds))
+	for i, p := range report.Refunds {
+		refunds[i] = ZReportPayment{
+			Type:      p.Type,
+			Count:     p.Count,
+			Amount:    NewMoney(p.Amount),
+			TipAmount: NewMoney(p.TipAmount),
+		}
+	}
+	taxes := make([]ZReportTax, len(report.Taxes))
+	for i, t := range report.Taxes {
+		taxes[i] = ZReportTax{
+			TaxID:      t.TaxID,
+			Name:       t.Name,
+			Percentage: t.Percentage,
+			Amount:     NewMoney(t.Amount),
+		}
+	}
+	discounts := make([]ZReportDiscount, len(report.Discounts))
+	for i, d := range report.Discounts {
+		discounts[i] = ZReportDiscount{
+			DiscountID: d.DiscountID,
+			Name:       d.Name,
+			Count:      d.Count,
+			Amount:     NewMoney(d.Amount),
+		}
+	}
+	return ZReport{
+		LocationID:           report.LocationID,
+		LocationName:         report.LocationName,
+		Date:                 report.Date,
+		BeginTime:            report.BeginTime,
+		EndTime:              report.EndTime,
+		OrderCount:           report.OrderCount,
+		GrossSalesAmount:     NewMoney(report.GrossSalesAmount),
+		DiscountAmount:       NewMoney(report.DiscountAmount),
+		TaxAmount:            NewMoney(report.TaxAmount),
+		TipAmount:            NewMoney(report.TipAmount),
+		TotalAmount:          NewMoney(report.TotalAmount),
+		CanceledOrderCount:   report.CanceledOrderCount,
+		CanceledOrderAmount:  NewMoney(report.CanceledOrderAmount),
+		Payments:             payments,
+		Refunds:              refunds,
+		Taxes:                taxes,
+		Discounts:            discounts,
+		CashExpectedAmount:   NewMoney(report.CashExpectedAmount),
+		CashCountedAmount:    NewMoney(report.CashCountedAmount),
+		CashDifferenceAmount: NewMoney(report.CashDifferenceAmount),
+		OpenShiftCount:       report.OpenShiftCount,
+		FirstReceiptNumber:   report.FirstReceiptNumber,
+		LastReceiptNumber:    report.LastReceiptNumber,
+	}
+}
+
+type EmployeeTips struct {
+	EmployeeID   core.ID `json:"employee_id"`
+	PaymentCount int64   `json:"payment_count"`
+	TipAmount    Money   `json:"tip_amount"`
+}
+
+func NewEmployeeTips(tips []core.EmployeeTips) []EmployeeTips {
+	resp := make([]EmployeeTips, len(tips))
+	for i, t := range tips {
+		resp[i] = EmployeeTips{
+			EmployeeID:   t.EmployeeID,
+			PaymentCount: t.PaymentCount,
+			TipAmount:    NewMoney(t.TipAmount),
+		}
+	}
+	return resp
+}
+
+type ShiftTips struct {
+	ShiftID      core.ID        `json:"shift_id"`
+	CashDrawerID core.ID        `json:"cash_drawer_id"`
+	LocationID   core.ID        `json:"location_id"`
+	OpenedAt     int64          `json:"opened_at"`
+	ClosedAt     int64          `json:"closed_at"`
+	TipAmount    Money          `json:"tip_amount"`
+	Employees    []EmployeeTips `json:"employees"`
+}
+
+type TipReport struct {
+	TipAmount Money          `json:"tip_amount"`
+	Employees []EmployeeTips `json:"employees"`
+	Shifts    []ShiftTips    `json:"shifts"`
+}
+
+func NewTipReport(report core.TipReport) TipReport {
+	shifts := make([]ShiftTips, len(report.Shifts))
+	for i, s := range report.Shifts {
+		shifts[i] = ShiftTips{
+			ShiftID:      s.ShiftID,
+			CashDrawerID: s.CashDrawerID,
+			LocationID:   s.LocationID,
+			OpenedAt:     s.OpenedAt,
+			ClosedAt:     s.ClosedAt,
+			TipAmount:    NewMoney(s.TipAmount),
+			Employees:    NewEmployeeTips(s.Employees),
+		}
+	}
+	return TipReport{
+		TipAmount: NewMoney(report.TipAmount),
+		Employees: NewEmployeeTips(report.Employees),
+		Shifts:    shifts,
+	}
+}
+
+type EmployeePerformance struct {
+	EmployeeID          core.ID `json:"employee_id"`
+	OrderCount          int64   `json:"order_count"`
+	GrossSalesAmount    Money   `json:"gross_sales_amount"`
+	SalesAmount         Money   `json:"sales_amount"`
+	AverageTicket       Money   `json:"average_ticket"`
+	DiscountCount       int64   `json:"discount_count"`
+	DiscountAmount      Money   `json:"discount_amount"`
+	DiscountRate        float64 `json:"discount_rate"`
+	CanceledOrderCount  int64   `json:"canceled_order_count"`
+	CanceledOrderAmount Money   `json:"canceled_order_amount"`
+	RefundCount         int64   `json:"refund_count"`
+	RefundAmount        Money   `json:"refund_amount"`
+}
+
+type EmployeePerformanceReport struct {
+	Employees []EmployeePerformance `json:"employees"`
+}
+
+func NewEmployeePerformanceReport(report core.EmployeePerformanceReport) EmployeePerformanceReport {
+	employees := make([]EmployeePerformance, len(report.Employees))
+	for i, p := range report.Employees {
+		employees[i] = EmployeePerformance{
+			EmployeeID:          p.EmployeeID,
+			OrderCount:          p.OrderCount,
+			GrossSalesAmount:    NewMoney(p.GrossSalesAmount),
+			SalesAmount:         NewMoney(p.SalesAmount),
+			AverageTicket:       NewMoney(p.AverageTicket),
+			DiscountCount:       p.DiscountCount,
+			DiscountAmount:      NewMoney(p.DiscountAmount),
+			DiscountRate:        p.DiscountRate,
+			CanceledOrderCount:  p.CanceledOrderCount,
+			CanceledOrderAmount: NewMoney(p.CanceledOrderAmount),
+			RefundCount:         p.RefundCount,
+			RefundAmount:        NewMoney(p.RefundAmount),
+		}
+	}
+	return EmployeePerformanceReport{Employees: employees}
+}
@@ -0,0 +1,221 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	RoleListDefaultSize = 10
+	RoleListMaxSize     = 50
+)
+
+func (h *Handler) HandleCreateRole(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleCreateRole")
+
+	type request struct {
+		Name        string            `json:"name" validate:"required"`
+		Permissions []core.Permission `json:"permissions" validate:"omitempty"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	for _, p := range req.Permissions {
+		if ok := p.Validate(); !ok {
+			msg := fmt.Sprintf("request field 'permissions' is not valid, it should be one of: %v", core.Permissions())
+			return errors.E(op, errors.KindValidation, msg)
+		}
+	}
+
+	role := core.NewRole(req.Name, merchant.ID)
+	if len(req.Permissions) != 0 {
+		role.Permissions = req.Permissions
+	}
+
+	role, err := h.RoleService.PutRole(ctx, role)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewRole(role))
+}
+
+func (h *Handler) HandleUpdateRole(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleUpdateRole")
+
+	type request struct {
+		ID          core.ID            `param:"id" validate:"required,id"`
+		Name        *string            `json:"name" validate:"omitempty,min=1"`
+		Permissions *[]core.Permission `json:"permissions" validate:"omitempty"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	role, err := h.RoleService.GetRole(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if req.Name != nil {
+		role.Name = *req.Name
+	}
+	if req.Permissions != nil {
+		for _, p := range *req.Permissions {
+			if ok := p.Validate(); !ok {
+				msg := fmt.Sprintf("request field 'permissions' is not valid, it should be one of: %v", core.Permissions())
+				return errors.E(op, errors.KindValidation, msg)
+			}
+		}
+		role.Permissions = *req.Permissions
+	}
+
+	role, err = h.RoleService.PutRole(ctx, role)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewRole(role))
+}
+
+func (h *Handler) HandleRetrieveRole(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRetrieveRole")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	role, err := h.RoleService.GetRole(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewRole(role))
+}
+
+func (h *Handler) HandleSearchRole(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSearchRole")
+
+	type filter struct {
+		IDs []core.ID `json:"ids" validate:"omitempty,dive,id"`
+	}
+
+	type request struct {
+		Limit  int64  `json:"limit" validate:"gte=0"`
+		Offset int64  `json:"offset" validate:"gte=0"`
+		Filter filter `json:"filter"`
+	}
+
+	type response struct {
+		Roles []Role `json:"roles"`
+		Total int64  `json:"total_count"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var limit int64 = RoleListDefaultSize
+	if req.Limit <= RoleListMaxSize {
+		limit = req.Limit
+	} else {
+		limit = RoleListMaxSize
+	}
+
+	roles, count, err := h.RoleService.ListRole(ctx, core.RoleQuery{
+		Limit:  limit,
+		Offset: req.Offset,
+		Filter: core.RoleFilter{
+			IDs:        req.Filter.IDs,
+			MerchantID: merchant.ID,
+		},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Roles: make([]Role, len(roles)),
+		Total: count,
+	}
+	for i, r := range roles {
+		resp.Roles[i] = NewRole(r)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleDeleteRole(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleDeleteRole")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	role, err := h.RoleService.DeleteRole(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewRole(role))
+}
+
+type Role struct {
+	ID          core.ID           `json:"id"`
+	Name        string            `json:"name"`
+	Permissions []core.Permission `json:"permissions"`
+	MerchantID  core.ID           `json:"merchant_id"`
+	CreatedAt   int64             `json:"created_at"`
+	UpdatedAt   int64             `json:"updated_at"`
+	Status      core.Status       `json:"status"`
+}
+
+func NewRole(role core.Role) Role {
+	return Role{
+		ID:          role.ID,
+		Name:        role.Name,
+		Permissions: role.Permissions,
+		MerchantID:  role.MerchantID,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+		Status:      role.Status,
+	}
+}
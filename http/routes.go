@@ -1,6 +1,7 @@
 package http
 
 import (
+	"github.com/backium/backend/core"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
@@ -11,97 +12,179 @@ func (s *Server) setupRoutes() {
 	s.Echo.Use(middleware.CORS())
 	s.Echo.Use(s.loggerMiddleware)
 
-	userGroup := s.Echo.Group("/api/v1",
-		RequireSession(s.MerchantStorage, s.SessionRepository, s.UserStorage, s.EmployeeStorage))
+	session := RequireSession(s.SessionKeys, h.Authorizer, s.MerchantStorage, s.SessionRepository, s.UserStorage, s.EmployeeStorage, s.CustomerStorage, s.DeviceStorage)
+	auth := RequireAuth(RequireAPIKey(h.MerchantService), session)
+	// Account routes are shared by employees and customers, the rest of the
+	// API is only for employees
+	accountGroup := s.Echo.Group("/api/v1", auth)
+	userGroup := s.Echo.Group("/api/v1", auth, RequireEmployee())
+	customerGroup := s.Echo.Group("/api/v1/me", session, RequireCustomer())
 	pubGroup := s.Echo.Group("/api/v1")
+	pendingGroup := s.Echo.Group("/api/v1/login/2fa", RequirePendingSession(s.SessionKeys, s.MerchantStorage, s.SessionRepository, s.UserStorage))
 
 	userGroup.GET("/merchants/:id", h.HandleRetrieveMerchant)
-	userGroup.POST("/keys", h.HandleCreateAPIKey)
+	userGroup.PUT("/merchants/:id", h.HandleUpdateMerchant, RequireOwner())
+	userGroup.POST("/keys", h.HandleCreateAPIKey, RequireOwner())
+	userGroup.GET("/keys", h.HandleListAPIKeys, RequireOwner())
+	userGroup.DELETE("/keys/:id", h.HandleRevokeAPIKey, RequireOwner())
 
 	pubGroup.POST("/signup", h.HandleRegisterOwner)
 	pubGroup.POST("/login", h.HandleLogin)
 	pubGroup.POST("/auth/signin", h.HandleUniversalLogin)
 	pubGroup.GET("/auth/session", h.HandleUniversalGetSession)
-	userGroup.POST("/signup/employee", h.HandleRegisterEmployee)
-	userGroup.POST("/signout", h.HandleLogout)
-
-	userGroup.GET("/employees/:id", h.HandleRetrieveEmployee)
-	userGroup.POST("/employees/search", h.HandleSearchEmployee)
-	userGroup.POST("/employees", h.HandleCreateEmployee)
-	userGroup.PUT("/employees/:id", h.HandleUpdateEmployee)
-	userGroup.DELETE("/employees/:id", h.HandleDeleteEmployee)
-
-	userGroup.GET("/locations/:id", h.HandleRetrieveLocation)
-	userGroup.GET("/locations", h.HandleListLocations)
-	userGroup.POST("/locations/search", h.HandleSearchLocation)
-	userGroup.POST("/locations", h.HandleCreateLocation)
-	userGroup.PUT("/locations/:id", h.HandleUpdateLocation)
-	userGroup.DELETE("/locations/:id", h.HandleDeleteLocation)
-
-	userGroup.POST("/cash-drawers/:id/adjust", h.HandleChangeCashDrawer)
-	userGroup.POST("/cash-drawers/search", h.HandleSearchCashDrawer)
-	userGroup.POST("/cash-drawers/adjustment/search", h.HandleSearchCashDrawerAdjustment)
-
-	userGroup.GET("/customers/:id", h.HandleRetrieveCustomer)
-	userGroup.GET("/customers", h.HandleListCustomers)
-	userGroup.POST("/customers/search", h.HandleSearchCustomer)
-	userGroup.POST("/customers", h.HandleCreateCustomer)
-	userGroup.PUT("/customers/:id", h.HandleUpdateCustomer)
-	userGroup.DELETE("/customers/:id", h.HandleDeleteCustomer)
-
-	userGroup.POST("/inventory/batch-change", h.HandleChangeInventory)
-	userGroup.POST("/inventory/batch-retrieve-counts", h.HandleBatchRetrieveInventory)
-	userGroup.POST("/inventory/adjustment/search", h.HandleSearchInventoryAdjustment)
-
-	userGroup.GET("/categories/:id", h.HandleRetrieveCategory)
-	userGroup.GET("/categories", h.HandleListCategories)
-	userGroup.POST("/categories/search", h.HandleSearchCategory)
-	userGroup.POST("/categories", h.HandleCreateCategory)
-	userGroup.PUT("/categories/:id", h.HandleUpdateCategory)
-	userGroup.DELETE("/categories/:id", h.HandleDeleteCategory)
-
-	userGroup.GET("/items/:id", h.HandleRetrieveItem)
-	userGroup.GET("/items", h.HandleListItems)
-	userGroup.POST("/items/search", h.HandleSearchItem)
-	userGroup.POST("/items", h.HandleCreateItem)
-	userGroup.PUT("/items/:id", h.HandleUpdateItem)
-	userGroup.DELETE("/items/:id", h.HandleDeleteItem)
-
-	userGroup.GET("/item_variations/:id", h.HandleRetrieveItemVariation)
-	userGroup.GET("/item_variations", h.HandleListItemVariations)
-	userGroup.POST("/item_variations/search", h.HandleSearchItemVariation)
-	userGroup.POST("/item_variations", h.HandleCreateItemVariation)
-	userGroup.PUT("/item_variations/:id", h.HandleUpdateItemVariation)
-	userGroup.DELETE("/item_variations/:id", h.HandleDeleteItemVariation)
-
-	userGroup.GET("/taxes/:id", h.HandleRetrieveTax)
-	userGroup.GET("/taxes", h.HandleListTaxes)
-	userGroup.POST("/taxes/search", h.HandleSearchTax)
-	userGroup.POST("/taxes", h.HandleCreateTax)
-	userGroup.PUT("/taxes/:id", h.HandleUpdateTax)
-	userGroup.DELETE("/taxes/:id", h.HandleDeleteTax)
-	userGroup.POST("/taxes/batch", h.HandleBatchCreateTax)
-
-	userGroup.GET("/discounts/:id", h.HandleRetrieveDiscount)
-	userGroup.GET("/discounts", h.HandleListDiscounts)
-	userGroup.POST("/discounts/search", h.HandleSearchDiscount)
-	userGroup.POST("/discounts", h.HandleCreateDiscount)
-	userGroup.PUT("/discounts/:id", h.HandleUpdateDiscount)
-	userGroup.DELETE("/discounts/:id", h.HandleDeleteDiscount)
-
-	userGroup.POST("/orders", h.HandleCreateOrder)
-	userGroup.POST("/orders/calculate", h.HandleCalculateOrder)
-	userGroup.POST("/orders/search", h.HandleSearchOrder)
-	userGroup.POST("/orders/:order_id/pay", h.HandlePayOrder)
-	userGroup.POST("/orders/:order_id/cancel", h.HandleCancelOrder)
-	userGroup.POST("/orders/receipt", h.HandleGenerateReceipt)
-	userGroup.POST("/orders/export", h.HandleExportOrders)
-
-	userGroup.POST("/payments", h.HandleCreatePayment)
-	userGroup.POST("/payments/search", h.HandleSearchPayment)
-
-	userGroup.POST("/reports/custom", h.HandleGenerateCustomReport)
-	userGroup.POST("/reports/stock", h.HandleGenerateStockReport)
+	userGroup.POST("/signup/employee", h.HandleRegisterEmployee, RequireOwner())
+	pubGroup.POST("/signup/customer", h.HandleRegisterCustomer)
+	accountGroup.POST("/signout", h.HandleLogout)
+	accountGroup.POST("/signout/all", h.HandleLogoutEverywhere)
+	accountGroup.PUT("/users/password", h.HandleChangePassword)
+	pubGroup.POST("/users/password/forgot", h.HandleForgotPassword)
+	pubGroup.POST("/users/password/reset", h.HandleResetPassword)
+	accountGroup.POST("/users/email/verification", h.HandleResendEmailVerification)
+	pubGroup.POST("/users/email/verify", h.HandleVerifyEmail)
+	accountGroup.GET("/sessions", h.HandleListSessions)
+
+	pendingGroup.POST("", h.HandleVerifyTwoFactor)
+	pendingGroup.POST("/enroll", h.HandleEnrollTwoFactor)
+	pendingGroup.POST("/confirm", h.HandleConfirmTwoFactor)
+	accountGroup.POST("/users/2fa/enroll", h.HandleEnrollTwoFactor)
+	accountGroup.POST("/users/2fa/confirm", h.HandleConfirmTwoFactor)
+	accountGroup.POST("/users/2fa/disable", h.HandleDisableTwoFactor)
+	accountGroup.DELETE("/sessions/:id", h.HandleRevokeSession)
+
+	customerGroup.GET("/customer", h.HandleRetrieveCustomerAccount)
+	customerGroup.POST("/orders/search", h.HandleSearchCustomerOrder)
+	customerGroup.GET("/orders/:id", h.HandleRetrieveCustomerOrder)
+	customerGroup.POST("/orders/:id/receipt", h.HandleGenerateCustomerReceipt)
+	customerGroup.GET("/loyalty", h.HandleRetrieveCustomerLoyalty)
+
+	userGroup.GET("/devices/:id", h.HandleRetrieveDevice, RequirePermission(core.LocationWrite))
+	userGroup.POST("/devices/search", h.HandleSearchDevice, RequirePermission(core.LocationWrite))
+	userGroup.POST("/devices", h.HandleRegisterDevice, RequirePermission(core.LocationWrite))
+	userGroup.DELETE("/devices/:id", h.HandleDeleteDevice, RequirePermission(core.LocationWrite))
+	pubGroup.POST("/devices/:id/login", h.HandlePINLogin)
+	userGroup.POST("/devices/switch", h.HandleSwitchEmployee, RequireDeviceSession())
+	userGroup.PUT("/employees/:id/pin", h.HandleSetEmployeePIN, RequireSelfOrOwner())
+
+	userGroup.GET("/roles/:id", h.HandleRetrieveRole, RequirePermission(core.EmployeeRead))
+	userGroup.POST("/roles/search", h.HandleSearchRole, RequirePermission(core.EmployeeRead))
+	userGroup.POST("/roles", h.HandleCreateRole, RequireOwner())
+	userGroup.PUT("/roles/:id", h.HandleUpdateRole, RequireOwner())
+	userGroup.DELETE("/roles/:id", h.HandleDeleteRole, RequireOwner())
+
+	userGroup.GET("/employees/:id", h.HandleRetrieveEmployee, RequirePermission(core.EmployeeRead))
+	userGroup.POST("/employees/search", h.HandleSearchEmployee, RequirePermission(core.EmployeeRead))
+	userGroup.POST("/employees", h.HandleCreateEmployee, RequireOwner())
+	userGroup.PUT("/employees/:id", h.HandleUpdateEmployee, RequireOwner())
+	userGroup.DELETE("/employees/:id", h.HandleDeleteEmployee, RequireOwner())
+
+	userGroup.POST("/timesheets/clock-in", h.HandleClockIn, RequirePermission(core.TimesheetAccess))
+	userGroup.POST("/timesheets/clock-out", h.HandleClockOut, RequirePermission(core.TimesheetAccess))
+	userGroup.POST("/timesheets/breaks/start", h.HandleStartBreak, RequirePermission(core.TimesheetAccess))
+	userGroup.POST("/timesheets/breaks/end", h.HandleEndBreak, RequirePermission(core.TimesheetAccess))
+	userGroup.GET("/timesheets/:id", h.HandleRetrieveTimesheet, RequirePermission(core.TimesheetAccess))
+	userGroup.PUT("/timesheets/:id", h.HandleUpdateTimesheet, RequirePermission(core.TimesheetApprove))
+	userGroup.POST("/timesheets/:id/approve", h.HandleApproveTimesheet, RequirePermission(core.TimesheetApprove))
+	userGroup.POST("/timesheets/search", h.HandleSearchTimesheet, RequirePermission(core.TimesheetAccess))
+	userGroup.POST("/timesheets/summary", h.HandleSummarizeTimesheets, RequirePermission(core.TimesheetAccess))
+
+	userGroup.POST("/payroll/run", h.HandleRunPayroll, RequireOwner())
+	userGroup.POST("/payroll/export", h.HandleExportPayroll, RequireOwner())
+
+	userGroup.GET("/locations/:id", h.HandleRetrieveLocation, RequirePermission(core.LocationRead))
+	userGroup.GET("/locations", h.HandleListLocations, RequirePermission(core.LocationRead))
+	userGroup.POST("/locations/search", h.HandleSearchLocation, RequirePermission(core.LocationRead))
+	userGroup.POST("/locations", h.HandleCreateLocation, RequirePermission(core.LocationWrite))
+	userGroup.PUT("/locations/:id", h.HandleUpdateLocation, RequirePermission(core.LocationWrite))
+	userGroup.DELETE("/locations/:id", h.HandleDeleteLocation, RequirePermission(core.LocationWrite))
+
+	userGroup.POST("/cash-drawers", h.HandleCreateCashDrawer, RequirePermission(core.CashDrawerAccess))
+	userGroup.POST("/cash-drawers/:id/adjust", h.HandleChangeCashDrawer, RequirePermission(core.CashDrawerAccess))
+	userGroup.POST("/cash-drawers/search", h.HandleSearchCashDrawer, RequirePermission(core.CashDrawerAccess))
+	userGroup.POST("/cash-drawers/adjustment/search", h.HandleSearchCashDrawerAdjustment, RequirePermission(core.CashDrawerAccess))
+	userGroup.POST("/cash-drawers/:id/shifts", h.HandleOpenCashDrawerShift, RequirePermission(core.CashDrawerAccess))
+	userGroup.GET("/cash-drawer-shifts/:id", h.HandleRetrieveCashDrawerShift, RequirePermission(core.CashDrawerAccess))
+	userGroup.POST("/cash-drawer-shifts/:id/close", h.HandleCloseCashDrawerShift, RequirePermission(core.CashDrawerAccess))
+	userGroup.POST("/cash-drawer-shifts/search", h.HandleSearchCashDrawerShift, RequirePermission(core.CashDrawerAccess))
+	userGroup.GET("/denominations/:currency", h.HandleListDenominations, RequirePermission(core.CashDrawerAccess))
+
+	userGroup.GET("/tip-pools/:id", h.HandleRetrieveTipPool, RequirePermission(core.ReportRead))
+	userGroup.POST("/tip-pools/search", h.HandleSearchTipPool, RequirePermission(core.ReportRead))
+	userGroup.POST("/tip-pools", h.HandleCreateTipPool, RequireOwner())
+	userGroup.PUT("/tip-pools/:id", h.HandleUpdateTipPool, RequireOwner())
+	userGroup.DELETE("/tip-pools/:id", h.HandleDeleteTipPool, RequireOwner())
+	userGroup.POST("/tip-pools/:id/payouts/calculate", h.HandleCalculateTipPayout, RequirePermission(core.ReportRead))
+	userGroup.POST("/tip-pools/:id/payouts", h.HandleCreateTipPayout, RequireOwner())
+	userGroup.POST("/tip-payouts/search", h.HandleSearchTipPayout, RequirePermission(core.ReportRead))
+
+	userGroup.GET("/customers/:id", h.HandleRetrieveCustomer, RequirePermission(core.CustomerRead))
+	userGroup.GET("/customers", h.HandleListCustomers, RequirePermission(core.CustomerRead))
+	userGroup.POST("/customers/search", h.HandleSearchCustomer, RequirePermission(core.CustomerRead))
+	userGroup.POST("/customers", h.HandleCreateCustomer, RequirePermission(core.CustomerWrite))
+	userGroup.PUT("/customers/:id", h.HandleUpdateCustomer, RequirePermission(core.CustomerWrite))
+	userGroup.DELETE("/customers/:id", h.HandleDeleteCustomer, RequirePermission(core.CustomerWrite))
+
+	userGroup.POST("/inventory/batch-change", h.HandleChangeInventory, RequirePermission(core.InventoryWrite))
+	userGroup.POST("/inventory/batch-retrieve-counts", h.HandleBatchRetrieveInventory, RequirePermission(core.InventoryRead))
+	userGroup.POST("/inventory/adjustment/search", h.HandleSearchInventoryAdjustment, RequirePermission(core.InventoryRead))
+	userGroup.POST("/inventory/sold-out", h.HandleSetSoldOut, RequirePermission(core.InventoryWrite))
+	userGroup.POST("/inventory/history", h.HandleGetInventoryHistory, RequirePermission(core.InventoryRead))
+
+	userGroup.GET("/categories/:id", h.HandleRetrieveCategory, RequirePermission(core.CatalogRead))
+	userGroup.GET("/categories", h.HandleListCategories, RequirePermission(core.CatalogRead))
+	userGroup.POST("/categories/search", h.HandleSearchCategory, RequirePermission(core.CatalogRead))
+	userGroup.POST("/categories", h.HandleCreateCategory, RequirePermission(core.CatalogWrite))
+	userGroup.PUT("/categories/:id", h.HandleUpdateCategory, RequirePermission(core.CatalogWrite))
+	userGroup.DELETE("/categories/:id", h.HandleDeleteCategory, RequirePermission(core.CatalogWrite))
+
+	userGroup.GET("/items/:id", h.HandleRetrieveItem, RequirePermission(core.CatalogRead))
+	userGroup.GET("/items", h.HandleListItems, RequirePermission(core.CatalogRead))
+	userGroup.POST("/items/search", h.HandleSearchItem, RequirePermission(core.CatalogRead))
+	userGroup.POST("/items", h.HandleCreateItem, RequirePermission(core.CatalogWrite))
+	userGroup.PUT("/items/:id", h.HandleUpdateItem, RequirePermission(core.CatalogWrite))
+	userGroup.DELETE("/items/:id", h.HandleDeleteItem, RequirePermission(core.CatalogWrite))
+
+	userGroup.GET("/item_variations/:id", h.HandleRetrieveItemVariation, RequirePermission(core.CatalogRead))
+	userGroup.GET("/item_variations", h.HandleListItemVariations, RequirePermission(core.CatalogRead))
+	userGroup.POST("/item_variations/search", h.HandleSearchItemVariation, RequirePermission(core.CatalogRead))
+	userGroup.POST("/item_variations", h.HandleCreateItemVariation, RequirePermission(core.CatalogWrite))
+	userGroup.PUT("/item_variations/:id", h.HandleUpdateItemVariation, RequirePermission(core.CatalogWrite))
+	userGroup.DELETE("/item_variations/:id", h.HandleDeleteItemVariation, RequirePermission(core.CatalogWrite))
+
+	userGroup.GET("/taxes/:id", h.HandleRetrieveTax, RequirePermission(core.CatalogRead))
+	userGroup.GET("/taxes", h.HandleListTaxes, RequirePermission(core.CatalogRead))
+	userGroup.POST("/taxes/search", h.HandleSearchTax, RequirePermission(core.CatalogRead))
+	userGroup.POST("/taxes", h.HandleCreateTax, RequirePermission(core.CatalogWrite))
+	userGroup.PUT("/taxes/:id", h.HandleUpdateTax, RequirePermission(core.CatalogWrite))
+	userGroup.DELETE("/taxes/:id", h.HandleDeleteTax, RequirePermission(core.CatalogWrite))
+	userGroup.POST("/taxes/batch", h.HandleBatchCreateTax, RequirePermission(core.CatalogWrite))
+
+	userGroup.GET("/discounts/:id", h.HandleRetrieveDiscount, RequirePermission(core.CatalogRead))
+	userGroup.GET("/discounts", h.HandleListDiscounts, RequirePermission(core.CatalogRead))
+	userGroup.POST("/discounts/search", h.HandleSearchDiscount, RequirePermission(core.CatalogRead))
+	userGroup.POST("/discounts", h.HandleCreateDiscount, RequirePermission(core.CatalogWrite))
+	userGroup.PUT("/discounts/:id", h.HandleUpdateDiscount, RequirePermission(core.CatalogWrite))
+	userGroup.DELETE("/discounts/:id", h.HandleDeleteDiscount, RequirePermission(core.CatalogWrite))
+
+	userGroup.POST("/orders", h.HandleCreateOrder, RequirePermission(core.OrderWrite))
+	userGroup.POST("/orders/calculate", h.HandleCalculateOrder, RequirePermission(core.OrderWrite))
+	userGroup.POST("/orders/search", h.HandleSearchOrder, RequirePermission(core.OrderRead))
+	userGroup.POST("/orders/:order_id/pay", h.HandlePayOrder, RequirePermission(core.OrderWrite))
+	userGroup.POST("/orders/:order_id/cancel", h.HandleCancelOrder, RequirePermission(core.OrderWrite))
+	userGroup.POST("/orders/receipt", h.HandleGenerateReceipt, RequirePermission(core.OrderRead))
+	userGroup.POST("/orders/export", h.HandleExportOrders, RequirePermission(core.OrderRead))
+
+	userGroup.POST("/payments", h.HandleCreatePayment, RequirePermission(core.OrderWrite))
+	userGroup.POST("/payments/search", h.HandleSearchPayment, RequirePermission(core.OrderRead))
+
+	userGroup.POST("/reports/custom", h.HandleGenerateCustomReport, RequirePermission(core.ReportRead))
+	userGroup.POST("/reports/stock", h.HandleGenerateStockReport, RequirePermission(core.ReportRead))
+	userGroup.POST("/reports/expiring-stock", h.HandleGenerateExpiringStockReport, RequirePermission(core.ReportRead))
+	userGroup.POST("/reports/shrinkage", h.HandleGenerateShrinkageReport, RequirePermission(core.ReportRead))
+	userGroup.POST("/reports/cash-drawers", h.HandleGenerateCashDrawerReport, RequirePermission(core.ReportRead))
+	userGroup.POST("/reports/z-report", h.HandleGenerateZReport, RequirePermission(core.ReportRead))
+	userGroup.POST("/reports/z-report/pdf", h.HandleGenerateZReportPDF, RequirePermission(core.ReportRead))
+	userGroup.POST("/reports/tips", h.HandleGenerateTipReport, RequirePermission(core.ReportRead))
+	userGroup.POST("/reports/employee-performance", h.HandleGenerateEmployeePerformanceReport, RequirePermission(core.ReportRead))
 }
 
 func (s *Server) loggerMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRoutesRequireGuard checks that every employee route declares the
+// permission it needs, a request made with an API key without scopes must
+// be rejected by a guard before reaching the handler
+func TestRoutesRequireGuard(t *testing.T) {
+	// Routes open to any authenticated user or without authentication
+	exempt := map[string]bool{
+		"POST /api/v1/signup":                   true,
+		"POST /api/v1/login":                    true,
+		"POST /api/v1/auth/signin":              true,
+		"GET /api/v1/auth/session":              true,
+		"POST /api/v1/signup/customer":          true,
+		"POST /api/v1/users/password/forgot":    true,
+		"POST /api/v1/users/password/reset":     true,
+		"POST /api/v1/users/email/verify":       true,
+		"POST /api/v1/devices/:id/login":        true,
+		"POST /api/v1/signout":                  true,
+		"POST /api/v1/signout/all":              true,
+		"PUT /api/v1/users/password":            true,
+		"POST /api/v1/users/email/verification": true,
+		"GET /api/v1/sessions":                  true,
+		"DELETE /api/v1/sessions/:id":           true,
+		"POST /api/v1/users/2fa/enroll":         true,
+		"POST /api/v1/users/2fa/confirm":        true,
+		"POST /api/v1/users/2fa/disable":        true,
+		"GET /api/v1/merchants/:id":             true,
+	}
+	guards := map[errors.Op]bool{
+		"http/RequirePermission":    true,
+		"http/RequireOwner":         true,
+		"http/RequireSelfOrOwner":   true,
+		"http/RequireDeviceSession": true,
+	}
+
+	key, token := core.NewKey("test", nil)
+	key.LastUsedAt = time.Now().Unix()
+	merchant := core.Merchant{ID: core.NewID("merch"), Keys: []core.Key{key}}
+	merchantStorage := core.NewMockMerchantStorage()
+	merchantStorage.GetByKeyFn = func(ctx context.Context, token string) (core.Merchant, error) {
+		return merchant, nil
+	}
+
+	s := Server{Echo: echo.New(), MerchantStorage: merchantStorage}
+	assert.NoError(t, s.Setup())
+	s.Echo.Logger.SetOutput(&strings.Builder{})
+	// Unguarded handlers reach the nil storages, recover them as errors
+	s.Echo.Use(middleware.Recover())
+	var handled error
+	s.Echo.HTTPErrorHandler = func(err error, c echo.Context) {
+		handled = err
+	}
+
+	for _, r := range s.Echo.Routes() {
+		route := r.Method + " " + r.Path
+		if !strings.Contains(r.Name, ".Handle") || exempt[route] ||
+			strings.HasPrefix(r.Path, "/api/v1/me/") || strings.HasPrefix(r.Path, "/api/v1/login/2fa") {
+			continue
+		}
+
+		path := r.Path
+		for _, part := range strings.Split(r.Path, "/") {
+			if strings.HasPrefix(part, ":") {
+				path = strings.Replace(path, part, "x", 1)
+			}
+		}
+		req := httptest.NewRequest(r.Method, path, strings.NewReader("{}"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		handled = nil
+		s.Echo.ServeHTTP(httptest.NewRecorder(), req)
+
+		var err *errors.Error
+		if assert.True(t, errors.As(handled, &err), "%s has no guard", route) {
+			assert.True(t, guards[err.Op], "%s has no guard, failed with %v", route, handled)
+			assert.True(t, errors.Is(handled, errors.KindNoPermission), route)
+		}
+	}
+}
+
+func TestRoutesGuardScopedKey(t *testing.T) {
+	key, token := core.NewKey("test", []core.Permission{core.CatalogRead})
+	key.LastUsedAt = time.Now().Unix()
+	merchant := core.Merchant{ID: core.NewID("merch"), Keys: []core.Key{key}}
+	merchantStorage := core.NewMockMerchantStorage()
+	merchantStorage.GetByKeyFn = func(ctx context.Context, token string) (core.Merchant, error) {
+		return merchant, nil
+	}
+
+	s := Server{Echo: echo.New(), MerchantStorage: merchantStorage}
+	assert.NoError(t, s.Setup())
+	s.Echo.Logger.SetOutput(&strings.Builder{})
+	var handled error
+	s.Echo.HTTPErrorHandler = func(err error, c echo.Context) {
+		handled = err
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/items", strings.NewReader("{}"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", "Bearer "+token)
+	s.Echo.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, errors.Is(handled, errors.KindNoPermission), "the key can't write the catalog")
+}
@@ -25,8 +25,18 @@ type Server struct {
 	InventoryStorage     core.InventoryStorage
 	EmployeeStorage      core.EmployeeStorage
 	CashDrawerStorage    core.CashDrawerStorage
+	TipStorage           core.TipStorage
+	TimesheetStorage     core.TimesheetStorage
+	DeviceStorage        core.DeviceStorage
+	RoleStorage          core.RoleStorage
+	UserTokenStorage     core.UserTokenStorage
+	FailedLoginStorage   core.FailedLoginStorage
+	LoginAttemptStorage  core.LoginAttemptStorage
 	SessionRepository    core.SessionStorage
+	SessionKeys          core.SessionKeys
 	Uploader             core.Uploader
+	Mailer               core.Mailer
+	AppURL               string
 }
 
 func (s *Server) Setup() error {
@@ -49,6 +59,14 @@ func (s *Server) setupHandlers() {
 		ItemVariationStorage: s.ItemVariationStorage,
 		CategoryStorage:      s.CategoryStorage,
 		EmployeeStorage:      s.EmployeeStorage,
+		RoleStorage:          s.RoleStorage,
+		LocationStorage:      s.LocationStorage,
+		OrderStorage:         s.OrderStorage,
+		TaxStorage:           s.TaxStorage,
+		DiscountStorage:      s.DiscountStorage,
+		CustomerStorage:      s.CustomerStorage,
+		CashDrawerStorage:    s.CashDrawerStorage,
+		TipStorage:           s.TipStorage,
 	}
 	locationService := core.LocationService{
 		LocationStorage:      s.LocationStorage,
@@ -57,16 +75,28 @@ func (s *Server) setupHandlers() {
 		InventoryStorage:     s.InventoryStorage,
 	}
 	customerService := core.CustomerService{CustomerStorage: s.CustomerStorage}
+	customerAccountService := core.CustomerAccountService{OrderStorage: s.OrderStorage}
 	merchantService := core.MerchantService{MerchantStorage: s.MerchantStorage}
 	userService := core.UserService{
-		UserStorage:       s.UserStorage,
-		EmployeeStorage:   s.EmployeeStorage,
-		MerchantStorage:   s.MerchantStorage,
-		LocationStorage:   s.LocationStorage,
-		CashDrawerStorage: s.CashDrawerStorage,
+		UserStorage:         s.UserStorage,
+		EmployeeStorage:     s.EmployeeStorage,
+		CustomerStorage:     s.CustomerStorage,
+		MerchantStorage:     s.MerchantStorage,
+		LocationStorage:     s.LocationStorage,
+		CashDrawerStorage:   s.CashDrawerStorage,
+		SessionStorage:      s.SessionRepository,
+		RoleStorage:         s.RoleStorage,
+		UserTokenStorage:    s.UserTokenStorage,
+		LoginAttemptStorage: s.LoginAttemptStorage,
+		FailedLoginStorage:  s.FailedLoginStorage,
+		Mailer:              s.Mailer,
+		AppURL:              s.AppURL,
 	}
 	employeeService := core.EmployeeService{
-		EmployeeStorage: s.EmployeeStorage,
+		EmployeeStorage:  s.EmployeeStorage,
+		TimesheetStorage: s.TimesheetStorage,
+		RoleStorage:      s.RoleStorage,
+		LocationStorage:  s.LocationStorage,
 	}
 	catalogService := core.CatalogService{
 		CategoryStorage:      s.CategoryStorage,
@@ -92,7 +122,8 @@ func (s *Server) setupHandlers() {
 		Uploader:             s.Uploader,
 	}
 	paymentService := core.PaymentService{
-		PaymentStorage: s.PaymentStorage,
+		PaymentStorage:    s.PaymentStorage,
+		CashDrawerStorage: s.CashDrawerStorage,
 	}
 	reportService := core.ReportService{
 		OrderStorage:         s.OrderStorage,
@@ -100,6 +131,10 @@ func (s *Server) setupHandlers() {
 		ItemVariationStorage: s.ItemVariationStorage,
 		InventoryStorage:     s.InventoryStorage,
 		CategoryStorage:      s.CategoryStorage,
+		CashDrawerStorage:    s.CashDrawerStorage,
+		PaymentStorage:       s.PaymentStorage,
+		LocationStorage:      s.LocationStorage,
+		Uploader:             s.Uploader,
 	}
 	exportService := core.ExportService{
 		OrderStorage:    s.OrderStorage,
@@ -107,21 +142,49 @@ func (s *Server) setupHandlers() {
 		EmployeeStorage: s.EmployeeStorage,
 		Uploader:        s.Uploader,
 	}
+	tipService := core.TipService{
+		TipStorage:        s.TipStorage,
+		OrderStorage:      s.OrderStorage,
+		PaymentStorage:    s.PaymentStorage,
+		CashDrawerStorage: s.CashDrawerStorage,
+		TimesheetStorage:  s.TimesheetStorage,
+	}
+	payrollService := core.PayrollService{
+		EmployeeStorage:  s.EmployeeStorage,
+		TimesheetStorage: s.TimesheetStorage,
+		TipStorage:       s.TipStorage,
+		OrderStorage:     s.OrderStorage,
+		PaymentStorage:   s.PaymentStorage,
+		Uploader:         s.Uploader,
+	}
+	deviceService := core.DeviceService{
+		DeviceStorage:   s.DeviceStorage,
+		EmployeeStorage: s.EmployeeStorage,
+		LocationStorage: s.LocationStorage,
+		SessionStorage:  s.SessionRepository,
+	}
+	roleService := core.RoleService{RoleStorage: s.RoleStorage}
 
 	// setup handlers
 	s.Handler = Handler{
-		Authorizer:        authorizer,
-		LocationService:   locationService,
-		CustomerService:   customerService,
-		MerchantService:   merchantService,
-		UserService:       userService,
-		EmployeeService:   employeeService,
-		CatalogService:    catalogService,
-		OrderingService:   orderingService,
-		PaymentService:    paymentService,
-		ReportService:     reportService,
-		ExportService:     exportService,
-		SessionRepository: s.SessionRepository,
+		Authorizer:             authorizer,
+		RoleService:            roleService,
+		LocationService:        locationService,
+		CustomerService:        customerService,
+		CustomerAccountService: customerAccountService,
+		MerchantService:        merchantService,
+		UserService:            userService,
+		EmployeeService:        employeeService,
+		CatalogService:         catalogService,
+		OrderingService:        orderingService,
+		PaymentService:         paymentService,
+		ReportService:          reportService,
+		ExportService:          exportService,
+		TipService:             tipService,
+		PayrollService:         payrollService,
+		DeviceService:          deviceService,
+		SessionRepository:      s.SessionRepository,
+		SessionKeys:            s.SessionKeys,
 	}
 }
 
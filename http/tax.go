@@ -37,7 +37,15 @@ func (h *Handler) HandleCreateTax(c echo.Context) error {
 		tax.LocationIDs = *req.LocationIDs
 	}
 
-	tax, err := h.CatalogService.PutTax(ctx, tax)
+	ok, err := h.Authorizer.CanCreateTax(ctx, tax)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage taxes of these locations")
+	}
+
+	tax, err = h.CatalogService.PutTax(ctx, tax)
 	if err != nil {
 		return errors.E(op, err)
 	}
@@ -68,6 +76,14 @@ func (h *Handler) HandleUpdateTax(c echo.Context) error {
 		return err
 	}
 
+	ok, err := h.Authorizer.CanUpdateTax(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this tax")
+	}
+
 	tax, err := h.CatalogService.GetTax(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -85,6 +101,14 @@ func (h *Handler) HandleUpdateTax(c echo.Context) error {
 		tax.LocationIDs = *req.LocationIDs
 	}
 
+	ok, err = h.Authorizer.CanCreateTax(ctx, tax)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to manage taxes of these locations")
+	}
+
 	tax, err = h.CatalogService.PutTax(ctx, tax)
 	if err != nil {
 		return errors.E(op, err)
@@ -131,6 +155,16 @@ func (h *Handler) HandleBatchCreateTax(c echo.Context) error {
 		}
 	}
 
+	for _, tax := range taxes {
+		ok, err := h.Authorizer.CanCreateTax(ctx, tax)
+		if err != nil {
+			return errors.E(op, err)
+		}
+		if !ok {
+			return errors.E(op, errors.KindNoPermission, "Not allowed to manage taxes of these locations")
+		}
+	}
+
 	taxes, err := h.CatalogService.PutTaxes(ctx, taxes)
 	if err != nil {
 		return errors.E(op, err)
@@ -163,6 +197,14 @@ func (h *Handler) HandleRetrieveTax(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanGetTax(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to access this tax")
+	}
+
 	tax, err := h.CatalogService.GetTax(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -196,10 +238,19 @@ func (h *Handler) HandleListTaxes(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, nil)
+	ok, err := h.Authorizer.CanSearch(ctx, core.CatalogRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the taxes of these locations")
+	}
+
 	taxes, count, err := h.CatalogService.ListTax(ctx, core.TaxQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
-		Filter: core.TaxFilter{MerchantID: merchant.ID},
+		Filter: core.TaxFilter{LocationIDs: locationIDs, MerchantID: merchant.ID},
 	})
 	if err != nil {
 		return errors.E(op, err)
@@ -253,12 +304,21 @@ func (h *Handler) HandleSearchTax(c echo.Context) error {
 		return err
 	}
 
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.CatalogRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the taxes of these locations")
+	}
+
 	taxes, count, err := h.CatalogService.ListTax(ctx, core.TaxQuery{
 		Limit:  req.Limit,
 		Offset: req.Offset,
 		Filter: core.TaxFilter{
 			Name:        req.Filter.Name,
-			LocationIDs: req.Filter.LocationIDs,
+			LocationIDs: locationIDs,
 			MerchantID:  merchant.ID,
 		},
 		Sort: core.TaxSort{
@@ -299,6 +359,14 @@ func (h *Handler) HandleDeleteTax(c echo.Context) error {
 		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
 	}
 
+	ok, err := h.Authorizer.CanUpdateTax(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to change this tax")
+	}
+
 	tax, err := h.CatalogService.DeleteTax(ctx, req.ID)
 	if err != nil {
 		return errors.E(op, err)
@@ -0,0 +1,368 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	TimesheetListDefaultSize = 10
+	TimesheetListMaxSize     = 50
+)
+
+func (h *Handler) HandleClockIn(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleClockIn")
+
+	type request struct {
+		LocationID core.ID `json:"location_id" validate:"required,id"`
+		Note       string  `json:"note"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ts, err := h.EmployeeService.ClockIn(ctx, req.LocationID, req.Note)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTimesheet(ts))
+}
+
+func (h *Handler) HandleClockOut(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleClockOut")
+
+	type request struct {
+		Note string `json:"note"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ts, err := h.EmployeeService.ClockOut(ctx, req.Note)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTimesheet(ts))
+}
+
+func (h *Handler) HandleStartBreak(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleStartBreak")
+
+	ctx := c.Request().Context()
+
+	ts, err := h.EmployeeService.StartBreak(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTimesheet(ts))
+}
+
+func (h *Handler) HandleEndBreak(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleEndBreak")
+
+	ctx := c.Request().Context()
+
+	ts, err := h.EmployeeService.EndBreak(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTimesheet(ts))
+}
+
+func (h *Handler) HandleRetrieveTimesheet(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRetrieveTimesheet")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ts, err := h.EmployeeService.GetTimesheet(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTimesheet(ts))
+}
+
+func (h *Handler) HandleUpdateTimesheet(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleUpdateTimesheet")
+
+	type timesheetBreak struct {
+		StartAt int64 `json:"start_at" validate:"required"`
+		EndAt   int64 `json:"end_at" validate:"required"`
+	}
+
+	type request struct {
+		ID         core.ID           `param:"id" validate:"required,id"`
+		ClockInAt  *int64            `json:"clock_in_at" validate:"omitempty,gt=0"`
+		ClockOutAt *int64            `json:"clock_out_at" validate:"omitempty,gt=0"`
+		Breaks     *[]timesheetBreak `json:"breaks" validate:"omitempty,dive"`
+		Note       *string           `json:"note"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ts, err := h.EmployeeService.GetTimesheet(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if req.ClockInAt != nil {
+		ts.ClockInAt = *req.ClockInAt
+	}
+	if req.ClockOutAt != nil {
+		ts.ClockOutAt = *req.ClockOutAt
+	}
+	if req.Breaks != nil {
+		ts.Breaks = make([]core.TimesheetBreak, len(*req.Breaks))
+		for i, b := range *req.Breaks {
+			ts.Breaks[i] = core.TimesheetBreak{StartAt: b.StartAt, EndAt: b.EndAt}
+		}
+	}
+	if req.Note != nil {
+		ts.Note = *req.Note
+	}
+
+	ts, err = h.EmployeeService.UpdateTimesheet(ctx, ts)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTimesheet(ts))
+}
+
+func (h *Handler) HandleApproveTimesheet(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleApproveTimesheet")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ts, err := h.EmployeeService.ApproveTimesheet(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTimesheet(ts))
+}
+
+func (h *Handler) HandleSearchTimesheet(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSearchTimesheet")
+
+	type dateFilter struct {
+		Gte int64 `json:"gte" validate:"gte=0"`
+		Lte int64 `json:"lte" validate:"gte=0"`
+	}
+
+	type filter struct {
+		IDs         []core.ID             `json:"ids" validate:"omitempty,dive,id"`
+		EmployeeIDs []core.ID             `json:"employee_ids" validate:"omitempty,dive,id"`
+		LocationIDs []core.ID             `json:"location_ids" validate:"omitempty,dive,id"`
+		States      []core.TimesheetState `json:"states" validate:"omitempty,dive,oneof=open closed approved"`
+		ClockInAt   dateFilter            `json:"clock_in_at"`
+	}
+
+	type request struct {
+		Limit  int64  `json:"limit" validate:"gte=0"`
+		Offset int64  `json:"offset" validate:"gte=0"`
+		Filter filter `json:"filter"`
+	}
+
+	type response struct {
+		Timesheets []Timesheet `json:"timesheets"`
+		Total      int64       `json:"total_count"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var limit int64 = TimesheetListDefaultSize
+	if req.Limit <= TimesheetListMaxSize {
+		limit = req.Limit
+	} else {
+		limit = TimesheetListMaxSize
+	}
+
+	timesheets, count, err := h.EmployeeService.ListTimesheet(ctx, core.TimesheetQuery{
+		Limit:  limit,
+		Offset: req.Offset,
+		Filter: core.TimesheetFilter{
+			IDs:         req.Filter.IDs,
+			EmployeeIDs: req.Filter.EmployeeIDs,
+			LocationIDs: req.Filter.LocationIDs,
+			States:      req.Filter.States,
+			ClockInAt:   core.DateFilter{Gte: req.Filter.ClockInAt.Gte, Lte: req.Filter.ClockInAt.Lte},
+			MerchantID:  merchant.ID,
+		},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		Timesheets: make([]Timesheet, len(timesheets)),
+		Total:      count,
+	}
+	for i, ts := range timesheets {
+		resp.Timesheets[i] = NewTimesheet(ts)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleSummarizeTimesheets(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSummarizeTimesheets")
+
+	type request struct {
+		EmployeeIDs  []core.ID `json:"employee_ids" validate:"omitempty,dive,id"`
+		LocationIDs  []core.ID `json:"location_ids" validate:"omitempty,dive,id"`
+		BeginTime    int64     `json:"begin_time" validate:"gte=0"`
+		EndTime      int64     `json:"end_time" validate:"gte=0"`
+		Timezone     string    `json:"timezone" validate:"required"`
+		ApprovedOnly bool      `json:"approved_only"`
+	}
+
+	type response struct {
+		Summaries []TimesheetSummary `json:"summaries"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	summaries, err := h.EmployeeService.SummarizeTimesheets(ctx, core.TimesheetSummaryRequest{
+		EmployeeIDs:  req.EmployeeIDs,
+		LocationIDs:  req.LocationIDs,
+		MerchantID:   merchant.ID,
+		BeginTime:    req.BeginTime,
+		EndTime:      req.EndTime,
+		Timezone:     req.Timezone,
+		ApprovedOnly: req.ApprovedOnly,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{Summaries: make([]TimesheetSummary, len(summaries))}
+	for i, s := range summaries {
+		resp.Summaries[i] = TimesheetSummary{
+			EmployeeID:     s.EmployeeID,
+			TimesheetCount: s.TimesheetCount,
+			RegularHours:   s.RegularHours,
+			OvertimeHours:  s.OvertimeHours,
+			RegularAmount:  NewMoney(s.RegularAmount),
+			OvertimeAmount: NewMoney(s.OvertimeAmount),
+			TotalAmount:    NewMoney(s.TotalAmount),
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+type TimesheetBreak struct {
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
+}
+
+type Timesheet struct {
+	ID            core.ID             `json:"id"`
+	EmployeeID    core.ID             `json:"employee_id"`
+	State         core.TimesheetState `json:"state"`
+	ClockInAt     int64               `json:"clock_in_at"`
+	ClockOutAt    int64               `json:"clock_out_at"`
+	Breaks        []TimesheetBreak    `json:"breaks"`
+	WorkedSeconds int64               `json:"worked_seconds"`
+	Note          string              `json:"note"`
+	EditedBy      core.ID             `json:"edited_by"`
+	ApprovedBy    core.ID             `json:"approved_by"`
+	ApprovedAt    int64               `json:"approved_at"`
+	LocationID    core.ID             `json:"location_id"`
+	MerchantID    core.ID             `json:"merchant_id"`
+	CreatedAt     int64               `json:"created_at"`
+	UpdatedAt     int64               `json:"updated_at"`
+}
+
+func NewTimesheet(ts core.Timesheet) Timesheet {
+	breaks := make([]TimesheetBreak, len(ts.Breaks))
+	for i, b := range ts.Breaks {
+		breaks[i] = TimesheetBreak{StartAt: b.StartAt, EndAt: b.EndAt}
+	}
+	return Timesheet{
+		ID:            ts.ID,
+		EmployeeID:    ts.EmployeeID,
+		State:         ts.State,
+		ClockInAt:     ts.ClockInAt,
+		ClockOutAt:    ts.ClockOutAt,
+		Breaks:        breaks,
+		WorkedSeconds: ts.WorkedSeconds(time.Now().Unix()),
+		Note:          ts.Note,
+		EditedBy:      ts.EditedBy,
+		ApprovedBy:    ts.ApprovedBy,
+		ApprovedAt:    ts.ApprovedAt,
+		LocationID:    ts.LocationID,
+		MerchantID:    ts.MerchantID,
+		CreatedAt:     ts.CreatedAt,
+		UpdatedAt:     ts.UpdatedAt,
+	}
+}
+
+type TimesheetSummary struct {
+	EmployeeID     core.ID `json:"employee_id"`
+	TimesheetCount int64   `json:"timesheet_count"`
+	RegularHours   float64 `json:"regular_hours"`
+	OvertimeHours  float64 `json:"overtime_hours"`
+	RegularAmount  Money   `json:"regular_amount"`
+	OvertimeAmount Money   `json:"overtime_amount"`
+	TotalAmount    Money   `json:"total_amount"`
+}
@@ -0,0 +1,476 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	TipListDefaultSize = 10
+	TipListMaxSize     = 50
+)
+
+func (h *Handler) HandleCreateTipPool(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleCreateTipPool")
+
+	type request struct {
+		Name        string             `json:"name" validate:"required"`
+		Method      core.TipPoolMethod `json:"method" validate:"required"`
+		EmployeeIDs []core.ID          `json:"employee_ids" validate:"omitempty,dive,id"`
+		LocationIDs []core.ID          `json:"location_ids" validate:"omitempty,dive,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if ok := req.Method.Validate(); !ok {
+		msg := fmt.Sprintf("request field 'method' is not valid, it should be one of: %v", core.TipPoolMethods())
+		return errors.E(op, errors.KindValidation, msg)
+	}
+
+	pool := core.NewTipPool(req.Name, req.Method, merchant.ID)
+	if req.EmployeeIDs != nil {
+		pool.EmployeeIDs = req.EmployeeIDs
+	}
+	if req.LocationIDs != nil {
+		pool.LocationIDs = req.LocationIDs
+	}
+
+	pool, err := h.TipService.PutTipPool(ctx, pool)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTipPool(pool))
+}
+
+func (h *Handler) HandleUpdateTipPool(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleUpdateTipPool")
+
+	type request struct {
+		ID          core.ID             `param:"id" validate:"required"`
+		Name        *string             `json:"name" validate:"omitempty,min=1"`
+		Method      *core.TipPoolMethod `json:"method"`
+		EmployeeIDs *[]core.ID          `json:"employee_ids" validate:"omitempty,dive,id"`
+		LocationIDs *[]core.ID          `json:"location_ids" validate:"omitempty,dive,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	pool, err := h.TipService.GetTipPool(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if req.Name != nil {
+		pool.Name = *req.Name
+	}
+	if req.Method != nil {
+		if ok := req.Method.Validate(); !ok {
+			msg := fmt.Sprintf("request field 'method' is not valid, it should be one of: %v", core.TipPoolMethods())
+			return errors.E(op, errors.KindValidation, msg)
+		}
+		pool.Method = *req.Method
+	}
+	if req.EmployeeIDs != nil {
+		pool.EmployeeIDs = *req.EmployeeIDs
+	}
+	if req.LocationIDs != nil {
+		pool.LocationIDs = *req.LocationIDs
+	}
+
+	pool, err = h.TipService.PutTipPool(ctx, pool)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTipPool(pool))
+}
+
+func (h *Handler) HandleRetrieveTipPool(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRetrieveTipPool")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ok, err := h.Authorizer.CanGetTipPool(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see this tip pool")
+	}
+
+	pool, err := h.TipService.GetTipPool(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTipPool(pool))
+}
+
+func (h *Handler) HandleSearchTipPool(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSearchTipPool")
+
+	type filter struct {
+		IDs         []core.ID `json:"ids" validate:"omitempty,dive,id"`
+		LocationIDs []core.ID `json:"location_ids" validate:"omitempty,dive,id"`
+	}
+
+	type request struct {
+		Limit  int64  `json:"limit" validate:"gte=0"`
+		Offset int64  `json:"offset" validate:"gte=0"`
+		Filter filter `json:"filter"`
+	}
+
+	type response struct {
+		TipPools []TipPool `json:"tip_pools"`
+		Total    int64     `json:"total_count"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var limit int64 = TipListDefaultSize
+	if req.Limit <= TipListMaxSize {
+		limit = req.Limit
+	} else {
+		limit = TipListMaxSize
+	}
+
+	locationIDs := h.Authorizer.LocationScope(ctx, req.Filter.LocationIDs)
+	ok, err := h.Authorizer.CanSearch(ctx, core.ReportRead, locationIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the tip pools of these locations")
+	}
+
+	pools, count, err := h.TipService.ListTipPool(ctx, core.TipPoolQuery{
+		Limit:  limit,
+		Offset: req.Offset,
+		Filter: core.TipPoolFilter{
+			IDs:         req.Filter.IDs,
+			LocationIDs: locationIDs,
+			MerchantID:  merchant.ID,
+		},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		TipPools: make([]TipPool, len(pools)),
+		Total:    count,
+	}
+	for i, pool := range pools {
+		resp.TipPools[i] = NewTipPool(pool)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleDeleteTipPool(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleDeleteTipPool")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	pool, err := h.TipService.DeleteTipPool(ctx, req.ID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTipPool(pool))
+}
+
+type TipPoolMemberRequest struct {
+	EmployeeID core.ID `json:"employee_id" validate:"required,id"`
+	Hours      float64 `json:"hours" validate:"gte=0"`
+}
+
+type TipPayoutRequest struct {
+	TipPoolID core.ID                `param:"id" validate:"required,id"`
+	BeginTime int64                  `json:"begin_time" validate:"gte=0"`
+	EndTime   int64                  `json:"end_time" validate:"gte=0"`
+	Members   []TipPoolMemberRequest `json:"members" validate:"omitempty,dive"`
+	Note      string                 `json:"note"`
+}
+
+func (req TipPayoutRequest) TipPayoutRequest() core.TipPayoutRequest {
+	members := make([]core.TipPoolMember, len(req.Members))
+	for i, m := range req.Members {
+		members[i] = core.TipPoolMember{EmployeeID: m.EmployeeID, Hours: m.Hours}
+	}
+	return core.TipPayoutRequest{
+		TipPoolID: req.TipPoolID,
+		BeginTime: req.BeginTime,
+		EndTime:   req.EndTime,
+		Members:   members,
+		Note:      req.Note,
+	}
+}
+
+func (h *Handler) HandleCalculateTipPayout(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleCalculateTipPayout")
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := TipPayoutRequest{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ok, err := h.Authorizer.CanGetTipPool(ctx, req.TipPoolID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to see this tip pool")
+	}
+
+	payout, err := h.TipService.CalculateTipPayout(ctx, req.TipPayoutRequest())
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTipPayout(payout))
+}
+
+func (h *Handler) HandleCreateTipPayout(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleCreateTipPayout")
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := TipPayoutRequest{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	payout, err := h.TipService.CreateTipPayout(ctx, req.TipPayoutRequest())
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewTipPayout(payout))
+}
+
+func (h *Handler) HandleSearchTipPayout(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleSearchTipPayout")
+
+	type dateFilter struct {
+		Gte int64 `json:"gte" validate:"gte=0"`
+		Lte int64 `json:"lte" validate:"gte=0"`
+	}
+
+	type filter struct {
+		IDs         []core.ID  `json:"ids" validate:"omitempty,dive,id"`
+		TipPoolIDs  []core.ID  `json:"tip_pool_ids" validate:"omitempty,dive,id"`
+		EmployeeIDs []core.ID  `json:"employee_ids" validate:"omitempty,dive,id"`
+		CreatedAt   dateFilter `json:"created_at"`
+	}
+
+	type request struct {
+		Limit  int64  `json:"limit" validate:"gte=0"`
+		Offset int64  `json:"offset" validate:"gte=0"`
+		Filter filter `json:"filter"`
+	}
+
+	type response struct {
+		TipPayouts []TipPayout `json:"tip_payouts"`
+		Total      int64       `json:"total_count"`
+	}
+
+	ctx := c.Request().Context()
+
+	merchant := core.MerchantFromContext(ctx)
+	if merchant == nil {
+		return errors.E(op, errors.KindUnexpected, "invalid echo.Context")
+	}
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	var limit int64 = TipListDefaultSize
+	if req.Limit <= TipListMaxSize {
+		limit = req.Limit
+	} else {
+		limit = TipListMaxSize
+	}
+
+	tipPoolIDs, err := h.Authorizer.TipPoolScope(ctx, req.Filter.TipPoolIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	ok, err := h.Authorizer.CanSearchTipPayout(ctx, tipPoolIDs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if !ok {
+		return errors.E(op, errors.KindNoPermission, "Not allowed to search the payouts of these tip pools")
+	}
+
+	payouts, count, err := h.TipService.ListTipPayout(ctx, core.TipPayoutQuery{
+		Limit:  limit,
+		Offset: req.Offset,
+		Filter: core.TipPayoutFilter{
+			IDs:         req.Filter.IDs,
+			TipPoolIDs:  tipPoolIDs,
+			EmployeeIDs: req.Filter.EmployeeIDs,
+			CreatedAt:   core.DateFilter{Gte: req.Filter.CreatedAt.Gte, Lte: req.Filter.CreatedAt.Lte},
+			MerchantID:  merchant.ID,
+		},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp := response{
+		TipPayouts: make([]TipPayout, len(payouts)),
+		Total:      count,
+	}
+	for i, payout := range payouts {
+		resp.TipPayouts[i] = NewTipPayout(payout)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+type TipPool struct {
+	ID          core.ID            `json:"id"`
+	Name        string             `json:"name"`
+	Method      core.TipPoolMethod `json:"method"`
+	EmployeeIDs []core.ID          `json:"employee_ids"`
+	LocationIDs []core.ID          `json:"location_ids"`
+	MerchantID  core.ID            `json:"merchant_id"`
+	CreatedAt   int64              `json:"created_at"`
+	UpdatedAt   int64              `json:"updated_at"`
+	Status      core.Status        `json:"status"`
+}
+
+func NewTipPool(pool core.TipPool) TipPool {
+	return TipPool{
+		ID:          pool.ID,
+		Name:        pool.Name,
+		Method:      pool.Method,
+		EmployeeIDs: pool.EmployeeIDs,
+		LocationIDs: pool.LocationIDs,
+		MerchantID:  pool.MerchantID,
+		CreatedAt:   pool.CreatedAt,
+		UpdatedAt:   pool.UpdatedAt,
+		Status:      pool.Status,
+	}
+}
+
+type TipPayoutLine struct {
+	EmployeeID core.ID `json:"employee_id"`
+	Hours      float64 `json:"hours"`
+	Amount     Money   `json:"amount"`
+}
+
+type TipPayout struct {
+	ID          core.ID            `json:"id"`
+	TipPoolID   core.ID            `json:"tip_pool_id"`
+	Method      core.TipPoolMethod `json:"method"`
+	BeginTime   int64              `json:"begin_time"`
+	EndTime     int64              `json:"end_time"`
+	TotalAmount Money              `json:"total_amount"`
+	Lines       []TipPayoutLine    `json:"lines"`
+	Note        string             `json:"note"`
+	EmployeeID  core.ID            `json:"employee_id"`
+	MerchantID  core.ID            `json:"merchant_id"`
+	CreatedAt   int64              `json:"created_at"`
+}
+
+func NewTipPayout(payout core.TipPayout) TipPayout {
+	lines := make([]TipPayoutLine, len(payout.Lines))
+	for i, l := range payout.Lines {
+		lines[i] = TipPayoutLine{
+			EmployeeID: l.EmployeeID,
+			Hours:      l.Hours,
+			Amount:     NewMoney(l.Amount),
+		}
+	}
+	return TipPayout{
+		ID:          payout.ID,
+		TipPoolID:   payout.TipPoolID,
+		Method:      payout.Method,
+		BeginTime:   payout.BeginTime,
+		EndTime:     payout.EndTime,
+		TotalAmount: NewMoney(payout.TotalAmount),
+		Lines:       lines,
+		Note:        payout.Note,
+		EmployeeID:  payout.EmployeeID,
+		MerchantID:  payout.MerchantID,
+		CreatedAt:   payout.CreatedAt,
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/backium/backend/core"
 	"github.com/backium/backend/errors"
@@ -84,6 +85,47 @@ func (h *Handler) HandleRegisterEmployee(c echo.Context) error {
 	})
 }
 
+// HandleRegisterCustomer signs up a customer of a business, customers can
+// sign up by themselves so the route is public
+func (h *Handler) HandleRegisterCustomer(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRegisterCustomer")
+
+	type request struct {
+		MerchantID core.ID `json:"merchant_id" validate:"required,id"`
+		Name       string  `json:"name" validate:"required"`
+		Phone      string  `json:"phone"`
+		Email      string  `json:"email" validate:"required,email"`
+		Password   string  `json:"password" validate:"required,password"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	customer := core.NewCustomer(req.Name, req.Email, req.MerchantID)
+	customer.Phone = req.Phone
+
+	user, err := h.UserService.CreateCustomer(ctx, req.MerchantID, customer, req.Email, req.Password)
+	if errors.Is(err, errors.KindUserExist) {
+		return c.JSON(http.StatusOK, RegisterResponse{
+			ExistingUser: true,
+		})
+	}
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, RegisterResponse{
+		UserID:       user.ID,
+		MerchantID:   user.MerchantID,
+		CustomerID:   user.CustomerID,
+		ExistingUser: false,
+	})
+}
+
 func (h *Handler) HandleLogin(c echo.Context) error {
 	const op = errors.Op("authHandler.Login")
 
@@ -92,6 +134,12 @@ func (h *Handler) HandleLogin(c echo.Context) error {
 		Password string `json:"password" validate:"required"`
 	}
 
+	type response struct {
+		User
+		// Second login step to complete before the session is usable
+		TwoFactorStep core.TwoFactorStep `json:"two_factor_step,omitempty"`
+	}
+
 	ctx := c.Request().Context()
 
 	req := request{}
@@ -99,16 +147,23 @@ func (h *Handler) HandleLogin(c echo.Context) error {
 		return errors.E(op, err)
 	}
 
-	user, err := h.UserService.Login(ctx, req.Email, req.Password)
+	user, err := h.UserService.Login(ctx, req.Email, req.Password, loginClient(c))
 	if err != nil {
 		return errors.E(op, err)
 	}
 
-	if err := h.setSession(c, user); err != nil {
+	session, err := h.newLoginSession(c, user)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	if err := h.setSessionCookie(c, session); err != nil {
 		return errors.E(op, err)
 	}
 
-	return c.JSON(http.StatusOK, NewUser(user))
+	return c.JSON(http.StatusOK, response{
+		User:          NewUser(user),
+		TwoFactorStep: session.TwoFactorStep,
+	})
 }
 
 func (h *Handler) HandleUniversalLogin(c echo.Context) error {
@@ -126,16 +181,19 @@ func (h *Handler) HandleUniversalLogin(c echo.Context) error {
 		return errors.E(op, err)
 	}
 
-	user, err := h.UserService.Login(ctx, req.Email, req.Password)
+	user, err := h.UserService.Login(ctx, req.Email, req.Password, loginClient(c))
 	if err != nil {
 		return errors.E(op, err)
 	}
 
-	s := core.NewSession(user)
-	if err := h.SessionRepository.Set(ctx, s); err != nil {
+	s, err := h.newLoginSession(c, user)
+	if err != nil {
 		return errors.E(op, err)
 	}
 	c.Response().Header().Set("session-id", string(s.ID))
+	if s.Pending() {
+		c.Response().Header().Set("two-factor-step", string(s.TwoFactorStep))
+	}
 
 	return c.NoContent(http.StatusOK)
 }
@@ -150,12 +208,16 @@ func (h *Handler) HandleUniversalGetSession(c echo.Context) error {
 	if err != nil {
 		return errors.E(op, errors.KindInvalidCredentials, err)
 	}
-	token, err := s.Encode([]byte("backium"))
+	token, err := s.Encode(h.SessionKeys)
+	if err != nil {
+		return errors.E(op, err)
+	}
 
 	c.SetCookie(&http.Cookie{
-		Name:  "web_session",
-		Value: token,
-		Path:  "/api/v1",
+		Name:    "web_session",
+		Value:   token,
+		Path:    "/api/v1",
+		Expires: time.Unix(s.ExpiresAt, 0),
 	})
 
 	return c.NoContent(http.StatusOK)
@@ -181,44 +243,414 @@ func (h *Handler) setSession(c echo.Context, u core.User) error {
 
 	ctx := c.Request().Context()
 
-	session := core.NewSession(u)
-	token, err := session.Encode([]byte("backium"))
-	if err != nil {
+	session := newClientSession(c, u)
+	if err := h.SessionRepository.Set(ctx, session); err != nil {
 		return errors.E(op, err)
 	}
+
+	if err := h.setSessionCookie(c, session); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// newLoginSession stores a session for the user logging in with its password,
+// the session is pending if the user has to go through a second login step
+func (h *Handler) newLoginSession(c echo.Context, u core.User) (core.Session, error) {
+	const op = errors.Op("http/Handler.newLoginSession")
+
+	ctx := c.Request().Context()
+
+	step, err := h.UserService.TwoFactorStep(ctx, u)
+	if err != nil {
+		return core.Session{}, errors.E(op, err)
+	}
+	session := newClientSession(c, u)
+	if step != core.TwoFactorNone {
+		session.RequireTwoFactor(step)
+	}
 	if err := h.SessionRepository.Set(ctx, session); err != nil {
+		return core.Session{}, errors.E(op, err)
+	}
+
+	return session, nil
+}
+
+// completeLogin replaces the pending session of the context by a full session
+func (h *Handler) completeLogin(c echo.Context, u core.User) error {
+	const op = errors.Op("http/Handler.completeLogin")
+
+	ctx := c.Request().Context()
+
+	pending := core.SessionFromContext(ctx)
+	if pending == nil || !pending.Pending() {
+		return nil
+	}
+	if err := h.SessionRepository.Delete(ctx, pending.ID); err != nil {
+		return errors.E(op, err)
+	}
+	if err := h.setSession(c, u); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// loginClient returns the client of the request logging in
+func loginClient(c echo.Context) core.LoginClient {
+	return core.LoginClient{
+		IP:        c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	}
+}
+
+// newClientSession returns a session for the user on the client of the request
+func newClientSession(c echo.Context, u core.User) core.Session {
+	session := core.NewSession(u)
+	session.UserAgent = c.Request().UserAgent()
+	session.IP = c.RealIP()
+	return session
+}
+
+func (h *Handler) setSessionCookie(c echo.Context, session core.Session) error {
+	const op = errors.Op("http/Handler.setSessionCookie")
+
+	token, err := session.Encode(h.SessionKeys)
+	if err != nil {
 		return errors.E(op, err)
 	}
 
 	c.SetCookie(&http.Cookie{
-		Name:  "web_session",
-		Value: token,
+		Name:    "web_session",
+		Value:   token,
+		Path:    "/api/v1",
+		Expires: time.Unix(session.ExpiresAt, 0),
 	})
 
 	return nil
 }
 
+func (h *Handler) HandleChangePassword(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleChangePassword")
+
+	type request struct {
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required,password"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.UserService.ChangePassword(ctx, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	// All the sessions were revoked, the client changing the password gets
+	// a new one
+	if err := h.setSession(c, user); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewUser(user))
+}
+
+func (h *Handler) HandleForgotPassword(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleForgotPassword")
+
+	type request struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.UserService.RequestPasswordReset(ctx, req.Email); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) HandleResetPassword(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleResetPassword")
+
+	type request struct {
+		Token       string `json:"token" validate:"required"`
+		NewPassword string `json:"new_password" validate:"required,password"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.UserService.ResetPassword(ctx, req.Token, req.NewPassword)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewUser(user))
+}
+
+func (h *Handler) HandleResendEmailVerification(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleResendEmailVerification")
+
+	ctx := c.Request().Context()
+
+	if err := h.UserService.ResendEmailVerification(ctx); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) HandleVerifyEmail(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleVerifyEmail")
+
+	type request struct {
+		Token string `json:"token" validate:"required"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.UserService.VerifyEmail(ctx, req.Token)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewUser(user))
+}
+
+func (h *Handler) HandleVerifyTwoFactor(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleVerifyTwoFactor")
+
+	type request struct {
+		Code string `json:"code" validate:"required"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.UserService.VerifyTwoFactor(ctx, req.Code, loginClient(c))
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := h.completeLogin(c, user); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewUser(user))
+}
+
+func (h *Handler) HandleEnrollTwoFactor(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleEnrollTwoFactor")
+
+	type response struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+
+	ctx := c.Request().Context()
+
+	secret, uri, err := h.UserService.EnrollTwoFactor(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, response{Secret: secret, URI: uri})
+}
+
+func (h *Handler) HandleConfirmTwoFactor(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleConfirmTwoFactor")
+
+	type request struct {
+		Code string `json:"code" validate:"required"`
+	}
+
+	type response struct {
+		User
+		// Only returned on confirmation
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, codes, err := h.UserService.ConfirmTwoFactor(ctx, req.Code)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	// Users required to enroll to log in are logged in once enrolled
+	if err := h.completeLogin(c, user); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, response{
+		User:          NewUser(user),
+		RecoveryCodes: codes,
+	})
+}
+
+func (h *Handler) HandleDisableTwoFactor(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleDisableTwoFactor")
+
+	type request struct {
+		Password string `json:"password" validate:"required"`
+		Code     string `json:"code" validate:"required"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.UserService.DisableTwoFactor(ctx, req.Password, req.Code)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.JSON(http.StatusOK, NewUser(user))
+}
+
+func (h *Handler) HandleListSessions(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleListSessions")
+
+	type response struct {
+		Sessions []Session `json:"sessions"`
+	}
+
+	ctx := c.Request().Context()
+
+	sessions, err := h.UserService.ListSessions(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	current := core.SessionFromContext(ctx)
+	resp := response{Sessions: make([]Session, len(sessions))}
+	for i, s := range sessions {
+		resp.Sessions[i] = NewSession(s)
+		resp.Sessions[i].Current = current != nil && s.ID == current.ID
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) HandleRevokeSession(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleRevokeSession")
+
+	type request struct {
+		ID core.ID `param:"id" validate:"required,id"`
+	}
+
+	ctx := c.Request().Context()
+
+	req := request{}
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.UserService.RevokeSession(ctx, req.ID); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) HandleLogoutEverywhere(c echo.Context) error {
+	const op = errors.Op("http/Handler.HandleLogoutEverywhere")
+
+	ctx := c.Request().Context()
+
+	if err := h.UserService.RevokeAllSessions(ctx); err != nil {
+		return errors.E(op, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
 type User struct {
-	ID         core.ID `json:"id"`
-	Email      string  `json:"email"`
-	IsOwner    bool    `json:"is_owner"`
-	EmployeeID core.ID `json:"employee_id"`
-	MerchantID core.ID `json:"merchant_id"`
+	ID               core.ID       `json:"id"`
+	Email            string        `json:"email"`
+	EmailVerified    bool          `json:"email_verified"`
+	TwoFactorEnabled bool          `json:"two_factor_enabled"`
+	Kind             core.UserKind `json:"kind"`
+	IsOwner          bool          `json:"is_owner"`
+	EmployeeID       core.ID       `json:"employee_id"`
+	CustomerID       core.ID       `json:"customer_id,omitempty"`
+	MerchantID       core.ID       `json:"merchant_id"`
 }
 
 func NewUser(user core.User) User {
 	return User{
-		ID:         user.ID,
-		Email:      user.Email,
-		IsOwner:    user.Kind == core.UserKindOwner,
-		EmployeeID: user.EmployeeID,
-		MerchantID: user.MerchantID,
+		ID:               user.ID,
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerified(),
+		TwoFactorEnabled: user.TwoFactorEnabled(),
+		Kind:             user.Kind,
+		IsOwner:          user.Kind == core.UserKindOwner,
+		EmployeeID:       user.EmployeeID,
+		CustomerID:       user.CustomerID,
+		MerchantID:       user.MerchantID,
 	}
 }
 
 type RegisterResponse struct {
 	UserID       core.ID `json:"user_id,omitempty"`
 	EmployeeID   core.ID `json:"employee_id,omitempty"`
+	CustomerID   core.ID `json:"customer_id,omitempty"`
 	MerchantID   core.ID `json:"merchant_id,omitempty"`
 	ExistingUser bool    `json:"existing_user"`
 }
+
+type Session struct {
+	ID         core.ID `json:"id"`
+	DeviceID   core.ID `json:"device_id,omitempty"`
+	UserAgent  string  `json:"user_agent"`
+	IP         string  `json:"ip"`
+	CreatedAt  int64   `json:"created_at"`
+	LastSeenAt int64   `json:"last_seen_at"`
+	ExpiresAt  int64   `json:"expires_at"`
+	Current    bool    `json:"current"`
+}
+
+func NewSession(session core.Session) Session {
+	return Session{
+		ID:         session.ID,
+		DeviceID:   session.DeviceID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+	}
+}
@@ -0,0 +1,43 @@
+// Package mail implements core.Mailer, mails are delivered through an SMTP
+// server or written out for local development
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/backium/backend/core"
+)
+
+type writerMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewFileMailer returns a mailer appending the mails to the file at path
+func NewFileMailer(path string) (core.Mailer, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &writerMailer{w: f}, nil
+}
+
+// NewLogMailer returns a mailer writing the mails to the standard logger
+func NewLogMailer() core.Mailer {
+	return &writerMailer{w: log.Writer()}
+}
+
+func (m *writerMailer) Send(ctx context.Context, mail core.Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC1123Z), mail.To, mail.Subject, mail.Body)
+	return err
+}
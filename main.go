@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/backium/backend/cloudinary"
@@ -15,11 +16,17 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	log.Printf("config: %+v", config)
+	sessionKeys, err := config.SessionSigningKeys()
+	if err != nil {
+		log.Fatalf("session keys: %v", err)
+	}
 	db, err := mongo.New(config.DBURI, config.DBName)
 	if err != nil {
 		log.Fatalf("mongodb: %v", err)
 	}
+	if err := db.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("mongodb indexes: %v", err)
+	}
 	uploader, err := cloudinary.New(config.CloudinaryURI)
 	if err != nil {
 		log.Fatalf("cloudinary: %v", err)
@@ -38,10 +45,28 @@ func main() {
 	paymentStorage := mongo.NewPaymentStorage(db)
 	inventoryStorage := mongo.NewInventoryStorage(db)
 	cashDrawerStorage := mongo.NewCashDrawerStorage(db)
+	tipStorage := mongo.NewTipStorage(db)
+	timesheetStorage := mongo.NewTimesheetStorage(db)
+	deviceStorage := mongo.NewDeviceStorage(db)
+	roleStorage := mongo.NewRoleStorage(db)
+	userTokenStorage := mongo.NewUserTokenStorage(db)
+	failedLoginStorage := mongo.NewFailedLoginStorage(db)
+
+	mailer, err := config.NewMailer()
+	if err != nil {
+		log.Fatalf("mailer: %v", err)
+	}
+	ipExtractor, err := config.IPExtractor()
+	if err != nil {
+		log.Fatalf("trusted proxies: %v", err)
+	}
 
+	loginAttemptStorage := redis.NewLoginAttemptStorage(config.RedisURI, config.RedisPassword)
 	redis := redis.NewSessionRepository(config.RedisURI, config.RedisPassword)
+	e := echo.New()
+	e.IPExtractor = ipExtractor
 	s := http.Server{
-		Echo:                 echo.New(),
+		Echo:                 e,
 		DB:                   db,
 		UserStorage:          userRepository,
 		EmployeeStorage:      employeeStorage,
@@ -57,8 +82,18 @@ func main() {
 		PaymentStorage:       paymentStorage,
 		InventoryStorage:     inventoryStorage,
 		CashDrawerStorage:    cashDrawerStorage,
+		TipStorage:           tipStorage,
+		TimesheetStorage:     timesheetStorage,
+		DeviceStorage:        deviceStorage,
+		RoleStorage:          roleStorage,
+		UserTokenStorage:     userTokenStorage,
+		FailedLoginStorage:   failedLoginStorage,
+		LoginAttemptStorage:  loginAttemptStorage,
 		SessionRepository:    redis,
+		SessionKeys:          sessionKeys,
 		Uploader:             uploader,
+		Mailer:               mailer,
+		AppURL:               config.AppURL,
 	}
 	s.Setup()
 	s.ListenAndServe(config.Port)
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/backium/backend/core"
+	"github.com/backium/backend/mail"
 	"github.com/backium/backend/storage/mongo"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -60,6 +61,8 @@ func main() {
 	inventoryStorage := mongo.NewInventoryStorage(db)
 	cashDrawerStorage := mongo.NewCashDrawerStorage(db)
 	customerStorage := mongo.NewCustomerStorage(db)
+	roleStorage := mongo.NewRoleStorage(db)
+	userTokenStorage := mongo.NewUserTokenStorage(db)
 
 	userService := core.UserService{
 		UserStorage:       userRepository,
@@ -67,6 +70,11 @@ func main() {
 		LocationStorage:   locationStorage,
 		EmployeeStorage:   employeeStorage,
 		CashDrawerStorage: cashDrawerStorage,
+		RoleStorage:       roleStorage,
+		UserTokenStorage:  userTokenStorage,
+		// The feeder only seeds development databases, the mails of the
+		// seeded users are logged
+		Mailer: mail.NewLogMailer(),
 	}
 
 	catalogService := core.CatalogService{
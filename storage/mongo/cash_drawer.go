@@ -15,33 +15,50 @@ const (
 	cashDrawerIDPrefix          = "cat"
 	cashDrawerCollectionName    = "cashdrawers"
 	cashDrawerAdjCollectionName = "cashdraweradjustments"
+	cashDrawerShiftCollection   = "cashdrawershifts"
 )
 
 type cashDrawerStorage struct {
-	collection    *mongo.Collection
-	adjCollection *mongo.Collection
-	driver        *mongoDriver
-	client        *mongo.Client
+	collection      *mongo.Collection
+	adjCollection   *mongo.Collection
+	shiftCollection *mongo.Collection
+	driver          *mongoDriver
+	shiftDriver     *mongoDriver
+	client          *mongo.Client
 }
 
 func NewCashDrawerStorage(db DB) core.CashDrawerStorage {
 	coll := db.Collection(cashDrawerCollectionName)
 	adj := db.Collection(cashDrawerAdjCollectionName)
+	shift := db.Collection(cashDrawerShiftCollection)
 	return &cashDrawerStorage{
-		collection:    coll,
-		adjCollection: adj,
-		driver:        &mongoDriver{Collection: coll},
-		client:        db.client,
+		collection:      coll,
+		adjCollection:   adj,
+		shiftCollection: shift,
+		driver:          &mongoDriver{Collection: coll},
+		shiftDriver:     &mongoDriver{Collection: shift},
+		client:          db.client,
 	}
 }
 
 func (s *cashDrawerStorage) Put(ctx context.Context, drawer core.CashDrawer) error {
 	const op = errors.Op("mongo/cashDrawerStorage.Put")
 
-	now := time.Now().Unix()
-	drawer.CalculatedAt = now
+	// The balance and open shift are only set on creation, afterwards they're
+	// changed by adjustments and shifts
 	filter := bson.M{"_id": drawer.ID}
-	query := bson.M{"$set": drawer}
+	query := bson.M{
+		"$set": bson.M{
+			"name":          drawer.Name,
+			"location_id":   drawer.LocationID,
+			"merchant_id":   drawer.MerchantID,
+			"calculated_at": time.Now().Unix(),
+		},
+		"$setOnInsert": bson.M{
+			"amount":   drawer.Amount,
+			"shift_id": drawer.ShiftID,
+		},
+	}
 	opts := options.Update().SetUpsert(true)
 
 	_, err := s.collection.UpdateOne(ctx, filter, query, opts)
@@ -76,6 +93,184 @@ func (s *cashDrawerStorage) PutAdj(ctx context.Context, adj core.CashDrawerAdjus
 	return nil
 }
 
+func (s *cashDrawerStorage) ApplyAdjustment(ctx context.Context, adj core.CashDrawerAdjustment) (core.CashDrawer, error) {
+	const op = errors.Op("mongo/cashDrawerStorage.ApplyAdjustment")
+
+	sess, err := s.client.StartSession()
+	if err != nil {
+		return core.CashDrawer{}, errors.E(op, errors.KindUnexpected, err)
+	}
+	defer sess.EndSession(ctx)
+
+	res, err := sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{"_id": adj.CashDrawerID, "merchant_id": adj.MerchantID}
+		query := bson.M{
+			"$inc": bson.M{"amount.value": adj.Delta()},
+			"$set": bson.M{"calculated_at": time.Now().Unix()},
+		}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+		drawer := core.CashDrawer{}
+		if err := s.collection.FindOneAndUpdate(sessCtx, filter, query, opts).Decode(&drawer); err != nil {
+			return nil, err
+		}
+
+		adj.LocationID = drawer.LocationID
+		adj.ShiftID = drawer.ShiftID
+		if err := s.PutAdj(sessCtx, adj); err != nil {
+			return nil, err
+		}
+		return drawer, nil
+	})
+	if err == mongo.ErrNoDocuments {
+		return core.CashDrawer{}, errors.E(op, errors.KindNotFound, err)
+	}
+	if err != nil {
+		return core.CashDrawer{}, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return res.(core.CashDrawer), nil
+}
+
+func (s *cashDrawerStorage) OpenShift(ctx context.Context, shift core.CashDrawerShift) (core.CashDrawer, error) {
+	const op = errors.Op("mongo/cashDrawerStorage.OpenShift")
+
+	sess, err := s.client.StartSession()
+	if err != nil {
+		return core.CashDrawer{}, errors.E(op, errors.KindUnexpected, err)
+	}
+	defer sess.EndSession(ctx)
+
+	res, err := sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// Only a drawer without an open shift is taken, concurrent opens conflict
+		// on the drawer and the later one finds it taken
+		filter := bson.M{
+			"_id":         shift.CashDrawerID,
+			"merchant_id": shift.MerchantID,
+			"shift_id":    bson.M{"$in": bson.A{"", nil}},
+		}
+		query := bson.M{"$set": bson.M{
+			"shift_id":      shift.ID,
+			"calculated_at": time.Now().Unix(),
+		}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+		drawer := core.CashDrawer{}
+		err := s.collection.FindOneAndUpdate(sessCtx, filter, query, opts).Decode(&drawer)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.E(errors.KindValidation, "Cash drawer already has an open shift")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.PutShift(sessCtx, shift); err != nil {
+			return nil, err
+		}
+
+		adj, ok := shift.OpeningFloat(drawer.Amount)
+		if !ok {
+			return drawer, nil
+		}
+		if err := s.incAmount(sessCtx, &drawer, adj); err != nil {
+			return nil, err
+		}
+		if err := s.PutAdj(sessCtx, adj); err != nil {
+			return nil, err
+		}
+		return drawer, nil
+	})
+	if err != nil {
+		if errors.Is(err, errors.KindValidation) {
+			return core.CashDrawer{}, errors.E(op, err)
+		}
+		return core.CashDrawer{}, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return res.(core.CashDrawer), nil
+}
+
+func (s *cashDrawerStorage) CloseShift(ctx context.Context, shift core.CashDrawerShift) (core.CashDrawerShift, error) {
+	const op = errors.Op("mongo/cashDrawerStorage.CloseShift")
+
+	sess, err := s.client.StartSession()
+	if err != nil {
+		return core.CashDrawerShift{}, errors.E(op, errors.KindUnexpected, err)
+	}
+	defer sess.EndSession(ctx)
+
+	res, err := sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// Releasing the drawer first holds it for the rest of the transaction,
+		// adjustments applied concurrently conflict and are retried after the
+		// close, so they either count in the shift or are left out of it
+		filter := bson.M{
+			"_id":         shift.CashDrawerID,
+			"merchant_id": shift.MerchantID,
+			"shift_id":    shift.ID,
+		}
+		query := bson.M{"$set": bson.M{
+			"shift_id":      "",
+			"calculated_at": time.Now().Unix(),
+		}}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+		drawer := core.CashDrawer{}
+		err := s.collection.FindOneAndUpdate(sessCtx, filter, query, opts).Decode(&drawer)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.E(errors.KindValidation, "Cash drawer shift is already closed")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		adjFilter := bson.M{
+			"shift_id":       shift.ID,
+			"cash_drawer_id": shift.CashDrawerID,
+			"merchant_id":    shift.MerchantID,
+		}
+		cur, err := s.adjCollection.Find(sessCtx, adjFilter)
+		if err != nil {
+			return nil, err
+		}
+		var adjs []core.CashDrawerAdjustment
+		if err := cur.All(sessCtx, &adjs); err != nil {
+			return nil, err
+		}
+
+		if adj, ok := shift.Reconcile(adjs, drawer.Amount); ok {
+			if err := s.incAmount(sessCtx, &drawer, adj); err != nil {
+				return nil, err
+			}
+			if err := s.PutAdj(sessCtx, adj); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.PutShift(sessCtx, shift); err != nil {
+			return nil, err
+		}
+		return shift, nil
+	})
+	if err != nil {
+		if errors.Is(err, errors.KindValidation) {
+			return core.CashDrawerShift{}, errors.E(op, err)
+		}
+		return core.CashDrawerShift{}, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return res.(core.CashDrawerShift), nil
+}
+
+// incAmount increments the drawer balance by the adjustment delta
+func (s *cashDrawerStorage) incAmount(ctx mongo.SessionContext, drawer *core.CashDrawer, adj core.CashDrawerAdjustment) error {
+	filter := bson.M{"_id": drawer.ID}
+	query := bson.M{"$inc": bson.M{"amount.value": adj.Delta()}}
+	if _, err := s.collection.UpdateOne(ctx, filter, query); err != nil {
+		return err
+	}
+	drawer.Amount.Value += adj.Delta()
+	return nil
+}
+
 func (s *cashDrawerStorage) Get(ctx context.Context, id core.ID) (core.CashDrawer, error) {
 	const op = errors.Op("mongo/cashDrawerStorage/Get")
 
@@ -140,6 +335,18 @@ func (s *cashDrawerStorage) ListAdjustment(ctx context.Context, q core.CashDrawe
 	if len(q.Filter.IDs) != 0 {
 		filter["_id"] = bson.M{"$in": q.Filter.IDs}
 	}
+	if len(q.Filter.CashDrawerIDs) != 0 {
+		filter["cash_drawer_id"] = bson.M{"$in": q.Filter.CashDrawerIDs}
+	}
+	if len(q.Filter.ShiftIDs) != 0 {
+		filter["shift_id"] = bson.M{"$in": q.Filter.ShiftIDs}
+	}
+	if q.Filter.CreatedAt.Gte != 0 {
+		filter["created_at"] = bson.M{"$gte": q.Filter.CreatedAt.Gte}
+	}
+	if q.Filter.CreatedAt.Lte != 0 {
+		filter["created_at"] = bson.M{"$gte": q.Filter.CreatedAt.Gte, "$lte": q.Filter.CreatedAt.Lte}
+	}
 	if len(q.Filter.LocationIDs) != 0 {
 		filter["location_id"] = bson.M{"$in": q.Filter.LocationIDs}
 	}
@@ -161,3 +368,86 @@ func (s *cashDrawerStorage) ListAdjustment(ctx context.Context, q core.CashDrawe
 
 	return adjs, count, nil
 }
+
+func (s *cashDrawerStorage) PutShift(ctx context.Context, shift core.CashDrawerShift) error {
+	const op = errors.Op("mongo/cashDrawerStorage.PutShift")
+
+	filter := bson.M{"_id": shift.ID}
+	query := bson.M{"$set": shift}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := s.shiftCollection.UpdateOne(ctx, filter, query, opts)
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
+
+func (s *cashDrawerStorage) GetShift(ctx context.Context, id core.ID) (core.CashDrawerShift, error) {
+	const op = errors.Op("mongo/cashDrawerStorage.GetShift")
+
+	shift := core.CashDrawerShift{}
+	filter := bson.M{"_id": id}
+
+	if err := s.shiftDriver.findOneAndDecode(ctx, &shift, filter); err != nil {
+		return core.CashDrawerShift{}, errors.E(op, err)
+	}
+
+	return shift, nil
+}
+
+func (s *cashDrawerStorage) ListShift(ctx context.Context, q core.CashDrawerShiftQuery) ([]core.CashDrawerShift, int64, error) {
+	const op = errors.Op("mongo/cashDrawerStorage.ListShift")
+
+	opts := options.Find().
+		SetLimit(q.Limit).
+		SetSkip(q.Offset).
+		SetSort(bson.M{"opened_at": -1})
+
+	filter := bson.M{}
+	if q.Filter.MerchantID != "" {
+		filter["merchant_id"] = q.Filter.MerchantID
+	}
+	if len(q.Filter.IDs) != 0 {
+		filter["_id"] = bson.M{"$in": q.Filter.IDs}
+	}
+	if len(q.Filter.CashDrawerIDs) != 0 {
+		filter["cash_drawer_id"] = bson.M{"$in": q.Filter.CashDrawerIDs}
+	}
+	if len(q.Filter.LocationIDs) != 0 {
+		filter["location_id"] = bson.M{"$in": q.Filter.LocationIDs}
+	}
+	if len(q.Filter.States) != 0 {
+		filter["state"] = bson.M{"$in": q.Filter.States}
+	}
+	if len(q.Filter.EmployeeIDs) != 0 {
+		filter["$or"] = bson.A{
+			bson.M{"opening_employee_id": bson.M{"$in": q.Filter.EmployeeIDs}},
+			bson.M{"closing_employee_id": bson.M{"$in": q.Filter.EmployeeIDs}},
+		}
+	}
+	if q.Filter.OpenedAt.Gte != 0 {
+		filter["opened_at"] = bson.M{"$gte": q.Filter.OpenedAt.Gte}
+	}
+	if q.Filter.OpenedAt.Lte != 0 {
+		filter["opened_at"] = bson.M{"$gte": q.Filter.OpenedAt.Gte, "$lte": q.Filter.OpenedAt.Lte}
+	}
+
+	count, err := s.shiftCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	res, err := s.shiftCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	var shifts []core.CashDrawerShift
+	if err := res.All(ctx, &shifts); err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return shifts, count, nil
+}
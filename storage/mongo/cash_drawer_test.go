@@ -0,0 +1,139 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/backium/backend/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCashDrawerApplyAdjustmentConcurrent(t *testing.T) {
+	const workers = 50
+
+	ctx := context.Background()
+	storage := NewCashDrawerStorage(testDB(t))
+
+	drawer := core.NewCashDrawer(core.NewID("loc"), core.NewID("merch"))
+	drawer.Amount = core.NewMoney(0, core.PEN)
+	if err := storage.Put(ctx, drawer); err != nil {
+		t.Fatal("creating drawer: ", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			adj := core.NewCashDrawerAdjustment(drawer.ID, drawer.MerchantID)
+			adj.Op = core.CashDrawerOpAdd
+			adj.Amount = core.NewMoney(100, core.PEN)
+			adj.AutoGenerated = true
+			_, err := storage.ApplyAdjustment(ctx, adj)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	drawer, err := storage.Get(ctx, drawer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100*workers), drawer.Amount.Value)
+
+	adjs, _, err := storage.ListAdjustment(ctx, core.CashDrawerQuery{
+		Filter: core.CashDrawerFilter{
+			CashDrawerIDs: []core.ID{drawer.ID},
+			AutoGenerated: true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, adjs, workers)
+}
+
+func TestCashDrawerCloseShiftConcurrent(t *testing.T) {
+	const workers = 50
+
+	storage := NewCashDrawerStorage(testDB(t))
+	svc := core.LocationService{CashDrawerStorage: storage}
+
+	drawer := core.NewCashDrawer(core.NewID("loc"), core.NewID("merch"))
+	drawer.Amount = core.NewMoney(0, core.PEN)
+	if err := storage.Put(context.Background(), drawer); err != nil {
+		t.Fatal("creating drawer: ", err)
+	}
+
+	ctx := core.ContextWithUser(context.Background(), &core.User{
+		MerchantID: drawer.MerchantID,
+		EmployeeID: core.NewID("emp"),
+	})
+	shift, err := svc.OpenCashDrawerShift(ctx, drawer.ID, core.NewMoney(1000, core.PEN), "")
+	if err != nil {
+		t.Fatal("opening shift: ", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers+1)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			adj := core.NewCashDrawerAdjustment(drawer.ID, drawer.MerchantID)
+			adj.Op = core.CashDrawerOpAdd
+			adj.Amount = core.NewMoney(100, core.PEN)
+			adj.AutoGenerated = true
+			_, err := storage.ApplyAdjustment(ctx, adj)
+			errs <- err
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var err error
+		shift, err = svc.CloseCashDrawerShift(ctx, shift.ID, core.NewMoney(2000, core.PEN), nil, "")
+		errs <- err
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	var adjs []core.CashDrawerAdjustment
+	for _, auto := range []bool{true, false} {
+		res, _, err := storage.ListAdjustment(ctx, core.CashDrawerQuery{
+			Filter: core.CashDrawerFilter{
+				CashDrawerIDs: []core.ID{drawer.ID},
+				AutoGenerated: auto,
+			},
+		})
+		assert.NoError(t, err)
+		adjs = append(adjs, res...)
+	}
+
+	// Sales either count in the shift or were applied after it was closed
+	var balance, inShift, afterShift int64
+	for _, adj := range adjs {
+		balance += adj.Delta()
+		if adj.Reason != "" {
+			continue
+		}
+		if adj.ShiftID == shift.ID {
+			inShift += adj.Delta()
+		} else {
+			afterShift += adj.Delta()
+		}
+	}
+	assert.Equal(t, int64(100*workers), inShift+afterShift)
+	assert.Equal(t, inShift, shift.CashPaymentAmount.Value)
+
+	drawer, err = storage.Get(ctx, drawer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, core.ID(""), drawer.ShiftID)
+	assert.Equal(t, balance, drawer.Amount.Value)
+	assert.Equal(t, int64(2000)+afterShift, drawer.Amount.Value)
+}
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"regexp"
 	"time"
 
 	"github.com/backium/backend/core"
@@ -116,6 +117,10 @@ func (s *customerStorage) List(ctx context.Context, q core.CustomerQuery) ([]cor
 	if q.Filter.Name != "" {
 		filter["name"] = bson.M{"$regex": primitive.Regex{Pattern: q.Filter.Name, Options: "i"}}
 	}
+	if q.Filter.Email != "" {
+		pattern := "^" + regexp.QuoteMeta(q.Filter.Email) + "$"
+		filter["email"] = bson.M{"$regex": primitive.Regex{Pattern: pattern, Options: "i"}}
+	}
 
 	count, err := s.collection.CountDocuments(ctx, filter)
 	if err != nil {
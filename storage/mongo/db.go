@@ -32,3 +32,9 @@ func (m DB) Collection(name string) *mongo.Collection {
 func (m DB) Disconnect() error {
 	return m.client.Disconnect(context.TODO())
 }
+
+// EnsureIndexes creates the indexes the storages rely on to keep the data consistent
+func (m DB) EnsureIndexes(ctx context.Context) error {
+	_, err := m.Collection(timesheetCollectionName).Indexes().CreateOne(ctx, openTimesheetIndex)
+	return err
+}
@@ -0,0 +1,106 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	deviceCollectionName = "devices"
+)
+
+type deviceStorage struct {
+	collection *mongo.Collection
+	driver     *mongoDriver
+}
+
+func NewDeviceStorage(db DB) core.DeviceStorage {
+	coll := db.Collection(deviceCollectionName)
+	return &deviceStorage{
+		collection: coll,
+		driver:     &mongoDriver{Collection: coll},
+	}
+}
+
+func (s *deviceStorage) Put(ctx context.Context, device core.Device) error {
+	const op = errors.Op("mongo/deviceStorage.Put")
+
+	now := time.Now().Unix()
+	device.UpdatedAt = now
+	filter := bson.M{"_id": device.ID}
+	query := bson.M{"$set": device}
+	opts := options.Update().SetUpsert(true)
+
+	res, err := s.collection.UpdateOne(ctx, filter, query, opts)
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	// Update created_at field if upserted
+	if res.UpsertedCount == 1 {
+		device.CreatedAt = now
+		query := bson.M{"$set": device}
+		_, err := s.collection.UpdateOne(ctx, filter, query, opts)
+		if err != nil {
+			return errors.E(op, errors.KindUnexpected, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *deviceStorage) Get(ctx context.Context, id core.ID) (core.Device, error) {
+	const op = errors.Op("mongo/deviceStorage.Get")
+
+	device := core.Device{}
+	filter := bson.M{"_id": id}
+
+	if err := s.driver.findOneAndDecode(ctx, &device, filter); err != nil {
+		return core.Device{}, errors.E(op, err)
+	}
+
+	return device, nil
+}
+
+func (s *deviceStorage) List(ctx context.Context, q core.DeviceQuery) ([]core.Device, int64, error) {
+	const op = errors.Op("mongo/deviceStorage.List")
+
+	opts := options.Find().
+		SetLimit(q.Limit).
+		SetSkip(q.Offset).
+		SetSort(bson.M{"created_at": -1})
+
+	filter := bson.M{"status": bson.M{"$ne": core.StatusShadowDeleted}}
+	if q.Filter.MerchantID != "" {
+		filter["merchant_id"] = q.Filter.MerchantID
+	}
+	if len(q.Filter.IDs) != 0 {
+		filter["_id"] = bson.M{"$in": q.Filter.IDs}
+	}
+	if len(q.Filter.LocationIDs) != 0 {
+		filter["location_id"] = bson.M{"$in": q.Filter.LocationIDs}
+	}
+
+	count, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	res, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	var devices []core.Device
+	if err := res.All(ctx, &devices); err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return devices, count, nil
+}
@@ -71,6 +71,49 @@ func (s *employeeStorage) Get(ctx context.Context, id core.ID) (core.Employee, e
 	return employee, nil
 }
 
+func (s *employeeStorage) AddPINFailure(ctx context.Context, id core.ID, maxAttempts int, lockedUntil int64) (core.Employee, error) {
+	const op = errors.Op("mongo/employeeStorage.AddPINFailure")
+
+	// The attempts are counted and checked in a single update so concurrent
+	// failures can't overwrite each other
+	attempts := bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$pin_failed_attempts", 0}}, 1}}
+	locked := bson.M{"$gte": bson.A{attempts, maxAttempts}}
+	query := bson.A{
+		bson.M{"$set": bson.M{
+			"pin_failed_attempts": bson.M{"$cond": bson.A{locked, 0, attempts}},
+			"pin_locked_until":    bson.M{"$cond": bson.A{locked, lockedUntil, "$pin_locked_until"}},
+		}},
+	}
+	filter := bson.M{"_id": id}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	employee := core.Employee{}
+	err := s.collection.FindOneAndUpdate(ctx, filter, query, opts).Decode(&employee)
+	if err == mongo.ErrNoDocuments {
+		return core.Employee{}, errors.E(op, errors.KindNotFound, err)
+	}
+	if err != nil {
+		return core.Employee{}, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return employee, nil
+}
+
+func (s *employeeStorage) ResetPINFailures(ctx context.Context, id core.ID) error {
+	const op = errors.Op("mongo/employeeStorage.ResetPINFailures")
+
+	filter := bson.M{"_id": id}
+	query := bson.M{"$set": bson.M{
+		"pin_failed_attempts": 0,
+		"pin_locked_until":    0,
+	}}
+	if _, err := s.collection.UpdateOne(ctx, filter, query); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
+
 func (s *employeeStorage) List(ctx context.Context, q core.EmployeeQuery) ([]core.Employee, int64, error) {
 	const op = errors.Op("mongo/employeeStorage.List")
 
@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/backium/backend/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmployeeAddPINFailureConcurrent(t *testing.T) {
+	const lockedUntil = 1000
+
+	ctx := context.Background()
+	storage := NewEmployeeStorage(testDB(t))
+
+	employee := core.NewEmployee("Anna", "Smith", core.NewID("merch"))
+	if err := storage.Put(ctx, employee); err != nil {
+		t.Fatal("creating employee: ", err)
+	}
+
+	// One attempt short of a second lockout, no failure is lost
+	workers := 2*core.MaxPINAttempts - 1
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := storage.AddPINFailure(ctx, employee.ID, core.MaxPINAttempts, lockedUntil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	employee, err := storage.Get(ctx, employee.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(lockedUntil), employee.PINLockedUntil)
+	assert.Equal(t, core.MaxPINAttempts-1, employee.PINFailedAttempts)
+
+	assert.NoError(t, storage.ResetPINFailures(ctx, employee.ID))
+	employee, err = storage.Get(ctx, employee.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, employee.PINLockedUntil)
+	assert.Zero(t, employee.PINFailedAttempts)
+}
@@ -0,0 +1,33 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	failedLoginCollectionName = "failed_logins"
+)
+
+type failedLoginStorage struct {
+	collection *mongo.Collection
+}
+
+func NewFailedLoginStorage(db DB) core.FailedLoginStorage {
+	return &failedLoginStorage{
+		collection: db.Collection(failedLoginCollectionName),
+	}
+}
+
+func (s *failedLoginStorage) Put(ctx context.Context, login core.FailedLogin) error {
+	const op = errors.Op("mongo/failedLoginStorage.Put")
+
+	if _, err := s.collection.InsertOne(ctx, login); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
@@ -181,6 +181,9 @@ func (s *inventoryStorage) ListAdjustment(ctx context.Context, f core.InventoryF
 	if len(f.EmployeeIDs) != 0 {
 		filter["employee_id"] = bson.M{"$in": f.EmployeeIDs}
 	}
+	if len(f.Reasons) != 0 {
+		filter["reason"] = bson.M{"$in": f.Reasons}
+	}
 	if len(f.IDs) != 0 {
 		filter["_id"] = bson.M{"$in": f.IDs}
 	}
@@ -209,3 +212,127 @@ func (s *inventoryStorage) ListAdjustment(ctx context.Context, f core.InventoryF
 
 	return adjs, count, nil
 }
+
+func (s *inventoryStorage) ApplyAdjustments(ctx context.Context, adjs []core.InventoryAdjustment) ([]core.InventoryCount, error) {
+	const op = errors.Op("mongo/inventoryStorage.ApplyAdjustments")
+
+	sess, err := s.client.StartSession()
+	if err != nil {
+		return nil, errors.E(op, errors.KindUnexpected, err)
+	}
+	defer sess.EndSession(ctx)
+
+	res, err := sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		counts := []core.InventoryCount{}
+		visited := map[[3]core.ID]bool{}
+		for _, adj := range adjs {
+			key := [3]core.ID{adj.ItemVariationID, adj.LocationID, adj.MerchantID}
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+
+			count, err := s.applyToCount(sessCtx, adj.ItemVariationID, adj.LocationID, adj.MerchantID, adjs)
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			counts = append(counts, count)
+		}
+		if len(counts) == 0 {
+			return counts, nil
+		}
+
+		for _, adj := range adjs {
+			if err := s.PutAdj(sessCtx, adj); err != nil {
+				return nil, err
+			}
+		}
+		return counts, nil
+	})
+	if err != nil {
+		if errors.Is(err, errors.KindValidation) {
+			return nil, errors.E(op, err)
+		}
+		return nil, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return res.([]core.InventoryCount), nil
+}
+
+// applyToCount increments the count quantity by the adjustments delta and then
+// updates the lots from the previous state. The increment holds the count for the
+// rest of the transaction, concurrent transactions on the same count conflict and
+// are retried by the driver so no update is lost.
+func (s *inventoryStorage) SetSoldOut(ctx context.Context, variationID, locationID, merchantID core.ID, soldOut bool) (core.InventoryCount, error) {
+	const op = errors.Op("mongo/inventoryStorage.SetSoldOut")
+
+	filter := bson.M{
+		"item_variation_id": variationID,
+		"location_id":       locationID,
+		"merchant_id":       merchantID,
+		"status":            bson.M{"$ne": core.StatusShadowDeleted},
+	}
+	query := bson.M{"$set": bson.M{"sold_out": soldOut}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	count := core.InventoryCount{}
+	err := s.countCollection.FindOneAndUpdate(ctx, filter, query, opts).Decode(&count)
+	if err == mongo.ErrNoDocuments {
+		return core.InventoryCount{}, errors.E(op, errors.KindNotFound, "Inventory count not found")
+	}
+	if err != nil {
+		return core.InventoryCount{}, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return count, nil
+}
+
+func (s *inventoryStorage) applyToCount(ctx mongo.SessionContext, variationID, locationID, merchantID core.ID, adjs []core.InventoryAdjustment) (core.InventoryCount, error) {
+	var delta int64
+	hasReset := false
+	for _, adj := range adjs {
+		if adj.ItemVariationID != variationID || adj.LocationID != locationID || adj.MerchantID != merchantID {
+			continue
+		}
+		delta += adj.Delta()
+		if adj.Op == core.InventoryOpResetStock {
+			hasReset = true
+		}
+	}
+
+	filter := bson.M{
+		"item_variation_id": variationID,
+		"location_id":       locationID,
+		"merchant_id":       merchantID,
+		"status":            bson.M{"$ne": core.StatusShadowDeleted},
+	}
+	query := bson.M{"$inc": bson.M{"quantity": delta}}
+
+	count := core.InventoryCount{}
+	if err := s.countCollection.FindOneAndUpdate(ctx, filter, query).Decode(&count); err != nil {
+		return core.InventoryCount{}, err
+	}
+	if _, err := count.ApplyAdjustments(adjs); err != nil {
+		return core.InventoryCount{}, err
+	}
+
+	count.CalculatedAt = time.Now().Unix()
+	set := bson.M{
+		"lots":          count.Lots,
+		"state":         count.State,
+		"calculated_at": count.CalculatedAt,
+	}
+	// A reset depends on the quantity at the time it's applied, so it can't be expressed
+	// as an increment. The count is already held by this transaction.
+	if hasReset {
+		set["quantity"] = count.Quantity
+	}
+	if _, err := s.countCollection.UpdateOne(ctx, bson.M{"_id": count.ID, "merchant_id": merchantID}, bson.M{"$set": set}); err != nil {
+		return core.InventoryCount{}, err
+	}
+
+	return count, nil
+}
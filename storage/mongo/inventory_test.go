@@ -0,0 +1,156 @@
+package mongo
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDB connects to the database given by BACKIUM_TEST_DB_URI, tests are skipped
+// when it's not set. Transactions require the server to be part of a replica set.
+func testDB(t *testing.T) DB {
+	uri := os.Getenv("BACKIUM_TEST_DB_URI")
+	if uri == "" {
+		t.Skip("BACKIUM_TEST_DB_URI not set")
+	}
+
+	db, err := New(uri, "backium_test")
+	if err != nil {
+		t.Fatal("connecting to mongo: ", err)
+	}
+	if err := db.EnsureIndexes(context.Background()); err != nil {
+		t.Fatal("creating indexes: ", err)
+	}
+	t.Cleanup(func() {
+		db.Disconnect()
+	})
+	return db
+}
+
+func TestInventoryApplyAdjustmentsConcurrent(t *testing.T) {
+	const workers = 50
+
+	ctx := context.Background()
+	storage := NewInventoryStorage(testDB(t))
+
+	merchantID := core.NewID("merch")
+	locationID := core.NewID("loc")
+	variationID := core.NewID("itemvar")
+	count := core.NewInventoryCount(variationID, locationID, merchantID)
+	count.Quantity = 100
+	if err := storage.PutCount(ctx, count); err != nil {
+		t.Fatal("creating count: ", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			adj := core.NewInventoryAdjustment(variationID, locationID, merchantID)
+			adj.Op = core.InventoryOpRemoveStock
+			adj.Reason = core.InventoryReasonSale
+			adj.Quantity = 1
+			_, err := storage.ApplyAdjustments(ctx, []core.InventoryAdjustment{adj})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	counts, _, err := storage.ListCount(ctx, core.InventoryFilter{IDs: []core.ID{count.ID}})
+	assert.NoError(t, err)
+	assert.Len(t, counts, 1)
+	assert.Equal(t, int64(100-workers), counts[0].Quantity)
+
+	adjs, _, err := storage.ListAdjustment(ctx, core.InventoryFilter{
+		ItemVariationIDs: []core.ID{variationID},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, adjs, workers)
+}
+
+func TestInventoryApplyAdjustmentsOtherMerchant(t *testing.T) {
+	ctx := context.Background()
+	storage := NewInventoryStorage(testDB(t))
+
+	locationID := core.NewID("loc")
+	variationID := core.NewID("itemvar")
+	count := core.NewInventoryCount(variationID, locationID, core.NewID("merch"))
+	count.Quantity = 10
+	if err := storage.PutCount(ctx, count); err != nil {
+		t.Fatal("creating count: ", err)
+	}
+
+	// The count is not found for adjustments of other merchants
+	adj := core.NewInventoryAdjustment(variationID, locationID, core.NewID("merch"))
+	adj.Op = core.InventoryOpRemoveStock
+	adj.Reason = core.InventoryReasonTheft
+	adj.Quantity = 4
+	counts, err := storage.ApplyAdjustments(ctx, []core.InventoryAdjustment{adj})
+	assert.NoError(t, err)
+	assert.Empty(t, counts)
+
+	counts, _, err = storage.ListCount(ctx, core.InventoryFilter{ItemVariationIDs: []core.ID{variationID}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), counts[0].Quantity)
+}
+
+func TestInventorySetSoldOutConcurrent(t *testing.T) {
+	const workers = 50
+
+	ctx := context.Background()
+	storage := NewInventoryStorage(testDB(t))
+
+	merchantID := core.NewID("merch")
+	locationID := core.NewID("loc")
+	variationID := core.NewID("itemvar")
+	count := core.NewInventoryCount(variationID, locationID, merchantID)
+	count.Quantity = 100
+	if err := storage.PutCount(ctx, count); err != nil {
+		t.Fatal("creating count: ", err)
+	}
+
+	// Sales applied while the count is flagged are not lost
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			adj := core.NewInventoryAdjustment(variationID, locationID, merchantID)
+			adj.Op = core.InventoryOpRemoveStock
+			adj.Reason = core.InventoryReasonSale
+			adj.Quantity = 1
+			_, err := storage.ApplyAdjustments(ctx, []core.InventoryAdjustment{adj})
+			errs <- err
+		}()
+		go func(i int) {
+			defer wg.Done()
+			_, err := storage.SetSoldOut(ctx, variationID, locationID, merchantID, i%2 == 0)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	count, err := storage.SetSoldOut(ctx, variationID, locationID, merchantID, true)
+	assert.NoError(t, err)
+	assert.True(t, count.SoldOut)
+	assert.Equal(t, int64(100-workers), count.Quantity)
+
+	_, err = storage.SetSoldOut(ctx, variationID, locationID, core.NewID("merch"), true)
+	assert.True(t, errors.Is(err, errors.KindNotFound), "counts of other merchants are not found")
+}
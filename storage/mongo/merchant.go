@@ -58,27 +58,94 @@ func (s *merchantStorage) Put(ctx context.Context, merchant core.Merchant) error
 	return nil
 }
 
+func (s *merchantStorage) UpdateSettings(ctx context.Context, merchant core.Merchant) error {
+	const op = errors.Op("mongo/merchantStorage/UpdateSettings")
+
+	filter := bson.M{"_id": merchant.ID}
+	query := bson.M{"$set": bson.M{
+		"business_name":      merchant.BusinessName,
+		"stock_policy":       merchant.StockPolicy,
+		"require_two_factor": merchant.RequireTwoFactor,
+		"updated_at":         time.Now().Unix(),
+	}}
+	res, err := s.collection.UpdateOne(ctx, filter, query)
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	if res.MatchedCount == 0 {
+		return errors.E(op, errors.KindNotFound, "merchant not found")
+	}
+
+	return nil
+}
+
 func (s *merchantStorage) PutKey(ctx context.Context, merchantID core.ID, key core.Key) error {
 	const op = errors.Op("mongo/merchantStorage/PutKey")
 
-	merchant, err := s.Get(ctx, merchantID)
+	filter := bson.M{"_id": merchantID, "keys.id": key.ID}
+	query := bson.M{"$set": bson.M{"keys.$": key}}
+	res, err := s.collection.UpdateOne(ctx, filter, query)
 	if err != nil {
-		return errors.E(op, err)
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	if res.MatchedCount == 1 {
+		return nil
 	}
 
-	newKey := true
-	for i, k := range merchant.Keys {
-		if k.Token == key.Token {
-			merchant.Keys[i].Name = key.Name
-			newKey = false
-			break
-		}
+	filter = bson.M{"_id": merchantID}
+	query = bson.M{"$push": bson.M{"keys": key}}
+	res, err = s.collection.UpdateOne(ctx, filter, query)
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	if res.MatchedCount == 0 {
+		return errors.E(op, errors.KindNotFound, "merchant not found")
+	}
+
+	return nil
+}
+
+func (s *merchantStorage) TouchKey(ctx context.Context, merchantID, keyID core.ID, usedAt int64) error {
+	const op = errors.Op("mongo/merchantStorage/TouchKey")
+
+	filter := bson.M{"_id": merchantID, "keys.id": keyID}
+	query := bson.M{"$set": bson.M{"keys.$.last_used_at": usedAt}}
+	if _, err := s.collection.UpdateOne(ctx, filter, query); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
+
+func (s *merchantStorage) RevokeKey(ctx context.Context, merchantID, keyID core.ID, revokedAt int64) error {
+	const op = errors.Op("mongo/merchantStorage/RevokeKey")
+
+	filter := bson.M{
+		"_id":  merchantID,
+		"keys": bson.M{"$elemMatch": bson.M{"id": keyID, "revoked_at": bson.M{"$in": bson.A{0, nil}}}},
 	}
-	if newKey {
-		merchant.Keys = append(merchant.Keys, key)
+	query := bson.M{"$set": bson.M{"keys.$.revoked_at": revokedAt}}
+	if _, err := s.collection.UpdateOne(ctx, filter, query); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
 	}
-	if err := s.Put(ctx, merchant); err != nil {
-		return errors.E(op, err)
+
+	return nil
+}
+
+func (s *merchantStorage) MigrateKey(ctx context.Context, merchantID core.ID, token string, key core.Key) error {
+	const op = errors.Op("mongo/merchantStorage/MigrateKey")
+
+	filter := bson.M{"_id": merchantID, "keys.token": token}
+	query := bson.M{
+		"$set": bson.M{
+			"keys.$.id":     key.ID,
+			"keys.$.prefix": key.Prefix,
+			"keys.$.hash":   key.Hash,
+		},
+		"$unset": bson.M{"keys.$.token": ""},
+	}
+	if _, err := s.collection.UpdateOne(ctx, filter, query); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
 	}
 
 	return nil
@@ -100,7 +167,10 @@ func (s *merchantStorage) GetByKey(ctx context.Context, key string) (core.Mercha
 	const op = errors.Op("mongo/merchantStorage/GetByKey")
 
 	merchant := core.Merchant{}
-	filter := bson.M{"keys.token": key}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"keys.hash": core.HashKey(key)},
+		bson.M{"keys.token": key},
+	}}
 	if err := s.driver.findOneAndDecode(ctx, &merchant, filter); err != nil {
 		return core.Merchant{}, errors.E(op, err)
 	}
@@ -0,0 +1,81 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/backium/backend/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerchantRevokeKeyConcurrent(t *testing.T) {
+	const touches = 50
+
+	ctx := context.Background()
+	storage := NewMerchantStorage(testDB(t))
+
+	merchant := core.NewMerchant()
+	key, _ := core.NewKey("Shop", nil)
+	merchant.Keys = []core.Key{key}
+	if err := storage.Put(ctx, merchant); err != nil {
+		t.Fatal("creating merchant: ", err)
+	}
+
+	// Uses of the key recorded around its revocation don't undo it
+	var wg sync.WaitGroup
+	errs := make(chan error, touches+1)
+	for i := 0; i < touches; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- storage.TouchKey(ctx, merchant.ID, key.ID, int64(i+1))
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs <- storage.RevokeKey(ctx, merchant.ID, key.ID, 1000)
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	merchant, err := storage.Get(ctx, merchant.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), merchant.Keys[0].RevokedAt)
+	assert.NotZero(t, merchant.Keys[0].LastUsedAt)
+
+	// The first revocation is kept
+	assert.NoError(t, storage.RevokeKey(ctx, merchant.ID, key.ID, 2000))
+	merchant, err = storage.Get(ctx, merchant.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), merchant.Keys[0].RevokedAt)
+}
+
+func TestMerchantUpdateSettingsKeepsKeys(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMerchantStorage(testDB(t))
+
+	merchant := core.NewMerchant()
+	merchant.BusinessName = "Old"
+	if err := storage.Put(ctx, merchant); err != nil {
+		t.Fatal("creating merchant: ", err)
+	}
+
+	// A key created after the settings were read isn't lost
+	stale := merchant
+	key, _ := core.NewKey("Shop", nil)
+	assert.NoError(t, storage.PutKey(ctx, merchant.ID, key))
+	stale.BusinessName = "New"
+	stale.StockPolicy = core.StockPolicyBlock
+	assert.NoError(t, storage.UpdateSettings(ctx, stale))
+
+	merchant, err := storage.Get(ctx, merchant.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "New", merchant.BusinessName)
+	assert.Equal(t, core.StockPolicyBlock, merchant.StockPolicy)
+	assert.Len(t, merchant.Keys, 1)
+}
@@ -96,6 +96,9 @@ func (s *paymentStorage) List(ctx context.Context, q core.PaymentQuery) ([]core.
 	if len(q.Filter.LocationIDs) != 0 {
 		filter["location_id"] = bson.M{"$in": q.Filter.LocationIDs}
 	}
+	if len(q.Filter.CashDrawerIDs) != 0 {
+		filter["cash_drawer_id"] = bson.M{"$in": q.Filter.CashDrawerIDs}
+	}
 	if q.Filter.CreatedAt.Gte != 0 {
 		filter["created_at"] = bson.M{"$gte": q.Filter.CreatedAt.Gte}
 	}
@@ -0,0 +1,128 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	roleCollectionName = "roles"
+)
+
+type roleStorage struct {
+	collection *mongo.Collection
+	driver     *mongoDriver
+	client     *mongo.Client
+}
+
+func NewRoleStorage(db DB) core.RoleStorage {
+	coll := db.Collection(roleCollectionName)
+	return &roleStorage{
+		collection: coll,
+		driver:     &mongoDriver{Collection: coll},
+		client:     db.client,
+	}
+}
+
+func (s *roleStorage) Put(ctx context.Context, role core.Role) error {
+	const op = errors.Op("mongo/roleStorage.Put")
+
+	now := time.Now().Unix()
+	role.UpdatedAt = now
+	filter := bson.M{"_id": role.ID}
+	query := bson.M{"$set": role}
+	opts := options.Update().SetUpsert(true)
+
+	res, err := s.collection.UpdateOne(ctx, filter, query, opts)
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	// Update created_at field if upserted
+	if res.UpsertedCount == 1 {
+		role.CreatedAt = now
+		query := bson.M{"$set": role}
+		_, err := s.collection.UpdateOne(ctx, filter, query, opts)
+		if err != nil {
+			return errors.E(op, errors.KindUnexpected, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *roleStorage) PutBatch(ctx context.Context, batch []core.Role) error {
+	const op = errors.Op("mongo/roleStorage.PutBatch")
+
+	session, err := s.client.StartSession()
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		for _, role := range batch {
+			if err := s.Put(sessCtx, role); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
+
+func (s *roleStorage) Get(ctx context.Context, id core.ID) (core.Role, error) {
+	const op = errors.Op("mongo/roleStorage.Get")
+
+	role := core.Role{}
+	filter := bson.M{"_id": id}
+
+	if err := s.driver.findOneAndDecode(ctx, &role, filter); err != nil {
+		return core.Role{}, errors.E(op, err)
+	}
+
+	return role, nil
+}
+
+func (s *roleStorage) List(ctx context.Context, q core.RoleQuery) ([]core.Role, int64, error) {
+	const op = errors.Op("mongo/roleStorage.List")
+
+	opts := options.Find().
+		SetLimit(q.Limit).
+		SetSkip(q.Offset).
+		SetSort(bson.M{"name": 1})
+
+	filter := bson.M{"status": bson.M{"$ne": core.StatusShadowDeleted}}
+	if q.Filter.MerchantID != "" {
+		filter["merchant_id"] = q.Filter.MerchantID
+	}
+	if len(q.Filter.IDs) != 0 {
+		filter["_id"] = bson.M{"$in": q.Filter.IDs}
+	}
+
+	count, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	res, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	var roles []core.Role
+	if err := res.All(ctx, &roles); err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return roles, count, nil
+}
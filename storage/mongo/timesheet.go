@@ -0,0 +1,130 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	timesheetCollectionName = "timesheets"
+)
+
+type timesheetStorage struct {
+	collection *mongo.Collection
+	driver     *mongoDriver
+}
+
+// openTimesheetIndex allows a single open timesheet per employee, so clocking
+// in twice at the same time can't open two of them
+var openTimesheetIndex = mongo.IndexModel{
+	Keys: bson.D{{Key: "employee_id", Value: 1}, {Key: "merchant_id", Value: 1}},
+	Options: options.Index().
+		SetUnique(true).
+		SetPartialFilterExpression(bson.M{"state": core.TimesheetStateOpen}),
+}
+
+func NewTimesheetStorage(db DB) core.TimesheetStorage {
+	coll := db.Collection(timesheetCollectionName)
+	return &timesheetStorage{
+		collection: coll,
+		driver:     &mongoDriver{Collection: coll},
+	}
+}
+
+func (s *timesheetStorage) Put(ctx context.Context, ts core.Timesheet) error {
+	const op = errors.Op("mongo/timesheetStorage.Put")
+
+	now := time.Now().Unix()
+	ts.UpdatedAt = now
+	filter := bson.M{"_id": ts.ID}
+	query := bson.M{"$set": ts}
+	opts := options.Update().SetUpsert(true)
+
+	res, err := s.collection.UpdateOne(ctx, filter, query, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.E(op, errors.KindValidation, "Employee is already clocked in")
+	}
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	// Update created_at field if upserted
+	if res.UpsertedCount == 1 {
+		ts.CreatedAt = now
+		query := bson.M{"$set": ts}
+		_, err := s.collection.UpdateOne(ctx, filter, query, opts)
+		if err != nil {
+			return errors.E(op, errors.KindUnexpected, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *timesheetStorage) Get(ctx context.Context, id core.ID) (core.Timesheet, error) {
+	const op = errors.Op("mongo/timesheetStorage.Get")
+
+	ts := core.Timesheet{}
+	filter := bson.M{"_id": id}
+
+	if err := s.driver.findOneAndDecode(ctx, &ts, filter); err != nil {
+		return core.Timesheet{}, errors.E(op, err)
+	}
+
+	return ts, nil
+}
+
+func (s *timesheetStorage) List(ctx context.Context, q core.TimesheetQuery) ([]core.Timesheet, int64, error) {
+	const op = errors.Op("mongo/timesheetStorage.List")
+
+	opts := options.Find().
+		SetLimit(q.Limit).
+		SetSkip(q.Offset).
+		SetSort(bson.M{"clock_in_at": -1})
+
+	filter := bson.M{}
+	if q.Filter.MerchantID != "" {
+		filter["merchant_id"] = q.Filter.MerchantID
+	}
+	if len(q.Filter.IDs) != 0 {
+		filter["_id"] = bson.M{"$in": q.Filter.IDs}
+	}
+	if len(q.Filter.EmployeeIDs) != 0 {
+		filter["employee_id"] = bson.M{"$in": q.Filter.EmployeeIDs}
+	}
+	if len(q.Filter.LocationIDs) != 0 {
+		filter["location_id"] = bson.M{"$in": q.Filter.LocationIDs}
+	}
+	if len(q.Filter.States) != 0 {
+		filter["state"] = bson.M{"$in": q.Filter.States}
+	}
+	if q.Filter.ClockInAt.Gte != 0 {
+		filter["clock_in_at"] = bson.M{"$gte": q.Filter.ClockInAt.Gte}
+	}
+	if q.Filter.ClockInAt.Lte != 0 {
+		filter["clock_in_at"] = bson.M{"$gte": q.Filter.ClockInAt.Gte, "$lte": q.Filter.ClockInAt.Lte}
+	}
+
+	count, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	res, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	var timesheets []core.Timesheet
+	if err := res.All(ctx, &timesheets); err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return timesheets, count, nil
+}
@@ -0,0 +1,59 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimesheetPutOneOpenConcurrent(t *testing.T) {
+	const workers = 20
+
+	ctx := context.Background()
+	storage := NewTimesheetStorage(testDB(t))
+
+	merchantID := core.NewID("merch")
+	employeeID := core.NewID("empl")
+	locationID := core.NewID("loc")
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = storage.Put(ctx, core.NewTimesheet(employeeID, locationID, merchantID))
+		}(i)
+	}
+	wg.Wait()
+
+	opened := 0
+	for _, err := range errs {
+		if err == nil {
+			opened++
+			continue
+		}
+		assert.True(t, errors.Is(err, errors.KindValidation), err)
+	}
+	assert.Equal(t, 1, opened)
+
+	open, _, err := storage.List(ctx, core.TimesheetQuery{
+		Filter: core.TimesheetFilter{
+			EmployeeIDs: []core.ID{employeeID},
+			States:      []core.TimesheetState{core.TimesheetStateOpen},
+			MerchantID:  merchantID,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, open, 1)
+
+	// Closed timesheets don't take part of the index
+	closed := open[0]
+	closed.State = core.TimesheetStateClosed
+	assert.NoError(t, storage.Put(ctx, closed))
+	assert.NoError(t, storage.Put(ctx, core.NewTimesheet(employeeID, locationID, merchantID)))
+}
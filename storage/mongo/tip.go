@@ -0,0 +1,185 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	tipPoolCollectionName   = "tippools"
+	tipPayoutCollectionName = "tippayouts"
+)
+
+type tipStorage struct {
+	collection       *mongo.Collection
+	payoutCollection *mongo.Collection
+	driver           *mongoDriver
+	payoutDriver     *mongoDriver
+}
+
+func NewTipStorage(db DB) core.TipStorage {
+	coll := db.Collection(tipPoolCollectionName)
+	payout := db.Collection(tipPayoutCollectionName)
+	return &tipStorage{
+		collection:       coll,
+		payoutCollection: payout,
+		driver:           &mongoDriver{Collection: coll},
+		payoutDriver:     &mongoDriver{Collection: payout},
+	}
+}
+
+func (s *tipStorage) PutPool(ctx context.Context, pool core.TipPool) error {
+	const op = errors.Op("mongo/tipStorage.PutPool")
+
+	now := time.Now().Unix()
+	pool.UpdatedAt = now
+	filter := bson.M{"_id": pool.ID}
+	query := bson.M{"$set": pool}
+	opts := options.Update().SetUpsert(true)
+
+	res, err := s.collection.UpdateOne(ctx, filter, query, opts)
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	// Update created_at field if upserted
+	if res.UpsertedCount == 1 {
+		pool.CreatedAt = now
+		query := bson.M{"$set": pool}
+		_, err := s.collection.UpdateOne(ctx, filter, query, opts)
+		if err != nil {
+			return errors.E(op, errors.KindUnexpected, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *tipStorage) GetPool(ctx context.Context, id core.ID) (core.TipPool, error) {
+	const op = errors.Op("mongo/tipStorage.GetPool")
+
+	pool := core.TipPool{}
+	filter := bson.M{"_id": id}
+
+	if err := s.driver.findOneAndDecode(ctx, &pool, filter); err != nil {
+		return core.TipPool{}, errors.E(op, err)
+	}
+
+	return pool, nil
+}
+
+func (s *tipStorage) ListPool(ctx context.Context, q core.TipPoolQuery) ([]core.TipPool, int64, error) {
+	const op = errors.Op("mongo/tipStorage.ListPool")
+
+	opts := options.Find().
+		SetLimit(q.Limit).
+		SetSkip(q.Offset)
+
+	filter := bson.M{"status": bson.M{"$ne": core.StatusShadowDeleted}}
+	if q.Filter.MerchantID != "" {
+		filter["merchant_id"] = q.Filter.MerchantID
+	}
+	if len(q.Filter.IDs) != 0 {
+		filter["_id"] = bson.M{"$in": q.Filter.IDs}
+	}
+	if len(q.Filter.LocationIDs) != 0 {
+		filter["location_ids"] = bson.M{"$in": q.Filter.LocationIDs}
+	}
+
+	count, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	res, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	var pools []core.TipPool
+	if err := res.All(ctx, &pools); err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return pools, count, nil
+}
+
+func (s *tipStorage) PutPayout(ctx context.Context, payout core.TipPayout) error {
+	const op = errors.Op("mongo/tipStorage.PutPayout")
+
+	filter := bson.M{"_id": payout.ID}
+	query := bson.M{"$set": payout}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := s.payoutCollection.UpdateOne(ctx, filter, query, opts)
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
+
+func (s *tipStorage) GetPayout(ctx context.Context, id core.ID) (core.TipPayout, error) {
+	const op = errors.Op("mongo/tipStorage.GetPayout")
+
+	payout := core.TipPayout{}
+	filter := bson.M{"_id": id}
+
+	if err := s.payoutDriver.findOneAndDecode(ctx, &payout, filter); err != nil {
+		return core.TipPayout{}, errors.E(op, err)
+	}
+
+	return payout, nil
+}
+
+func (s *tipStorage) ListPayout(ctx context.Context, q core.TipPayoutQuery) ([]core.TipPayout, int64, error) {
+	const op = errors.Op("mongo/tipStorage.ListPayout")
+
+	opts := options.Find().
+		SetLimit(q.Limit).
+		SetSkip(q.Offset).
+		SetSort(bson.M{"created_at": -1})
+
+	filter := bson.M{}
+	if q.Filter.MerchantID != "" {
+		filter["merchant_id"] = q.Filter.MerchantID
+	}
+	if len(q.Filter.IDs) != 0 {
+		filter["_id"] = bson.M{"$in": q.Filter.IDs}
+	}
+	if len(q.Filter.TipPoolIDs) != 0 {
+		filter["tip_pool_id"] = bson.M{"$in": q.Filter.TipPoolIDs}
+	}
+	if len(q.Filter.EmployeeIDs) != 0 {
+		filter["lines.employee_id"] = bson.M{"$in": q.Filter.EmployeeIDs}
+	}
+	if q.Filter.CreatedAt.Gte != 0 {
+		filter["created_at"] = bson.M{"$gte": q.Filter.CreatedAt.Gte}
+	}
+	if q.Filter.CreatedAt.Lte != 0 {
+		filter["created_at"] = bson.M{"$gte": q.Filter.CreatedAt.Gte, "$lte": q.Filter.CreatedAt.Lte}
+	}
+
+	count, err := s.payoutCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	res, err := s.payoutCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	var payouts []core.TipPayout
+	if err := res.All(ctx, &payouts); err != nil {
+		return nil, 0, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return payouts, count, nil
+}
@@ -0,0 +1,76 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	userTokenCollectionName = "user_tokens"
+)
+
+type userTokenStorage struct {
+	collection *mongo.Collection
+	driver     *mongoDriver
+}
+
+func NewUserTokenStorage(db DB) core.UserTokenStorage {
+	coll := db.Collection(userTokenCollectionName)
+	return &userTokenStorage{
+		collection: coll,
+		driver:     &mongoDriver{Collection: coll},
+	}
+}
+
+func (s *userTokenStorage) Put(ctx context.Context, token core.UserToken) error {
+	const op = errors.Op("mongo/userTokenStorage.Put")
+
+	filter := bson.M{"_id": token.ID}
+	query := bson.M{"$set": token}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := s.collection.UpdateOne(ctx, filter, query, opts)
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
+
+func (s *userTokenStorage) Claim(ctx context.Context, hash string, kind core.UserTokenKind, at time.Time) (core.UserToken, error) {
+	const op = errors.Op("mongo/userTokenStorage.Claim")
+
+	token := core.UserToken{}
+	filter := bson.M{
+		"hash":       hash,
+		"kind":       kind,
+		"expires_at": bson.M{"$gt": at.Unix()},
+	}
+
+	err := s.collection.FindOneAndDelete(ctx, filter).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return core.UserToken{}, errors.E(op, errors.KindNotFound, "User token not found")
+	}
+	if err != nil {
+		return core.UserToken{}, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return token, nil
+}
+
+func (s *userTokenStorage) DeleteByUser(ctx context.Context, userID core.ID, kind core.UserTokenKind) error {
+	const op = errors.Op("mongo/userTokenStorage.DeleteByUser")
+
+	filter := bson.M{"user_id": userID, "kind": kind}
+	if _, err := s.collection.DeleteMany(ctx, filter); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserTokenClaimConcurrent(t *testing.T) {
+	const workers = 20
+
+	ctx := context.Background()
+	storage := NewUserTokenStorage(testDB(t))
+
+	token, _ := core.NewUserToken(core.UserTokenPasswordReset, core.NewID("user"), time.Hour)
+	assert.NoError(t, storage.Put(ctx, token))
+
+	_, err := storage.Claim(ctx, token.Hash, core.UserTokenEmailVerification, time.Now())
+	assert.True(t, errors.Is(err, errors.KindNotFound), "tokens are claimed only for their kind")
+	_, err = storage.Claim(ctx, token.Hash, core.UserTokenPasswordReset, time.Now().Add(2*time.Hour))
+	assert.True(t, errors.Is(err, errors.KindNotFound), "expired tokens can't be claimed")
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = storage.Claim(ctx, token.Hash, core.UserTokenPasswordReset, time.Now())
+		}(i)
+	}
+	wg.Wait()
+
+	claimed := 0
+	for _, err := range errs {
+		if err == nil {
+			claimed++
+			continue
+		}
+		assert.True(t, errors.Is(err, errors.KindNotFound), err)
+	}
+	assert.Equal(t, 1, claimed)
+}
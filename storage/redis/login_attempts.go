@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/go-redis/redis/v8"
+)
+
+// loginAttemptsPrefix is the prefix of the hashes counting failed logins
+const loginAttemptsPrefix = "login_attempts:"
+
+type loginAttemptStorage struct {
+	client *redis.Client
+}
+
+func NewLoginAttemptStorage(addr string, password string) core.LoginAttemptStorage {
+	return &loginAttemptStorage{
+		client: redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: password,
+			DB:       0,
+		}),
+	}
+}
+
+func loginAttemptsKey(key string) string {
+	return loginAttemptsPrefix + key
+}
+
+// RecordFailure counts the failure and keeps the attempts until the window
+// passes without new failures, the attempts before it are read in the same
+// transaction so every concurrent failure gets its own count
+func (r *loginAttemptStorage) RecordFailure(ctx context.Context, key string, now time.Time) (core.LoginAttempts, error) {
+	const op = errors.Op("redis/loginAttemptStorage.RecordFailure")
+
+	k := loginAttemptsKey(key)
+	pipe := r.client.TxPipeline()
+	before := pipe.HGetAll(ctx, k)
+	pipe.HIncrBy(ctx, k, "failures", 1)
+	pipe.HSet(ctx, k, "last_failure_at", now.Unix())
+	pipe.Expire(ctx, k, core.LoginAttemptWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return core.LoginAttempts{}, errors.E(op, errors.KindUnexpected, err)
+	}
+	attempts, err := parseLoginAttempts(before.Val())
+	if err != nil {
+		return core.LoginAttempts{}, errors.E(op, errors.KindUnexpected, err)
+	}
+	return attempts, nil
+}
+
+// cancelFailure decrements the failures unless the attempts were reset or
+// expired meanwhile, and restores the time of the last failure, ARGV[2], when
+// no failure was recorded after the cancelled one at ARGV[1]
+var cancelFailure = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], "failures") == 0 then
+	return 0
+end
+if redis.call("HGET", KEYS[1], "last_failure_at") == ARGV[1] then
+	redis.call("HSET", KEYS[1], "last_failure_at", ARGV[2])
+end
+return redis.call("HINCRBY", KEYS[1], "failures", -1)
+`)
+
+func (r *loginAttemptStorage) CancelFailure(ctx context.Context, key string, at time.Time, before core.LoginAttempts) error {
+	const op = errors.Op("redis/loginAttemptStorage.CancelFailure")
+
+	keys := []string{loginAttemptsKey(key)}
+	if err := cancelFailure.Run(ctx, r.client, keys, at.Unix(), before.LastFailureAt).Err(); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	return nil
+}
+
+func (r *loginAttemptStorage) Reset(ctx context.Context, key string) error {
+	const op = errors.Op("redis/loginAttemptStorage.Reset")
+
+	if err := r.client.Del(ctx, loginAttemptsKey(key)).Err(); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	return nil
+}
+
+func parseLoginAttempts(values map[string]string) (core.LoginAttempts, error) {
+	attempts := core.LoginAttempts{}
+	if v, ok := values["failures"]; ok {
+		failures, err := strconv.Atoi(v)
+		if err != nil {
+			return core.LoginAttempts{}, err
+		}
+		attempts.Failures = failures
+	}
+	if v, ok := values["last_failure_at"]; ok {
+		last, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return core.LoginAttempts{}, err
+		}
+		attempts.LastFailureAt = last
+	}
+	return attempts, nil
+}
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backium/backend/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptsConcurrent(t *testing.T) {
+	const failures = 50
+
+	ctx := context.Background()
+	storage := NewLoginAttemptStorage(testAddr(t), "")
+	key := "email:" + string(core.NewID("test")) + "@mail.com"
+	defer storage.Reset(ctx, key)
+
+	// Every failure sees the attempts made before it
+	seen := make(chan int, failures)
+	var wg sync.WaitGroup
+	for i := 0; i < failures; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			before, err := storage.RecordFailure(ctx, key, time.Now())
+			assert.NoError(t, err)
+			seen <- before.Failures
+		}()
+	}
+	wg.Wait()
+	close(seen)
+
+	counts := map[int]bool{}
+	for n := range seen {
+		counts[n] = true
+	}
+	assert.Len(t, counts, failures)
+
+	// A cancelled attempt doesn't restart the wait of the failures before it
+	last, err := storage.RecordFailure(ctx, key, time.Unix(1000, 0))
+	assert.NoError(t, err)
+	at := time.Unix(2000, 0)
+	before, err := storage.RecordFailure(ctx, key, at)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), before.LastFailureAt)
+	assert.NoError(t, storage.CancelFailure(ctx, key, at, before))
+	before, err = storage.RecordFailure(ctx, key, time.Unix(3000, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, last.Failures+1, before.Failures)
+	assert.Equal(t, int64(1000), before.LastFailureAt)
+
+	// Failures recorded after the cancelled one keep their time
+	before, err = storage.RecordFailure(ctx, key, time.Unix(4000, 0))
+	assert.NoError(t, err)
+	assert.NoError(t, storage.CancelFailure(ctx, key, time.Unix(3000, 0), core.LoginAttempts{LastFailureAt: 1000}))
+	after, err := storage.RecordFailure(ctx, key, time.Unix(5000, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, before.Failures, after.Failures)
+	assert.Equal(t, int64(4000), after.LastFailureAt)
+
+	// Reset attempts are not cancelled below zero
+	assert.NoError(t, storage.Reset(ctx, key))
+	assert.NoError(t, storage.CancelFailure(ctx, key, time.Now(), core.LoginAttempts{}))
+	before, err = storage.RecordFailure(ctx, key, time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, before.Failures)
+}
@@ -3,11 +3,16 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
 	"github.com/go-redis/redis/v8"
 )
 
+// userSessionsPrefix is the prefix of the sets indexing the sessions of a user
+const userSessionsPrefix = "user_sessions:"
+
 type redisRepository struct {
 	client *redis.Client
 }
@@ -22,29 +27,160 @@ func NewSessionRepository(addr string, password string) core.SessionStorage {
 	}
 }
 
+func userSessionsKey(userID core.ID) string {
+	return userSessionsPrefix + string(userID)
+}
+
+// Set stores the session until it expires, the user index is kept for the
+// absolute timeout of the last session added to it, sessions that expired
+// before are removed from the index when listed
 func (r *redisRepository) Set(ctx context.Context, sess core.Session) error {
+	const op = errors.Op("redis/redisRepository.Set")
+
+	ttl := sess.TTL(time.Now())
+	if ttl <= 0 {
+		return errors.E(op, errors.KindInvalidSession, "session expired")
+	}
+	b, err := json.Marshal(sess)
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, string(sess.ID), string(b), ttl)
+	if sess.UserID != "" {
+		pipe.SAdd(ctx, userSessionsKey(sess.UserID), string(sess.ID))
+		pipe.Expire(ctx, userSessionsKey(sess.UserID), core.SessionAbsoluteTimeout)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	return nil
+}
+
+// Renew replaces the stored session if it still exists, the check and the
+// write are a single command so a concurrent delete is never undone
+func (r *redisRepository) Renew(ctx context.Context, sess core.Session) error {
+	const op = errors.Op("redis/redisRepository.Renew")
+
+	ttl := sess.TTL(time.Now())
+	if ttl <= 0 {
+		return errors.E(op, errors.KindInvalidSession, "session expired")
+	}
 	b, err := json.Marshal(sess)
 	if err != nil {
-		return err
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	renewed, err := r.client.SetXX(ctx, string(sess.ID), string(b), ttl).Result()
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	if !renewed {
+		return errors.E(op, errors.KindNotFound, "session not found")
 	}
-	if err := r.client.Set(ctx, string(sess.ID), string(b), 0).Err(); err != nil {
-		return err
+	if sess.UserID != "" {
+		if err := r.client.Expire(ctx, userSessionsKey(sess.UserID), core.SessionAbsoluteTimeout).Err(); err != nil {
+			return errors.E(op, errors.KindUnexpected, err)
+		}
 	}
 	return nil
 }
 
 func (r *redisRepository) Get(ctx context.Context, id core.ID) (core.Session, error) {
+	const op = errors.Op("redis/redisRepository.Get")
+
 	sess := core.Session{}
 	bs, err := r.client.Get(ctx, string(id)).Result()
+	if err == redis.Nil {
+		return sess, errors.E(op, errors.KindNotFound, "session not found")
+	}
 	if err != nil {
-		return sess, err
+		return sess, errors.E(op, errors.KindUnexpected, err)
 	}
 	if err := json.Unmarshal([]byte(bs), &sess); err != nil {
-		return sess, err
+		return sess, errors.E(op, errors.KindUnexpected, err)
 	}
-	return sess, err
+	return sess, nil
 }
 
 func (r *redisRepository) Delete(ctx context.Context, id core.ID) error {
-	return r.client.Del(ctx, string(id)).Err()
+	const op = errors.Op("redis/redisRepository.Delete")
+
+	sess, err := r.Get(ctx, id)
+	if errors.Is(err, errors.KindNotFound) {
+		return nil
+	}
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, string(id))
+	if sess.UserID != "" {
+		pipe.SRem(ctx, userSessionsKey(sess.UserID), string(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	return nil
+}
+
+func (r *redisRepository) ListByUser(ctx context.Context, userID core.ID) ([]core.Session, error) {
+	const op = errors.Op("redis/redisRepository.ListByUser")
+
+	key := userSessionsKey(userID)
+	ids, err := r.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, errors.E(op, errors.KindUnexpected, err)
+	}
+	sessions := []core.Session{}
+	if len(ids) == 0 {
+		return sessions, nil
+	}
+
+	values, err := r.client.MGet(ctx, ids...).Result()
+	if err != nil {
+		return nil, errors.E(op, errors.KindUnexpected, err)
+	}
+	var expired []interface{}
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			expired = append(expired, ids[i])
+			continue
+		}
+		sess := core.Session{}
+		if err := json.Unmarshal([]byte(s), &sess); err != nil {
+			return nil, errors.E(op, errors.KindUnexpected, err)
+		}
+		sessions = append(sessions, sess)
+	}
+	if len(expired) != 0 {
+		if err := r.client.SRem(ctx, key, expired...).Err(); err != nil {
+			return nil, errors.E(op, errors.KindUnexpected, err)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (r *redisRepository) DeleteByUser(ctx context.Context, userID core.ID) error {
+	const op = errors.Op("redis/redisRepository.DeleteByUser")
+
+	key := userSessionsKey(userID)
+	ids, err := r.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	pipe := r.client.TxPipeline()
+	if len(ids) != 0 {
+		pipe.Del(ctx, ids...)
+	}
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+	return nil
 }
@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/backium/backend/core"
+	"github.com/backium/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// testAddr returns the server given by BACKIUM_TEST_REDIS_URI, tests are
+// skipped when it's not set
+func testAddr(t *testing.T) string {
+	addr := os.Getenv("BACKIUM_TEST_REDIS_URI")
+	if addr == "" {
+		t.Skip("BACKIUM_TEST_REDIS_URI not set")
+	}
+	return addr
+}
+
+func testSessions(t *testing.T) core.SessionStorage {
+	return NewSessionRepository(testAddr(t), "")
+}
+
+func TestSessionRenewRevoked(t *testing.T) {
+	ctx := context.Background()
+	storage := testSessions(t)
+
+	sess := core.NewSession(core.User{ID: core.NewID("user"), MerchantID: core.NewID("merch")})
+	assert.NoError(t, storage.Set(ctx, sess))
+	assert.NoError(t, storage.Renew(ctx, sess))
+
+	assert.NoError(t, storage.DeleteByUser(ctx, sess.UserID))
+	err := storage.Renew(ctx, sess)
+	assert.True(t, errors.Is(err, errors.KindNotFound), "revoked sessions are not renewed")
+	_, err = storage.Get(ctx, sess.ID)
+	assert.True(t, errors.Is(err, errors.KindNotFound))
+}
+
+func TestSessionRenewDuringRevoke(t *testing.T) {
+	const renewals = 50
+
+	ctx := context.Background()
+	storage := testSessions(t)
+
+	sess := core.NewSession(core.User{ID: core.NewID("user"), MerchantID: core.NewID("merch")})
+	assert.NoError(t, storage.Set(ctx, sess))
+
+	var wg sync.WaitGroup
+	for i := 0; i < renewals; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := storage.Renew(ctx, sess)
+			if err != nil && !errors.Is(err, errors.KindNotFound) {
+				t.Error("renewing session: ", err)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := storage.DeleteByUser(ctx, sess.UserID); err != nil {
+			t.Error("revoking sessions: ", err)
+		}
+	}()
+	wg.Wait()
+
+	// No renewal brings the session back once it's revoked
+	_, err := storage.Get(ctx, sess.ID)
+	assert.True(t, errors.Is(err, errors.KindNotFound))
+	sessions, err := storage.ListByUser(ctx, sess.UserID)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}